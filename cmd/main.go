@@ -1,6 +1,10 @@
 package main
 
 import (
+	"errors"
+	"fmt"
+	"os"
+
 	"bank-api/internal/app"
 	"bank-api/pkg/config"
 
@@ -16,6 +20,18 @@ func main() {
 
 	cfg := config.LoadConfig(log)
 
+	if len(os.Args) > 1 {
+		err := app.RunCommand(log, cfg, os.Args[1:])
+		if errors.Is(err, app.ErrUsage) {
+			fmt.Fprintln(os.Stderr, err)
+			os.Exit(2)
+		}
+		if err != nil {
+			log.Fatalln("Command failed: ", err)
+		}
+		return
+	}
+
 	a := app.New(log, cfg)
 
 	a.Run()
@@ -8,12 +8,15 @@ import (
 	"os"
 	"time"
 
+	"bank-api/internal/auth"
 	"bank-api/internal/handlers"
 	"bank-api/internal/repository"
 	"bank-api/internal/router"
 	"bank-api/internal/server"
 	"bank-api/internal/service"
 	"bank-api/pkg/config"
+	"bank-api/pkg/mail"
+	"bank-api/pkg/secretbox"
 	"bank-api/pkg/signal"
 
 	"github.com/golang-migrate/migrate/v4"
@@ -29,6 +32,10 @@ type App struct {
 	ctx     context.Context
 	server  *server.Server
 	log     *zap.SugaredLogger
+	tr      service.TransactionService
+	st      service.StatementService
+	so      service.StandingOrderService
+	pw      service.PasswordService
 }
 
 func New(log *zap.SugaredLogger, cfg *config.Config) *App {
@@ -36,17 +43,80 @@ func New(log *zap.SugaredLogger, cfg *config.Config) *App {
 
 	ctx := context.Background()
 
-	userRepo, accountRepo := setupRepo(ctx, log, cfg)
+	repos := setupRepo(ctx, log, cfg)
 
 	processMigration(cfg.MigrationPath, cfg.DbUrl, log)
 
-	userService := service.NewUserService(userRepo)
-	accountService := service.NewAccountService(accountRepo)
-	transactionService := service.NewTransactionService(accountRepo)
+	if err := repos.Accounts.RefreshCurrencies(ctx); err != nil {
+		log.Fatalln("Failed to load currencies: ", err)
+	}
+
+	var secrets *secretbox.Box
+	if cfg.TwoFactorKey != "" {
+		var err error
+		if secrets, err = secretbox.NewFromBase64(cfg.TwoFactorKey); err != nil {
+			log.Fatalln("Invalid two-factor key: ", err)
+		}
+	} else {
+		log.Warnln("TWO_FACTOR_KEY is not set, two-factor authentication is disabled")
+	}
+	lockout := service.LockoutPolicy{
+		FreeAttempts:  cfg.LoginFreeAttempts,
+		BaseDelay:     cfg.LoginBackoffBase,
+		MaxDelay:      cfg.LoginBackoffMax,
+		MaxFailures:   cfg.LoginMaxFailures,
+		MaxIPFailures: cfg.LoginIPMaxFailures,
+		Lockout:       cfg.LoginLockout,
+		Window:        cfg.LoginFailureWindow,
+	}
+	userService := service.NewUserService(repos.Users, service.TwoFactorConfig{
+		Secrets:      secrets,
+		Issuer:       cfg.TwoFactorIssuer,
+		ChallengeTTL: cfg.TwoFactorChallengeTTL,
+	}, lockout)
+	accountService := service.NewAccountService(repos.Accounts)
+	rateProvider := service.NewRateProvider(repos.Exchange)
+	transactionService := service.NewTransactionService(repos.Accounts, repos.Exchange, rateProvider, cfg.FxQuoteTTL, cfg.HoldTTL)
+
+	if err := transactionService.VerifyLedger(ctx); err != nil {
+		log.Errorln("Ledger verification failed: ", err)
+	}
+
+	idempotencyService := service.NewIdempotencyService(repos.Idempotency, cfg.IdempotencyKeyTTL)
 
-	h := handlers.NewHandler(cfg.JwtSecret, userService, accountService, transactionService)
+	statementService := service.NewStatementService(repos.Accounts, repos.Users, repos.Statements)
 
-	srv := server.New(router.NewRouter(h))
+	standingOrderService := service.NewStandingOrderService(repos.StandingOrders, repos.Accounts, transactionService, service.RetryPolicy{
+		MaxAttempts: cfg.StandingOrderMaxAttempts,
+		Delay:       cfg.StandingOrderRetryDelay,
+	})
+
+	jwt := setupJWT(log, cfg)
+	tokenService := service.NewTokenService(repos.Sessions, repos.Users, jwt, cfg.AccessTokenTTL, cfg.RefreshTokenTTL, cfg.SessionCacheTTL)
+
+	apiKeyService := service.NewAPIKeyService(repos.APIKeys)
+
+	adminService := service.NewAdminService(repos.Admin, repos.Users, repos.Accounts, transactionService, tokenService)
+
+	mailer := setupMailer(log, cfg)
+	passwordService := service.NewPasswordService(repos.Users, tokenService, mailer, service.PasswordResetConfig{
+		TTL:      cfg.PasswordResetTTL,
+		Interval: cfg.PasswordResetInterval,
+		URL:      cfg.PasswordResetURL,
+	}, lockout)
+	emailService := service.NewEmailService(repos.Users, mailer, service.EmailVerificationConfig{
+		TTL:      cfg.EmailVerificationTTL,
+		Interval: cfg.EmailVerificationInterval,
+		URL:      cfg.EmailVerificationURL,
+	})
+
+	h := handlers.NewHandler(jwt, userService, accountService, transactionService, idempotencyService, statementService, standingOrderService, tokenService, apiKeyService, adminService, passwordService, emailService)
+
+	r, err := router.NewRouter(h, cfg.TrustedProxies, secrets != nil)
+	if err != nil {
+		log.Fatalln("Invalid trusted proxies: ", err)
+	}
+	srv := server.New(r)
 
 	return &App{
 		config:  cfg,
@@ -54,10 +124,22 @@ func New(log *zap.SugaredLogger, cfg *config.Config) *App {
 		ctx:     ctx,
 		server:  srv,
 		log:     log,
+		tr:      transactionService,
+		st:      statementService,
+		so:      standingOrderService,
+		pw:      passwordService,
 	}
 }
 
 func (a *App) Run() {
+	jobsCtx, stopJobs := context.WithCancel(a.ctx)
+	defer stopJobs()
+	go a.sweepHolds(jobsCtx)
+	go a.sweepPending(jobsCtx)
+	go a.generateStatements(jobsCtx)
+	go a.runStandingOrders(jobsCtx)
+	go a.sendPasswordResets(jobsCtx)
+
 	go func() {
 		a.log.Infoln("Starting server on port ", a.config.HttpPort)
 		if err := a.server.Run(a.config.HttpPort); err != nil && !errors.Is(err, http.ErrServerClosed) {
@@ -67,6 +149,7 @@ func (a *App) Run() {
 
 	<-a.sigQuit
 	a.log.Infoln("Gracefully shutting down server")
+	stopJobs()
 
 	ctx, cancel := context.WithTimeout(a.ctx, 2*time.Second)
 	defer cancel()
@@ -78,7 +161,167 @@ func (a *App) Run() {
 	a.log.Infoln("Server shutdown is successful")
 }
 
-func setupRepo(ctx context.Context, log *zap.SugaredLogger, cfg *config.Config) (repository.UserRepository, repository.AccountRepository) {
+// sweepHolds expires overdue holds every HoldSweepInterval until ctx is canceled.
+func (a *App) sweepHolds(ctx context.Context) {
+	ticker := time.NewTicker(a.config.HoldSweepInterval)
+	defer ticker.Stop()
+
+	for {
+		select {
+		case <-ctx.Done():
+			return
+		case <-ticker.C:
+			n, err := a.tr.ExpireHolds(ctx)
+			if err != nil {
+				a.log.Errorln("Failed to expire holds: ", err)
+				continue
+			}
+			if n > 0 {
+				a.log.Infoln("Expired holds: ", n)
+			}
+		}
+	}
+}
+
+// sweepPending fails the transactions left pending for longer than PendingTimeout every PendingSweepInterval
+// until ctx is canceled.
+func (a *App) sweepPending(ctx context.Context) {
+	ticker := time.NewTicker(a.config.PendingSweepInterval)
+	defer ticker.Stop()
+
+	for {
+		select {
+		case <-ctx.Done():
+			return
+		case <-ticker.C:
+			n, err := a.tr.FailStalePending(ctx, a.config.PendingTimeout)
+			if err != nil {
+				a.log.Errorln("Failed to fail pending transactions: ", err)
+				continue
+			}
+			if n > 0 {
+				a.log.Warnln("Failed transactions left pending: ", n)
+			}
+		}
+	}
+}
+
+// generateStatements stores the statements missing for the past months on startup, so that months the
+// service was down for get theirs too, and then on the 1st of every month until ctx is canceled. A run that
+// fails is tried again after StatementRetryInterval rather than next month.
+func (a *App) generateStatements(ctx context.Context) {
+	for {
+		month := time.Now().UTC()
+		month = time.Date(month.Year(), month.Month(), 1, 0, 0, 0, 0, time.UTC)
+
+		n, err := a.st.GenerateMonthly(ctx, month)
+		if n > 0 {
+			a.log.Infoln("Generated statements: ", n)
+		}
+		wait := time.Until(month.AddDate(0, 1, 0))
+		if err != nil {
+			a.log.Errorln("Failed to generate statements: ", err)
+			wait = min(wait, a.config.StatementRetryInterval)
+		}
+
+		select {
+		case <-ctx.Done():
+			return
+		case <-time.After(wait):
+		}
+	}
+}
+
+// runStandingOrders executes the due standing orders every StandingOrderInterval until ctx is canceled.
+func (a *App) runStandingOrders(ctx context.Context) {
+	ticker := time.NewTicker(a.config.StandingOrderInterval)
+	defer ticker.Stop()
+
+	for {
+		select {
+		case <-ctx.Done():
+			return
+		case <-ticker.C:
+			n, err := a.so.RunDue(ctx)
+			if err != nil {
+				a.log.Errorln("Failed to execute standing orders: ", err)
+			}
+			if n > 0 {
+				a.log.Infoln("Executed standing orders: ", n)
+			}
+		}
+	}
+}
+
+// sendPasswordResets emails the requested password resets every PasswordResetSendInterval until ctx is canceled.
+func (a *App) sendPasswordResets(ctx context.Context) {
+	ticker := time.NewTicker(a.config.PasswordResetSendInterval)
+	defer ticker.Stop()
+
+	for {
+		select {
+		case <-ctx.Done():
+			return
+		case <-ticker.C:
+			if _, err := a.pw.SendResets(ctx); err != nil {
+				a.log.Errorln("Failed to send password resets: ", err)
+			}
+		}
+	}
+}
+
+func setupMailer(log *zap.SugaredLogger, cfg *config.Config) mail.Mailer {
+	switch cfg.MailDriver {
+	case "smtp":
+		return mail.NewSMTP(mail.SMTPConfig{
+			Host:     cfg.SmtpHost,
+			Port:     cfg.SmtpPort,
+			Username: cfg.SmtpUsername,
+			Password: cfg.SmtpPassword,
+			From:     cfg.MailFrom,
+		})
+	case "file":
+		f, err := os.OpenFile(cfg.MailFile, os.O_APPEND|os.O_CREATE|os.O_WRONLY, 0o600)
+		if err != nil {
+			log.Fatalln("Failed to open mail file: ", err)
+		}
+		log.Infoln("Writing emails to ", cfg.MailFile)
+		return mail.NewWriter(f, cfg.MailFrom)
+	case "log":
+		log.Warnln("Printing emails instead of sending them")
+		return mail.NewWriter(os.Stderr, cfg.MailFrom)
+	default:
+		log.Fatalln("Unknown mail driver: ", cfg.MailDriver)
+		return nil
+	}
+}
+
+func setupJWT(log *zap.SugaredLogger, cfg *config.Config) *auth.JWT {
+	var active *auth.Key
+	if cfg.JwtSigningKey != "" {
+		var err error
+		if active, err = auth.LoadKey(cfg.JwtSigningKey); err != nil {
+			log.Fatalln("Failed to load JWT signing key: ", err)
+		}
+		log.Infoln("Signing tokens with key ", active.Id)
+	}
+
+	retired := make([]*auth.Key, len(cfg.JwtRetiredKeys))
+	for i, path := range cfg.JwtRetiredKeys {
+		var err error
+		if retired[i], err = auth.LoadKey(path); err != nil {
+			log.Fatalln("Failed to load retired JWT key: ", err)
+		}
+	}
+
+	jwt, err := auth.NewJWTWithKeys(cfg.JwtSecret, cfg.JwtIssuer, cfg.JwtAudience, cfg.JwtLeeway, active, retired...)
+	if err != nil {
+		log.Fatalln("Failed to set up JWT: ", err)
+	}
+	return jwt
+}
+
+func setupRepo(ctx context.Context, log *zap.SugaredLogger, cfg *config.Config) *repository.Repositories {
 	pool, err := setupPgxPool(ctx, log, cfg)
 	if err != nil {
 		log.Fatalln(err)
@@ -0,0 +1,146 @@
+package app
+
+import (
+	"context"
+	"errors"
+	"flag"
+	"fmt"
+	"os"
+	"strings"
+
+	"bank-api/internal/domain"
+	"bank-api/internal/service"
+	"bank-api/pkg/config"
+	"bank-api/pkg/fxrates"
+
+	"go.uber.org/zap"
+)
+
+const usage = `Usage:
+  bank-api                                      start the HTTP server
+  bank-api rates import --file <path> [--format xml|csv]
+                                                import exchange rates from an ECB XML or a CSV file
+  bank-api api-keys create --name <name> --scopes <scope,...> [--user <id>]
+                                                create a key of the user, or a system key for the staff
+                                                routes with admin scopes, e.g. admin:accounts:read
+  bank-api users set-role --user <id> --role customer|support|admin|auditor
+                                                change the role of a user, e.g. to appoint the first admin`
+
+var ErrUsage = errors.New(usage)
+
+// RunCommand runs a command given on the command line instead of the HTTP server.
+func RunCommand(log *zap.SugaredLogger, cfg *config.Config, args []string) error {
+	if len(args) >= 2 && args[0] == "rates" && args[1] == "import" {
+		return importRates(log, cfg, args[2:])
+	}
+	if len(args) >= 2 && args[0] == "api-keys" && args[1] == "create" {
+		return createAPIKey(log, cfg, args[2:])
+	}
+	if len(args) >= 2 && args[0] == "users" && args[1] == "set-role" {
+		return setUserRole(log, cfg, args[2:])
+	}
+	return ErrUsage
+}
+
+func importRates(log *zap.SugaredLogger, cfg *config.Config, args []string) error {
+	flags := flag.NewFlagSet("rates import", flag.ContinueOnError)
+	file := flags.String("file", "", "path to the rates file")
+	format := flags.String("format", "", "rates file format, xml or csv; guessed by the file extension if not set")
+	if err := flags.Parse(args); err != nil || *file == "" {
+		return ErrUsage
+	}
+
+	if *format == "" {
+		var err error
+		if *format, err = fxrates.FormatOf(*file); err != nil {
+			return fmt.Errorf("%w, set it with --format", err)
+		}
+	}
+
+	f, err := os.Open(*file)
+	if err != nil {
+		return err
+	}
+	defer f.Close()
+
+	rates, err := fxrates.Parse(f, strings.ToLower(*format))
+	if err != nil {
+		return fmt.Errorf("can't parse %s: %w", *file, err)
+	}
+
+	ctx := context.Background()
+
+	repos := setupRepo(ctx, log, cfg)
+
+	processMigration(cfg.MigrationPath, cfg.DbUrl, log)
+
+	imported, skipped, err := service.NewRateImportService(repos.Exchange).Import(ctx, rates)
+	if len(skipped) > 0 {
+		log.Warnln("Skipped rates of currencies missing from the currency table: ", strings.Join(skipped, ", "))
+	}
+	if err != nil {
+		return err
+	}
+
+	log.Infof("Imported %d of %d rates from %s", imported, len(rates), *file)
+	return nil
+}
+
+func createAPIKey(log *zap.SugaredLogger, cfg *config.Config, args []string) error {
+	flags := flag.NewFlagSet("api-keys create", flag.ContinueOnError)
+	name := flags.String("name", "", "what the key is for")
+	scopes := flags.String("scopes", "", "comma separated scopes of the key")
+	userId := flags.Int("user", 0, "id of the user the key acts as; a system key is created if not set")
+	if err := flags.Parse(args); err != nil || *name == "" || *scopes == "" {
+		return ErrUsage
+	}
+
+	var keyScopes []domain.Scope
+	for _, scope := range strings.Split(*scopes, ",") {
+		keyScopes = append(keyScopes, domain.Scope(strings.TrimSpace(scope)))
+	}
+
+	ctx := context.Background()
+
+	repos := setupRepo(ctx, log, cfg)
+
+	processMigration(cfg.MigrationPath, cfg.DbUrl, log)
+
+	k, key, err := service.NewAPIKeyService(repos.APIKeys).CreateAPIKey(ctx, *userId, *name, keyScopes)
+	if err != nil {
+		return err
+	}
+
+	log.Infof("Created API key %d (%s)", k.Id, k.Prefix)
+	fmt.Println(key)
+	return nil
+}
+
+func setUserRole(log *zap.SugaredLogger, cfg *config.Config, args []string) error {
+	flags := flag.NewFlagSet("users set-role", flag.ContinueOnError)
+	userId := flags.Int("user", 0, "id of the user")
+	role := flags.String("role", "", "the new role")
+	if err := flags.Parse(args); err != nil || *userId == 0 || *role == "" {
+		return ErrUsage
+	}
+
+	ctx := context.Background()
+
+	repos := setupRepo(ctx, log, cfg)
+
+	processMigration(cfg.MigrationPath, cfg.DbUrl, log)
+
+	tokens := service.NewTokenService(repos.Sessions, repos.Users, setupJWT(log, cfg), cfg.AccessTokenTTL, cfg.RefreshTokenTTL, cfg.SessionCacheTTL)
+	transactions := service.NewTransactionService(repos.Accounts, repos.Exchange, service.NewRateProvider(repos.Exchange), cfg.FxQuoteTTL, cfg.HoldTTL)
+	admin := service.NewAdminService(repos.Admin, repos.Users, repos.Accounts, transactions, tokens)
+
+	// Whoever can run the server is an admin. The audit log records the change without an acting user.
+	operator := &domain.Principal{Role: domain.RoleAdmin}
+	user, err := admin.SetUserRole(ctx, operator, *userId, domain.Role(*role))
+	if err != nil {
+		return err
+	}
+
+	log.Infof("User %d (%s) is now %s", user.Id, user.Email, user.Role)
+	return nil
+}
@@ -0,0 +1,177 @@
+package auth
+
+import (
+	"errors"
+	"fmt"
+	"slices"
+	"strconv"
+	"time"
+
+	"bank-api/internal/domain"
+
+	"github.com/golang-jwt/jwt/v5"
+)
+
+var (
+	ErrInvalidToken = errors.New("invalid token")
+	ErrExpiredToken = errors.New("expired token")
+)
+
+// Claims are the claims of an access token. The user id is the subject.
+type Claims struct {
+	jwt.RegisteredClaims
+	SessionId int `json:"sid"`
+	// Role is missing from tokens issued before there were roles, which were all customers.
+	Role string `json:"role,omitempty"`
+}
+
+// Validate is called by the parser after the registered claims are checked.
+func (c *Claims) Validate() error {
+	if id, err := strconv.Atoi(c.Subject); err != nil || id <= 0 {
+		return errors.New("subject is not a user id")
+	}
+	if c.SessionId <= 0 {
+		return errors.New("session id is missing")
+	}
+	if c.Role != "" && !domain.Role(c.Role).Valid() {
+		return errors.New("unknown role")
+	}
+	return nil
+}
+
+// JWT issues and verifies access tokens for one issuer and audience. Tokens are signed with the active key,
+// or with HS256 and the shared secret if there is none. Tokens of the retired keys and, when there is a secret,
+// HS256 tokens are still accepted until they expire.
+type JWT struct {
+	secret []byte
+	active *Key
+	keys   map[string]*Key
+	// public are the keys in the order JWKS lists them, the active one first.
+	public   []*Key
+	issuer   string
+	audience string
+	parser   *jwt.Parser
+}
+
+// NewJWT returns a JWT that signs with HS256 only. It accepts tokens whose times are off by up to leeway,
+// to allow for clock skew.
+func NewJWT(secret, issuer, audience string, leeway time.Duration) *JWT {
+	j, _ := NewJWTWithKeys(secret, issuer, audience, leeway, nil)
+	return j
+}
+
+// NewJWTWithKeys returns a JWT that signs with active, if it isn't nil, and verifies with active and retired.
+func NewJWTWithKeys(secret, issuer, audience string, leeway time.Duration, active *Key, retired ...*Key) (*JWT, error) {
+	if secret == "" && active == nil {
+		return nil, errors.New("neither a secret nor a signing key is set")
+	}
+	if active != nil && !active.CanSign() {
+		return nil, errors.New("signing key is a public key")
+	}
+
+	j := &JWT{
+		secret:   []byte(secret),
+		active:   active,
+		keys:     make(map[string]*Key),
+		issuer:   issuer,
+		audience: audience,
+	}
+
+	var methods []string
+	if secret != "" {
+		methods = append(methods, jwt.SigningMethodHS256.Alg())
+	}
+	for _, key := range append([]*Key{active}, retired...) {
+		if key == nil {
+			continue
+		}
+		j.keys[key.Id] = key
+		j.public = append(j.public, key)
+		if !slices.Contains(methods, key.Method.Alg()) {
+			methods = append(methods, key.Method.Alg())
+		}
+	}
+
+	j.parser = jwt.NewParser(
+		// Only the algorithms of the configured keys are accepted, and keyFunc checks that a token's
+		// algorithm is the one of its key, so a token can't choose how it is checked.
+		jwt.WithValidMethods(methods),
+		jwt.WithIssuer(issuer),
+		jwt.WithAudience(audience),
+		jwt.WithExpirationRequired(),
+		jwt.WithIssuedAt(),
+		jwt.WithLeeway(leeway),
+	)
+	return j, nil
+}
+
+// Issue signs a token for the principal that is valid for ttl from now.
+func (j *JWT) Issue(p domain.Principal, now time.Time, ttl time.Duration) (string, error) {
+	claims := &Claims{
+		RegisteredClaims: jwt.RegisteredClaims{
+			Issuer:    j.issuer,
+			Subject:   strconv.Itoa(p.UserId),
+			Audience:  jwt.ClaimStrings{j.audience},
+			ExpiresAt: jwt.NewNumericDate(now.Add(ttl)),
+			NotBefore: jwt.NewNumericDate(now),
+			IssuedAt:  jwt.NewNumericDate(now),
+		},
+		SessionId: p.SessionId,
+		Role:      string(p.Role),
+	}
+
+	if j.active == nil {
+		return jwt.NewWithClaims(jwt.SigningMethodHS256, claims).SignedString(j.secret)
+	}
+	token := jwt.NewWithClaims(j.active.Method, claims)
+	token.Header["kid"] = j.active.Id
+	return token.SignedString(j.active.private)
+}
+
+// Verify checks the token and returns the principal it was issued for.
+func (j *JWT) Verify(token string) (*domain.Principal, error) {
+	var claims Claims
+	_, err := j.parser.ParseWithClaims(token, &claims, j.keyFunc)
+	if errors.Is(err, jwt.ErrTokenExpired) {
+		return nil, fmt.Errorf("%w: %w", ErrExpiredToken, err)
+	}
+	if err != nil {
+		return nil, fmt.Errorf("%w: %w", ErrInvalidToken, err)
+	}
+
+	userId, _ := strconv.Atoi(claims.Subject)
+	role := domain.Role(claims.Role)
+	if role == "" {
+		role = domain.RoleCustomer
+	}
+	return &domain.Principal{UserId: userId, SessionId: claims.SessionId, Role: role}, nil
+}
+
+// keyFunc picks the key of the token by its kid. Tokens without one are HS256 tokens.
+func (j *JWT) keyFunc(token *jwt.Token) (any, error) {
+	kid, _ := token.Header["kid"].(string)
+	if kid == "" {
+		if len(j.secret) == 0 || token.Method.Alg() != jwt.SigningMethodHS256.Alg() {
+			return nil, jwt.ErrTokenUnverifiable
+		}
+		return j.secret, nil
+	}
+
+	key, ok := j.keys[kid]
+	if !ok {
+		return nil, errUnknownKey
+	}
+	if token.Method.Alg() != key.Method.Alg() {
+		return nil, jwt.ErrTokenSignatureInvalid
+	}
+	return key.public, nil
+}
+
+// JWKS returns the public keys tokens are verified with, for other services to verify them too.
+func (j *JWT) JWKS() JWKSet {
+	set := JWKSet{Keys: make([]JWK, len(j.public))}
+	for i, key := range j.public {
+		set.Keys[i] = key.JWK()
+	}
+	return set
+}
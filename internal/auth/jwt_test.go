@@ -0,0 +1,112 @@
+package auth
+
+import (
+	"crypto/ed25519"
+	"crypto/rand"
+	"testing"
+	"time"
+
+	"bank-api/internal/domain"
+
+	"github.com/golang-jwt/jwt/v5"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+const leeway = 30 * time.Second
+
+func validClaims(now time.Time) jwt.MapClaims {
+	return jwt.MapClaims{
+		"iss": "bank-api",
+		"aud": "bank-api",
+		"sub": "1",
+		"sid": 5,
+		"iat": now.Unix(),
+		"nbf": now.Unix(),
+		"exp": now.Add(15 * time.Minute).Unix(),
+	}
+}
+
+func sign(t *testing.T, method jwt.SigningMethod, key any, claims jwt.MapClaims) string {
+	token, err := jwt.NewWithClaims(method, claims).SignedString(key)
+	require.NoError(t, err)
+	return token
+}
+
+func TestJWT_IssueVerify(t *testing.T) {
+	j := NewJWT("secret", "bank-api", "bank-api", leeway)
+
+	token, err := j.Issue(domain.Principal{UserId: 1, SessionId: 5, Role: domain.RoleAuditor}, time.Now(), time.Minute)
+	require.NoError(t, err)
+
+	principal, err := j.Verify(token)
+	assert.NoError(t, err)
+	assert.Equal(t, &domain.Principal{UserId: 1, SessionId: 5, Role: domain.RoleAuditor}, principal)
+}
+
+func TestJWT_Verify(t *testing.T) {
+	j := NewJWT("secret", "bank-api", "bank-api", leeway)
+	secret := []byte("secret")
+	now := time.Now()
+
+	_, edKey, err := ed25519.GenerateKey(rand.Reader)
+	require.NoError(t, err)
+
+	with := func(key string, value any) jwt.MapClaims {
+		claims := validClaims(now)
+		if value == nil {
+			delete(claims, key)
+		} else {
+			claims[key] = value
+		}
+		return claims
+	}
+
+	tests := []struct {
+		name  string
+		token string
+		role  domain.Role
+		err   error
+	}{
+		{name: "valid", token: sign(t, jwt.SigningMethodHS256, secret, validClaims(now))},
+		{name: "expired within leeway", token: sign(t, jwt.SigningMethodHS256, secret, with("exp", now.Add(-10*time.Second).Unix()))},
+		{name: "expired", token: sign(t, jwt.SigningMethodHS256, secret, with("exp", now.Add(-time.Minute).Unix())), err: ErrExpiredToken},
+		{name: "no expiry", token: sign(t, jwt.SigningMethodHS256, secret, with("exp", nil)), err: ErrInvalidToken},
+		{name: "not valid yet", token: sign(t, jwt.SigningMethodHS256, secret, with("nbf", now.Add(time.Minute).Unix())), err: ErrInvalidToken},
+		{name: "issued in the future", token: sign(t, jwt.SigningMethodHS256, secret, with("iat", now.Add(time.Minute).Unix())), err: ErrInvalidToken},
+		{name: "issued in the future within leeway", token: sign(t, jwt.SigningMethodHS256, secret, with("iat", now.Add(10*time.Second).Unix()))},
+		{name: "other issuer", token: sign(t, jwt.SigningMethodHS256, secret, with("iss", "someone")), err: ErrInvalidToken},
+		{name: "no issuer", token: sign(t, jwt.SigningMethodHS256, secret, with("iss", nil)), err: ErrInvalidToken},
+		{name: "other audience", token: sign(t, jwt.SigningMethodHS256, secret, with("aud", "other-service")), err: ErrInvalidToken},
+		{name: "no audience", token: sign(t, jwt.SigningMethodHS256, secret, with("aud", nil)), err: ErrInvalidToken},
+		{name: "subject is not a user id", token: sign(t, jwt.SigningMethodHS256, secret, with("sub", "admin")), err: ErrInvalidToken},
+		{name: "numeric subject", token: sign(t, jwt.SigningMethodHS256, secret, with("sub", 1)), err: ErrInvalidToken},
+		{name: "no session", token: sign(t, jwt.SigningMethodHS256, secret, with("sid", nil)), err: ErrInvalidToken},
+		{name: "role", token: sign(t, jwt.SigningMethodHS256, secret, with("role", "admin")), role: domain.RoleAdmin},
+		{name: "unknown role", token: sign(t, jwt.SigningMethodHS256, secret, with("role", "root")), err: ErrInvalidToken},
+		{name: "wrong secret", token: sign(t, jwt.SigningMethodHS256, []byte("guess"), validClaims(now)), err: ErrInvalidToken},
+		{name: "other HMAC algorithm", token: sign(t, jwt.SigningMethodHS512, secret, validClaims(now)), err: ErrInvalidToken},
+		{name: "asymmetric algorithm", token: sign(t, jwt.SigningMethodEdDSA, edKey, validClaims(now)), err: ErrInvalidToken},
+		{name: "no signature", token: sign(t, jwt.SigningMethodNone, jwt.UnsafeAllowNoneSignatureType, validClaims(now)), err: ErrInvalidToken},
+		{name: "malformed", token: "not.a.token", err: ErrInvalidToken},
+		{name: "empty", token: "", err: ErrInvalidToken},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			principal, err := j.Verify(tt.token)
+			if tt.err != nil {
+				assert.ErrorIs(t, err, tt.err)
+				assert.Nil(t, principal)
+				return
+			}
+			assert.NoError(t, err)
+			role := tt.role
+			if role == "" {
+				// Tokens without a role are of customers.
+				role = domain.RoleCustomer
+			}
+			assert.Equal(t, &domain.Principal{UserId: 1, SessionId: 5, Role: role}, principal)
+		})
+	}
+}
@@ -0,0 +1,138 @@
+package auth
+
+import (
+	"crypto/ed25519"
+	"crypto/rsa"
+	"crypto/sha256"
+	"crypto/x509"
+	"encoding/base64"
+	"encoding/json"
+	"encoding/pem"
+	"errors"
+	"fmt"
+	"math/big"
+	"os"
+
+	"github.com/golang-jwt/jwt/v5"
+)
+
+const minRSABits = 2048
+
+// Key is an asymmetric key tokens are signed or verified with. Keys loaded from a public key can only verify.
+type Key struct {
+	// Id is the JWK thumbprint of the public key (RFC 7638), so it doesn't have to be configured.
+	Id      string
+	Method  jwt.SigningMethod
+	private any
+	public  any
+}
+
+func (k *Key) CanSign() bool {
+	return k.private != nil
+}
+
+// LoadKey reads an RSA or Ed25519 key from a PEM file, either a private key (PKCS #8, or PKCS #1 for RSA)
+// or a public key (PKIX).
+func LoadKey(path string) (*Key, error) {
+	data, err := os.ReadFile(path)
+	if err != nil {
+		return nil, err
+	}
+	block, _ := pem.Decode(data)
+	if block == nil {
+		return nil, fmt.Errorf("%s: no PEM block", path)
+	}
+
+	var parsed any
+	switch block.Type {
+	case "PRIVATE KEY":
+		parsed, err = x509.ParsePKCS8PrivateKey(block.Bytes)
+	case "RSA PRIVATE KEY":
+		parsed, err = x509.ParsePKCS1PrivateKey(block.Bytes)
+	case "PUBLIC KEY":
+		parsed, err = x509.ParsePKIXPublicKey(block.Bytes)
+	default:
+		return nil, fmt.Errorf("%s: unsupported PEM block %q", path, block.Type)
+	}
+	if err != nil {
+		return nil, fmt.Errorf("%s: %w", path, err)
+	}
+
+	key, err := NewKey(parsed)
+	if err != nil {
+		return nil, fmt.Errorf("%s: %w", path, err)
+	}
+	return key, nil
+}
+
+// NewKey makes a Key of an *rsa.PrivateKey, *rsa.PublicKey, ed25519.PrivateKey or ed25519.PublicKey.
+// RSA keys sign with RS256 and Ed25519 keys with EdDSA.
+func NewKey(k any) (*Key, error) {
+	key := &Key{}
+	switch k := k.(type) {
+	case *rsa.PrivateKey:
+		key.Method, key.private, key.public = jwt.SigningMethodRS256, k, &k.PublicKey
+	case *rsa.PublicKey:
+		key.Method, key.public = jwt.SigningMethodRS256, k
+	case ed25519.PrivateKey:
+		key.Method, key.private, key.public = jwt.SigningMethodEdDSA, k, k.Public()
+	case ed25519.PublicKey:
+		key.Method, key.public = jwt.SigningMethodEdDSA, k
+	default:
+		return nil, fmt.Errorf("unsupported key type %T", k)
+	}
+
+	if public, ok := key.public.(*rsa.PublicKey); ok && public.N.BitLen() < minRSABits {
+		return nil, fmt.Errorf("RSA key is shorter than %d bits", minRSABits)
+	}
+
+	thumbprint, err := json.Marshal(key.jwk())
+	if err != nil {
+		return nil, err
+	}
+	sum := sha256.Sum256(thumbprint)
+	key.Id = base64.RawURLEncoding.EncodeToString(sum[:])
+	return key, nil
+}
+
+// JWK is a public key in the JSON Web Key format (RFC 7517).
+type JWK struct {
+	// The members are in the lexicographic order RFC 7638 computes thumbprints with.
+	Crv string `json:"crv,omitempty"`
+	E   string `json:"e,omitempty"`
+	Kty string `json:"kty"`
+	N   string `json:"n,omitempty"`
+	X   string `json:"x,omitempty"`
+
+	Alg string `json:"alg,omitempty"`
+	Kid string `json:"kid,omitempty"`
+	Use string `json:"use,omitempty"`
+}
+
+type JWKSet struct {
+	Keys []JWK `json:"keys"`
+}
+
+// jwk returns the required members of the public key only, which are what its thumbprint is made of.
+func (k *Key) jwk() JWK {
+	switch public := k.public.(type) {
+	case *rsa.PublicKey:
+		return JWK{
+			Kty: "RSA",
+			N:   base64.RawURLEncoding.EncodeToString(public.N.Bytes()),
+			E:   base64.RawURLEncoding.EncodeToString(big.NewInt(int64(public.E)).Bytes()),
+		}
+	case ed25519.PublicKey:
+		return JWK{Kty: "OKP", Crv: "Ed25519", X: base64.RawURLEncoding.EncodeToString(public)}
+	}
+	return JWK{}
+}
+
+// JWK returns the public key with its id and algorithm.
+func (k *Key) JWK() JWK {
+	jwk := k.jwk()
+	jwk.Alg, jwk.Kid, jwk.Use = k.Method.Alg(), k.Id, "sig"
+	return jwk
+}
+
+var errUnknownKey = errors.New("unknown key id")
@@ -0,0 +1,184 @@
+package auth
+
+import (
+	"crypto/ed25519"
+	"crypto/rand"
+	"crypto/rsa"
+	"crypto/x509"
+	"encoding/pem"
+	"os"
+	"path/filepath"
+	"testing"
+	"time"
+
+	"bank-api/internal/domain"
+
+	"github.com/golang-jwt/jwt/v5"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func writePEM(t *testing.T, blockType string, der []byte) string {
+	path := filepath.Join(t.TempDir(), "key.pem")
+	require.NoError(t, os.WriteFile(path, pem.EncodeToMemory(&pem.Block{Type: blockType, Bytes: der}), 0o600))
+	return path
+}
+
+func TestLoadKey(t *testing.T) {
+	rsaKey, err := rsa.GenerateKey(rand.Reader, 2048)
+	require.NoError(t, err)
+	edPublic, edPrivate, err := ed25519.GenerateKey(rand.Reader)
+	require.NoError(t, err)
+
+	pkcs8RSA, err := x509.MarshalPKCS8PrivateKey(rsaKey)
+	require.NoError(t, err)
+	pkixRSA, err := x509.MarshalPKIXPublicKey(&rsaKey.PublicKey)
+	require.NoError(t, err)
+	pkcs8Ed, err := x509.MarshalPKCS8PrivateKey(edPrivate)
+	require.NoError(t, err)
+	pkixEd, err := x509.MarshalPKIXPublicKey(edPublic)
+	require.NoError(t, err)
+
+	tests := []struct {
+		name    string
+		path    string
+		alg     string
+		canSign bool
+	}{
+		{name: "RSA PKCS #8", path: writePEM(t, "PRIVATE KEY", pkcs8RSA), alg: "RS256", canSign: true},
+		{name: "RSA PKCS #1", path: writePEM(t, "RSA PRIVATE KEY", x509.MarshalPKCS1PrivateKey(rsaKey)), alg: "RS256", canSign: true},
+		{name: "RSA public", path: writePEM(t, "PUBLIC KEY", pkixRSA), alg: "RS256"},
+		{name: "Ed25519 PKCS #8", path: writePEM(t, "PRIVATE KEY", pkcs8Ed), alg: "EdDSA", canSign: true},
+		{name: "Ed25519 public", path: writePEM(t, "PUBLIC KEY", pkixEd), alg: "EdDSA"},
+	}
+
+	ids := map[string]string{}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			key, err := LoadKey(tt.path)
+			require.NoError(t, err)
+			assert.Equal(t, tt.alg, key.Method.Alg())
+			assert.Equal(t, tt.canSign, key.CanSign())
+			ids[tt.name] = key.Id
+		})
+	}
+
+	// The id depends on the public key only.
+	assert.Equal(t, ids["RSA PKCS #8"], ids["RSA PKCS #1"])
+	assert.Equal(t, ids["RSA PKCS #8"], ids["RSA public"])
+	assert.Equal(t, ids["Ed25519 PKCS #8"], ids["Ed25519 public"])
+	assert.NotEqual(t, ids["RSA public"], ids["Ed25519 public"])
+}
+
+func TestLoadKey_Errors(t *testing.T) {
+	small, err := rsa.GenerateKey(rand.Reader, 1024)
+	require.NoError(t, err)
+
+	notPEM := filepath.Join(t.TempDir(), "key.txt")
+	require.NoError(t, os.WriteFile(notPEM, []byte("key"), 0o600))
+
+	for name, path := range map[string]string{
+		"missing file":  filepath.Join(t.TempDir(), "missing.pem"),
+		"not PEM":       notPEM,
+		"certificate":   writePEM(t, "CERTIFICATE", []byte{1}),
+		"short RSA key": writePEM(t, "RSA PRIVATE KEY", x509.MarshalPKCS1PrivateKey(small)),
+	} {
+		_, err := LoadKey(path)
+		assert.Error(t, err, name)
+	}
+}
+
+func TestJWT_Rotation(t *testing.T) {
+	_, oldKey, err := ed25519.GenerateKey(rand.Reader)
+	require.NoError(t, err)
+	_, newKey, err := ed25519.GenerateKey(rand.Reader)
+	require.NoError(t, err)
+	rsaKey, err := rsa.GenerateKey(rand.Reader, 2048)
+	require.NoError(t, err)
+
+	older, err := NewKey(oldKey)
+	require.NoError(t, err)
+	newer, err := NewKey(newKey)
+	require.NoError(t, err)
+	retiredRSA, err := NewKey(&rsaKey.PublicKey)
+	require.NoError(t, err)
+
+	p := domain.Principal{UserId: 1, SessionId: 5, Role: domain.RoleCustomer}
+	before, err := NewJWTWithKeys("", "bank-api", "bank-api", 0, older)
+	require.NoError(t, err)
+	oldToken, err := before.Issue(p, time.Now(), time.Minute)
+	require.NoError(t, err)
+
+	j, err := NewJWTWithKeys("secret", "bank-api", "bank-api", 0, newer, verifyingKey(t, older), retiredRSA)
+	require.NoError(t, err)
+
+	newToken, err := j.Issue(p, time.Now(), time.Minute)
+	require.NoError(t, err)
+	parsed, _, err := jwt.NewParser().ParseUnverified(newToken, &Claims{})
+	require.NoError(t, err)
+	assert.Equal(t, newer.Id, parsed.Header["kid"])
+	assert.Equal(t, "EdDSA", parsed.Header["alg"])
+
+	hsToken, err := NewJWT("secret", "bank-api", "bank-api", 0).Issue(p, time.Now(), time.Minute)
+	require.NoError(t, err)
+
+	for name, token := range map[string]string{"active key": newToken, "retired key": oldToken, "HS256": hsToken} {
+		principal, err := j.Verify(token)
+		assert.NoError(t, err, name)
+		assert.Equal(t, &p, principal, name)
+	}
+
+	// A token of an unknown key, or with the algorithm of another key type, is rejected.
+	unknown, err := NewJWTWithKeys("", "bank-api", "bank-api", 0, func() *Key {
+		_, k, _ := ed25519.GenerateKey(rand.Reader)
+		key, _ := NewKey(k)
+		return key
+	}())
+	require.NoError(t, err)
+	unknownToken, err := unknown.Issue(p, time.Now(), time.Minute)
+	require.NoError(t, err)
+	_, err = j.Verify(unknownToken)
+	assert.ErrorIs(t, err, ErrInvalidToken)
+
+	confused := jwt.NewWithClaims(jwt.SigningMethodHS256, validClaims(time.Now()))
+	confused.Header["kid"] = newer.Id
+	confusedToken, err := confused.SignedString([]byte("secret"))
+	require.NoError(t, err)
+	_, err = j.Verify(confusedToken)
+	assert.ErrorIs(t, err, ErrInvalidToken)
+
+	// Without a secret HS256 tokens are not accepted anymore.
+	asymmetricOnly, err := NewJWTWithKeys("", "bank-api", "bank-api", 0, newer)
+	require.NoError(t, err)
+	_, err = asymmetricOnly.Verify(hsToken)
+	assert.ErrorIs(t, err, ErrInvalidToken)
+
+	jwks := j.JWKS()
+	require.Len(t, jwks.Keys, 3)
+	assert.Equal(t, newer.Id, jwks.Keys[0].Kid)
+	assert.Equal(t, "OKP", jwks.Keys[0].Kty)
+	assert.Equal(t, "Ed25519", jwks.Keys[0].Crv)
+	assert.Equal(t, older.Id, jwks.Keys[1].Kid)
+	assert.Equal(t, "RSA", jwks.Keys[2].Kty)
+	assert.Equal(t, "RS256", jwks.Keys[2].Alg)
+	assert.Equal(t, "AQAB", jwks.Keys[2].E)
+}
+
+// verifyingKey returns the public half of the key, as a retired key is usually configured.
+func verifyingKey(t *testing.T, k *Key) *Key {
+	key, err := NewKey(k.public)
+	require.NoError(t, err)
+	return key
+}
+
+func TestNewJWTWithKeys_Errors(t *testing.T) {
+	public, _, err := ed25519.GenerateKey(rand.Reader)
+	require.NoError(t, err)
+	verifyOnly, err := NewKey(public)
+	require.NoError(t, err)
+
+	_, err = NewJWTWithKeys("", "bank-api", "bank-api", 0, nil)
+	assert.Error(t, err)
+	_, err = NewJWTWithKeys("", "bank-api", "bank-api", 0, verifyOnly)
+	assert.Error(t, err)
+}
@@ -1,8 +1,27 @@
 package domain
 
+import (
+	"errors"
+	"time"
+)
+
 type Account struct {
 	Id     int
 	UserId int
 	Cur    Currency
-	Amount int
+	Amount Money
+	// Available is Amount less the active holds on the account.
+	Available Money
+	// FrozenAt is set while staff has frozen the account, which then can't send or receive money.
+	FrozenAt     time.Time
+	FrozenReason string
+}
+
+func (a *Account) Frozen() bool {
+	return !a.FrozenAt.IsZero()
 }
+
+var (
+	ErrInsufficientFunds = errors.New("insufficient funds")
+	ErrAccountFrozen     = errors.New("account is frozen")
+)
@@ -0,0 +1,70 @@
+package domain
+
+import (
+	"slices"
+	"time"
+)
+
+// Scope is something an API key is allowed to do. Users logged in with a session can do everything on their own.
+type Scope string
+
+const (
+	ScopeAccountsRead        Scope = "accounts:read"
+	ScopeAccountsWrite       Scope = "accounts:write"
+	ScopeTransactionsWrite   Scope = "transactions:write"
+	ScopeHistoryRead         Scope = "history:read"
+	ScopeStandingOrdersRead  Scope = "standing-orders:read"
+	ScopeStandingOrdersWrite Scope = "standing-orders:write"
+)
+
+var Scopes = []Scope{
+	ScopeAccountsRead,
+	ScopeAccountsWrite,
+	ScopeTransactionsWrite,
+	ScopeHistoryRead,
+	ScopeStandingOrdersRead,
+	ScopeStandingOrdersWrite,
+}
+
+// AdminScope is the scope that gives a system key the permission on the data of any user.
+// Only system keys can have admin scopes, and they can't have any other.
+func AdminScope(perm Permission) Scope {
+	return Scope("admin:" + string(perm))
+}
+
+// Valid tells whether the scope is one a key of a user can have.
+func (s Scope) Valid() bool {
+	return slices.Contains(Scopes, s)
+}
+
+// Admin tells whether the scope is the admin scope of a permission, which only system keys can have.
+func (s Scope) Admin() bool {
+	for _, perm := range Permissions {
+		if s == AdminScope(perm) {
+			return true
+		}
+	}
+	return false
+}
+
+// APIKey is stored by its prefix, which identifies it, and the hash of its secret. A key with no UserId
+// is a system key, which doesn't act as any user but can use the staff routes its admin scopes allow.
+type APIKey struct {
+	Id         int
+	UserId     int
+	Name       string
+	Prefix     string
+	Hash       []byte
+	Scopes     []Scope
+	LastUsedAt time.Time
+	RevokedAt  time.Time
+	CreatedAt  time.Time
+}
+
+func (k *APIKey) Revoked() bool {
+	return !k.RevokedAt.IsZero()
+}
+
+func (k *APIKey) System() bool {
+	return k.UserId == 0
+}
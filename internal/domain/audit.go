@@ -0,0 +1,29 @@
+package domain
+
+import "time"
+
+// AuditEntry records an action staff took through the admin API, and who took it. An entry without
+// an ActorUserId was made from the command line.
+type AuditEntry struct {
+	Id             int
+	ActorUserId    int
+	ActorSessionId int
+	ActorRole      Role
+	// ActorAPIKeyId is the system key the change was made with, if any.
+	ActorAPIKeyId int
+	Action        string
+	TargetType    string
+	TargetId      int
+	Details       map[string]any
+	CreatedAt     time.Time
+}
+
+// AuditFilter selects audit entries, newest first. Zero values don't filter anything.
+type AuditFilter struct {
+	ActorUserId int
+	TargetType  string
+	TargetId    int
+	// AfterId is the id of the last entry of the previous page.
+	AfterId int
+	Limit   int
+}
@@ -4,3 +4,18 @@ type Currency struct {
 	Id     int
 	Symbol string
 }
+
+// ISO 4217 currencies whose minor unit differs from the usual two digits.
+var minorUnits = map[string]int{
+	"BIF": 0, "CLP": 0, "DJF": 0, "GNF": 0, "ISK": 0, "JPY": 0, "KMF": 0, "KRW": 0,
+	"PYG": 0, "RWF": 0, "UGX": 0, "UYI": 0, "VND": 0, "VUV": 0, "XAF": 0, "XOF": 0, "XPF": 0,
+	"BHD": 3, "IQD": 3, "JOD": 3, "KWD": 3, "LYD": 3, "OMR": 3, "TND": 3,
+}
+
+// MinorUnits returns the number of fractional digits amounts of the currency have.
+func (c Currency) MinorUnits() int {
+	if units, ok := minorUnits[c.Symbol]; ok {
+		return units
+	}
+	return 2
+}
@@ -0,0 +1,137 @@
+package domain
+
+import (
+	"errors"
+	"math/big"
+	"time"
+)
+
+// RateScale is the number of fractional digits exchange rates are stored and applied with.
+const RateScale = 10
+
+// ExchangeRate tells how many units of To one unit of From is worth.
+type ExchangeRate struct {
+	// Id is the id of the stored rate, or zero for a rate derived from stored ones.
+	Id          int
+	From        Currency
+	To          Currency
+	Rate        *big.Rat
+	EffectiveAt time.Time
+	// Sources are the stored rates a derived rate is calculated from, in the order they are applied.
+	Sources []RateSource
+}
+
+// RateSource is a stored rate that is applied as it is, or inverted.
+type RateSource struct {
+	Id       int
+	Inverted bool
+}
+
+// RateSources returns the stored rates the rate is calculated from: just itself if it is a stored one.
+func (r *ExchangeRate) RateSources() []RateSource {
+	if r.Id != 0 {
+		return []RateSource{{Id: r.Id}}
+	}
+	return r.Sources
+}
+
+// SetSources sets the stored rates the rate is calculated from. The rate is that stored rate itself
+// if it's the only one and isn't inverted.
+func (r *ExchangeRate) SetSources(sources []RateSource) {
+	if len(sources) == 1 && !sources[0].Inverted {
+		r.Id, r.Sources = sources[0].Id, nil
+		return
+	}
+	r.Id, r.Sources = 0, sources
+}
+
+// ParseRate parses a decimal exchange rate, rounding it to RateScale fractional digits.
+func ParseRate(s string) (*big.Rat, bool) {
+	rate, ok := new(big.Rat).SetString(s)
+	if !ok || rate.Sign() <= 0 {
+		return nil, false
+	}
+	return roundRate(rate), true
+}
+
+func roundRate(rate *big.Rat) *big.Rat {
+	rounded, _ := new(big.Rat).SetString(rate.FloatString(RateScale))
+	return rounded
+}
+
+// Inverse returns the rate of the opposite direction, derived from the sources of the rate inverted.
+func (r *ExchangeRate) Inverse() *ExchangeRate {
+	sources := r.RateSources()
+	inverted := make([]RateSource, 0, len(sources))
+	for i := len(sources) - 1; i >= 0; i-- {
+		inverted = append(inverted, RateSource{Id: sources[i].Id, Inverted: !sources[i].Inverted})
+	}
+	inverse := &ExchangeRate{
+		From:        r.To,
+		To:          r.From,
+		Rate:        roundRate(new(big.Rat).Inv(r.Rate)),
+		EffectiveAt: r.EffectiveAt,
+	}
+	inverse.SetSources(inverted)
+	return inverse
+}
+
+// Cross chains two rates (From -> X and X -> To) into a From -> To rate derived from the sources of both.
+func (r *ExchangeRate) Cross(next *ExchangeRate) *ExchangeRate {
+	effectiveAt := r.EffectiveAt
+	if next.EffectiveAt.Before(effectiveAt) {
+		effectiveAt = next.EffectiveAt
+	}
+	cross := &ExchangeRate{
+		From:        r.From,
+		To:          next.To,
+		Rate:        roundRate(new(big.Rat).Mul(r.Rate, next.Rate)),
+		EffectiveAt: effectiveAt,
+	}
+	cross.SetSources(append(append([]RateSource{}, r.RateSources()...), next.RateSources()...))
+	return cross
+}
+
+// Convert converts an amount of From into To, see Money.Convert for the rounding rules.
+func (r *ExchangeRate) Convert(amount Money) (Money, error) {
+	return amount.Convert(r.Rate, r.To)
+}
+
+func (r *ExchangeRate) String() string {
+	return formatRate(r.Rate)
+}
+
+func formatRate(rate *big.Rat) string {
+	s := rate.FloatString(RateScale)
+	for s[len(s)-1] == '0' {
+		s = s[:len(s)-1]
+	}
+	if s[len(s)-1] == '.' {
+		s = s[:len(s)-1]
+	}
+	return s
+}
+
+var (
+	ErrQuoteExpired = errors.New("quote has expired")
+	ErrQuoteUsed    = errors.New("quote is already used")
+)
+
+// Quote locks the exchange rate of a transfer between two accounts until it expires or is used.
+type Quote struct {
+	Id            int
+	UserId        int
+	FromAccountId int
+	ToAccountId   int
+	Cur           Currency
+	Amount        Money
+	ToCur         Currency
+	ToAmount      Money
+	Rate          *ExchangeRate
+	ExpiresAt     time.Time
+	Used          bool
+}
+
+func (q *Quote) Expired(now time.Time) bool {
+	return !now.Before(q.ExpiresAt)
+}
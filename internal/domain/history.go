@@ -0,0 +1,70 @@
+package domain
+
+import (
+	"encoding/base64"
+	"errors"
+	"strconv"
+	"strings"
+	"time"
+)
+
+var ErrInvalidCursor = errors.New("invalid cursor")
+
+// TransactionFilter selects transactions of the accounts of UserId, or only of AccountId if it is set.
+// Zero values don't filter anything.
+type TransactionFilter struct {
+	UserId    int
+	AccountId int
+
+	// From is inclusive and To is exclusive.
+	From time.Time
+	To   time.Time
+	Type *TransactionType
+	// MinAmount and MaxAmount are compared with Amount, in the currency the transaction was made in.
+	MinAmount *Money
+	MaxAmount *Money
+	Cur       Currency
+	// CounterpartyId is the account on the other side of the transaction.
+	CounterpartyId int
+
+	After *Cursor
+	Limit int
+}
+
+// Cursor points at the last transaction of a page. History is ordered newest first by (Time, Id),
+// so the next page starts right after it.
+type Cursor struct {
+	Time time.Time
+	Id   int
+}
+
+func CursorOf(t *Transaction) *Cursor {
+	return &Cursor{Time: t.Time, Id: t.Id}
+}
+
+func (c *Cursor) String() string {
+	raw := strconv.FormatInt(c.Time.UnixMicro(), 10) + "." + strconv.Itoa(c.Id)
+	return base64.RawURLEncoding.EncodeToString([]byte(raw))
+}
+
+func ParseCursor(s string) (*Cursor, error) {
+	raw, err := base64.RawURLEncoding.DecodeString(s)
+	if err != nil {
+		return nil, ErrInvalidCursor
+	}
+
+	micros, id, ok := strings.Cut(string(raw), ".")
+	if !ok {
+		return nil, ErrInvalidCursor
+	}
+	usec, err := strconv.ParseInt(micros, 10, 64)
+	if err != nil {
+		return nil, ErrInvalidCursor
+	}
+	cursor := Cursor{Time: time.UnixMicro(usec).UTC()}
+	if cursor.Id, err = strconv.Atoi(id); err != nil || cursor.Id <= 0 {
+		return nil, ErrInvalidCursor
+	}
+
+	return &cursor, nil
+}
@@ -0,0 +1,23 @@
+package domain
+
+import (
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestCursor(t *testing.T) {
+	cursor := &Cursor{Time: time.Date(2024, 6, 9, 12, 30, 15, 123456000, time.UTC), Id: 42}
+
+	parsed, err := ParseCursor(cursor.String())
+	assert.NoError(t, err)
+	assert.Equal(t, cursor, parsed)
+}
+
+func TestParseCursor_Invalid(t *testing.T) {
+	for _, s := range []string{"", "!!", "MTIz", "YS40Mg", "MTIzLmI", "MTIzLi0x"} {
+		_, err := ParseCursor(s)
+		assert.ErrorIs(t, err, ErrInvalidCursor, s)
+	}
+}
@@ -0,0 +1,38 @@
+package domain
+
+import (
+	"errors"
+	"time"
+)
+
+type HoldStatus string
+
+const (
+	HoldActive   HoldStatus = "active"
+	HoldCaptured HoldStatus = "captured"
+	HoldReleased HoldStatus = "released"
+	HoldExpired  HoldStatus = "expired"
+)
+
+var (
+	ErrHoldNotActive      = errors.New("hold is not active")
+	ErrCaptureExceedsHold = errors.New("capture exceeds the held amount")
+)
+
+// Hold reserves Amount on an account: it reduces the available balance without moving money until
+// it is captured into ToAccountId (or out of the bank when ToAccountId is 0), released or expires.
+type Hold struct {
+	Id             int
+	AccountId      int
+	ToAccountId    int
+	Cur            Currency
+	Amount         Money
+	CapturedAmount Money
+	Status         HoldStatus
+	ExpiresAt      time.Time
+	Time           time.Time
+}
+
+func (h *Hold) Active(now time.Time) bool {
+	return h.Status == HoldActive && now.Before(h.ExpiresAt)
+}
@@ -0,0 +1,20 @@
+package domain
+
+import (
+	"time"
+)
+
+type IdempotentResponse struct {
+	StatusCode  int
+	ContentType string
+	Body        []byte
+}
+
+type IdempotencyRecord struct {
+	UserId      int
+	Key         string
+	RequestHash string
+	// Response is nil while the first request with the key is still being processed.
+	Response  *IdempotentResponse
+	ExpiresAt time.Time
+}
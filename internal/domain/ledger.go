@@ -0,0 +1,66 @@
+package domain
+
+import (
+	"time"
+)
+
+// Posting is a signed movement on a single account: positive amounts increase its balance.
+type Posting struct {
+	AccountId int
+	Cur       Currency
+	Amount    Money
+}
+
+type JournalEntry struct {
+	Id            int
+	TransactionId int
+	Description   string
+	Postings      []Posting
+	Time          time.Time
+}
+
+// Balanced reports whether postings of the entry sum up to zero in every currency.
+func (e *JournalEntry) Balanced() bool {
+	if len(e.Postings) < 2 {
+		return false
+	}
+
+	sums := make(map[int]Money)
+	for _, p := range e.Postings {
+		if p.Amount.IsZero() {
+			return false
+		}
+		sum, err := sums[p.Cur.Id].Add(p.Amount)
+		if err != nil {
+			return false
+		}
+		sums[p.Cur.Id] = sum
+	}
+	for _, sum := range sums {
+		if !sum.IsZero() {
+			return false
+		}
+	}
+
+	return true
+}
+
+type CurrencyImbalance struct {
+	Cur Currency
+	Sum Money
+}
+
+type AccountDrift struct {
+	AccountId int
+	Balance   Money
+	Posted    Money
+}
+
+type LedgerReport struct {
+	Imbalances []CurrencyImbalance
+	Drifts     []AccountDrift
+}
+
+func (r *LedgerReport) Consistent() bool {
+	return len(r.Imbalances) == 0 && len(r.Drifts) == 0
+}
@@ -0,0 +1,271 @@
+package domain
+
+import (
+	"database/sql/driver"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"math"
+	"math/big"
+	"strconv"
+	"strings"
+)
+
+const maxMoneyDigits = 18
+
+var (
+	ErrInvalidMoney   = errors.New("invalid money amount")
+	ErrMoneyPrecision = errors.New("money amount has more fractional digits than its currency allows")
+	ErrMoneyOverflow  = errors.New("money amount overflows")
+)
+
+// Money is an exact decimal amount: units * 10^-scale.
+// Amounts that belong to an account or a transaction are kept in the minor units of their currency,
+// so that 12.34 USD is {1234, 2} and 1234 JPY is {1234, 0}.
+type Money struct {
+	units int64
+	scale int
+}
+
+// NewMoney returns an amount of cur given in its minor units.
+func NewMoney(minor int64, cur Currency) Money {
+	return Money{units: minor, scale: cur.MinorUnits()}
+}
+
+// ParseMoney parses a plain decimal string such as "12", "-0.5" or "1000.25" without losing precision.
+func ParseMoney(s string) (Money, error) {
+	if s == "" {
+		return Money{}, ErrInvalidMoney
+	}
+
+	neg := false
+	switch s[0] {
+	case '-':
+		neg = true
+		s = s[1:]
+	case '+':
+		s = s[1:]
+	}
+
+	intPart, fracPart, hasPoint := strings.Cut(s, ".")
+	if intPart == "" || (hasPoint && fracPart == "") {
+		return Money{}, ErrInvalidMoney
+	}
+	digits := intPart + fracPart
+	for _, r := range digits {
+		if r < '0' || r > '9' {
+			return Money{}, ErrInvalidMoney
+		}
+	}
+	if len(strings.TrimLeft(digits, "0")) > maxMoneyDigits || len(fracPart) > maxMoneyDigits {
+		return Money{}, ErrMoneyOverflow
+	}
+
+	units, err := strconv.ParseInt(digits, 10, 64)
+	if err != nil {
+		return Money{}, ErrMoneyOverflow
+	}
+	if neg {
+		units = -units
+	}
+
+	return Money{units: units, scale: len(fracPart)}, nil
+}
+
+// Units returns the amount as an integer number of 10^-Scale() units.
+func (m Money) Units() int64 {
+	return m.units
+}
+
+func (m Money) Scale() int {
+	return m.scale
+}
+
+// In returns the amount expressed in the minor units of cur.
+// It fails with ErrMoneyPrecision instead of rounding when the amount has more fractional digits than cur allows.
+func (m Money) In(cur Currency) (Money, error) {
+	return m.rescale(cur.MinorUnits())
+}
+
+func (m Money) rescale(scale int) (Money, error) {
+	units := m.units
+	for s := m.scale; s < scale; s++ {
+		if units > math.MaxInt64/10 || units < math.MinInt64/10 {
+			return Money{}, ErrMoneyOverflow
+		}
+		units *= 10
+	}
+	for s := m.scale; s > scale; s-- {
+		if units%10 != 0 {
+			return Money{}, ErrMoneyPrecision
+		}
+		units /= 10
+	}
+	return Money{units: units, scale: scale}, nil
+}
+
+func align(a, b Money) (Money, Money, error) {
+	scale := max(a.scale, b.scale)
+	a, err := a.rescale(scale)
+	if err != nil {
+		return Money{}, Money{}, err
+	}
+	b, err = b.rescale(scale)
+	if err != nil {
+		return Money{}, Money{}, err
+	}
+	return a, b, nil
+}
+
+func (m Money) Add(o Money) (Money, error) {
+	a, b, err := align(m, o)
+	if err != nil {
+		return Money{}, err
+	}
+	sum := a.units + b.units
+	if (b.units > 0 && sum < a.units) || (b.units < 0 && sum > a.units) {
+		return Money{}, ErrMoneyOverflow
+	}
+	return Money{units: sum, scale: a.scale}, nil
+}
+
+func (m Money) Sub(o Money) (Money, error) {
+	return m.Add(o.Neg())
+}
+
+func (m Money) Neg() Money {
+	return Money{units: -m.units, scale: m.scale}
+}
+
+// Cmp compares amounts by value regardless of their scale and returns -1, 0 or +1.
+func (m Money) Cmp(o Money) int {
+	a, b, err := align(m, o)
+	if err != nil {
+		// Only possible when aligning scales overflows, in which case the bigger scale decides.
+		if m.scale > o.scale {
+			return m.Sign()
+		}
+		return -o.Sign()
+	}
+	switch {
+	case a.units < b.units:
+		return -1
+	case a.units > b.units:
+		return 1
+	default:
+		return 0
+	}
+}
+
+// Convert multiplies the amount by rate and expresses the result in the minor units of cur.
+// The result is rounded half to even ("banker's rounding"), so that rounding errors don't
+// accumulate in either the customer's or the bank's favour over many conversions.
+func (m Money) Convert(rate *big.Rat, cur Currency) (Money, error) {
+	scale := cur.MinorUnits()
+
+	num := new(big.Int).Mul(big.NewInt(m.units), rate.Num())
+	num.Mul(num, pow10(scale))
+	den := new(big.Int).Mul(rate.Denom(), pow10(m.scale))
+
+	quo, rem := new(big.Int).QuoRem(num, den, new(big.Int))
+	rem.Abs(rem).Lsh(rem, 1)
+	if c := rem.Cmp(den); c > 0 || (c == 0 && quo.Bit(0) == 1) {
+		if num.Sign() < 0 {
+			quo.Sub(quo, big.NewInt(1))
+		} else {
+			quo.Add(quo, big.NewInt(1))
+		}
+	}
+
+	if !quo.IsInt64() {
+		return Money{}, ErrMoneyOverflow
+	}
+	return Money{units: quo.Int64(), scale: scale}, nil
+}
+
+func pow10(n int) *big.Int {
+	return new(big.Int).Exp(big.NewInt(10), big.NewInt(int64(n)), nil)
+}
+
+func (m Money) Sign() int {
+	switch {
+	case m.units < 0:
+		return -1
+	case m.units > 0:
+		return 1
+	default:
+		return 0
+	}
+}
+
+func (m Money) IsZero() bool {
+	return m.units == 0
+}
+
+func (m Money) String() string {
+	units := m.units
+	sign := ""
+	if units < 0 {
+		sign = "-"
+	}
+
+	digits := strconv.FormatUint(absUnits(units), 10)
+	if m.scale == 0 {
+		return sign + digits
+	}
+	if len(digits) <= m.scale {
+		digits = strings.Repeat("0", m.scale-len(digits)+1) + digits
+	}
+	point := len(digits) - m.scale
+	return sign + digits[:point] + "." + digits[point:]
+}
+
+func absUnits(units int64) uint64 {
+	if units < 0 {
+		return uint64(-(units + 1)) + 1
+	}
+	return uint64(units)
+}
+
+func (m Money) MarshalJSON() ([]byte, error) {
+	return json.Marshal(m.String())
+}
+
+// UnmarshalJSON accepts both JSON strings ("12.34") and plain JSON numbers (12.34).
+func (m *Money) UnmarshalJSON(data []byte) error {
+	s := string(data)
+	if len(s) >= 2 && s[0] == '"' && s[len(s)-1] == '"' {
+		s = s[1 : len(s)-1]
+	}
+
+	parsed, err := ParseMoney(s)
+	if err != nil {
+		return err
+	}
+	*m = parsed
+	return nil
+}
+
+func (m *Money) Scan(src any) error {
+	switch v := src.(type) {
+	case string:
+		parsed, err := ParseMoney(v)
+		if err != nil {
+			return err
+		}
+		*m = parsed
+	case []byte:
+		return m.Scan(string(v))
+	case int64:
+		*m = Money{units: v}
+	case nil:
+		*m = Money{}
+	default:
+		return fmt.Errorf("can't scan %T into money", src)
+	}
+	return nil
+}
+
+func (m Money) Value() (driver.Value, error) {
+	return m.String(), nil
+}
@@ -0,0 +1,132 @@
+package domain
+
+import (
+	"encoding/json"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestParseMoney(t *testing.T) {
+	tests := []struct {
+		in    string
+		units int64
+		scale int
+		err   error
+	}{
+		{in: "0", units: 0, scale: 0},
+		{in: "12", units: 12, scale: 0},
+		{in: "12.34", units: 1234, scale: 2},
+		{in: "-0.5", units: -5, scale: 1},
+		{in: "+1.000", units: 1000, scale: 3},
+		{in: "", err: ErrInvalidMoney},
+		{in: ".5", err: ErrInvalidMoney},
+		{in: "5.", err: ErrInvalidMoney},
+		{in: "1e3", err: ErrInvalidMoney},
+		{in: "1,5", err: ErrInvalidMoney},
+		{in: "1234567890123456789", err: ErrMoneyOverflow},
+	}
+
+	for _, tt := range tests {
+		m, err := ParseMoney(tt.in)
+		if tt.err != nil {
+			assert.ErrorIs(t, err, tt.err, tt.in)
+			continue
+		}
+		assert.NoError(t, err, tt.in)
+		assert.Equal(t, tt.units, m.Units(), tt.in)
+		assert.Equal(t, tt.scale, m.Scale(), tt.in)
+	}
+}
+
+func TestMoney_In(t *testing.T) {
+	usd := Currency{Symbol: "USD"}
+	jpy := Currency{Symbol: "JPY"}
+	kwd := Currency{Symbol: "KWD"}
+
+	m, _ := ParseMoney("12.3")
+
+	got, err := m.In(usd)
+	assert.NoError(t, err)
+	assert.Equal(t, NewMoney(1230, usd), got)
+	assert.Equal(t, "12.30", got.String())
+
+	got, err = m.In(kwd)
+	assert.NoError(t, err)
+	assert.Equal(t, "12.300", got.String())
+
+	_, err = m.In(jpy)
+	assert.ErrorIs(t, err, ErrMoneyPrecision)
+
+	m, _ = ParseMoney("4000.0000")
+	got, err = m.In(jpy)
+	assert.NoError(t, err)
+	assert.Equal(t, "4000", got.String())
+}
+
+func TestMoney_Arithmetic(t *testing.T) {
+	a, _ := ParseMoney("10.05")
+	b, _ := ParseMoney("0.5")
+
+	sum, err := a.Add(b)
+	assert.NoError(t, err)
+	assert.Equal(t, "10.55", sum.String())
+
+	diff, err := b.Sub(a)
+	assert.NoError(t, err)
+	assert.Equal(t, "-9.55", diff.String())
+
+	assert.Equal(t, 1, a.Cmp(b))
+	assert.Equal(t, -1, b.Cmp(a))
+	assert.Equal(t, 0, b.Cmp(NewMoney(50, Currency{Symbol: "EUR"})))
+
+	small, _ := ParseMoney("-0.05")
+	assert.Equal(t, "-0.05", small.String())
+}
+
+func TestMoney_JSON(t *testing.T) {
+	var req struct {
+		Amount Money `json:"amount"`
+	}
+
+	assert.NoError(t, json.Unmarshal([]byte(`{"amount": "100.25"}`), &req))
+	assert.Equal(t, "100.25", req.Amount.String())
+
+	assert.NoError(t, json.Unmarshal([]byte(`{"amount": 7.5}`), &req))
+	assert.Equal(t, "7.5", req.Amount.String())
+
+	assert.Error(t, json.Unmarshal([]byte(`{"amount": "abc"}`), &req))
+
+	out, err := json.Marshal(NewMoney(123456, Currency{Symbol: "USD"}))
+	assert.NoError(t, err)
+	assert.Equal(t, `"1234.56"`, string(out))
+}
+
+func TestMoney_Convert(t *testing.T) {
+	usd := Currency{Symbol: "USD"}
+	jpy := Currency{Symbol: "JPY"}
+
+	tests := []struct {
+		amount string
+		rate   string
+		cur    Currency
+		want   string
+	}{
+		{amount: "100", rate: "1.2345", cur: usd, want: "123.45"},
+		{amount: "0.05", rate: "0.5", cur: usd, want: "0.02"},
+		{amount: "0.07", rate: "0.5", cur: usd, want: "0.04"},
+		{amount: "-0.05", rate: "0.5", cur: usd, want: "-0.02"},
+		{amount: "10.01", rate: "149.5", cur: jpy, want: "1496"},
+		{amount: "1", rate: "0.0001", cur: usd, want: "0.00"},
+	}
+
+	for _, tt := range tests {
+		m, _ := ParseMoney(tt.amount)
+		rate, ok := ParseRate(tt.rate)
+		assert.True(t, ok, tt.rate)
+
+		got, err := m.Convert(rate, tt.cur)
+		assert.NoError(t, err, tt.amount)
+		assert.Equal(t, tt.want, got.String(), tt.amount+" * "+tt.rate)
+	}
+}
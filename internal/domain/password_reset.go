@@ -0,0 +1,14 @@
+package domain
+
+import "time"
+
+// PasswordResetToken lets whoever got it by email set a new password, once and before it expires.
+// Like refresh tokens, it is stored by the hash of its value only.
+type PasswordResetToken struct {
+	Id        int
+	UserId    int
+	Hash      []byte
+	ExpiresAt time.Time
+	UsedAt    time.Time
+	CreatedAt time.Time
+}
@@ -0,0 +1,28 @@
+package domain
+
+import "slices"
+
+// Principal is who an authenticated request acts as.
+type Principal struct {
+	UserId    int
+	SessionId int
+	// Role is only known for requests authenticated by an access token.
+	Role Role
+	// APIKeyId is set when the request is authenticated by an API key, which only allows its Scopes.
+	APIKeyId int
+	Scopes   []Scope
+}
+
+// Allows tells whether the principal may do what needs the scope.
+func (p *Principal) Allows(scope Scope) bool {
+	return p.APIKeyId == 0 || slices.Contains(p.Scopes, scope)
+}
+
+// Can tells whether the principal may act on data of other users with the permission: by its role, or by
+// the admin scope of the permission for system keys. Keys of users never can, whatever the role of the user.
+func (p *Principal) Can(perm Permission) bool {
+	if p.APIKeyId != 0 {
+		return p.UserId == 0 && slices.Contains(p.Scopes, AdminScope(perm))
+	}
+	return p.Role.Can(perm)
+}
@@ -0,0 +1,44 @@
+package domain
+
+import "slices"
+
+// Role is what a user is allowed to do besides managing their own accounts, which every user can.
+type Role string
+
+const (
+	RoleCustomer Role = "customer"
+	RoleSupport  Role = "support"
+	RoleAdmin    Role = "admin"
+	// RoleAuditor can see everything staff can, but can't change anything.
+	RoleAuditor Role = "auditor"
+)
+
+var Roles = []Role{RoleCustomer, RoleSupport, RoleAdmin, RoleAuditor}
+
+func (r Role) Valid() bool {
+	return slices.Contains(Roles, r)
+}
+
+// Permission is something a role allows on the data of any user.
+type Permission string
+
+const (
+	PermUsersRead      Permission = "users:read"
+	PermUsersManage    Permission = "users:manage"
+	PermAccountsRead   Permission = "accounts:read"
+	PermAccountsFreeze Permission = "accounts:freeze"
+	PermAuditRead      Permission = "audit:read"
+)
+
+var Permissions = []Permission{PermUsersRead, PermUsersManage, PermAccountsRead, PermAccountsFreeze, PermAuditRead}
+
+var rolePermissions = map[Role][]Permission{
+	RoleSupport: {PermUsersRead, PermAccountsRead, PermAccountsFreeze},
+	RoleAdmin:   {PermUsersRead, PermUsersManage, PermAccountsRead, PermAccountsFreeze, PermAuditRead},
+	RoleAuditor: {PermUsersRead, PermAccountsRead, PermAuditRead},
+}
+
+// Can tells whether the role has the permission.
+func (r Role) Can(p Permission) bool {
+	return slices.Contains(rolePermissions[r], p)
+}
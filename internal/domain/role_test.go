@@ -0,0 +1,47 @@
+package domain
+
+import (
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestRole_Can(t *testing.T) {
+	tests := []struct {
+		role Role
+		can  []Permission
+	}{
+		{RoleCustomer, nil},
+		{RoleSupport, []Permission{PermUsersRead, PermAccountsRead, PermAccountsFreeze}},
+		{RoleAuditor, []Permission{PermUsersRead, PermAccountsRead, PermAuditRead}},
+		{RoleAdmin, []Permission{PermUsersRead, PermUsersManage, PermAccountsRead, PermAccountsFreeze, PermAuditRead}},
+		{Role("root"), nil},
+	}
+
+	all := []Permission{PermUsersRead, PermUsersManage, PermAccountsRead, PermAccountsFreeze, PermAuditRead}
+	for _, tt := range tests {
+		for _, perm := range all {
+			want := false
+			for _, p := range tt.can {
+				want = want || p == perm
+			}
+			assert.Equal(t, want, tt.role.Can(perm), "%s %s", tt.role, perm)
+		}
+	}
+}
+
+func TestPrincipal_Can(t *testing.T) {
+	session := &Principal{UserId: 1, SessionId: 5, Role: RoleAdmin}
+	assert.True(t, session.Can(PermAccountsFreeze))
+
+	// API keys act only on their user's own resources, whatever the role of the user.
+	key := &Principal{UserId: 1, APIKeyId: 3, Role: RoleAdmin, Scopes: Scopes}
+	assert.False(t, key.Can(PermUsersRead))
+	key.Scopes = append(key.Scopes, AdminScope(PermUsersRead))
+	assert.False(t, key.Can(PermUsersRead))
+
+	// System keys can do what their admin scopes allow.
+	system := &Principal{APIKeyId: 4, Scopes: []Scope{AdminScope(PermUsersRead), ScopeAccountsRead}}
+	assert.True(t, system.Can(PermUsersRead))
+	assert.False(t, system.Can(PermAccountsRead))
+}
@@ -0,0 +1,36 @@
+package domain
+
+import (
+	"time"
+)
+
+// Session is the family of refresh tokens that descend from one login. Revoking it logs out
+// every access and refresh token issued in it.
+type Session struct {
+	Id        int
+	UserId    int
+	RevokedAt time.Time
+	CreatedAt time.Time
+}
+
+func (s *Session) Revoked() bool {
+	return !s.RevokedAt.IsZero()
+}
+
+// RefreshToken is stored by the hash of its value only. A refresh token can be used once:
+// using it again means it was stolen, one way or another.
+type RefreshToken struct {
+	Id        int
+	SessionId int
+	Hash      []byte
+	ExpiresAt time.Time
+	UsedAt    time.Time
+}
+
+type Tokens struct {
+	SessionId        int
+	AccessToken      string
+	AccessExpiresAt  time.Time
+	RefreshToken     string
+	RefreshExpiresAt time.Time
+}
@@ -0,0 +1,115 @@
+package domain
+
+import (
+	"errors"
+	"time"
+)
+
+type Frequency string
+
+const (
+	FrequencyOnce    Frequency = "once"
+	FrequencyDaily   Frequency = "daily"
+	FrequencyWeekly  Frequency = "weekly"
+	FrequencyMonthly Frequency = "monthly"
+)
+
+func (f Frequency) Valid() bool {
+	switch f {
+	case FrequencyOnce, FrequencyDaily, FrequencyWeekly, FrequencyMonthly:
+		return true
+	}
+	return false
+}
+
+var (
+	ErrStandingOrderNotActive = errors.New("standing order is not active")
+	ErrAlreadyExecuted        = errors.New("standing order occurrence is already executed")
+)
+
+type StandingOrderStatus string
+
+const (
+	StandingOrderActive    StandingOrderStatus = "active"
+	StandingOrderFinished  StandingOrderStatus = "finished"
+	StandingOrderCancelled StandingOrderStatus = "cancelled"
+)
+
+// StandingOrder transfers Amount from FromAccountId to ToAccountId on every occurrence of its schedule,
+// starting at StartAt, until MaxOccurrences occurrences are done or the next one would be after EndAt.
+// Zero MaxOccurrences and EndAt mean no limit.
+type StandingOrder struct {
+	Id             int
+	UserId         int
+	FromAccountId  int
+	ToAccountId    int
+	Cur            Currency
+	Amount         Money
+	Frequency      Frequency
+	StartAt        time.Time
+	EndAt          time.Time
+	MaxOccurrences int
+	// Occurrences is how many occurrences are done, successfully or not.
+	Occurrences int
+	// Attempts is how many times the current occurrence has failed.
+	Attempts  int
+	NextRunAt time.Time
+	Status    StandingOrderStatus
+	Time      time.Time
+}
+
+// Occurrence returns when the n-th occurrence, counting from 0, is due. Monthly orders that start on a day
+// some months don't have run on the last day of those months, and on the original day again afterwards.
+func (o *StandingOrder) Occurrence(n int) time.Time {
+	switch o.Frequency {
+	case FrequencyDaily:
+		return o.StartAt.AddDate(0, 0, n)
+	case FrequencyWeekly:
+		return o.StartAt.AddDate(0, 0, 7*n)
+	case FrequencyMonthly:
+		return addMonths(o.StartAt, n)
+	}
+	return o.StartAt
+}
+
+func addMonths(t time.Time, n int) time.Time {
+	first := time.Date(t.Year(), t.Month()+time.Month(n), 1, t.Hour(), t.Minute(), t.Second(), t.Nanosecond(), t.Location())
+	day := min(t.Day(), first.AddDate(0, 1, -1).Day())
+	return first.AddDate(0, 0, day-1)
+}
+
+// Advance marks the current occurrence done and schedules the next one, or finishes the order if there is none.
+func (o *StandingOrder) Advance() {
+	o.Occurrences++
+	o.Attempts = 0
+
+	if o.Frequency == FrequencyOnce || (o.MaxOccurrences > 0 && o.Occurrences >= o.MaxOccurrences) {
+		o.Status = StandingOrderFinished
+		return
+	}
+	next := o.Occurrence(o.Occurrences)
+	if !o.EndAt.IsZero() && next.After(o.EndAt) {
+		o.Status = StandingOrderFinished
+		return
+	}
+	o.NextRunAt = next
+}
+
+type ExecutionStatus string
+
+const (
+	ExecutionSucceeded ExecutionStatus = "succeeded"
+	ExecutionFailed    ExecutionStatus = "failed"
+)
+
+// StandingOrderExecution is one attempt to run an occurrence of a standing order.
+type StandingOrderExecution struct {
+	Id              int
+	StandingOrderId int
+	ScheduledAt     time.Time
+	Attempt         int
+	TransactionId   int
+	Status          ExecutionStatus
+	FailureReason   string
+	Time            time.Time
+}
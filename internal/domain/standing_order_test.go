@@ -0,0 +1,68 @@
+package domain
+
+import (
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func date(year int, month time.Month, day int) time.Time {
+	return time.Date(year, month, day, 9, 0, 0, 0, time.UTC)
+}
+
+func TestStandingOrder_Occurrence(t *testing.T) {
+	start := date(2024, time.January, 31)
+
+	tests := []struct {
+		frequency Frequency
+		n         int
+		want      time.Time
+	}{
+		{FrequencyOnce, 3, start},
+		{FrequencyDaily, 1, date(2024, time.February, 1)},
+		{FrequencyWeekly, 2, date(2024, time.February, 14)},
+		{FrequencyMonthly, 1, date(2024, time.February, 29)},
+		{FrequencyMonthly, 2, date(2024, time.March, 31)},
+		{FrequencyMonthly, 3, date(2024, time.April, 30)},
+		{FrequencyMonthly, 13, date(2025, time.February, 28)},
+	}
+	for _, tt := range tests {
+		o := &StandingOrder{Frequency: tt.frequency, StartAt: start}
+		assert.Equal(t, tt.want, o.Occurrence(tt.n), "%s %d", tt.frequency, tt.n)
+	}
+}
+
+func TestStandingOrder_Advance(t *testing.T) {
+	t.Run("once", func(t *testing.T) {
+		o := &StandingOrder{Frequency: FrequencyOnce, StartAt: date(2024, time.June, 1), Status: StandingOrderActive}
+		o.Advance()
+		assert.Equal(t, StandingOrderFinished, o.Status)
+		assert.Equal(t, 1, o.Occurrences)
+	})
+
+	t.Run("max occurrences", func(t *testing.T) {
+		o := &StandingOrder{Frequency: FrequencyMonthly, StartAt: date(2024, time.June, 1), MaxOccurrences: 2, Status: StandingOrderActive}
+		o.Advance()
+		assert.Equal(t, StandingOrderActive, o.Status)
+		assert.Equal(t, date(2024, time.July, 1), o.NextRunAt)
+		o.Advance()
+		assert.Equal(t, StandingOrderFinished, o.Status)
+	})
+
+	t.Run("end date", func(t *testing.T) {
+		o := &StandingOrder{Frequency: FrequencyWeekly, StartAt: date(2024, time.June, 1), EndAt: date(2024, time.June, 8), Status: StandingOrderActive}
+		o.Advance()
+		assert.Equal(t, StandingOrderActive, o.Status)
+		assert.Equal(t, date(2024, time.June, 8), o.NextRunAt)
+		o.Advance()
+		assert.Equal(t, StandingOrderFinished, o.Status)
+	})
+
+	t.Run("resets attempts", func(t *testing.T) {
+		o := &StandingOrder{Frequency: FrequencyDaily, StartAt: date(2024, time.June, 1), Attempts: 2, Status: StandingOrderActive}
+		o.Advance()
+		assert.Equal(t, 0, o.Attempts)
+		assert.Equal(t, date(2024, time.June, 2), o.NextRunAt)
+	})
+}
@@ -0,0 +1,107 @@
+package domain
+
+import (
+	"strconv"
+	"time"
+)
+
+// Statement lists what happened to an account between From (inclusive) and To (exclusive).
+type Statement struct {
+	Account   *Account
+	Holder    *User
+	From      time.Time
+	To        time.Time
+	Opening   Money
+	Closing   Money
+	Entries   []StatementEntry
+	CreatedAt time.Time
+}
+
+type StatementEntry struct {
+	// Transaction is what the entry is about, unless it's an Adjustment.
+	Transaction *Transaction
+	Adjustment  *Adjustment
+	// Amount is what the entry credited to the account, it is negative for debits.
+	Amount Money
+	// Balance is the balance of the account right after the entry.
+	Balance        Money
+	CounterpartyId int
+}
+
+// Adjustment is a journal entry of an account that isn't part of a transaction, such as the opening balance
+// of an account that existed before the ledger.
+type Adjustment struct {
+	EntryId     int
+	Description string
+	// Amount is what the entry credited to the account, it is negative for debits.
+	Amount Money
+	Time   time.Time
+}
+
+// Time is when the entry was booked.
+func (e *StatementEntry) Time() time.Time {
+	if e.Adjustment != nil {
+		return e.Adjustment.Time
+	}
+	return e.Transaction.Time
+}
+
+// Reference identifies the entry: it is the id of its transaction, or the id of the journal entry of an
+// adjustment prefixed with "J".
+func (e *StatementEntry) Reference() string {
+	if e.Adjustment != nil {
+		return "J" + strconv.Itoa(e.Adjustment.EntryId)
+	}
+	return strconv.Itoa(e.Transaction.Id)
+}
+
+// NewStatement builds the entries of a statement from its transactions and adjustments, both oldest first.
+func NewStatement(account *Account, from, to time.Time, opening, closing Money, transactions []*Transaction, adjustments []*Adjustment) (*Statement, error) {
+	statement := &Statement{
+		Account: account,
+		From:    from,
+		To:      to,
+		Opening: opening,
+		Closing: closing,
+		Entries: make([]StatementEntry, 0, len(transactions)+len(adjustments)),
+	}
+
+	balance := opening
+	for len(transactions) > 0 || len(adjustments) > 0 {
+		var entry StatementEntry
+		if len(adjustments) > 0 && (len(transactions) == 0 || !transactions[0].Time.Before(adjustments[0].Time)) {
+			entry.Adjustment, entry.Amount = adjustments[0], adjustments[0].Amount
+			adjustments = adjustments[1:]
+		} else {
+			t := transactions[0]
+			transactions = transactions[1:]
+			entry.Transaction = t
+			if t.ToAccountId == account.Id {
+				entry.Amount = t.ToAmount
+				entry.CounterpartyId = t.FromAccountId
+			} else {
+				entry.Amount = t.Amount.Neg()
+				entry.CounterpartyId = t.ToAccountId
+			}
+		}
+
+		var err error
+		if balance, err = balance.Add(entry.Amount); err != nil {
+			return nil, err
+		}
+		entry.Balance = balance
+		statement.Entries = append(statement.Entries, entry)
+	}
+
+	return statement, nil
+}
+
+// StatementFile is a statement rendered ahead of time and stored for download.
+type StatementFile struct {
+	Id        int
+	AccountId int
+	From      time.Time
+	To        time.Time
+	Content   []byte
+	CreatedAt time.Time
+}
@@ -1,6 +1,8 @@
 package domain
 
 import (
+	"errors"
+	"math/big"
 	"time"
 )
 
@@ -13,12 +15,113 @@ const (
 	Transfer
 )
 
+var transactionTypeNames = map[TransactionType]string{
+	Deposit:  "deposit",
+	Withdraw: "withdraw",
+	Transfer: "transfer",
+}
+
+func (t TransactionType) String() string {
+	return transactionTypeNames[t]
+}
+
+func ParseTransactionType(s string) (TransactionType, bool) {
+	for t, name := range transactionTypeNames {
+		if name == s {
+			return t, true
+		}
+	}
+	return 0, false
+}
+
+type TransactionStatus string
+
+const (
+	StatusPending  TransactionStatus = "pending"
+	StatusPosted   TransactionStatus = "posted"
+	StatusFailed   TransactionStatus = "failed"
+	StatusReversed TransactionStatus = "reversed"
+)
+
+var (
+	ErrInvalidStatusTransition = errors.New("invalid transaction status transition")
+	ErrNotReversible           = errors.New("transaction can't be reversed")
+	ErrReversalExceedsAmount   = errors.New("reversal exceeds the amount left to reverse")
+)
+
+// A transaction is created pending and is either posted together with its journal entry or marked failed.
+// Only posted transactions can be reversed.
+var statusTransitions = map[TransactionStatus][]TransactionStatus{
+	StatusPending: {StatusPosted, StatusFailed},
+	StatusPosted:  {StatusReversed},
+}
+
+func (s TransactionStatus) CanTransitionTo(next TransactionStatus) bool {
+	for _, allowed := range statusTransitions[s] {
+		if allowed == next {
+			return true
+		}
+	}
+	return false
+}
+
 type Transaction struct {
+	Id            int
 	UserId        int
 	FromAccountId int
 	ToAccountId   int
 	Cur           Currency
-	Amount        int
+	Amount        Money
+	ToCur         Currency
+	ToAmount      Money
+	Rate          *ExchangeRate
+	QuoteId       int
+	HoldId        int
+	// Execution, if set, is recorded as succeeded together with the transfer, which fails with
+	// ErrAlreadyExecuted instead if the same attempt of the standing order was recorded before.
+	Execution     *StandingOrderExecution
 	Type          TransactionType
+	Status        TransactionStatus
+	FailureReason string
 	Time          time.Time
+
+	// ReversesId links a reversal to the transfer it reverses.
+	ReversesId int
+	// ReversedAmount is how much of ToAmount posted reversals have returned so far,
+	// and RefundedAmount is what the sender got back for it in Cur.
+	ReversedAmount Money
+	RefundedAmount Money
+}
+
+// Refund returns how much of Amount goes back to the sender when amount of ToAmount is reversed,
+// or ErrReversalExceedsAmount if less than amount is left to reverse.
+// Partial refunds are proportional and rounded half to even; the one that completes the reversal
+// refunds whatever is left, so that a transfer reversed in parts gives back exactly Amount.
+func (t *Transaction) Refund(amount Money) (Money, error) {
+	if t.ToAmount.Sign() <= 0 {
+		return Money{}, ErrNotReversible
+	}
+
+	reversed, err := t.ReversedAmount.Add(amount)
+	if err != nil {
+		return Money{}, err
+	}
+	switch reversed.Cmp(t.ToAmount) {
+	case 1:
+		return Money{}, ErrReversalExceedsAmount
+	case 0:
+		return t.Amount.Sub(t.RefundedAmount)
+	}
+
+	ratio := new(big.Rat).SetFrac(
+		new(big.Int).Mul(big.NewInt(t.Amount.Units()), pow10(t.ToAmount.Scale())),
+		new(big.Int).Mul(big.NewInt(t.ToAmount.Units()), pow10(t.Amount.Scale())),
+	)
+	return amount.Convert(ratio, t.Cur)
+}
+
+// Remaining returns how much of ToAmount is left to reverse.
+func (t *Transaction) Remaining() Money {
+	remaining, _ := t.ToAmount.Sub(t.ReversedAmount)
+	return remaining
 }
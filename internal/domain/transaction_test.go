@@ -0,0 +1,57 @@
+package domain
+
+import (
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestTransactionStatus_CanTransitionTo(t *testing.T) {
+	statuses := []TransactionStatus{StatusPending, StatusPosted, StatusFailed, StatusReversed}
+	allowed := map[[2]TransactionStatus]bool{
+		{StatusPending, StatusPosted}:  true,
+		{StatusPending, StatusFailed}:  true,
+		{StatusPosted, StatusReversed}: true,
+	}
+
+	for _, from := range statuses {
+		for _, to := range statuses {
+			assert.Equal(t, allowed[[2]TransactionStatus{from, to}], from.CanTransitionTo(to), "%s -> %s", from, to)
+		}
+	}
+}
+
+func TestTransaction_Refund(t *testing.T) {
+	rub := Currency{Symbol: "RUB"}
+	usd := Currency{Symbol: "USD"}
+
+	// 100.00 RUB were converted into 1.09 USD.
+	transfer := &Transaction{Cur: rub, Amount: NewMoney(10000, rub), ToCur: usd, ToAmount: NewMoney(109, usd)}
+
+	refund, err := transfer.Refund(NewMoney(50, usd))
+	assert.NoError(t, err)
+	assert.Equal(t, "45.87", refund.String())
+
+	transfer.ReversedAmount = NewMoney(50, usd)
+	transfer.RefundedAmount = refund
+
+	_, err = transfer.Refund(NewMoney(60, usd))
+	assert.ErrorIs(t, err, ErrReversalExceedsAmount)
+
+	refund, err = transfer.Refund(NewMoney(59, usd))
+	assert.NoError(t, err)
+	assert.Equal(t, "54.13", refund.String())
+}
+
+func TestTransaction_Refund_SameCurrency(t *testing.T) {
+	rub := Currency{Symbol: "RUB"}
+	transfer := &Transaction{Cur: rub, Amount: NewMoney(10000, rub), ToCur: rub, ToAmount: NewMoney(10000, rub)}
+
+	refund, err := transfer.Refund(NewMoney(3333, rub))
+	assert.NoError(t, err)
+	assert.Equal(t, NewMoney(3333, rub), refund)
+
+	refund, err = transfer.Refund(NewMoney(10000, rub))
+	assert.NoError(t, err)
+	assert.Equal(t, NewMoney(10000, rub), refund)
+}
@@ -0,0 +1,40 @@
+package domain
+
+import (
+	"errors"
+	"time"
+)
+
+var ErrTwoFactorEnabled = errors.New("two-factor authentication is already enabled")
+
+// TwoFactor is the TOTP enrollment of a user. It only protects logins once it is confirmed with a first code.
+type TwoFactor struct {
+	UserId int
+	// Secret is the TOTP secret, encrypted.
+	Secret []byte
+	// LastStep is the time step of the last accepted code; codes of that step or earlier are refused.
+	LastStep    int64
+	ConfirmedAt time.Time
+	CreatedAt   time.Time
+}
+
+func (t *TwoFactor) Enabled() bool {
+	return !t.ConfirmedAt.IsZero()
+}
+
+// TwoFactorEnrollment is what the user adds to their authenticator app.
+type TwoFactorEnrollment struct {
+	Secret string
+	URI    string
+}
+
+// TwoFactorChallenge is handed out instead of a session when a user with 2FA logs in with their password.
+// It is stored by the hash of its token only, and can be exchanged for a session once, with a valid code.
+type TwoFactorChallenge struct {
+	Id        int
+	UserId    int
+	Token     string
+	Hash      []byte
+	Attempts  int
+	ExpiresAt time.Time
+}
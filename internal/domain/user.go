@@ -1,6 +1,7 @@
 package domain
 
 import (
+	"errors"
 	"time"
 )
 
@@ -10,10 +11,51 @@ type UserInfo struct {
 	Password string
 }
 
+var ErrEmailTaken = errors.New("email is taken")
+
 type User struct {
 	Id             int
 	Name           string
 	Email          string
 	HashedPassword string
-	CreatedAt      time.Time
+	Role           Role
+	// EmailVerifiedAt is zero until the user confirms that Email is theirs.
+	EmailVerifiedAt time.Time
+	// PendingEmail is what the user is changing Email to, which only happens once it's verified.
+	PendingEmail string
+	CreatedAt    time.Time
+}
+
+// UserUpdate is what a user changes about themselves at once.
+type UserUpdate struct {
+	// Name replaces the name of the user, unless it's empty.
+	Name string
+	// Verification, if set, is stored and its email becomes the pending email of the user.
+	Verification *EmailVerification
+	// CancelEmailChange clears the pending email of the user.
+	CancelEmailChange bool
+}
+
+func (u *User) EmailVerified() bool {
+	return !u.EmailVerifiedAt.IsZero()
+}
+
+// EmailVerification confirms that Email belongs to the user who got the token. Like refresh tokens, it is
+// stored by the hash of its value only.
+type EmailVerification struct {
+	Id        int
+	UserId    int
+	Email     string
+	Hash      []byte
+	ExpiresAt time.Time
+	UsedAt    time.Time
+	CreatedAt time.Time
+}
+
+// UserFilter selects users whose name or email contains Query, ordered by id.
+type UserFilter struct {
+	Query   string
+	Role    Role
+	AfterId int
+	Limit   int
 }
@@ -13,9 +13,11 @@ type newAccountRequest struct {
 }
 
 type accountInfoResponse struct {
-	Id           int    `json:"id"`
-	CurrencyName string `json:"currency_name"`
-	Amount       int    `json:"amount"`
+	Id           int          `json:"id"`
+	CurrencyName string       `json:"currency_name"`
+	Amount       domain.Money `json:"amount"`
+	Available    domain.Money `json:"available_amount"`
+	Frozen       bool         `json:"frozen"`
 }
 
 func (h *Handler) NewAccount() gin.HandlerFunc {
@@ -43,6 +45,8 @@ func (h *Handler) NewAccount() gin.HandlerFunc {
 			Id:           account.Id,
 			CurrencyName: account.Cur.Symbol,
 			Amount:       account.Amount,
+			Available:    account.Available,
+			Frozen:       account.Frozen(),
 		})
 	}
 }
@@ -71,6 +75,8 @@ func (h *Handler) GetAccount() gin.HandlerFunc {
 			Id:           account.Id,
 			CurrencyName: account.Cur.Symbol,
 			Amount:       account.Amount,
+			Available:    account.Available,
+			Frozen:       account.Frozen(),
 		})
 	}
 }
@@ -0,0 +1,353 @@
+package handlers
+
+import (
+	"net/http"
+	"strconv"
+	"time"
+
+	"bank-api/internal/domain"
+	"bank-api/internal/middleware"
+
+	"github.com/gin-gonic/gin"
+)
+
+type adminUser struct {
+	Id            int            `json:"id"`
+	Name          string         `json:"name"`
+	Email         string         `json:"email"`
+	EmailVerified bool           `json:"email_verified"`
+	PendingEmail  string         `json:"pending_email,omitempty"`
+	Role          string         `json:"role"`
+	CreatedAt     time.Time      `json:"created_at"`
+	Accounts      []adminAccount `json:"accounts,omitempty"`
+}
+
+func newAdminUser(u *domain.User) adminUser {
+	return adminUser{
+		Id:            u.Id,
+		Name:          u.Name,
+		Email:         u.Email,
+		EmailVerified: u.EmailVerified(),
+		PendingEmail:  u.PendingEmail,
+		Role:          string(u.Role),
+		CreatedAt:     u.CreatedAt,
+	}
+}
+
+type adminAccount struct {
+	Id           int          `json:"id"`
+	UserId       int          `json:"user_id"`
+	CurrencyName string       `json:"currency_name"`
+	Amount       domain.Money `json:"amount"`
+	Available    domain.Money `json:"available_amount"`
+	Frozen       bool         `json:"frozen"`
+	FrozenAt     *time.Time   `json:"frozen_at,omitempty"`
+	FrozenReason string       `json:"frozen_reason,omitempty"`
+}
+
+func newAdminAccount(a *domain.Account) adminAccount {
+	resp := adminAccount{
+		Id:           a.Id,
+		UserId:       a.UserId,
+		CurrencyName: a.Cur.Symbol,
+		Amount:       a.Amount,
+		Available:    a.Available,
+		Frozen:       a.Frozen(),
+		FrozenReason: a.FrozenReason,
+	}
+	if a.Frozen() {
+		resp.FrozenAt = &a.FrozenAt
+	}
+	return resp
+}
+
+type searchUsersQuery struct {
+	Query string `form:"q"`
+	Role  string `form:"role"`
+	After int    `form:"after"`
+	Limit int    `form:"limit"`
+}
+
+type searchUsersResponse struct {
+	Users []adminUser `json:"users"`
+}
+
+func (h *Handler) SearchUsers() gin.HandlerFunc {
+	return func(c *gin.Context) {
+		actor, ok := middleware.GetPrincipal(c)
+		if !ok {
+			returnBadRequest(c)
+			return
+		}
+
+		var query searchUsersQuery
+		if err := c.ShouldBindQuery(&query); err != nil {
+			returnBadRequest(c)
+			return
+		}
+
+		users, err := h.adm.SearchUsers(c, actor, &domain.UserFilter{
+			Query:   query.Query,
+			Role:    domain.Role(query.Role),
+			AfterId: query.After,
+			Limit:   query.Limit,
+		})
+		if err != nil {
+			returnError(c, err)
+			return
+		}
+
+		resp := searchUsersResponse{Users: make([]adminUser, len(users))}
+		for i, u := range users {
+			resp.Users[i] = newAdminUser(u)
+		}
+
+		c.JSON(http.StatusOK, resp)
+	}
+}
+
+func (h *Handler) AdminGetUser() gin.HandlerFunc {
+	return func(c *gin.Context) {
+		actor, ok := middleware.GetPrincipal(c)
+		if !ok {
+			returnBadRequest(c)
+			return
+		}
+
+		userId, err := strconv.Atoi(c.Param("id"))
+		if err != nil {
+			returnBadRequest(c)
+			return
+		}
+
+		user, accounts, err := h.adm.GetUser(c, actor, userId)
+		if err != nil {
+			returnError(c, err)
+			return
+		}
+
+		resp := newAdminUser(user)
+		resp.Accounts = make([]adminAccount, len(accounts))
+		for i, a := range accounts {
+			resp.Accounts[i] = newAdminAccount(a)
+		}
+
+		c.JSON(http.StatusOK, resp)
+	}
+}
+
+type setRoleRequest struct {
+	Role string `json:"role" binding:"required"`
+}
+
+func (h *Handler) SetUserRole() gin.HandlerFunc {
+	return func(c *gin.Context) {
+		actor, ok := middleware.GetPrincipal(c)
+		if !ok {
+			returnBadRequest(c)
+			return
+		}
+
+		userId, err := strconv.Atoi(c.Param("id"))
+		if err != nil {
+			returnBadRequest(c)
+			return
+		}
+
+		var req setRoleRequest
+		if err := c.ShouldBindJSON(&req); err != nil {
+			returnBadRequest(c)
+			return
+		}
+
+		user, err := h.adm.SetUserRole(c, actor, userId, domain.Role(req.Role))
+		if err != nil {
+			returnError(c, err)
+			return
+		}
+
+		c.JSON(http.StatusOK, newAdminUser(user))
+	}
+}
+
+func (h *Handler) AdminGetAccount() gin.HandlerFunc {
+	return func(c *gin.Context) {
+		actor, ok := middleware.GetPrincipal(c)
+		if !ok {
+			returnBadRequest(c)
+			return
+		}
+
+		var accountId int
+		if ok := getAccountId(c, &accountId); !ok {
+			returnBadRequest(c)
+			return
+		}
+
+		account, err := h.adm.GetAccount(c, actor, accountId)
+		if err != nil {
+			returnError(c, err)
+			return
+		}
+
+		c.JSON(http.StatusOK, newAdminAccount(account))
+	}
+}
+
+func (h *Handler) AdminListAccountTransactions() gin.HandlerFunc {
+	return func(c *gin.Context) {
+		actor, ok := middleware.GetPrincipal(c)
+		if !ok {
+			returnBadRequest(c)
+			return
+		}
+
+		var accountId int
+		if ok := getAccountId(c, &accountId); !ok {
+			returnBadRequest(c)
+			return
+		}
+
+		filter := domain.TransactionFilter{AccountId: accountId}
+		if ok := bindTransactionFilter(c, &filter); !ok {
+			returnBadRequest(c)
+			return
+		}
+
+		transactions, next, err := h.adm.ListAccountTransactions(c, actor, &filter)
+		if err != nil {
+			returnError(c, err)
+			return
+		}
+		writeTransactions(c, transactions, next)
+	}
+}
+
+type freezeRequest struct {
+	Reason string `json:"reason" binding:"required"`
+}
+
+func (h *Handler) FreezeAccount() gin.HandlerFunc {
+	return func(c *gin.Context) {
+		actor, ok := middleware.GetPrincipal(c)
+		if !ok {
+			returnBadRequest(c)
+			return
+		}
+
+		var accountId int
+		if ok := getAccountId(c, &accountId); !ok {
+			returnBadRequest(c)
+			return
+		}
+
+		var req freezeRequest
+		if err := c.ShouldBindJSON(&req); err != nil {
+			returnBadRequest(c)
+			return
+		}
+
+		account, err := h.adm.FreezeAccount(c, actor, accountId, req.Reason)
+		if err != nil {
+			returnError(c, err)
+			return
+		}
+
+		c.JSON(http.StatusOK, newAdminAccount(account))
+	}
+}
+
+func (h *Handler) UnfreezeAccount() gin.HandlerFunc {
+	return func(c *gin.Context) {
+		actor, ok := middleware.GetPrincipal(c)
+		if !ok {
+			returnBadRequest(c)
+			return
+		}
+
+		var accountId int
+		if ok := getAccountId(c, &accountId); !ok {
+			returnBadRequest(c)
+			return
+		}
+
+		account, err := h.adm.UnfreezeAccount(c, actor, accountId)
+		if err != nil {
+			returnError(c, err)
+			return
+		}
+
+		c.JSON(http.StatusOK, newAdminAccount(account))
+	}
+}
+
+type auditLogQuery struct {
+	Actor      int    `form:"actor"`
+	TargetType string `form:"target_type"`
+	TargetId   int    `form:"target_id"`
+	After      int    `form:"after"`
+	Limit      int    `form:"limit"`
+}
+
+type auditEntry struct {
+	Id             int            `json:"id"`
+	ActorUserId    int            `json:"actor_user_id,omitempty"`
+	ActorSessionId int            `json:"actor_session_id,omitempty"`
+	ActorRole      string         `json:"actor_role,omitempty"`
+	ActorAPIKeyId  int            `json:"actor_api_key_id,omitempty"`
+	Action         string         `json:"action"`
+	TargetType     string         `json:"target_type"`
+	TargetId       int            `json:"target_id,omitempty"`
+	Details        map[string]any `json:"details,omitempty"`
+	CreatedAt      time.Time      `json:"created_at"`
+}
+
+type auditLogResponse struct {
+	Entries []auditEntry `json:"entries"`
+}
+
+func (h *Handler) ListAuditLog() gin.HandlerFunc {
+	return func(c *gin.Context) {
+		actor, ok := middleware.GetPrincipal(c)
+		if !ok {
+			returnBadRequest(c)
+			return
+		}
+
+		var query auditLogQuery
+		if err := c.ShouldBindQuery(&query); err != nil {
+			returnBadRequest(c)
+			return
+		}
+
+		entries, err := h.adm.ListAuditEntries(c, actor, &domain.AuditFilter{
+			ActorUserId: query.Actor,
+			TargetType:  query.TargetType,
+			TargetId:    query.TargetId,
+			AfterId:     query.After,
+			Limit:       query.Limit,
+		})
+		if err != nil {
+			returnError(c, err)
+			return
+		}
+
+		resp := auditLogResponse{Entries: make([]auditEntry, len(entries))}
+		for i, e := range entries {
+			resp.Entries[i] = auditEntry{
+				Id:             e.Id,
+				ActorUserId:    e.ActorUserId,
+				ActorSessionId: e.ActorSessionId,
+				ActorRole:      string(e.ActorRole),
+				ActorAPIKeyId:  e.ActorAPIKeyId,
+				Action:         e.Action,
+				TargetType:     e.TargetType,
+				TargetId:       e.TargetId,
+				Details:        e.Details,
+				CreatedAt:      e.CreatedAt,
+			}
+		}
+
+		c.JSON(http.StatusOK, resp)
+	}
+}
@@ -0,0 +1,131 @@
+package handlers
+
+import (
+	"net/http"
+	"strconv"
+	"time"
+
+	"bank-api/internal/domain"
+
+	"github.com/gin-gonic/gin"
+)
+
+type apiKeyRequest struct {
+	Name   string   `json:"name" binding:"required"`
+	Scopes []string `json:"scopes" binding:"required"`
+}
+
+type apiKey struct {
+	Id         int      `json:"id"`
+	Name       string   `json:"name"`
+	Prefix     string   `json:"prefix"`
+	Scopes     []string `json:"scopes"`
+	LastUsedAt string   `json:"last_used_at,omitempty"`
+	RevokedAt  string   `json:"revoked_at,omitempty"`
+	CreatedAt  string   `json:"created_at"`
+}
+
+type newAPIKeyResponse struct {
+	apiKey
+	// Key is shown only once, when the key is created.
+	Key string `json:"key"`
+}
+
+func newAPIKey(k *domain.APIKey) apiKey {
+	resp := apiKey{
+		Id:        k.Id,
+		Name:      k.Name,
+		Prefix:    k.Prefix,
+		Scopes:    make([]string, len(k.Scopes)),
+		CreatedAt: k.CreatedAt.Format(time.RFC3339),
+	}
+	for i, scope := range k.Scopes {
+		resp.Scopes[i] = string(scope)
+	}
+	if !k.LastUsedAt.IsZero() {
+		resp.LastUsedAt = k.LastUsedAt.Format(time.RFC3339)
+	}
+	if k.Revoked() {
+		resp.RevokedAt = k.RevokedAt.Format(time.RFC3339)
+	}
+	return resp
+}
+
+func (h *Handler) NewAPIKey() gin.HandlerFunc {
+	return func(c *gin.Context) {
+		var userId int
+		if ok := getUserId(c, &userId); !ok {
+			returnBadRequest(c)
+			return
+		}
+
+		var req apiKeyRequest
+		if err := c.ShouldBindJSON(&req); err != nil {
+			returnBadRequest(c)
+			return
+		}
+
+		scopes := make([]domain.Scope, len(req.Scopes))
+		for i, scope := range req.Scopes {
+			scopes[i] = domain.Scope(scope)
+		}
+
+		k, key, err := h.ak.CreateAPIKey(c, userId, req.Name, scopes)
+		if err != nil {
+			returnError(c, err)
+			return
+		}
+
+		c.JSON(http.StatusCreated, newAPIKeyResponse{apiKey: newAPIKey(k), Key: key})
+	}
+}
+
+type listAPIKeysResponse struct {
+	APIKeys []apiKey `json:"api_keys"`
+}
+
+func (h *Handler) ListAPIKeys() gin.HandlerFunc {
+	return func(c *gin.Context) {
+		var userId int
+		if ok := getUserId(c, &userId); !ok {
+			returnBadRequest(c)
+			return
+		}
+
+		keys, err := h.ak.ListAPIKeys(c, userId)
+		if err != nil {
+			returnError(c, err)
+			return
+		}
+
+		resp := listAPIKeysResponse{APIKeys: make([]apiKey, len(keys))}
+		for i, k := range keys {
+			resp.APIKeys[i] = newAPIKey(k)
+		}
+
+		c.JSON(http.StatusOK, resp)
+	}
+}
+
+func (h *Handler) RevokeAPIKey() gin.HandlerFunc {
+	return func(c *gin.Context) {
+		var userId int
+		if ok := getUserId(c, &userId); !ok {
+			returnBadRequest(c)
+			return
+		}
+
+		id, err := strconv.Atoi(c.Param("id"))
+		if err != nil {
+			returnBadRequest(c)
+			return
+		}
+
+		if err := h.ak.RevokeAPIKey(c, userId, id); err != nil {
+			returnError(c, err)
+			return
+		}
+
+		c.Status(http.StatusNoContent)
+	}
+}
@@ -1,17 +1,19 @@
 package handlers
 
 import (
+	"errors"
+	"math"
 	"net/http"
+	"strconv"
 	"time"
 
 	"bank-api/internal/domain"
+	"bank-api/internal/middleware"
+	"bank-api/internal/service"
 
 	"github.com/gin-gonic/gin"
-	"github.com/golang-jwt/jwt/v5"
 )
 
-const expireDeadline = time.Hour * 24
-
 type signUpRequest struct {
 	Name     string `json:"name" binding:"required"`
 	Email    string `json:"email" binding:"required"`
@@ -19,10 +21,24 @@ type signUpRequest struct {
 }
 
 type userResponse struct {
-	Id        int       `json:"id"`
-	Name      string    `json:"name"`
-	Email     string    `json:"email"`
-	CreatedAt time.Time `json:"created_at"`
+	Id            int    `json:"id"`
+	Name          string `json:"name"`
+	Email         string `json:"email"`
+	EmailVerified bool   `json:"email_verified"`
+	// PendingEmail is the email the user is changing to, until it's verified.
+	PendingEmail string    `json:"pending_email,omitempty"`
+	CreatedAt    time.Time `json:"created_at"`
+}
+
+func newUserResponse(u *domain.User) userResponse {
+	return userResponse{
+		Id:            u.Id,
+		Name:          u.Name,
+		Email:         u.Email,
+		EmailVerified: u.EmailVerified(),
+		PendingEmail:  u.PendingEmail,
+		CreatedAt:     u.CreatedAt,
+	}
 }
 
 func (h *Handler) SignUp() gin.HandlerFunc {
@@ -43,20 +59,38 @@ func (h *Handler) SignUp() gin.HandlerFunc {
 			return
 		}
 
-		c.JSON(http.StatusCreated, userResponse{
-			Id:        user.Id,
-			Name:      user.Name,
-			Email:     user.Email,
-			CreatedAt: user.CreatedAt,
-		})
+		// The user exists either way: if the email can't be sent now, it can be sent again later.
+		if err := h.em.SendVerification(c, user.Id); err != nil {
+			_ = c.Error(err)
+		}
+
+		c.JSON(http.StatusCreated, newUserResponse(user))
 	}
 }
 
 type loginRequest struct {
 	Email    string `json:"email" binding:"required"`
 	Password string `json:"password" binding:"required"`
+	// ReturnTokens makes Login return the tokens in the body instead of setting cookies,
+	// for clients that send them in the Authorization header.
+	ReturnTokens bool `json:"return_tokens"`
 }
 
+type tokensResponse struct {
+	AccessToken      string `json:"access_token"`
+	TokenType        string `json:"token_type"`
+	ExpiresIn        int    `json:"expires_in"`
+	RefreshToken     string `json:"refresh_token"`
+	RefreshExpiresIn int    `json:"refresh_expires_in"`
+}
+
+type twoFactorChallengeResponse struct {
+	TwoFactorRequired bool   `json:"two_factor_required"`
+	ChallengeToken    string `json:"challenge_token"`
+	ExpiresIn         int    `json:"expires_in"`
+}
+
+// Login starts a session, unless the user has 2FA: then it returns a challenge for LoginTwoFactor.
 func (h *Handler) Login() gin.HandlerFunc {
 	return func(c *gin.Context) {
 		var req loginRequest
@@ -65,36 +99,180 @@ func (h *Handler) Login() gin.HandlerFunc {
 			return
 		}
 
-		u, err := h.us.AuthenticateUser(c, &domain.UserInfo{
+		u, challenge, err := h.us.AuthenticateUser(c, &domain.UserInfo{
 			Email:    req.Email,
 			Password: req.Password,
-		})
+		}, c.ClientIP())
+		var locked *service.LoginLockedError
+		if errors.As(err, &locked) {
+			c.Header("Retry-After", strconv.Itoa(int(math.Ceil(time.Until(locked.Until).Seconds()))))
+		}
+		if err != nil {
+			returnError(c, err)
+			return
+		}
+
+		if challenge != nil {
+			c.JSON(http.StatusOK, twoFactorChallengeResponse{
+				TwoFactorRequired: true,
+				ChallengeToken:    challenge.Token,
+				ExpiresIn:         int(time.Until(challenge.ExpiresAt).Seconds()),
+			})
+			return
+		}
+
+		h.startSession(c, u.Id, req.ReturnTokens)
+	}
+}
+
+type loginTwoFactorRequest struct {
+	ChallengeToken string `json:"challenge_token" binding:"required"`
+	// Code is a code of the authenticator app or a recovery code.
+	Code         string `json:"code" binding:"required"`
+	ReturnTokens bool   `json:"return_tokens"`
+}
+
+func (h *Handler) LoginTwoFactor() gin.HandlerFunc {
+	return func(c *gin.Context) {
+		var req loginTwoFactorRequest
+		if err := c.ShouldBindJSON(&req); err != nil {
+			returnBadRequest(c)
+			return
+		}
+
+		u, err := h.us.CompleteTwoFactorLogin(c, req.ChallengeToken, req.Code, c.ClientIP())
+		var locked *service.LoginLockedError
+		if errors.As(err, &locked) {
+			c.Header("Retry-After", strconv.Itoa(int(math.Ceil(time.Until(locked.Until).Seconds()))))
+		}
 		if err != nil {
 			returnError(c, err)
 			return
 		}
 
-		t, err := h.generateJWT(u)
+		h.startSession(c, u.Id, req.ReturnTokens)
+	}
+}
+
+// startSession issues the tokens of a new session and returns them in the body or sets them in cookies.
+func (h *Handler) startSession(c *gin.Context, userId int, returnTokens bool) {
+	tokens, err := h.tk.IssueTokens(c, userId)
+	if err != nil {
+		returnError(c, err)
+		return
+	}
+
+	if returnTokens {
+		c.JSON(http.StatusOK, newTokensResponse(tokens))
+		return
+	}
+	if err := setCookieTokens(c, tokens); err != nil {
+		returnError(c, err)
+		return
+	}
+	c.Status(http.StatusOK)
+}
+
+type refreshRequest struct {
+	RefreshToken string `json:"refresh_token"`
+}
+
+// RefreshToken takes the refresh token from the Refresh cookie and sets the new tokens in cookies,
+// or takes it from the body and returns the new tokens in the body.
+func (h *Handler) RefreshToken() gin.HandlerFunc {
+	return func(c *gin.Context) {
+		refreshToken, err := c.Cookie(RefreshCookie)
+		fromCookie := err == nil
+		if !fromCookie {
+			var req refreshRequest
+			if err := c.ShouldBindJSON(&req); err != nil || req.RefreshToken == "" {
+				c.String(http.StatusUnauthorized, "Invalid refresh token")
+				return
+			}
+			refreshToken = req.RefreshToken
+		}
+
+		tokens, err := h.tk.RefreshTokens(c, refreshToken)
 		if err != nil {
+			if fromCookie {
+				clearCookieTokens(c)
+			}
 			returnError(c, err)
 			return
 		}
 
-		setCookieToken(c, t)
+		if !fromCookie {
+			c.JSON(http.StatusOK, newTokensResponse(tokens))
+			return
+		}
+		if err := setCookieTokens(c, tokens); err != nil {
+			returnError(c, err)
+			return
+		}
 		c.Status(http.StatusOK)
 	}
 }
 
-func (h *Handler) generateJWT(u *domain.User) (string, error) {
-	token := jwt.NewWithClaims(jwt.SigningMethodHS256, jwt.MapClaims{
-		"sub": u.Id,
-		"exp": time.Now().Add(expireDeadline).Unix(),
-	})
+func (h *Handler) Logout() gin.HandlerFunc {
+	return func(c *gin.Context) {
+		var sessionId int
+		if ok := getSessionId(c, &sessionId); !ok {
+			returnBadRequest(c)
+			return
+		}
+
+		if err := h.tk.RevokeSession(c, sessionId); err != nil {
+			returnError(c, err)
+			return
+		}
+
+		clearCookieTokens(c)
+		c.Status(http.StatusNoContent)
+	}
+}
+
+func newTokensResponse(tokens *domain.Tokens) tokensResponse {
+	return tokensResponse{
+		AccessToken:      tokens.AccessToken,
+		TokenType:        "Bearer",
+		ExpiresIn:        int(time.Until(tokens.AccessExpiresAt).Seconds()),
+		RefreshToken:     tokens.RefreshToken,
+		RefreshExpiresIn: int(time.Until(tokens.RefreshExpiresAt).Seconds()),
+	}
+}
 
-	return token.SignedString([]byte(h.JwtSecret))
+const (
+	accessCookie  = "Authorization"
+	RefreshCookie = "Refresh"
+	// refreshCookiePath keeps the refresh token from being sent anywhere but to the refresh endpoint.
+	refreshCookiePath = "/user/token"
+)
+
+// setCookieTokens sets the tokens in cookies together with a new CSRF token that lives as long as the session can.
+func setCookieTokens(c *gin.Context, tokens *domain.Tokens) error {
+	refreshMaxAge := int(time.Until(tokens.RefreshExpiresAt).Seconds())
+	if err := middleware.SetCSRFCookie(c, refreshMaxAge); err != nil {
+		return err
+	}
+
+	c.SetSameSite(http.SameSiteLaxMode)
+	c.SetCookie(accessCookie, tokens.AccessToken, int(time.Until(tokens.AccessExpiresAt).Seconds()), "", "", true, true)
+	c.SetCookie(RefreshCookie, tokens.RefreshToken, refreshMaxAge, refreshCookiePath, "", true, true)
+	return nil
 }
 
-func setCookieToken(c *gin.Context, token string) {
+func clearCookieTokens(c *gin.Context) {
+	middleware.ClearCSRFCookie(c)
+
 	c.SetSameSite(http.SameSiteLaxMode)
-	c.SetCookie("Authorization", token, 3600*24, "", "", true, true)
+	c.SetCookie(accessCookie, "", -1, "", "", true, true)
+	c.SetCookie(RefreshCookie, "", -1, refreshCookiePath, "", true, true)
+}
+
+// JWKS publishes the public keys access tokens are signed with.
+func (h *Handler) JWKS() gin.HandlerFunc {
+	return func(c *gin.Context) {
+		c.Header("Cache-Control", "public, max-age=300")
+		c.JSON(http.StatusOK, h.JWT.JWKS())
+	}
 }
@@ -0,0 +1,68 @@
+package handlers
+
+import (
+	"net/http"
+	"time"
+
+	"bank-api/internal/domain"
+
+	"github.com/gin-gonic/gin"
+)
+
+type quoteRequest struct {
+	FromAccountId int          `json:"from_account_id" binding:"required"`
+	ToAccountId   int          `json:"to_account_id" binding:"required"`
+	Amount        domain.Money `json:"amount"`
+}
+
+type quoteResponse struct {
+	QuoteId          int          `json:"quote_id"`
+	FromAccountId    int          `json:"from_account_id"`
+	ToAccountId      int          `json:"to_account_id"`
+	CurrencySymbol   string       `json:"currency_name"`
+	Amount           domain.Money `json:"amount"`
+	ToCurrencySymbol string       `json:"to_currency_name"`
+	ToAmount         domain.Money `json:"to_amount"`
+	Rate             string       `json:"rate"`
+	ExpiresAt        string       `json:"expires_at"`
+}
+
+func (h *Handler) NewQuote() gin.HandlerFunc {
+	return func(c *gin.Context) {
+		var id int
+		if ok := getUserId(c, &id); !ok {
+			returnBadRequest(c)
+			return
+		}
+
+		var req quoteRequest
+		if err := c.ShouldBindJSON(&req); err != nil {
+			returnBadRequest(c)
+			return
+		}
+
+		quote, err := h.tr.CreateQuote(c, &domain.Transaction{
+			UserId:        id,
+			FromAccountId: req.FromAccountId,
+			ToAccountId:   req.ToAccountId,
+			Amount:        req.Amount,
+			Type:          domain.Transfer,
+		})
+		if err != nil {
+			returnError(c, err)
+			return
+		}
+
+		c.JSON(http.StatusCreated, quoteResponse{
+			QuoteId:          quote.Id,
+			FromAccountId:    quote.FromAccountId,
+			ToAccountId:      quote.ToAccountId,
+			CurrencySymbol:   quote.Cur.Symbol,
+			Amount:           quote.Amount,
+			ToCurrencySymbol: quote.ToCur.Symbol,
+			ToAmount:         quote.ToAmount,
+			Rate:             quote.Rate.String(),
+			ExpiresAt:        quote.ExpiresAt.Format(time.RFC3339),
+		})
+	}
+}
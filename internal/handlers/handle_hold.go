@@ -0,0 +1,140 @@
+package handlers
+
+import (
+	"errors"
+	"io"
+	"net/http"
+	"strconv"
+	"time"
+
+	"bank-api/internal/domain"
+
+	"github.com/gin-gonic/gin"
+)
+
+type holdRequest struct {
+	ToAccountId int          `json:"to_account_id"`
+	Amount      domain.Money `json:"amount"`
+	ExpiresIn   int          `json:"expires_in"`
+}
+
+type holdResponse struct {
+	Id             int          `json:"id"`
+	AccountId      int          `json:"account_id"`
+	ToAccountId    int          `json:"to_account_id,omitempty"`
+	CurrencySymbol string       `json:"currency_name"`
+	Amount         domain.Money `json:"amount"`
+	Status         string       `json:"status"`
+	ExpiresAt      string       `json:"expires_at"`
+}
+
+func (h *Handler) NewHold() gin.HandlerFunc {
+	return func(c *gin.Context) {
+		var id int
+		if ok := getUserId(c, &id); !ok {
+			returnBadRequest(c)
+			return
+		}
+
+		var accountId int
+		if ok := getAccountId(c, &accountId); !ok {
+			returnBadRequest(c)
+			return
+		}
+
+		var req holdRequest
+		if err := c.ShouldBindJSON(&req); err != nil {
+			returnBadRequest(c)
+			return
+		}
+
+		ttl := time.Duration(req.ExpiresIn) * time.Second
+		hold, err := h.tr.PlaceHold(c, id, &domain.Hold{
+			AccountId:   accountId,
+			ToAccountId: req.ToAccountId,
+			Amount:      req.Amount,
+		}, ttl)
+		if err != nil {
+			returnError(c, err)
+			return
+		}
+
+		c.JSON(http.StatusCreated, holdResponse{
+			Id:             hold.Id,
+			AccountId:      hold.AccountId,
+			ToAccountId:    hold.ToAccountId,
+			CurrencySymbol: hold.Cur.Symbol,
+			Amount:         hold.Amount,
+			Status:         string(hold.Status),
+			ExpiresAt:      hold.ExpiresAt.Format(time.RFC3339),
+		})
+	}
+}
+
+type captureRequest struct {
+	Amount domain.Money `json:"amount"`
+}
+
+func (h *Handler) CaptureHold() gin.HandlerFunc {
+	return func(c *gin.Context) {
+		var id int
+		if ok := getUserId(c, &id); !ok {
+			returnBadRequest(c)
+			return
+		}
+
+		var holdId int
+		if ok := getHoldId(c, &holdId); !ok {
+			returnBadRequest(c)
+			return
+		}
+
+		// The body is optional, without an amount the whole hold is captured.
+		var req captureRequest
+		if err := c.ShouldBindJSON(&req); err != nil && !errors.Is(err, io.EOF) {
+			returnBadRequest(c)
+			return
+		}
+
+		capture, err := h.tr.CaptureHold(c, id, holdId, req.Amount)
+		if err != nil {
+			returnError(c, err)
+			return
+		}
+
+		c.JSON(http.StatusCreated, newTransaction(capture))
+	}
+}
+
+func (h *Handler) ReleaseHold() gin.HandlerFunc {
+	return func(c *gin.Context) {
+		var id int
+		if ok := getUserId(c, &id); !ok {
+			returnBadRequest(c)
+			return
+		}
+
+		var holdId int
+		if ok := getHoldId(c, &holdId); !ok {
+			returnBadRequest(c)
+			return
+		}
+
+		if err := h.tr.ReleaseHold(c, id, holdId); err != nil {
+			returnError(c, err)
+			return
+		}
+
+		c.Status(http.StatusNoContent)
+	}
+}
+
+func getHoldId(c *gin.Context, id *int) bool {
+	holdId, err := strconv.Atoi(c.Param("id"))
+	if err != nil {
+		return false
+	}
+
+	*id = holdId
+	return true
+}
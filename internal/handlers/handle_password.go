@@ -0,0 +1,92 @@
+package handlers
+
+import (
+	"errors"
+	"math"
+	"net/http"
+	"strconv"
+	"time"
+
+	"bank-api/internal/middleware"
+	"bank-api/internal/service"
+
+	"github.com/gin-gonic/gin"
+)
+
+type changePasswordRequest struct {
+	CurrentPassword string `json:"current_password" binding:"required"`
+	NewPassword     string `json:"new_password" binding:"required"`
+}
+
+// ChangePassword keeps the session it's called in, and logs the user out of all the others.
+func (h *Handler) ChangePassword() gin.HandlerFunc {
+	return func(c *gin.Context) {
+		principal, ok := middleware.GetPrincipal(c)
+		if !ok {
+			returnBadRequest(c)
+			return
+		}
+
+		var req changePasswordRequest
+		if err := c.ShouldBindJSON(&req); err != nil {
+			returnBadRequest(c)
+			return
+		}
+
+		err := h.pw.ChangePassword(c, principal.UserId, principal.SessionId, req.CurrentPassword, req.NewPassword, c.ClientIP())
+		var locked *service.LoginLockedError
+		if errors.As(err, &locked) {
+			c.Header("Retry-After", strconv.Itoa(int(math.Ceil(time.Until(locked.Until).Seconds()))))
+		}
+		if err != nil {
+			returnError(c, err)
+			return
+		}
+
+		c.Status(http.StatusNoContent)
+	}
+}
+
+type requestPasswordResetRequest struct {
+	Email string `json:"email" binding:"required"`
+}
+
+// RequestPasswordReset answers the same whether or not there is a user with the email.
+func (h *Handler) RequestPasswordReset() gin.HandlerFunc {
+	return func(c *gin.Context) {
+		var req requestPasswordResetRequest
+		if err := c.ShouldBindJSON(&req); err != nil {
+			returnBadRequest(c)
+			return
+		}
+
+		if err := h.pw.RequestReset(c, req.Email); err != nil {
+			returnError(c, err)
+			return
+		}
+
+		c.Status(http.StatusAccepted)
+	}
+}
+
+type resetPasswordRequest struct {
+	Token       string `json:"token" binding:"required"`
+	NewPassword string `json:"new_password" binding:"required"`
+}
+
+func (h *Handler) ResetPassword() gin.HandlerFunc {
+	return func(c *gin.Context) {
+		var req resetPasswordRequest
+		if err := c.ShouldBindJSON(&req); err != nil {
+			returnBadRequest(c)
+			return
+		}
+
+		if err := h.pw.ResetPassword(c, req.Token, req.NewPassword); err != nil {
+			returnError(c, err)
+			return
+		}
+
+		c.Status(http.StatusNoContent)
+	}
+}
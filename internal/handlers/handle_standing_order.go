@@ -0,0 +1,277 @@
+package handlers
+
+import (
+	"net/http"
+	"strconv"
+	"time"
+
+	"bank-api/internal/domain"
+	"bank-api/internal/service"
+
+	"github.com/gin-gonic/gin"
+)
+
+type standingOrderRequest struct {
+	FromAccountId  int          `json:"from_account_id" binding:"required"`
+	ToAccountId    int          `json:"to_account_id" binding:"required"`
+	Amount         domain.Money `json:"amount"`
+	Frequency      string       `json:"frequency" binding:"required"`
+	StartAt        string       `json:"start_at"`
+	EndAt          string       `json:"end_at"`
+	MaxOccurrences int          `json:"max_occurrences"`
+}
+
+type standingOrder struct {
+	Id             int          `json:"id"`
+	FromAccountId  int          `json:"from_account_id"`
+	ToAccountId    int          `json:"to_account_id"`
+	CurrencySymbol string       `json:"currency_name"`
+	Amount         domain.Money `json:"amount"`
+	Frequency      string       `json:"frequency"`
+	StartAt        string       `json:"start_at"`
+	EndAt          string       `json:"end_at,omitempty"`
+	MaxOccurrences int          `json:"max_occurrences,omitempty"`
+	Occurrences    int          `json:"occurrences"`
+	NextRunAt      string       `json:"next_run_at,omitempty"`
+	Status         string       `json:"status"`
+	CreatedAt      string       `json:"created_at"`
+}
+
+func newStandingOrder(o *domain.StandingOrder) standingOrder {
+	resp := standingOrder{
+		Id:             o.Id,
+		FromAccountId:  o.FromAccountId,
+		ToAccountId:    o.ToAccountId,
+		CurrencySymbol: o.Cur.Symbol,
+		Amount:         o.Amount,
+		Frequency:      string(o.Frequency),
+		StartAt:        o.StartAt.Format(time.RFC3339),
+		MaxOccurrences: o.MaxOccurrences,
+		Occurrences:    o.Occurrences,
+		Status:         string(o.Status),
+		CreatedAt:      o.Time.Format(time.RFC3339),
+	}
+	if !o.EndAt.IsZero() {
+		resp.EndAt = o.EndAt.Format(time.RFC3339)
+	}
+	if o.Status == domain.StandingOrderActive {
+		resp.NextRunAt = o.NextRunAt.Format(time.RFC3339)
+	}
+	return resp
+}
+
+func (h *Handler) NewStandingOrder() gin.HandlerFunc {
+	return func(c *gin.Context) {
+		var id int
+		if ok := getUserId(c, &id); !ok {
+			returnBadRequest(c)
+			return
+		}
+
+		var req standingOrderRequest
+		if err := c.ShouldBindJSON(&req); err != nil {
+			returnBadRequest(c)
+			return
+		}
+
+		startAt, err := parseTime(req.StartAt)
+		if err != nil {
+			returnBadRequest(c)
+			return
+		}
+		endAt, err := parseTime(req.EndAt)
+		if err != nil {
+			returnBadRequest(c)
+			return
+		}
+
+		order, err := h.so.CreateStandingOrder(c, id, &domain.StandingOrder{
+			FromAccountId:  req.FromAccountId,
+			ToAccountId:    req.ToAccountId,
+			Amount:         req.Amount,
+			Frequency:      domain.Frequency(req.Frequency),
+			StartAt:        startAt,
+			EndAt:          endAt,
+			MaxOccurrences: req.MaxOccurrences,
+		})
+		if err != nil {
+			returnError(c, err)
+			return
+		}
+
+		c.JSON(http.StatusCreated, newStandingOrder(order))
+	}
+}
+
+type listStandingOrdersResponse struct {
+	StandingOrders []standingOrder `json:"standing_orders"`
+}
+
+func (h *Handler) ListStandingOrders() gin.HandlerFunc {
+	return func(c *gin.Context) {
+		var id int
+		if ok := getUserId(c, &id); !ok {
+			returnBadRequest(c)
+			return
+		}
+
+		orders, err := h.so.ListStandingOrders(c, id)
+		if err != nil {
+			returnError(c, err)
+			return
+		}
+
+		resp := listStandingOrdersResponse{StandingOrders: make([]standingOrder, len(orders))}
+		for i, o := range orders {
+			resp.StandingOrders[i] = newStandingOrder(o)
+		}
+
+		c.JSON(http.StatusOK, resp)
+	}
+}
+
+// updateStandingOrderRequest leaves the fields that are not sent as they are. An empty end_at or
+// a zero max_occurrences removes the limit.
+type updateStandingOrderRequest struct {
+	Amount         *domain.Money `json:"amount"`
+	Frequency      *string       `json:"frequency"`
+	StartAt        *string       `json:"start_at"`
+	EndAt          *string       `json:"end_at"`
+	MaxOccurrences *int          `json:"max_occurrences"`
+}
+
+func (h *Handler) UpdateStandingOrder() gin.HandlerFunc {
+	return func(c *gin.Context) {
+		var id int
+		if ok := getUserId(c, &id); !ok {
+			returnBadRequest(c)
+			return
+		}
+
+		var orderId int
+		if ok := getStandingOrderId(c, &orderId); !ok {
+			returnBadRequest(c)
+			return
+		}
+
+		var req updateStandingOrderRequest
+		if err := c.ShouldBindJSON(&req); err != nil {
+			returnBadRequest(c)
+			return
+		}
+
+		update := service.StandingOrderUpdate{Amount: req.Amount, MaxOccurrences: req.MaxOccurrences}
+		if req.Frequency != nil {
+			frequency := domain.Frequency(*req.Frequency)
+			update.Frequency = &frequency
+		}
+		if req.StartAt != nil {
+			startAt, err := parseTime(*req.StartAt)
+			if err != nil || startAt.IsZero() {
+				returnBadRequest(c)
+				return
+			}
+			update.StartAt = &startAt
+		}
+		if req.EndAt != nil {
+			endAt, err := parseTime(*req.EndAt)
+			if err != nil {
+				returnBadRequest(c)
+				return
+			}
+			update.EndAt = &endAt
+		}
+
+		order, err := h.so.UpdateStandingOrder(c, id, orderId, &update)
+		if err != nil {
+			returnError(c, err)
+			return
+		}
+
+		c.JSON(http.StatusOK, newStandingOrder(order))
+	}
+}
+
+func (h *Handler) CancelStandingOrder() gin.HandlerFunc {
+	return func(c *gin.Context) {
+		var id int
+		if ok := getUserId(c, &id); !ok {
+			returnBadRequest(c)
+			return
+		}
+
+		var orderId int
+		if ok := getStandingOrderId(c, &orderId); !ok {
+			returnBadRequest(c)
+			return
+		}
+
+		if err := h.so.CancelStandingOrder(c, id, orderId); err != nil {
+			returnError(c, err)
+			return
+		}
+
+		c.Status(http.StatusNoContent)
+	}
+}
+
+type execution struct {
+	Id            int    `json:"id"`
+	ScheduledAt   string `json:"scheduled_at"`
+	Attempt       int    `json:"attempt"`
+	TransactionId int    `json:"transaction_id,omitempty"`
+	Status        string `json:"status"`
+	FailureReason string `json:"failure_reason,omitempty"`
+	ExecutedAt    string `json:"executed_at"`
+}
+
+type listExecutionsResponse struct {
+	Executions []execution `json:"executions"`
+}
+
+func (h *Handler) ListStandingOrderExecutions() gin.HandlerFunc {
+	return func(c *gin.Context) {
+		var id int
+		if ok := getUserId(c, &id); !ok {
+			returnBadRequest(c)
+			return
+		}
+
+		var orderId int
+		if ok := getStandingOrderId(c, &orderId); !ok {
+			returnBadRequest(c)
+			return
+		}
+
+		executions, err := h.so.ListExecutions(c, id, orderId)
+		if err != nil {
+			returnError(c, err)
+			return
+		}
+
+		resp := listExecutionsResponse{Executions: make([]execution, len(executions))}
+		for i, e := range executions {
+			resp.Executions[i] = execution{
+				Id:            e.Id,
+				ScheduledAt:   e.ScheduledAt.Format(time.RFC3339),
+				Attempt:       e.Attempt,
+				TransactionId: e.TransactionId,
+				Status:        string(e.Status),
+				FailureReason: e.FailureReason,
+				ExecutedAt:    e.Time.Format(time.RFC3339),
+			}
+		}
+
+		c.JSON(http.StatusOK, resp)
+	}
+}
+
+func getStandingOrderId(c *gin.Context, id *int) bool {
+	orderId, err := strconv.Atoi(c.Param("id"))
+	if err != nil {
+		return false
+	}
+
+	*id = orderId
+	return true
+}
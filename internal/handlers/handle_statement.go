@@ -0,0 +1,159 @@
+package handlers
+
+import (
+	"bytes"
+	"fmt"
+	"net/http"
+	"strconv"
+	"time"
+
+	"bank-api/internal/statement"
+
+	"github.com/gin-gonic/gin"
+)
+
+type statementQuery struct {
+	From   string `form:"from"`
+	To     string `form:"to"`
+	Format string `form:"format"`
+}
+
+func (h *Handler) GetStatement() gin.HandlerFunc {
+	return func(c *gin.Context) {
+		var id int
+		if ok := getUserId(c, &id); !ok {
+			returnBadRequest(c)
+			return
+		}
+
+		var accountId int
+		if ok := getAccountId(c, &accountId); !ok {
+			returnBadRequest(c)
+			return
+		}
+
+		var query statementQuery
+		if err := c.ShouldBindQuery(&query); err != nil {
+			returnBadRequest(c)
+			return
+		}
+		if query.Format == "" {
+			query.Format = statement.FormatCSV
+		}
+		contentType := statement.ContentType(query.Format)
+		if contentType == "" {
+			returnBadRequest(c)
+			return
+		}
+
+		// Without a period the statement covers the current month up to now.
+		to, err := parseTime(query.To)
+		if err != nil {
+			returnBadRequest(c)
+			return
+		}
+		if to.IsZero() {
+			to = time.Now().UTC()
+		}
+		from, err := parseTime(query.From)
+		if err != nil {
+			returnBadRequest(c)
+			return
+		}
+		if from.IsZero() {
+			from = time.Date(to.Year(), to.Month(), 1, 0, 0, 0, 0, time.UTC)
+		}
+
+		st, err := h.st.Statement(c, id, accountId, from, to)
+		if err != nil {
+			returnError(c, err)
+			return
+		}
+
+		var buf bytes.Buffer
+		if err := statement.Write(&buf, st, query.Format); err != nil {
+			returnError(c, err)
+			return
+		}
+
+		c.Header("Content-Disposition", fmt.Sprintf("attachment; filename=%q", statement.FileName(st, query.Format)))
+		c.Data(http.StatusOK, contentType, buf.Bytes())
+	}
+}
+
+type listStatementsResponse struct {
+	Statements []statementFile `json:"statements"`
+}
+
+type statementFile struct {
+	Id        int    `json:"id"`
+	From      string `json:"from"`
+	To        string `json:"to"`
+	CreatedAt string `json:"created_at"`
+}
+
+func (h *Handler) ListStatements() gin.HandlerFunc {
+	return func(c *gin.Context) {
+		var id int
+		if ok := getUserId(c, &id); !ok {
+			returnBadRequest(c)
+			return
+		}
+
+		var accountId int
+		if ok := getAccountId(c, &accountId); !ok {
+			returnBadRequest(c)
+			return
+		}
+
+		files, err := h.st.ListMonthly(c, id, accountId)
+		if err != nil {
+			returnError(c, err)
+			return
+		}
+
+		resp := listStatementsResponse{Statements: make([]statementFile, len(files))}
+		for i, f := range files {
+			resp.Statements[i] = statementFile{
+				Id:        f.Id,
+				From:      f.From.Format(time.RFC3339),
+				To:        f.To.Format(time.RFC3339),
+				CreatedAt: f.CreatedAt.Format(time.RFC3339),
+			}
+		}
+
+		c.JSON(http.StatusOK, resp)
+	}
+}
+
+func (h *Handler) DownloadStatement() gin.HandlerFunc {
+	return func(c *gin.Context) {
+		var id int
+		if ok := getUserId(c, &id); !ok {
+			returnBadRequest(c)
+			return
+		}
+
+		var accountId int
+		if ok := getAccountId(c, &accountId); !ok {
+			returnBadRequest(c)
+			return
+		}
+
+		statementId, err := strconv.Atoi(c.Param("statementId"))
+		if err != nil {
+			returnBadRequest(c)
+			return
+		}
+
+		file, err := h.st.GetMonthly(c, id, accountId, statementId)
+		if err != nil {
+			returnError(c, err)
+			return
+		}
+
+		name := fmt.Sprintf("statement-%d-%s.pdf", file.AccountId, file.From.Format("200601"))
+		c.Header("Content-Disposition", fmt.Sprintf("attachment; filename=%q", name))
+		c.Data(http.StatusOK, statement.ContentType(statement.FormatPDF), file.Content)
+	}
+}
@@ -1,7 +1,10 @@
 package handlers
 
 import (
+	"errors"
+	"io"
 	"net/http"
+	"time"
 
 	"bank-api/internal/domain"
 
@@ -9,7 +12,7 @@ import (
 )
 
 type depositRequest struct {
-	Amount int `json:"amount" binding:"required"`
+	Amount domain.Money `json:"amount"`
 }
 
 func (h *Handler) Deposit() gin.HandlerFunc {
@@ -47,7 +50,7 @@ func (h *Handler) Deposit() gin.HandlerFunc {
 }
 
 type withdrawRequest struct {
-	Amount int `json:"amount" binding:"required"`
+	Amount domain.Money `json:"amount"`
 }
 
 func (h *Handler) Withdraw() gin.HandlerFunc {
@@ -85,9 +88,10 @@ func (h *Handler) Withdraw() gin.HandlerFunc {
 }
 
 type transferRequest struct {
-	FromAccountId int `json:"from_account_id" binding:"required"`
-	ToAccountId   int `json:"to_account_id" binding:"required"`
-	Amount        int `json:"amount" binding:"required"`
+	FromAccountId int          `json:"from_account_id" binding:"required"`
+	ToAccountId   int          `json:"to_account_id" binding:"required"`
+	Amount        domain.Money `json:"amount"`
+	QuoteId       int          `json:"quote_id"`
 }
 
 func (h *Handler) Transfer() gin.HandlerFunc {
@@ -109,6 +113,7 @@ func (h *Handler) Transfer() gin.HandlerFunc {
 			FromAccountId: req.FromAccountId,
 			ToAccountId:   req.ToAccountId,
 			Amount:        req.Amount,
+			QuoteId:       req.QuoteId,
 			Type:          domain.Transfer,
 		}); err != nil {
 			returnError(c, err)
@@ -121,14 +126,50 @@ func (h *Handler) Transfer() gin.HandlerFunc {
 
 type listTransactionsResponse struct {
 	Transactions []transaction `json:"transactions"`
+	NextCursor   string        `json:"next_cursor,omitempty"`
 }
 
 type transaction struct {
-	FromAccountId  int    `json:"from_account_id"`
-	ToAccountId    int    `json:"to_account_id"`
-	CurrencySymbol string `json:"currency_name"`
-	Amount         int    `json:"amount"`
-	Time           string `json:"processed_at"`
+	Id               int           `json:"id"`
+	Type             string        `json:"type"`
+	Status           string        `json:"status"`
+	FailureReason    string        `json:"failure_reason,omitempty"`
+	FromAccountId    int           `json:"from_account_id"`
+	ToAccountId      int           `json:"to_account_id"`
+	CurrencySymbol   string        `json:"currency_name"`
+	Amount           domain.Money  `json:"amount"`
+	ToCurrencySymbol string        `json:"to_currency_name"`
+	ToAmount         domain.Money  `json:"to_amount"`
+	Rate             string        `json:"rate,omitempty"`
+	ReversesId       int           `json:"reverses_id,omitempty"`
+	ReversedAmount   *domain.Money `json:"reversed_amount,omitempty"`
+	HoldId           int           `json:"hold_id,omitempty"`
+	Time             string        `json:"processed_at"`
+}
+
+func newTransaction(t *domain.Transaction) transaction {
+	resp := transaction{
+		Id:               t.Id,
+		Type:             t.Type.String(),
+		Status:           string(t.Status),
+		FailureReason:    t.FailureReason,
+		FromAccountId:    t.FromAccountId,
+		ToAccountId:      t.ToAccountId,
+		CurrencySymbol:   t.Cur.Symbol,
+		Amount:           t.Amount,
+		ToCurrencySymbol: t.ToCur.Symbol,
+		ToAmount:         t.ToAmount,
+		ReversesId:       t.ReversesId,
+		HoldId:           t.HoldId,
+		Time:             t.Time.Format("2006-01-02 15:04:05"),
+	}
+	if t.Rate != nil {
+		resp.Rate = t.Rate.String()
+	}
+	if !t.ReversedAmount.IsZero() {
+		resp.ReversedAmount = &t.ReversedAmount
+	}
+	return resp
 }
 
 func (h *Handler) ListTransactions() gin.HandlerFunc {
@@ -139,28 +180,172 @@ func (h *Handler) ListTransactions() gin.HandlerFunc {
 			return
 		}
 
-		transactions, err := h.tr.ListTransactions(c, id)
-		if err != nil {
-			returnError(c, err)
+		filter := domain.TransactionFilter{UserId: id}
+		if ok := bindTransactionFilter(c, &filter); !ok {
+			returnBadRequest(c)
+			return
+		}
+
+		h.listTransactions(c, &filter)
+	}
+}
+
+func (h *Handler) ListAccountTransactions() gin.HandlerFunc {
+	return func(c *gin.Context) {
+		var id int
+		if ok := getUserId(c, &id); !ok {
+			returnBadRequest(c)
+			return
+		}
+
+		var accountId int
+		if ok := getAccountId(c, &accountId); !ok {
+			returnBadRequest(c)
 			return
 		}
-		if len(transactions) == 0 {
-			c.Status(http.StatusNoContent)
+
+		filter := domain.TransactionFilter{UserId: id, AccountId: accountId}
+		if ok := bindTransactionFilter(c, &filter); !ok {
+			returnBadRequest(c)
 			return
 		}
 
-		var resp listTransactionsResponse
-		resp.Transactions = make([]transaction, len(transactions))
-		for i := range resp.Transactions {
-			resp.Transactions[i] = transaction{
-				FromAccountId:  transactions[i].FromAccountId,
-				ToAccountId:    transactions[i].ToAccountId,
-				CurrencySymbol: transactions[i].Cur.Symbol,
-				Amount:         transactions[i].Amount,
-				Time:           transactions[i].Time.Format("2006-01-02 15:04:05"),
-			}
+		h.listTransactions(c, &filter)
+	}
+}
+
+func (h *Handler) listTransactions(c *gin.Context, filter *domain.TransactionFilter) {
+	transactions, next, err := h.tr.ListTransactions(c, filter)
+	if err != nil {
+		returnError(c, err)
+		return
+	}
+	writeTransactions(c, transactions, next)
+}
+
+// writeTransactions responds with a page of history, or no content if the page is empty.
+func writeTransactions(c *gin.Context, transactions []*domain.Transaction, next *domain.Cursor) {
+	if len(transactions) == 0 {
+		c.Status(http.StatusNoContent)
+		return
+	}
+
+	var resp listTransactionsResponse
+	resp.Transactions = make([]transaction, len(transactions))
+	for i := range resp.Transactions {
+		resp.Transactions[i] = newTransaction(transactions[i])
+	}
+	if next != nil {
+		resp.NextCursor = next.String()
+	}
+
+	c.JSON(http.StatusOK, resp)
+}
+
+type transactionFilterQuery struct {
+	From         string `form:"from"`
+	To           string `form:"to"`
+	Type         string `form:"type"`
+	MinAmount    string `form:"min_amount"`
+	MaxAmount    string `form:"max_amount"`
+	Currency     string `form:"currency"`
+	Counterparty int    `form:"counterparty"`
+	Cursor       string `form:"cursor"`
+	Limit        int    `form:"limit"`
+}
+
+// bindTransactionFilter reads the history filter from the query string.
+func bindTransactionFilter(c *gin.Context, filter *domain.TransactionFilter) bool {
+	var query transactionFilterQuery
+	if err := c.ShouldBindQuery(&query); err != nil {
+		return false
+	}
+
+	var err error
+	if filter.From, err = parseTime(query.From); err != nil {
+		return false
+	}
+	if filter.To, err = parseTime(query.To); err != nil {
+		return false
+	}
+	if query.Type != "" {
+		t, ok := domain.ParseTransactionType(query.Type)
+		if !ok {
+			return false
+		}
+		filter.Type = &t
+	}
+	if filter.MinAmount, err = parseAmount(query.MinAmount); err != nil {
+		return false
+	}
+	if filter.MaxAmount, err = parseAmount(query.MaxAmount); err != nil {
+		return false
+	}
+	if query.Cursor != "" {
+		if filter.After, err = domain.ParseCursor(query.Cursor); err != nil {
+			return false
+		}
+	}
+	filter.Cur = domain.Currency{Symbol: query.Currency}
+	filter.CounterpartyId = query.Counterparty
+	filter.Limit = query.Limit
+
+	return true
+}
+
+// parseTime accepts either a date or a RFC 3339 time, an empty string is the zero time.
+func parseTime(s string) (time.Time, error) {
+	if s == "" {
+		return time.Time{}, nil
+	}
+	if t, err := time.Parse(time.DateOnly, s); err == nil {
+		return t, nil
+	}
+	return time.Parse(time.RFC3339, s)
+}
+
+func parseAmount(s string) (*domain.Money, error) {
+	if s == "" {
+		return nil, nil
+	}
+	amount, err := domain.ParseMoney(s)
+	if err != nil {
+		return nil, err
+	}
+	return &amount, nil
+}
+
+type reverseRequest struct {
+	Amount domain.Money `json:"amount"`
+}
+
+func (h *Handler) ReverseTransaction() gin.HandlerFunc {
+	return func(c *gin.Context) {
+		var id int
+		if ok := getUserId(c, &id); !ok {
+			returnBadRequest(c)
+			return
+		}
+
+		var transactionId int
+		if ok := getTransactionId(c, &transactionId); !ok {
+			returnBadRequest(c)
+			return
+		}
+
+		// The body is optional, without an amount whatever is left of the transfer is reversed.
+		var req reverseRequest
+		if err := c.ShouldBindJSON(&req); err != nil && !errors.Is(err, io.EOF) {
+			returnBadRequest(c)
+			return
+		}
+
+		reversal, err := h.tr.ReverseTransaction(c, id, transactionId, req.Amount)
+		if err != nil {
+			returnError(c, err)
+			return
 		}
 
-		c.JSON(http.StatusOK, resp)
+		c.JSON(http.StatusCreated, newTransaction(reversal))
 	}
 }
@@ -0,0 +1,88 @@
+package handlers
+
+import (
+	"net/http"
+
+	"github.com/gin-gonic/gin"
+)
+
+type twoFactorEnrollmentResponse struct {
+	Secret     string `json:"secret"`
+	OtpauthURI string `json:"otpauth_uri"`
+}
+
+func (h *Handler) EnrollTwoFactor() gin.HandlerFunc {
+	return func(c *gin.Context) {
+		var userId int
+		if ok := getUserId(c, &userId); !ok {
+			returnBadRequest(c)
+			return
+		}
+
+		enrollment, err := h.us.EnrollTwoFactor(c, userId)
+		if err != nil {
+			returnError(c, err)
+			return
+		}
+
+		c.JSON(http.StatusOK, twoFactorEnrollmentResponse{
+			Secret:     enrollment.Secret,
+			OtpauthURI: enrollment.URI,
+		})
+	}
+}
+
+type twoFactorCodeRequest struct {
+	Code string `json:"code" binding:"required"`
+}
+
+type recoveryCodesResponse struct {
+	RecoveryCodes []string `json:"recovery_codes"`
+}
+
+func (h *Handler) ConfirmTwoFactor() gin.HandlerFunc {
+	return func(c *gin.Context) {
+		var userId int
+		if ok := getUserId(c, &userId); !ok {
+			returnBadRequest(c)
+			return
+		}
+
+		var req twoFactorCodeRequest
+		if err := c.ShouldBindJSON(&req); err != nil {
+			returnBadRequest(c)
+			return
+		}
+
+		codes, err := h.us.ConfirmTwoFactor(c, userId, req.Code)
+		if err != nil {
+			returnError(c, err)
+			return
+		}
+
+		c.JSON(http.StatusOK, recoveryCodesResponse{RecoveryCodes: codes})
+	}
+}
+
+func (h *Handler) DisableTwoFactor() gin.HandlerFunc {
+	return func(c *gin.Context) {
+		var userId int
+		if ok := getUserId(c, &userId); !ok {
+			returnBadRequest(c)
+			return
+		}
+
+		var req twoFactorCodeRequest
+		if err := c.ShouldBindJSON(&req); err != nil {
+			returnBadRequest(c)
+			return
+		}
+
+		if err := h.us.DisableTwoFactor(c, userId, req.Code); err != nil {
+			returnError(c, err)
+			return
+		}
+
+		c.Status(http.StatusNoContent)
+	}
+}
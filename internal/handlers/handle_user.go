@@ -22,12 +22,7 @@ func (h *Handler) GetUser() gin.HandlerFunc {
 			return
 		}
 
-		c.JSON(http.StatusOK, userResponse{
-			Id:        user.Id,
-			Name:      user.Name,
-			Email:     user.Email,
-			CreatedAt: user.CreatedAt,
-		})
+		c.JSON(http.StatusOK, newUserResponse(user))
 	}
 }
 
@@ -36,6 +31,7 @@ type updateUserRequest struct {
 	Email string `json:"email"`
 }
 
+// UpdateUser changes the name right away, while a new email only replaces the current one once it's verified.
 func (h *Handler) UpdateUser() gin.HandlerFunc {
 	return func(c *gin.Context) {
 		var id int
@@ -50,21 +46,52 @@ func (h *Handler) UpdateUser() gin.HandlerFunc {
 			return
 		}
 
-		account, err := h.us.UpdateUserInfo(c, id, &domain.UserInfo{
-			Name:  req.Name,
-			Email: req.Email,
-		})
+		user, err := h.em.UpdateUser(c, id, &domain.UserInfo{Name: req.Name, Email: req.Email})
 		if err != nil {
 			returnError(c, err)
 			return
 		}
 
-		c.JSON(http.StatusOK, userResponse{
-			Id:        account.Id,
-			Name:      account.Name,
-			Email:     account.Email,
-			CreatedAt: account.CreatedAt,
-		})
+		c.JSON(http.StatusOK, newUserResponse(user))
+	}
+}
+
+type verifyEmailRequest struct {
+	Token string `json:"token" binding:"required"`
+}
+
+func (h *Handler) VerifyEmail() gin.HandlerFunc {
+	return func(c *gin.Context) {
+		var req verifyEmailRequest
+		if err := c.ShouldBindJSON(&req); err != nil {
+			returnBadRequest(c)
+			return
+		}
+
+		user, err := h.em.VerifyEmail(c, req.Token)
+		if err != nil {
+			returnError(c, err)
+			return
+		}
+
+		c.JSON(http.StatusOK, newUserResponse(user))
+	}
+}
+
+func (h *Handler) ResendVerification() gin.HandlerFunc {
+	return func(c *gin.Context) {
+		var id int
+		if ok := getUserId(c, &id); !ok {
+			returnBadRequest(c)
+			return
+		}
+
+		if err := h.em.SendVerification(c, id); err != nil {
+			returnError(c, err)
+			return
+		}
+
+		c.Status(http.StatusAccepted)
 	}
 }
 
@@ -1,22 +1,54 @@
 package handlers
 
 import (
+	"bank-api/internal/auth"
 	"bank-api/internal/service"
 )
 
 type Handler struct {
-	us service.UserService
-	ac service.AccountService
-	tr service.TransactionService
+	us   service.UserService
+	ac   service.AccountService
+	tr   service.TransactionService
+	idem service.IdempotencyService
+	st   service.StatementService
+	so   service.StandingOrderService
+	tk   service.TokenService
+	ak   service.APIKeyService
+	adm  service.AdminService
+	pw   service.PasswordService
+	em   service.EmailService
 
-	JwtSecret string
+	JWT *auth.JWT
 }
 
-func NewHandler(jwtSecrete string, us service.UserService, as service.AccountService, tr service.TransactionService) *Handler {
+func NewHandler(jwt *auth.JWT, us service.UserService, as service.AccountService, tr service.TransactionService, idem service.IdempotencyService, st service.StatementService, so service.StandingOrderService, tk service.TokenService, ak service.APIKeyService, adm service.AdminService, pw service.PasswordService, em service.EmailService) *Handler {
 	return &Handler{
-		us:        us,
-		ac:        as,
-		tr:        tr,
-		JwtSecret: jwtSecrete,
+		us:   us,
+		ac:   as,
+		tr:   tr,
+		idem: idem,
+		st:   st,
+		so:   so,
+		tk:   tk,
+		ak:   ak,
+		adm:  adm,
+		pw:   pw,
+		em:   em,
+		JWT:  jwt,
 	}
 }
+
+// Sessions is what the auth middleware checks access tokens against.
+func (h *Handler) Sessions() service.TokenService {
+	return h.tk
+}
+
+// Emails is what the middleware checks that users verified their email against.
+func (h *Handler) Emails() service.EmailService {
+	return h.em
+}
+
+// APIKeys is what the auth middleware checks API keys against.
+func (h *Handler) APIKeys() service.APIKeyService {
+	return h.ak
+}
@@ -0,0 +1,112 @@
+package handlers
+
+import (
+	"bytes"
+	"context"
+	"crypto/sha256"
+	"encoding/hex"
+	"io"
+	"net/http"
+
+	"bank-api/internal/domain"
+
+	"github.com/gin-gonic/gin"
+)
+
+const idempotencyKeyHeader = "Idempotency-Key"
+
+type responseRecorder struct {
+	gin.ResponseWriter
+	body bytes.Buffer
+}
+
+func (w *responseRecorder) Write(b []byte) (int, error) {
+	w.body.Write(b)
+	return w.ResponseWriter.Write(b)
+}
+
+func (w *responseRecorder) WriteString(s string) (int, error) {
+	w.body.WriteString(s)
+	return w.ResponseWriter.WriteString(s)
+}
+
+// Idempotent makes the wrapped handler safe to retry: the outcome of the first request with an
+// Idempotency-Key header is stored and replayed for every later request with the same key.
+func (h *Handler) Idempotent() gin.HandlerFunc {
+	return func(c *gin.Context) {
+		key := c.GetHeader(idempotencyKeyHeader)
+		if key == "" {
+			c.Next()
+			return
+		}
+
+		var id int
+		if ok := getUserId(c, &id); !ok {
+			returnBadRequest(c)
+			c.Abort()
+			return
+		}
+
+		body, err := io.ReadAll(c.Request.Body)
+		if err != nil {
+			returnBadRequest(c)
+			c.Abort()
+			return
+		}
+		c.Request.Body = io.NopCloser(bytes.NewReader(body))
+
+		stored, err := h.idem.Begin(c, id, key, requestHash(c.Request, body))
+		if err != nil {
+			returnError(c, err)
+			c.Abort()
+			return
+		}
+		if stored != nil {
+			c.Header("Idempotent-Replayed", "true")
+			c.Data(stored.StatusCode, stored.ContentType, stored.Body)
+			c.Abort()
+			return
+		}
+
+		recorder := &responseRecorder{ResponseWriter: c.Writer}
+		c.Writer = recorder
+
+		// The key must be settled even if the client has already gone away.
+		ctx := context.WithoutCancel(c.Request.Context())
+		defer func() {
+			if p := recover(); p != nil {
+				// Release the key so that the request can be retried, and leave the answer to the recovery middleware.
+				if err := h.idem.Abandon(ctx, id, key); err != nil {
+					_ = c.Error(err)
+				}
+				panic(p)
+			}
+		}()
+
+		c.Next()
+
+		if recorder.Status() >= http.StatusInternalServerError {
+			err = h.idem.Abandon(ctx, id, key)
+		} else {
+			err = h.idem.Complete(ctx, id, key, &domain.IdempotentResponse{
+				StatusCode:  recorder.Status(),
+				ContentType: recorder.Header().Get("Content-Type"),
+				Body:        recorder.body.Bytes(),
+			})
+		}
+		if err != nil {
+			_ = c.Error(err)
+		}
+	}
+}
+
+func requestHash(r *http.Request, body []byte) string {
+	target := r.URL.Path
+	if r.URL.RawQuery != "" {
+		target += "?" + r.URL.RawQuery
+	}
+	hash := sha256.New()
+	hash.Write([]byte(r.Method + " " + target + "\n"))
+	hash.Write(body)
+	return hex.EncodeToString(hash.Sum(nil))
+}
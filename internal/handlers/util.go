@@ -5,6 +5,7 @@ import (
 	"net/http"
 	"strconv"
 
+	"bank-api/internal/middleware"
 	"bank-api/internal/service"
 	"bank-api/pkg/validate"
 
@@ -21,11 +22,20 @@ func returnError(c *gin.Context, err error) {
 }
 
 func getUserId(c *gin.Context, id *int) bool {
-	userIdClaim, ok := c.Get("user_id")
+	principal, ok := middleware.GetPrincipal(c)
 	if !ok {
 		return false
 	}
-	*id = int(userIdClaim.(float64))
+	*id = principal.UserId
+	return true
+}
+
+func getSessionId(c *gin.Context, id *int) bool {
+	principal, ok := middleware.GetPrincipal(c)
+	if !ok {
+		return false
+	}
+	*id = principal.SessionId
 	return true
 }
 
@@ -39,6 +49,16 @@ func getAccountId(c *gin.Context, id *int) bool {
 	return true
 }
 
+func getTransactionId(c *gin.Context, id *int) bool {
+	transactionId, err := strconv.Atoi(c.Param("id"))
+	if err != nil {
+		return false
+	}
+
+	*id = transactionId
+	return true
+}
+
 func getCodeAndMessage(err error) (int, string) {
 	switch {
 	case errors.Is(err, service.ErrInvalidAccount):
@@ -49,8 +69,56 @@ func getCodeAndMessage(err error) (int, string) {
 		return http.StatusNotFound, "No such currency"
 	case errors.Is(err, service.ErrNotEnoughMoney):
 		return http.StatusForbidden, "Not enough money"
+	case errors.Is(err, service.ErrAccountFrozen):
+		return http.StatusForbidden, "Account is frozen"
 	case errors.Is(err, service.ErrInvalidAmount):
 		return http.StatusForbidden, "Invalid amount"
+	case errors.Is(err, service.ErrNoExchangeRate):
+		return http.StatusUnprocessableEntity, "No exchange rate for these currencies"
+	case errors.Is(err, service.ErrSameCurrency):
+		return http.StatusBadRequest, "Accounts have the same currency"
+	case errors.Is(err, service.ErrNoSuchQuote):
+		return http.StatusNotFound, "No such quote"
+	case errors.Is(err, service.ErrInvalidQuote):
+		return http.StatusBadRequest, "Quote doesn't match the transfer"
+	case errors.Is(err, service.ErrQuoteExpired):
+		return http.StatusGone, "Quote has expired"
+	case errors.Is(err, service.ErrQuoteAlreadyUsed):
+		return http.StatusConflict, "Quote is already used"
+	case errors.Is(err, service.ErrNoSuchTransaction):
+		return http.StatusNotFound, "No such transaction"
+	case errors.Is(err, service.ErrNotReversible):
+		return http.StatusConflict, "Transaction can't be reversed"
+	case errors.Is(err, service.ErrReversalExceedsAmount):
+		return http.StatusConflict, "Reversal exceeds the amount left to reverse"
+	case errors.Is(err, service.ErrNoSuchHold):
+		return http.StatusNotFound, "No such hold"
+	case errors.Is(err, service.ErrHoldNotActive):
+		return http.StatusConflict, "Hold is not active"
+	case errors.Is(err, service.ErrCaptureExceedsHold):
+		return http.StatusConflict, "Capture exceeds the held amount"
+	case errors.Is(err, service.ErrInvalidHoldTTL):
+		return http.StatusBadRequest, "Invalid hold expiry"
+	case errors.Is(err, service.ErrCurrencyMismatch):
+		return http.StatusBadRequest, "Accounts have different currencies"
+	case errors.Is(err, service.ErrInvalidFilter):
+		return http.StatusBadRequest, "Invalid transaction filter"
+	case errors.Is(err, service.ErrInvalidPeriod):
+		return http.StatusBadRequest, "Invalid statement period"
+	case errors.Is(err, service.ErrNoSuchStatement):
+		return http.StatusNotFound, "No such statement"
+	case errors.Is(err, service.ErrNoSuchStandingOrder):
+		return http.StatusNotFound, "No such standing order"
+	case errors.Is(err, service.ErrStandingOrderNotActive):
+		return http.StatusConflict, "Standing order is not active"
+	case errors.Is(err, service.ErrInvalidSchedule):
+		return http.StatusBadRequest, "Invalid schedule"
+	case errors.Is(err, service.ErrInvalidIdempotencyKey):
+		return http.StatusBadRequest, "Invalid idempotency key"
+	case errors.Is(err, service.ErrIdempotencyKeyReused):
+		return http.StatusUnprocessableEntity, "Idempotency key is already used for another request"
+	case errors.Is(err, service.ErrIdempotencyKeyInProgress):
+		return http.StatusConflict, "Request with the same idempotency key is in progress"
 	case errors.Is(err, service.ErrUserAlreadyExists):
 		return http.StatusConflict, "User already exists"
 	case errors.Is(err, service.ErrNoSuchUser):
@@ -58,7 +126,57 @@ func getCodeAndMessage(err error) (int, string) {
 	case errors.Is(err, service.ErrEmptyUserInfo):
 		return http.StatusBadRequest, "Empty user info"
 	case errors.Is(err, service.ErrWrongPassword):
-		return http.StatusUnauthorized, "Wrong password"
+		return http.StatusUnauthorized, "Wrong email or password"
+	case errors.Is(err, service.ErrWrongCurrentPassword):
+		return http.StatusForbidden, "Wrong current password"
+	case errors.Is(err, service.ErrInvalidResetToken):
+		return http.StatusBadRequest, "Invalid or expired password reset token"
+	case errors.Is(err, service.ErrEmailTaken):
+		return http.StatusConflict, "Email is already taken"
+	case errors.Is(err, service.ErrEmailAlreadyVerified):
+		return http.StatusConflict, "Email is already verified"
+	case errors.Is(err, service.ErrInvalidVerificationToken):
+		return http.StatusBadRequest, "Invalid or expired email verification token"
+	case errors.Is(err, service.ErrVerificationThrottled):
+		return http.StatusTooManyRequests, "Verification email was sent recently, try again later"
+	case errors.Is(err, service.ErrLoginLocked):
+		return http.StatusTooManyRequests, "Too many failed logins, try again later"
+	case errors.Is(err, service.ErrTwoFactorEnabled):
+		return http.StatusConflict, "Two-factor authentication is already enabled"
+	case errors.Is(err, service.ErrTwoFactorNotEnrolled):
+		return http.StatusConflict, "Two-factor authentication isn't enrolled"
+	case errors.Is(err, service.ErrTwoFactorNotEnabled):
+		return http.StatusConflict, "Two-factor authentication isn't enabled"
+	case errors.Is(err, service.ErrWrongTwoFactorCode):
+		return http.StatusUnauthorized, "Wrong two-factor code"
+	case errors.Is(err, service.ErrInvalidChallenge):
+		return http.StatusUnauthorized, "Invalid or expired two-factor challenge"
+	case errors.Is(err, service.ErrTwoFactorUnavailable):
+		return http.StatusServiceUnavailable, "Two-factor authentication is unavailable"
+	case errors.Is(err, service.ErrNoSuchAPIKey):
+		return http.StatusNotFound, "No such API key"
+	case errors.Is(err, service.ErrInvalidAPIKeyName):
+		return http.StatusBadRequest, "Invalid API key name"
+	case errors.Is(err, service.ErrInvalidScope):
+		return http.StatusBadRequest, "Invalid scope"
+	case errors.Is(err, service.ErrForbidden):
+		return http.StatusForbidden, "Forbidden"
+	case errors.Is(err, service.ErrInvalidRole):
+		return http.StatusBadRequest, "Invalid role"
+	case errors.Is(err, service.ErrOwnRole):
+		return http.StatusForbidden, "Can't change own role"
+	case errors.Is(err, service.ErrInvalidLimit):
+		return http.StatusBadRequest, "Invalid limit"
+	case errors.Is(err, service.ErrInvalidFreezeReason):
+		return http.StatusBadRequest, "Invalid freeze reason"
+	case errors.Is(err, service.ErrAccountAlreadyFrozen):
+		return http.StatusConflict, "Account is already frozen"
+	case errors.Is(err, service.ErrAccountNotFrozen):
+		return http.StatusConflict, "Account isn't frozen"
+	case errors.Is(err, service.ErrInvalidRefreshToken):
+		return http.StatusUnauthorized, "Invalid refresh token"
+	case errors.Is(err, service.ErrRefreshTokenReused):
+		return http.StatusUnauthorized, "Refresh token is already used, the session is revoked"
 	case errors.Is(err, validate.ErrInvalidName):
 		return http.StatusBadRequest, "Invalid name"
 	case errors.Is(err, validate.ErrInvalidEmail):
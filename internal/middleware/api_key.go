@@ -0,0 +1,81 @@
+package middleware
+
+import (
+	"context"
+	"net/http"
+
+	"bank-api/internal/domain"
+
+	"github.com/gin-gonic/gin"
+)
+
+const APIKeyHeader = "X-API-Key"
+
+// APIKeys tells who an API key acts as: nil if the key is unknown or revoked.
+type APIKeys interface {
+	AuthenticateAPIKey(ctx context.Context, key string) (*domain.Principal, error)
+}
+
+// APIKey authenticates requests by the X-API-Key header on the routes that API keys can use, and
+// hands the other requests to Jwt.
+type APIKey struct {
+	Jwt  *Jwt
+	Keys APIKeys
+}
+
+// RequireScope lets a request with an API key through if the key has the scope, and authenticates requests
+// without one like Jwt.RequireAuth does. The routes are of the resources of the user the principal acts as,
+// so system keys, which act as no user, can't use them whatever their scopes.
+func (a *APIKey) RequireScope(scope domain.Scope) gin.HandlerFunc {
+	return func(c *gin.Context) {
+		principal, ok := a.authenticate(c)
+		if !ok {
+			return
+		}
+		if principal.UserId == 0 || !principal.Allows(scope) {
+			c.AbortWithStatusJSON(http.StatusForbidden, gin.H{"message": "insufficient scope", "scope": scope})
+			return
+		}
+
+		c.Set(principalKey, principal)
+		c.Next()
+	}
+}
+
+// RequireSystemKey lets a request with a system API key through, and authenticates requests without an API key
+// like Jwt.RequireAuth does. It guards the staff routes, where RequirePermission checks the admin scopes of the key.
+func (a *APIKey) RequireSystemKey(c *gin.Context) {
+	principal, ok := a.authenticate(c)
+	if !ok {
+		return
+	}
+	if principal.UserId != 0 {
+		c.AbortWithStatusJSON(http.StatusForbidden, gin.H{"message": "forbidden"})
+		return
+	}
+
+	c.Set(principalKey, principal)
+	c.Next()
+}
+
+// authenticate returns the principal of the API key of the request. A request without a key is handed
+// to Jwt.RequireAuth instead, and like a request with an invalid key, it isn't ok.
+func (a *APIKey) authenticate(c *gin.Context) (*domain.Principal, bool) {
+	key := c.GetHeader(APIKeyHeader)
+	if key == "" {
+		a.Jwt.RequireAuth(c)
+		return nil, false
+	}
+
+	principal, err := a.Keys.AuthenticateAPIKey(c, key)
+	if err != nil {
+		_ = c.Error(err)
+		c.AbortWithStatusJSON(http.StatusInternalServerError, gin.H{"message": "internal server error"})
+		return nil, false
+	}
+	if principal == nil {
+		c.AbortWithStatusJSON(http.StatusUnauthorized, gin.H{"message": "invalid api key"})
+		return nil, false
+	}
+	return principal, true
+}
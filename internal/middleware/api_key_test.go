@@ -0,0 +1,150 @@
+package middleware
+
+import (
+	"context"
+	"errors"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+	"time"
+
+	"bank-api/internal/auth"
+	"bank-api/internal/domain"
+
+	"github.com/gin-gonic/gin"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+type fakeAPIKeys struct {
+	keys map[string]*domain.Principal
+	err  error
+}
+
+func (f *fakeAPIKeys) AuthenticateAPIKey(_ context.Context, key string) (*domain.Principal, error) {
+	return f.keys[key], f.err
+}
+
+func TestRequireScope(t *testing.T) {
+	gin.SetMode(gin.TestMode)
+
+	tokens := auth.NewJWT("secret", "bank-api", "bank-api", 0)
+	token, err := tokens.Issue(domain.Principal{UserId: 1, SessionId: 5}, time.Now(), time.Minute)
+	require.NoError(t, err)
+
+	reader := &domain.Principal{UserId: 1, APIKeyId: 3, Scopes: []domain.Scope{domain.ScopeAccountsRead}}
+	system := &domain.Principal{APIKeyId: 4, Scopes: []domain.Scope{domain.ScopeAccountsRead}}
+	keys := &fakeAPIKeys{keys: map[string]*domain.Principal{"reader": reader, "system": system}}
+
+	tests := []struct {
+		name      string
+		key       string
+		bearer    string
+		keys      *fakeAPIKeys
+		code      int
+		principal *domain.Principal
+	}{
+		{name: "key with the scope", key: "reader", code: http.StatusOK, principal: reader},
+		{name: "session", bearer: token, code: http.StatusOK, principal: &domain.Principal{UserId: 1, SessionId: 5, Role: domain.RoleCustomer}},
+		{name: "unknown key", key: "unknown", code: http.StatusUnauthorized},
+		{name: "unknown key with a valid session", key: "unknown", bearer: token, code: http.StatusUnauthorized},
+		{name: "system key", key: "system", code: http.StatusForbidden},
+		{name: "nothing", code: http.StatusUnauthorized},
+		{name: "keys unavailable", key: "reader", keys: &fakeAPIKeys{err: errors.New("db is down")}, code: http.StatusInternalServerError},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if tt.keys == nil {
+				tt.keys = keys
+			}
+			a := &APIKey{Jwt: &Jwt{Tokens: tokens, Sessions: &fakeSessions{}}, Keys: tt.keys}
+
+			var principal *domain.Principal
+			r := gin.New()
+			handler := func(c *gin.Context) {
+				principal, _ = GetPrincipal(c)
+				c.Status(http.StatusOK)
+			}
+			r.GET("/", a.RequireScope(domain.ScopeAccountsRead), handler)
+
+			req := httptest.NewRequest(http.MethodGet, "/", nil)
+			if tt.key != "" {
+				req.Header.Set(APIKeyHeader, tt.key)
+			}
+			if tt.bearer != "" {
+				req.Header.Set("Authorization", "Bearer "+tt.bearer)
+			}
+
+			w := httptest.NewRecorder()
+			r.ServeHTTP(w, req)
+
+			assert.Equal(t, tt.code, w.Code)
+			assert.Equal(t, tt.principal, principal)
+		})
+	}
+
+	// A key without the scope of the route is refused.
+	r := gin.New()
+	a := &APIKey{Jwt: &Jwt{Tokens: tokens, Sessions: &fakeSessions{}}, Keys: keys}
+	r.POST("/", a.RequireScope(domain.ScopeTransactionsWrite), func(c *gin.Context) { c.Status(http.StatusOK) })
+	req := httptest.NewRequest(http.MethodPost, "/", nil)
+	req.Header.Set(APIKeyHeader, "reader")
+	w := httptest.NewRecorder()
+	r.ServeHTTP(w, req)
+	assert.Equal(t, http.StatusForbidden, w.Code)
+	assert.JSONEq(t, `{"message":"insufficient scope","scope":"transactions:write"}`, w.Body.String())
+}
+
+func TestRequireSystemKey(t *testing.T) {
+	gin.SetMode(gin.TestMode)
+
+	tokens := auth.NewJWT("secret", "bank-api", "bank-api", 0)
+	token, err := tokens.Issue(domain.Principal{UserId: 1, SessionId: 5, Role: domain.RoleAdmin}, time.Now(), time.Minute)
+	require.NoError(t, err)
+
+	system := &domain.Principal{APIKeyId: 4, Scopes: []domain.Scope{domain.AdminScope(domain.PermUsersRead)}}
+	user := &domain.Principal{UserId: 1, APIKeyId: 3, Scopes: []domain.Scope{domain.ScopeAccountsRead}}
+	keys := &fakeAPIKeys{keys: map[string]*domain.Principal{"system": system, "user": user}}
+
+	tests := []struct {
+		name      string
+		key       string
+		bearer    string
+		code      int
+		principal *domain.Principal
+	}{
+		{name: "system key", key: "system", code: http.StatusOK, principal: system},
+		{name: "session", bearer: token, code: http.StatusOK, principal: &domain.Principal{UserId: 1, SessionId: 5, Role: domain.RoleAdmin}},
+		{name: "key of a user", key: "user", code: http.StatusForbidden},
+		{name: "unknown key", key: "unknown", code: http.StatusUnauthorized},
+		{name: "nothing", code: http.StatusUnauthorized},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			a := &APIKey{Jwt: &Jwt{Tokens: tokens, Sessions: &fakeSessions{}}, Keys: keys}
+
+			var principal *domain.Principal
+			r := gin.New()
+			r.GET("/", a.RequireSystemKey, func(c *gin.Context) {
+				principal, _ = GetPrincipal(c)
+				c.Status(http.StatusOK)
+			})
+
+			req := httptest.NewRequest(http.MethodGet, "/", nil)
+			if tt.key != "" {
+				req.Header.Set(APIKeyHeader, tt.key)
+			}
+			if tt.bearer != "" {
+				req.Header.Set("Authorization", "Bearer "+tt.bearer)
+			}
+
+			w := httptest.NewRecorder()
+			r.ServeHTTP(w, req)
+
+			assert.Equal(t, tt.code, w.Code)
+			assert.Equal(t, tt.principal, principal)
+		})
+	}
+}
@@ -0,0 +1,56 @@
+package middleware
+
+import (
+	"context"
+	"net/http"
+
+	"bank-api/internal/domain"
+
+	"github.com/gin-gonic/gin"
+)
+
+// RequirePermission lets a request through only if the role of its principal has the permission.
+// It must run after RequireAuth.
+func RequirePermission(perm domain.Permission) gin.HandlerFunc {
+	return func(c *gin.Context) {
+		principal, ok := GetPrincipal(c)
+		if !ok {
+			abortUnauthorized(c, errMissingToken)
+			return
+		}
+		if !principal.Can(perm) {
+			c.AbortWithStatusJSON(http.StatusForbidden, gin.H{"message": "forbidden"})
+			return
+		}
+		c.Next()
+	}
+}
+
+// EmailVerifications tells whether users have verified their email.
+type EmailVerifications interface {
+	EmailVerified(ctx context.Context, userId int) (bool, error)
+}
+
+// RequireVerifiedEmail lets a request through only if the user its principal acts as has verified their email,
+// whether it is authenticated by an access token or an API key. It must run after the authentication.
+func RequireVerifiedEmail(emails EmailVerifications) gin.HandlerFunc {
+	return func(c *gin.Context) {
+		principal, ok := GetPrincipal(c)
+		if !ok {
+			abortUnauthorized(c, errMissingToken)
+			return
+		}
+
+		verified, err := emails.EmailVerified(c, principal.UserId)
+		if err != nil {
+			_ = c.Error(err)
+			c.AbortWithStatusJSON(http.StatusInternalServerError, gin.H{"message": "internal server error"})
+			return
+		}
+		if !verified {
+			c.AbortWithStatusJSON(http.StatusForbidden, gin.H{"message": "email not verified"})
+			return
+		}
+		c.Next()
+	}
+}
@@ -0,0 +1,94 @@
+package middleware
+
+import (
+	"context"
+	"errors"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+
+	"bank-api/internal/domain"
+
+	"github.com/gin-gonic/gin"
+	"github.com/stretchr/testify/assert"
+)
+
+func TestRequirePermission(t *testing.T) {
+	gin.SetMode(gin.TestMode)
+
+	tests := []struct {
+		name      string
+		principal *domain.Principal
+		code      int
+	}{
+		{name: "admin", principal: &domain.Principal{UserId: 1, SessionId: 5, Role: domain.RoleAdmin}, code: http.StatusOK},
+		{name: "auditor", principal: &domain.Principal{UserId: 1, SessionId: 5, Role: domain.RoleAuditor}, code: http.StatusForbidden},
+		{name: "customer", principal: &domain.Principal{UserId: 1, SessionId: 5, Role: domain.RoleCustomer}, code: http.StatusForbidden},
+		{name: "api key of an admin", principal: &domain.Principal{UserId: 1, APIKeyId: 3, Role: domain.RoleAdmin}, code: http.StatusForbidden},
+		{name: "system key with the scope", principal: &domain.Principal{APIKeyId: 4, Scopes: []domain.Scope{domain.AdminScope(domain.PermAccountsFreeze)}}, code: http.StatusOK},
+		{name: "system key without the scope", principal: &domain.Principal{APIKeyId: 4, Scopes: []domain.Scope{domain.AdminScope(domain.PermAccountsRead)}}, code: http.StatusForbidden},
+		{name: "not authenticated", code: http.StatusUnauthorized},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			r := gin.New()
+			r.POST("/", func(c *gin.Context) {
+				if tt.principal != nil {
+					c.Set(principalKey, tt.principal)
+				}
+			}, RequirePermission(domain.PermAccountsFreeze), func(c *gin.Context) {
+				c.Status(http.StatusOK)
+			})
+
+			w := httptest.NewRecorder()
+			r.ServeHTTP(w, httptest.NewRequest(http.MethodPost, "/", nil))
+			assert.Equal(t, tt.code, w.Code)
+		})
+	}
+}
+
+type stubEmails map[int]bool
+
+func (s stubEmails) EmailVerified(_ context.Context, userId int) (bool, error) {
+	verified, ok := s[userId]
+	if !ok {
+		return false, errors.New("no such user")
+	}
+	return verified, nil
+}
+
+func TestRequireVerifiedEmail(t *testing.T) {
+	gin.SetMode(gin.TestMode)
+	emails := stubEmails{1: true, 2: false}
+
+	tests := []struct {
+		name      string
+		principal *domain.Principal
+		code      int
+	}{
+		{name: "verified", principal: &domain.Principal{UserId: 1, SessionId: 5}, code: http.StatusOK},
+		{name: "api key of a verified user", principal: &domain.Principal{UserId: 1, APIKeyId: 3}, code: http.StatusOK},
+		{name: "unverified", principal: &domain.Principal{UserId: 2, SessionId: 6}, code: http.StatusForbidden},
+		{name: "api key of an unverified user", principal: &domain.Principal{UserId: 2, APIKeyId: 4}, code: http.StatusForbidden},
+		{name: "lookup fails", principal: &domain.Principal{UserId: 3, SessionId: 7}, code: http.StatusInternalServerError},
+		{name: "not authenticated", code: http.StatusUnauthorized},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			r := gin.New()
+			r.POST("/", func(c *gin.Context) {
+				if tt.principal != nil {
+					c.Set(principalKey, tt.principal)
+				}
+			}, RequireVerifiedEmail(emails), func(c *gin.Context) {
+				c.Status(http.StatusOK)
+			})
+
+			w := httptest.NewRecorder()
+			r.ServeHTTP(w, httptest.NewRequest(http.MethodPost, "/", nil))
+			assert.Equal(t, tt.code, w.Code)
+		})
+	}
+}
@@ -0,0 +1,58 @@
+package middleware
+
+import (
+	"crypto/rand"
+	"crypto/subtle"
+	"encoding/base64"
+	"net/http"
+
+	"github.com/gin-gonic/gin"
+)
+
+// Requests authenticated by a cookie must repeat the value of the CSRF cookie in the CSRF header.
+// Another site can make the browser send the cookies, but it can't read them to set the header.
+const (
+	CSRFCookie = "csrf_token"
+	CSRFHeader = "X-CSRF-Token"
+)
+
+// RequireCSRF checks the CSRF token of unsafe requests that carry the given cookie. Requests without it
+// authenticate some other way, which a browser doesn't do on its own.
+func RequireCSRF(cookie string) gin.HandlerFunc {
+	return func(c *gin.Context) {
+		if _, err := c.Cookie(cookie); err == nil && !validCSRF(c) {
+			abortForbidden(c)
+			return
+		}
+		c.Next()
+	}
+}
+
+func validCSRF(c *gin.Context) bool {
+	switch c.Request.Method {
+	case http.MethodGet, http.MethodHead, http.MethodOptions:
+		return true
+	}
+
+	token, err := c.Cookie(CSRFCookie)
+	if err != nil || token == "" {
+		return false
+	}
+	return subtle.ConstantTimeCompare([]byte(token), []byte(c.GetHeader(CSRFHeader))) == 1
+}
+
+// SetCSRFCookie gives the client a new CSRF token. The cookie is readable by scripts on purpose.
+func SetCSRFCookie(c *gin.Context, maxAge int) error {
+	raw := make([]byte, 32)
+	if _, err := rand.Read(raw); err != nil {
+		return err
+	}
+	c.SetSameSite(http.SameSiteLaxMode)
+	c.SetCookie(CSRFCookie, base64.RawURLEncoding.EncodeToString(raw), maxAge, "/", "", true, false)
+	return nil
+}
+
+func ClearCSRFCookie(c *gin.Context) {
+	c.SetSameSite(http.SameSiteLaxMode)
+	c.SetCookie(CSRFCookie, "", -1, "/", "", true, false)
+}
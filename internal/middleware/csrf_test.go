@@ -0,0 +1,69 @@
+package middleware
+
+import (
+	"net/http"
+	"net/http/httptest"
+	"testing"
+
+	"github.com/gin-gonic/gin"
+	"github.com/stretchr/testify/assert"
+)
+
+func TestRequireCSRF(t *testing.T) {
+	gin.SetMode(gin.TestMode)
+
+	tests := []struct {
+		name   string
+		method string
+		cookie bool
+		csrf   string
+		header string
+		code   int
+	}{
+		{name: "no auth cookie", method: http.MethodPost, code: http.StatusOK},
+		{name: "safe method", method: http.MethodGet, cookie: true, code: http.StatusOK},
+		{name: "matching token", method: http.MethodPost, cookie: true, csrf: "token", header: "token", code: http.StatusOK},
+		{name: "missing header", method: http.MethodPost, cookie: true, csrf: "token", code: http.StatusForbidden},
+		{name: "wrong header", method: http.MethodDelete, cookie: true, csrf: "token", header: "other", code: http.StatusForbidden},
+		{name: "missing csrf cookie", method: http.MethodPost, cookie: true, header: "token", code: http.StatusForbidden},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			r := gin.New()
+			r.Handle(tt.method, "/", RequireCSRF("Refresh"), func(c *gin.Context) { c.Status(http.StatusOK) })
+
+			req := httptest.NewRequest(tt.method, "/", nil)
+			if tt.cookie {
+				req.AddCookie(&http.Cookie{Name: "Refresh", Value: "refresh"})
+			}
+			if tt.csrf != "" {
+				req.AddCookie(&http.Cookie{Name: CSRFCookie, Value: tt.csrf})
+			}
+			if tt.header != "" {
+				req.Header.Set(CSRFHeader, tt.header)
+			}
+
+			w := httptest.NewRecorder()
+			r.ServeHTTP(w, req)
+			assert.Equal(t, tt.code, w.Code)
+		})
+	}
+}
+
+func TestBearerToken(t *testing.T) {
+	for header, want := range map[string]string{
+		"Bearer abc": "abc",
+		"bearer abc": "abc",
+		"Basic abc":  "",
+		"abc":        "",
+		"":           "",
+	} {
+		c, _ := gin.CreateTestContext(httptest.NewRecorder())
+		c.Request = httptest.NewRequest(http.MethodGet, "/", nil)
+		c.Request.Header.Set("Authorization", header)
+
+		token, _ := bearerToken(c)
+		assert.Equal(t, want, token, header)
+	}
+}
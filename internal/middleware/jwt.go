@@ -1,36 +1,106 @@
 package middleware
 
 import (
+	"context"
+	"errors"
 	"net/http"
-	"time"
+	"strings"
+
+	"bank-api/internal/auth"
+	"bank-api/internal/domain"
 
 	"github.com/gin-gonic/gin"
-	"github.com/golang-jwt/jwt/v5"
 )
 
+const principalKey = "principal"
+
+var (
+	errMissingToken = errors.New("missing token")
+	errRevokedToken = errors.New("revoked token")
+)
+
+// Sessions tells whether the session an access token was issued in has been revoked.
+type Sessions interface {
+	SessionRevoked(ctx context.Context, sessionId int) (bool, error)
+}
+
 type Jwt struct {
-	Secret string
+	Tokens   *auth.JWT
+	Sessions Sessions
 }
 
+// RequireAuth takes the access token from a Bearer Authorization header, or else from the Authorization cookie,
+// in which case the request must also pass the CSRF check. The principal of a valid token is put in the context.
 func (j *Jwt) RequireAuth(c *gin.Context) {
-	tokenStr, err := c.Cookie("Authorization")
-	if err != nil {
-		c.AbortWithStatus(http.StatusUnauthorized)
-	}
-	token, err := jwt.Parse(tokenStr, func(token *jwt.Token) (any, error) {
-		if _, ok := token.Method.(*jwt.SigningMethodHMAC); !ok {
-			return nil, jwt.ErrSignatureInvalid
+	tokenStr, ok := bearerToken(c)
+	if !ok {
+		cookie, err := c.Cookie("Authorization")
+		if err != nil || cookie == "" {
+			abortUnauthorized(c, errMissingToken)
+			return
 		}
-		return []byte(j.Secret), nil
-	})
-
-	if claims, ok := token.Claims.(jwt.MapClaims); ok && token.Valid {
-		if time.Now().Unix() > int64(claims["exp"].(float64)) {
-			c.AbortWithStatus(http.StatusUnauthorized)
-		} else {
-			c.Set("user_id", claims["sub"])
+		if !validCSRF(c) {
+			abortForbidden(c)
+			return
 		}
+		tokenStr = cookie
+	}
+
+	principal, err := j.Tokens.Verify(tokenStr)
+	if err != nil {
+		abortUnauthorized(c, err)
+		return
 	}
 
+	revoked, err := j.Sessions.SessionRevoked(c, principal.SessionId)
+	if err != nil {
+		_ = c.Error(err)
+		c.AbortWithStatusJSON(http.StatusInternalServerError, gin.H{"message": "internal server error"})
+		return
+	}
+	if revoked {
+		abortUnauthorized(c, errRevokedToken)
+		return
+	}
+
+	c.Set(principalKey, principal)
 	c.Next()
 }
+
+// GetPrincipal returns the principal RequireAuth authenticated the request as.
+func GetPrincipal(c *gin.Context) (*domain.Principal, bool) {
+	principal, ok := c.Get(principalKey)
+	if !ok {
+		return nil, false
+	}
+	p, ok := principal.(*domain.Principal)
+	return p, ok
+}
+
+func bearerToken(c *gin.Context) (string, bool) {
+	scheme, token, ok := strings.Cut(c.GetHeader("Authorization"), " ")
+	if !ok || !strings.EqualFold(scheme, "Bearer") {
+		return "", false
+	}
+	return token, true
+}
+
+// abortUnauthorized tells why the token is rejected without telling more than that.
+func abortUnauthorized(c *gin.Context, err error) {
+	message := "invalid token"
+	switch {
+	case errors.Is(err, errMissingToken):
+		message = "missing token"
+	case errors.Is(err, auth.ErrExpiredToken):
+		message = "expired token"
+	case errors.Is(err, errRevokedToken):
+		message = "revoked token"
+	}
+
+	c.Header("WWW-Authenticate", "Bearer")
+	c.AbortWithStatusJSON(http.StatusUnauthorized, gin.H{"message": message})
+}
+
+func abortForbidden(c *gin.Context) {
+	c.AbortWithStatusJSON(http.StatusForbidden, gin.H{"message": "invalid csrf token"})
+}
@@ -0,0 +1,115 @@
+package middleware
+
+import (
+	"context"
+	"errors"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+	"time"
+
+	"bank-api/internal/auth"
+	"bank-api/internal/domain"
+
+	"github.com/gin-gonic/gin"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+type fakeSessions struct {
+	revoked map[int]bool
+	err     error
+}
+
+func (f *fakeSessions) SessionRevoked(_ context.Context, sessionId int) (bool, error) {
+	return f.revoked[sessionId], f.err
+}
+
+func TestRequireAuth(t *testing.T) {
+	gin.SetMode(gin.TestMode)
+
+	tokens := auth.NewJWT("secret", "bank-api", "bank-api", 0)
+	issue := func(p domain.Principal, ttl time.Duration) string {
+		token, err := tokens.Issue(p, time.Now(), ttl)
+		require.NoError(t, err)
+		return token
+	}
+	valid := issue(domain.Principal{UserId: 1, SessionId: 5}, time.Minute)
+
+	tests := []struct {
+		name     string
+		method   string
+		header   string
+		cookie   string
+		csrf     bool
+		sessions *fakeSessions
+		code     int
+		body     string
+	}{
+		{name: "bearer", header: "Bearer " + valid, code: http.StatusOK},
+		{name: "bearer on a POST needs no csrf token", method: http.MethodPost, header: "Bearer " + valid, code: http.StatusOK},
+		{name: "cookie", cookie: valid, code: http.StatusOK},
+		{name: "cookie with csrf token", method: http.MethodPost, cookie: valid, csrf: true, code: http.StatusOK},
+		{name: "no token", code: http.StatusUnauthorized, body: `{"message":"missing token"}`},
+		{name: "other scheme", header: "Basic dXNlcjpwYXNz", code: http.StatusUnauthorized, body: `{"message":"missing token"}`},
+		{name: "empty bearer", header: "Bearer ", code: http.StatusUnauthorized, body: `{"message":"invalid token"}`},
+		{name: "garbage", header: "Bearer garbage", code: http.StatusUnauthorized, body: `{"message":"invalid token"}`},
+		{name: "signed with another secret", header: "Bearer " + func() string {
+			token, _ := auth.NewJWT("other", "bank-api", "bank-api", 0).Issue(domain.Principal{UserId: 1, SessionId: 5}, time.Now(), time.Minute)
+			return token
+		}(), code: http.StatusUnauthorized, body: `{"message":"invalid token"}`},
+		{
+			name:   "expired",
+			header: "Bearer " + issue(domain.Principal{UserId: 1, SessionId: 5}, -time.Minute),
+			code:   http.StatusUnauthorized,
+			body:   `{"message":"expired token"}`,
+		},
+		{name: "cookie without csrf token", method: http.MethodPost, cookie: valid, code: http.StatusForbidden, body: `{"message":"invalid csrf token"}`},
+		{name: "revoked session", header: "Bearer " + valid, sessions: &fakeSessions{revoked: map[int]bool{5: true}}, code: http.StatusUnauthorized, body: `{"message":"revoked token"}`},
+		{name: "sessions unavailable", header: "Bearer " + valid, sessions: &fakeSessions{err: errors.New("db is down")}, code: http.StatusInternalServerError},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if tt.method == "" {
+				tt.method = http.MethodGet
+			}
+			if tt.sessions == nil {
+				tt.sessions = &fakeSessions{}
+			}
+			j := Jwt{Tokens: tokens, Sessions: tt.sessions}
+
+			var principal *domain.Principal
+			r := gin.New()
+			r.Handle(tt.method, "/", j.RequireAuth, func(c *gin.Context) {
+				principal, _ = GetPrincipal(c)
+				c.Status(http.StatusOK)
+			})
+
+			req := httptest.NewRequest(tt.method, "/", nil)
+			if tt.header != "" {
+				req.Header.Set("Authorization", tt.header)
+			}
+			if tt.cookie != "" {
+				req.AddCookie(&http.Cookie{Name: "Authorization", Value: tt.cookie})
+			}
+			if tt.csrf {
+				req.AddCookie(&http.Cookie{Name: CSRFCookie, Value: "csrf"})
+				req.Header.Set(CSRFHeader, "csrf")
+			}
+
+			w := httptest.NewRecorder()
+			r.ServeHTTP(w, req)
+
+			assert.Equal(t, tt.code, w.Code)
+			if tt.code == http.StatusOK {
+				assert.Equal(t, &domain.Principal{UserId: 1, SessionId: 5, Role: domain.RoleCustomer}, principal)
+				return
+			}
+			assert.Nil(t, principal)
+			if tt.body != "" {
+				assert.JSONEq(t, tt.body, w.Body.String())
+			}
+		})
+	}
+}
@@ -3,85 +3,71 @@ package queries
 import (
 	"context"
 	"fmt"
+	"time"
 
 	"bank-api/internal/domain"
-)
-
-const currencyIdBySymbol = `
-SELECT id FROM currency
-WHERE symbol = $1
-`
-
-func (q *Queries) GetCurrencyId(ctx context.Context, cur domain.Currency) (int, error) {
-	var id int
-	err := q.pool.QueryRow(ctx, currencyIdBySymbol, cur.Symbol).Scan(&id)
-	if err != nil {
-		return 0, fmt.Errorf("error getting currency id: %w", err)
-	}
-	return id, nil
-}
-
-const currencySymbolById = `
-SELECT symbol FROM currency
-WHERE id = $1
-`
 
-func (q *Queries) GetCurrencySymbol(ctx context.Context, id int) (string, error) {
-	var symbol string
-	err := q.pool.QueryRow(ctx, currencySymbolById, id).Scan(&symbol)
-	if err != nil {
-		return "", fmt.Errorf("error getting currency symbol: %w", err)
-	}
-	return symbol, nil
-}
-
-const currencyExists = `
-SELECT EXISTS (
-	SELECT 1
-	FROM currency
-	WHERE symbol = $1
+	"github.com/jackc/pgx/v5"
 )
-`
-
-func (q *Queries) CurrencyExists(ctx context.Context, cur domain.Currency) (bool, error) {
-	var exists bool
-	if err := q.pool.QueryRow(ctx, currencyExists, cur.Symbol).Scan(&exists); err != nil {
-		return false, fmt.Errorf("error checking if currency exists: %w", err)
-	}
-	return exists, nil
-}
 
 const createAccount = `
 INSERT INTO account (user_id, currency_id)
-VALUES ($1, (SELECT id FROM currency WHERE symbol = $2))
+VALUES ($1, $2)
 RETURNING id, user_id, amount
 `
 
 func (q *Queries) CreateAccount(ctx context.Context, userId int, cur domain.Currency) (*domain.Account, error) {
+	var err error
 	var account domain.Account
-	if err := q.pool.QueryRow(ctx, createAccount, userId, cur.Symbol).Scan(&account.Id, &account.UserId, &account.Amount); err != nil {
+	if account.Cur.Id, err = q.GetCurrencyId(ctx, cur); err != nil {
+		return nil, fmt.Errorf("error creating account: %w", err)
+	}
+	account.Cur.Symbol = cur.Symbol
+	if err := q.pool.QueryRow(ctx, createAccount, userId, account.Cur.Id).Scan(&account.Id, &account.UserId, &account.Amount); err != nil {
 		return nil, fmt.Errorf("error creating account: %w", err)
 	}
-	account.Cur = cur
+	if err := inCurrency(&account.Amount, account.Cur); err != nil {
+		return nil, err
+	}
+	account.Available = account.Amount
 	return &account, nil
 }
 
-const getAccount = `
-SELECT account.id, account.user_id, currency.symbol, account.amount
+const selectAccounts = `
+SELECT account.id, account.user_id, currency.id, currency.symbol, account.amount, account.amount - ` + heldAmount + `,
+       account.frozen_at, account.frozen_reason
 FROM account
 JOIN currency ON currency.id = account.currency_id
-WHERE account.id = $1
 `
 
 func (q *Queries) GetAccount(ctx context.Context, accountId int) (*domain.Account, error) {
-	var account domain.Account
-	err := q.pool.QueryRow(ctx, getAccount, accountId).Scan(&account.Id, &account.UserId, &account.Cur.Symbol, &account.Amount)
+	account, err := scanAccount(q.pool.QueryRow(ctx, selectAccounts+`WHERE account.id = $1 AND account.kind = 'customer'`, accountId))
 	if err != nil {
 		return nil, fmt.Errorf("error getting account: %w", err)
 	}
-	account.Cur.Id, err = q.GetCurrencyId(ctx, account.Cur)
+	return account, nil
+}
+
+func scanAccount(row pgx.Row) (*domain.Account, error) {
+	var account domain.Account
+	var frozenAt *time.Time
+	var frozenReason *string
+	err := row.Scan(&account.Id, &account.UserId, &account.Cur.Id, &account.Cur.Symbol, &account.Amount, &account.Available,
+		&frozenAt, &frozenReason)
 	if err != nil {
-		return nil, fmt.Errorf("error getting currency id: %w", err)
+		return nil, err
+	}
+	if frozenAt != nil {
+		account.FrozenAt = *frozenAt
+	}
+	if frozenReason != nil {
+		account.FrozenReason = *frozenReason
+	}
+	if err := inCurrency(&account.Amount, account.Cur); err != nil {
+		return nil, err
+	}
+	if err := inCurrency(&account.Available, account.Cur); err != nil {
+		return nil, err
 	}
 	return &account, nil
 }
@@ -90,7 +76,7 @@ const accountExists = `
 SELECT EXISTS (
 	SELECT 1
 	FROM account
-	WHERE id = $1
+	WHERE id = $1 AND kind = 'customer'
 )
 `
 
@@ -102,26 +88,66 @@ func (q *Queries) AccountExists(ctx context.Context, id int) (bool, error) {
 	return exists, nil
 }
 
-const updateAccount = `
-UPDATE account
-SET amount = $2
+const getUpdatedAccount = `
+SELECT id, user_id, currency_id, amount, amount - ` + heldAmount + ` FROM account
 WHERE id = $1
-RETURNING id, user_id, currency_id, amount
 `
 
-func (q *Queries) UpdateAccount(ctx context.Context, accountId int, amount int) (*domain.Account, error) {
+// UpdateAccount sets the balance by posting the difference against the external account.
+func (q *Queries) UpdateAccount(ctx context.Context, accountId int, amount domain.Money) (*domain.Account, error) {
 	var account domain.Account
-	err := q.pool.QueryRow(ctx, updateAccount, accountId, amount).Scan(&account.Id, &account.UserId, &account.Cur.Id, &account.Amount)
+	err := q.inTx(ctx, func(tx pgx.Tx) error {
+		accounts, err := lockAccounts(ctx, tx, accountId)
+		if err != nil {
+			return err
+		}
+		locked := accounts[accountId]
+
+		delta, err := amount.Sub(locked.balance)
+		if err != nil {
+			return fmt.Errorf("error computing balance adjustment: %w", err)
+		}
+
+		if !delta.IsZero() {
+			externalId, err := systemAccountId(ctx, tx, externalAccount, locked.currencyId)
+			if err != nil {
+				return err
+			}
+
+			cur := domain.Currency{Id: locked.currencyId}
+			entry := &domain.JournalEntry{
+				Description: "balance adjustment",
+				Postings: []domain.Posting{
+					{AccountId: accountId, Cur: cur, Amount: delta},
+					{AccountId: externalId, Cur: cur, Amount: delta.Neg()},
+				},
+			}
+			if err := postEntry(ctx, tx, entry); err != nil {
+				return fmt.Errorf("error updating account: %w", err)
+			}
+		}
+
+		return tx.QueryRow(ctx, getUpdatedAccount, accountId).Scan(&account.Id, &account.UserId, &account.Cur.Id, &account.Amount, &account.Available)
+	})
 	if err != nil {
 		return nil, fmt.Errorf("error updating account: %w", err)
 	}
-	account.Cur.Symbol, _ = q.GetCurrencySymbol(ctx, account.Cur.Id)
+
+	if account.Cur, err = q.currency(ctx, account.Cur.Id); err != nil {
+		return nil, err
+	}
+	if err := inCurrency(&account.Amount, account.Cur); err != nil {
+		return nil, err
+	}
+	if err := inCurrency(&account.Available, account.Cur); err != nil {
+		return nil, err
+	}
 	return &account, nil
 }
 
 const deleteAccount = `
 DELETE FROM account
-WHERE id = $1
+WHERE id = $1 AND kind = 'customer'
 `
 
 func (q *Queries) DeleteAccount(ctx context.Context, id int) error {
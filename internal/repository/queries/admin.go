@@ -0,0 +1,232 @@
+package queries
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"strings"
+	"time"
+
+	"bank-api/internal/domain"
+
+	"github.com/jackc/pgx/v5"
+)
+
+// queryer is implemented by both the pool and a transaction.
+type queryer interface {
+	QueryRow(ctx context.Context, sql string, args ...any) pgx.Row
+}
+
+// likeEscaper escapes the wildcards of a LIKE pattern, so a search matches them literally.
+var likeEscaper = strings.NewReplacer(`\`, `\\`, `%`, `\%`, `_`, `\_`)
+
+const searchUsers = `
+SELECT ` + userColumns + `
+FROM "user"
+WHERE ($1 = '' OR name ILIKE '%' || $1 || '%' OR email ILIKE '%' || $1 || '%')
+  AND ($2 = '' OR role = $2)
+  AND id > $3
+ORDER BY id
+LIMIT $4
+`
+
+// SearchUsers returns the users matching filter, ordered by id.
+func (q *Queries) SearchUsers(ctx context.Context, filter *domain.UserFilter) ([]*domain.User, error) {
+	rows, err := q.pool.Query(ctx, searchUsers, likeEscaper.Replace(filter.Query), string(filter.Role), filter.AfterId, filter.Limit)
+	if err != nil {
+		return nil, fmt.Errorf("error searching users: %w", err)
+	}
+	defer rows.Close()
+
+	users := make([]*domain.User, 0)
+	for rows.Next() {
+		user, err := scanUser(rows)
+		if err != nil {
+			return nil, fmt.Errorf("error scanning user: %w", err)
+		}
+		users = append(users, user)
+	}
+	if err := rows.Err(); err != nil {
+		return nil, fmt.Errorf("error searching users: %w", err)
+	}
+	return users, nil
+}
+
+// ListUserAccounts returns the accounts of the user, ordered by id.
+func (q *Queries) ListUserAccounts(ctx context.Context, userId int) ([]*domain.Account, error) {
+	rows, err := q.pool.Query(ctx, selectAccounts+`WHERE account.user_id = $1 AND account.kind = 'customer' ORDER BY account.id`, userId)
+	if err != nil {
+		return nil, fmt.Errorf("error listing accounts: %w", err)
+	}
+	defer rows.Close()
+
+	accounts := make([]*domain.Account, 0)
+	for rows.Next() {
+		account, err := scanAccount(rows)
+		if err != nil {
+			return nil, fmt.Errorf("error scanning account: %w", err)
+		}
+		accounts = append(accounts, account)
+	}
+	if err := rows.Err(); err != nil {
+		return nil, fmt.Errorf("error listing accounts: %w", err)
+	}
+	return accounts, nil
+}
+
+const setUserRole = `
+UPDATE "user" SET role = $2
+WHERE id = $1
+`
+
+// SetUserRole changes the role of the user and records entry in the same transaction.
+func (q *Queries) SetUserRole(ctx context.Context, userId int, role domain.Role, entry *domain.AuditEntry) error {
+	err := q.inTx(ctx, func(tx pgx.Tx) error {
+		if _, err := tx.Exec(ctx, setUserRole, userId, string(role)); err != nil {
+			return err
+		}
+		return recordAudit(ctx, tx, entry)
+	})
+	if err != nil {
+		return fmt.Errorf("error setting user role: %w", err)
+	}
+	return nil
+}
+
+const freezeAccount = `
+UPDATE account SET frozen_at = $2, frozen_reason = $3
+WHERE id = $1 AND kind = 'customer' AND frozen_at IS NULL
+`
+
+// FreezeAccount freezes the account and records entry in the same transaction. It returns false,
+// and records nothing, if the account is already frozen.
+func (q *Queries) FreezeAccount(ctx context.Context, accountId int, reason string, now time.Time, entry *domain.AuditEntry) (bool, error) {
+	var frozen bool
+	err := q.inTx(ctx, func(tx pgx.Tx) error {
+		tag, err := tx.Exec(ctx, freezeAccount, accountId, now.UTC(), reason)
+		if err != nil {
+			return err
+		}
+		if frozen = tag.RowsAffected() > 0; !frozen {
+			return nil
+		}
+		return recordAudit(ctx, tx, entry)
+	})
+	if err != nil {
+		return false, fmt.Errorf("error freezing account: %w", err)
+	}
+	return frozen, nil
+}
+
+const unfreezeAccount = `
+UPDATE account SET frozen_at = NULL, frozen_reason = NULL
+WHERE id = $1 AND kind = 'customer' AND frozen_at IS NOT NULL
+`
+
+// UnfreezeAccount unfreezes the account and records entry in the same transaction. It returns false,
+// and records nothing, if the account isn't frozen.
+func (q *Queries) UnfreezeAccount(ctx context.Context, accountId int, entry *domain.AuditEntry) (bool, error) {
+	var unfrozen bool
+	err := q.inTx(ctx, func(tx pgx.Tx) error {
+		tag, err := tx.Exec(ctx, unfreezeAccount, accountId)
+		if err != nil {
+			return err
+		}
+		if unfrozen = tag.RowsAffected() > 0; !unfrozen {
+			return nil
+		}
+		return recordAudit(ctx, tx, entry)
+	})
+	if err != nil {
+		return false, fmt.Errorf("error unfreezing account: %w", err)
+	}
+	return unfrozen, nil
+}
+
+const createAuditEntry = `
+INSERT INTO admin_audit (actor_user_id, actor_session_id, actor_role, actor_api_key_id, action, target_type, target_id, details)
+VALUES ($1, $2, $3, $4, $5, $6, $7, $8)
+RETURNING id, created_at
+`
+
+func (q *Queries) RecordAudit(ctx context.Context, entry *domain.AuditEntry) error {
+	if err := recordAudit(ctx, q.pool, entry); err != nil {
+		return fmt.Errorf("error recording audit entry: %w", err)
+	}
+	return nil
+}
+
+func recordAudit(ctx context.Context, db queryer, entry *domain.AuditEntry) error {
+	details := entry.Details
+	if details == nil {
+		details = map[string]any{}
+	}
+	data, err := json.Marshal(details)
+	if err != nil {
+		return fmt.Errorf("error encoding audit details: %w", err)
+	}
+
+	var role *string
+	if entry.ActorRole != "" {
+		value := string(entry.ActorRole)
+		role = &value
+	}
+	return db.QueryRow(ctx, createAuditEntry, nullId(entry.ActorUserId), nullId(entry.ActorSessionId), role,
+		nullId(entry.ActorAPIKeyId), entry.Action, entry.TargetType, nullId(entry.TargetId), data).Scan(&entry.Id, &entry.CreatedAt)
+}
+
+const listAuditEntries = `
+SELECT id, actor_user_id, actor_session_id, actor_role, actor_api_key_id, action, target_type, target_id, details, created_at
+FROM admin_audit
+WHERE ($1 = 0 OR actor_user_id = $1)
+  AND ($2 = '' OR target_type = $2)
+  AND ($3 = 0 OR target_id = $3)
+  AND ($4 = 0 OR id < $4)
+ORDER BY id DESC
+LIMIT $5
+`
+
+// ListAuditEntries returns the audit entries matching filter, newest first.
+func (q *Queries) ListAuditEntries(ctx context.Context, filter *domain.AuditFilter) ([]*domain.AuditEntry, error) {
+	rows, err := q.pool.Query(ctx, listAuditEntries, filter.ActorUserId, filter.TargetType, filter.TargetId, filter.AfterId, filter.Limit)
+	if err != nil {
+		return nil, fmt.Errorf("error listing audit entries: %w", err)
+	}
+	defer rows.Close()
+
+	entries := make([]*domain.AuditEntry, 0)
+	for rows.Next() {
+		var entry domain.AuditEntry
+		var actorUserId, actorSessionId, actorAPIKeyId, targetId *int
+		var actorRole *string
+		var details []byte
+		err := rows.Scan(&entry.Id, &actorUserId, &actorSessionId, &actorRole, &actorAPIKeyId, &entry.Action, &entry.TargetType, &targetId,
+			&details, &entry.CreatedAt)
+		if err != nil {
+			return nil, fmt.Errorf("error scanning audit entry: %w", err)
+		}
+		if actorUserId != nil {
+			entry.ActorUserId = *actorUserId
+		}
+		if actorSessionId != nil {
+			entry.ActorSessionId = *actorSessionId
+		}
+		if actorRole != nil {
+			entry.ActorRole = domain.Role(*actorRole)
+		}
+		if actorAPIKeyId != nil {
+			entry.ActorAPIKeyId = *actorAPIKeyId
+		}
+		if targetId != nil {
+			entry.TargetId = *targetId
+		}
+		if err := json.Unmarshal(details, &entry.Details); err != nil {
+			return nil, fmt.Errorf("error decoding audit details: %w", err)
+		}
+		entries = append(entries, &entry)
+	}
+	if err := rows.Err(); err != nil {
+		return nil, fmt.Errorf("error listing audit entries: %w", err)
+	}
+	return entries, nil
+}
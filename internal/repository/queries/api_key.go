@@ -0,0 +1,129 @@
+package queries
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"time"
+
+	"bank-api/internal/domain"
+
+	"github.com/jackc/pgx/v5"
+)
+
+const createAPIKey = `
+INSERT INTO api_key (user_id, name, prefix, secret_hash, scopes)
+VALUES ($1, $2, $3, $4, $5)
+RETURNING id, created_at
+`
+
+func (q *Queries) CreateAPIKey(ctx context.Context, k *domain.APIKey) error {
+	scopes := make([]string, len(k.Scopes))
+	for i, scope := range k.Scopes {
+		scopes[i] = string(scope)
+	}
+	err := q.pool.QueryRow(ctx, createAPIKey, nullId(k.UserId), k.Name, k.Prefix, k.Hash, scopes).Scan(&k.Id, &k.CreatedAt)
+	if err != nil {
+		return fmt.Errorf("error creating api key: %w", err)
+	}
+	return nil
+}
+
+const selectAPIKeys = `
+SELECT id, user_id, name, prefix, secret_hash, scopes, last_used_at, revoked_at, created_at
+FROM api_key
+`
+
+// GetAPIKey returns the API key with the given id, or nil if there is none.
+func (q *Queries) GetAPIKey(ctx context.Context, id int) (*domain.APIKey, error) {
+	k, err := scanAPIKey(q.pool.QueryRow(ctx, selectAPIKeys+`WHERE id = $1`, id))
+	if errors.Is(err, pgx.ErrNoRows) {
+		return nil, nil
+	}
+	if err != nil {
+		return nil, fmt.Errorf("error getting api key: %w", err)
+	}
+	return k, nil
+}
+
+// GetAPIKeyByPrefix returns the API key with the given prefix, or nil if there is none.
+func (q *Queries) GetAPIKeyByPrefix(ctx context.Context, prefix string) (*domain.APIKey, error) {
+	k, err := scanAPIKey(q.pool.QueryRow(ctx, selectAPIKeys+`WHERE prefix = $1`, prefix))
+	if errors.Is(err, pgx.ErrNoRows) {
+		return nil, nil
+	}
+	if err != nil {
+		return nil, fmt.Errorf("error getting api key: %w", err)
+	}
+	return k, nil
+}
+
+// ListAPIKeys returns the API keys of the user, revoked ones included, newest first.
+func (q *Queries) ListAPIKeys(ctx context.Context, userId int) ([]*domain.APIKey, error) {
+	rows, err := q.pool.Query(ctx, selectAPIKeys+`WHERE user_id = $1 ORDER BY id DESC`, userId)
+	if err != nil {
+		return nil, fmt.Errorf("error listing api keys: %w", err)
+	}
+	defer rows.Close()
+
+	keys := make([]*domain.APIKey, 0)
+	for rows.Next() {
+		k, err := scanAPIKey(rows)
+		if err != nil {
+			return nil, fmt.Errorf("error scanning api key: %w", err)
+		}
+		keys = append(keys, k)
+	}
+	if err := rows.Err(); err != nil {
+		return nil, fmt.Errorf("error listing api keys: %w", err)
+	}
+	return keys, nil
+}
+
+func scanAPIKey(row pgx.Row) (*domain.APIKey, error) {
+	var k domain.APIKey
+	var userId *int
+	var scopes []string
+	var lastUsedAt, revokedAt *time.Time
+	if err := row.Scan(&k.Id, &userId, &k.Name, &k.Prefix, &k.Hash, &scopes, &lastUsedAt, &revokedAt, &k.CreatedAt); err != nil {
+		return nil, err
+	}
+	if userId != nil {
+		k.UserId = *userId
+	}
+	k.Scopes = make([]domain.Scope, len(scopes))
+	for i, scope := range scopes {
+		k.Scopes[i] = domain.Scope(scope)
+	}
+	if lastUsedAt != nil {
+		k.LastUsedAt = *lastUsedAt
+	}
+	if revokedAt != nil {
+		k.RevokedAt = *revokedAt
+	}
+	return &k, nil
+}
+
+const revokeAPIKey = `
+UPDATE api_key SET revoked_at = $2
+WHERE id = $1 AND revoked_at IS NULL
+`
+
+func (q *Queries) RevokeAPIKey(ctx context.Context, id int, now time.Time) error {
+	if _, err := q.pool.Exec(ctx, revokeAPIKey, id, now.UTC()); err != nil {
+		return fmt.Errorf("error revoking api key: %w", err)
+	}
+	return nil
+}
+
+const touchAPIKey = `
+UPDATE api_key SET last_used_at = $2
+WHERE id = $1
+`
+
+func (q *Queries) TouchAPIKey(ctx context.Context, id int, now time.Time) error {
+	if _, err := q.pool.Exec(ctx, touchAPIKey, id, now.UTC()); err != nil {
+		return fmt.Errorf("error updating api key last use: %w", err)
+	}
+	return nil
+}
@@ -0,0 +1,190 @@
+package queries
+
+import (
+	"context"
+	"fmt"
+	"sync"
+	"time"
+
+	"bank-api/internal/domain"
+
+	"github.com/jackc/pgx/v5"
+)
+
+// missRefreshInterval is how often at most lookups that miss reload the catalog. Unknown currencies come
+// from user input, so without it every request naming one would query the whole table.
+const missRefreshInterval = time.Minute
+
+// currencyCatalog keeps the currency table in memory, so that resolving a currency doesn't take a round trip.
+// Currencies are only added by migrations, a lookup that misses reloads the table in case one was added since,
+// unless it was loaded less than missRefreshInterval ago.
+type currencyCatalog struct {
+	load func(ctx context.Context) ([]domain.Currency, error)
+
+	// reloading lets one lookup that misses reload the catalog while the others wait for it.
+	reloading sync.Mutex
+
+	mu       sync.RWMutex
+	loadedAt time.Time
+	all      []domain.Currency
+	byId     map[int]domain.Currency
+	bySymbol map[string]domain.Currency
+}
+
+func newCurrencyCatalog(load func(ctx context.Context) ([]domain.Currency, error)) *currencyCatalog {
+	return &currencyCatalog{load: load}
+}
+
+func (c *currencyCatalog) refresh(ctx context.Context) error {
+	currencies, err := c.load(ctx)
+	if err != nil {
+		return err
+	}
+
+	byId := make(map[int]domain.Currency, len(currencies))
+	bySymbol := make(map[string]domain.Currency, len(currencies))
+	for _, cur := range currencies {
+		byId[cur.Id] = cur
+		bySymbol[cur.Symbol] = cur
+	}
+
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	c.loadedAt = time.Now()
+	c.all, c.byId, c.bySymbol = currencies, byId, bySymbol
+	return nil
+}
+
+// lookup finds a currency with find, reloading the catalog once if it isn't there and wasn't loaded recently.
+func (c *currencyCatalog) lookup(ctx context.Context, find func() (domain.Currency, bool)) (domain.Currency, bool, error) {
+	c.mu.RLock()
+	cur, ok := find()
+	c.mu.RUnlock()
+	if ok {
+		return cur, true, nil
+	}
+
+	c.reloading.Lock()
+	defer c.reloading.Unlock()
+
+	// Another lookup may have reloaded the catalog in the meantime.
+	c.mu.RLock()
+	cur, ok = find()
+	recent := !c.loadedAt.IsZero() && time.Since(c.loadedAt) < missRefreshInterval
+	c.mu.RUnlock()
+	if ok || recent {
+		return cur, ok, nil
+	}
+
+	if err := c.refresh(ctx); err != nil {
+		return domain.Currency{}, false, err
+	}
+
+	c.mu.RLock()
+	defer c.mu.RUnlock()
+	cur, ok = find()
+	return cur, ok, nil
+}
+
+func (c *currencyCatalog) byIdOf(ctx context.Context, id int) (domain.Currency, bool, error) {
+	return c.lookup(ctx, func() (domain.Currency, bool) {
+		cur, ok := c.byId[id]
+		return cur, ok
+	})
+}
+
+func (c *currencyCatalog) bySymbolOf(ctx context.Context, symbol string) (domain.Currency, bool, error) {
+	return c.lookup(ctx, func() (domain.Currency, bool) {
+		cur, ok := c.bySymbol[symbol]
+		return cur, ok
+	})
+}
+
+func (c *currencyCatalog) list(ctx context.Context) ([]domain.Currency, error) {
+	c.mu.RLock()
+	loaded, all := !c.loadedAt.IsZero(), c.all
+	c.mu.RUnlock()
+	if loaded {
+		return all, nil
+	}
+
+	if err := c.refresh(ctx); err != nil {
+		return nil, err
+	}
+	c.mu.RLock()
+	defer c.mu.RUnlock()
+	return c.all, nil
+}
+
+const listCurrencies = `
+SELECT id, symbol FROM currency
+ORDER BY id
+`
+
+func (q *Queries) loadCurrencies(ctx context.Context) ([]domain.Currency, error) {
+	rows, err := q.pool.Query(ctx, listCurrencies)
+	if err != nil {
+		return nil, fmt.Errorf("error listing currencies: %w", err)
+	}
+	defer rows.Close()
+
+	var currencies []domain.Currency
+	for rows.Next() {
+		var cur domain.Currency
+		if err := rows.Scan(&cur.Id, &cur.Symbol); err != nil {
+			return nil, fmt.Errorf("error scanning currency: %w", err)
+		}
+		currencies = append(currencies, cur)
+	}
+	if err := rows.Err(); err != nil {
+		return nil, fmt.Errorf("error listing currencies: %w", err)
+	}
+	return currencies, nil
+}
+
+// RefreshCurrencies reloads the currency catalog from the database.
+func (q *Queries) RefreshCurrencies(ctx context.Context) error {
+	if err := q.currencies.refresh(ctx); err != nil {
+		return fmt.Errorf("error refreshing currencies: %w", err)
+	}
+	return nil
+}
+
+func (q *Queries) ListCurrencies(ctx context.Context) ([]domain.Currency, error) {
+	currencies, err := q.currencies.list(ctx)
+	if err != nil {
+		return nil, fmt.Errorf("error listing currencies: %w", err)
+	}
+	return currencies, nil
+}
+
+func (q *Queries) GetCurrencyId(ctx context.Context, cur domain.Currency) (int, error) {
+	found, ok, err := q.currencies.bySymbolOf(ctx, cur.Symbol)
+	if err != nil {
+		return 0, fmt.Errorf("error getting currency id: %w", err)
+	}
+	if !ok {
+		return 0, fmt.Errorf("error getting currency id: %w", pgx.ErrNoRows)
+	}
+	return found.Id, nil
+}
+
+func (q *Queries) CurrencyExists(ctx context.Context, cur domain.Currency) (bool, error) {
+	_, ok, err := q.currencies.bySymbolOf(ctx, cur.Symbol)
+	if err != nil {
+		return false, fmt.Errorf("error checking if currency exists: %w", err)
+	}
+	return ok, nil
+}
+
+// currency resolves a currency id read from the database.
+func (q *Queries) currency(ctx context.Context, id int) (domain.Currency, error) {
+	cur, ok, err := q.currencies.byIdOf(ctx, id)
+	if err != nil {
+		return domain.Currency{}, fmt.Errorf("error getting currency: %w", err)
+	}
+	if !ok {
+		return domain.Currency{}, fmt.Errorf("error getting currency %d: %w", id, pgx.ErrNoRows)
+	}
+	return cur, nil
+}
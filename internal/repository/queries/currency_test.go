@@ -0,0 +1,88 @@
+package queries
+
+import (
+	"context"
+	"fmt"
+	"sync"
+	"testing"
+	"time"
+
+	"bank-api/internal/domain"
+
+	"github.com/jackc/pgx/v5"
+	"github.com/stretchr/testify/assert"
+)
+
+// countingLoader serves the currency table and counts how many times it was queried.
+type countingLoader struct {
+	currencies []domain.Currency
+	queries    int
+}
+
+func (l *countingLoader) load(context.Context) ([]domain.Currency, error) {
+	l.queries++
+	return l.currencies, nil
+}
+
+func TestCurrencyCatalog(t *testing.T) {
+	loader := &countingLoader{currencies: []domain.Currency{{Id: 1, Symbol: "RUB"}, {Id: 3, Symbol: "USD"}}}
+	q := &Queries{currencies: newCurrencyCatalog(loader.load)}
+	ctx := context.Background()
+
+	assert.NoError(t, q.RefreshCurrencies(ctx))
+
+	id, err := q.GetCurrencyId(ctx, domain.Currency{Symbol: "USD"})
+	assert.NoError(t, err)
+	assert.Equal(t, 3, id)
+	cur, err := q.currency(ctx, 1)
+	assert.NoError(t, err)
+	assert.Equal(t, domain.Currency{Id: 1, Symbol: "RUB"}, cur)
+	assert.Equal(t, 1, loader.queries)
+
+	// A currency added after the catalog was loaded is found by reloading it, once it isn't fresh.
+	loader.currencies = append(loader.currencies, domain.Currency{Id: 4, Symbol: "GBP"})
+	ok, err := q.CurrencyExists(ctx, domain.Currency{Symbol: "GBP"})
+	assert.NoError(t, err)
+	assert.False(t, ok)
+	assert.Equal(t, 1, loader.queries)
+
+	q.currencies.loadedAt = time.Now().Add(-missRefreshInterval)
+	ok, err = q.CurrencyExists(ctx, domain.Currency{Symbol: "GBP"})
+	assert.NoError(t, err)
+	assert.True(t, ok)
+	assert.Equal(t, 2, loader.queries)
+
+	ok, err = q.CurrencyExists(ctx, domain.Currency{Symbol: "XXX"})
+	assert.NoError(t, err)
+	assert.False(t, ok)
+	_, err = q.currency(ctx, 42)
+	assert.ErrorIs(t, err, pgx.ErrNoRows)
+}
+
+func TestCurrencyCatalog_Misses(t *testing.T) {
+	loader := &countingLoader{currencies: []domain.Currency{{Id: 1, Symbol: "RUB"}}}
+	q := &Queries{currencies: newCurrencyCatalog(loader.load)}
+	ctx := context.Background()
+
+	// The first lookup loads the catalog, and misses right after don't reload it.
+	for i := 0; i < 100; i++ {
+		ok, err := q.CurrencyExists(ctx, domain.Currency{Symbol: fmt.Sprintf("X%d", i)})
+		assert.NoError(t, err)
+		assert.False(t, ok)
+	}
+	assert.Equal(t, 1, loader.queries)
+
+	// Once the catalog is stale, concurrent misses reload it once between them.
+	q.currencies.loadedAt = time.Now().Add(-missRefreshInterval)
+	var wg sync.WaitGroup
+	for i := 0; i < 20; i++ {
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			_, err := q.currency(ctx, 42)
+			assert.ErrorIs(t, err, pgx.ErrNoRows)
+		}()
+	}
+	wg.Wait()
+	assert.Equal(t, 2, loader.queries)
+}
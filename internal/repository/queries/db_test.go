@@ -0,0 +1,60 @@
+package queries
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"os"
+	"testing"
+	"time"
+
+	"bank-api/internal/domain"
+
+	"github.com/golang-migrate/migrate/v4"
+	_ "github.com/golang-migrate/migrate/v4/database/postgres"
+	_ "github.com/golang-migrate/migrate/v4/source/file"
+	"github.com/jackc/pgx/v5/pgxpool"
+	"github.com/stretchr/testify/require"
+)
+
+// testQueries connects to the database of TEST_DB_URL and migrates it, or skips the test if it isn't set.
+// Tests add users and accounts of their own, so the database can be shared between runs, but it shouldn't
+// be one anybody else uses.
+func testQueries(t *testing.T) *Queries {
+	t.Helper()
+	url := os.Getenv("TEST_DB_URL")
+	if url == "" {
+		t.Skip("TEST_DB_URL is not set")
+	}
+
+	migration, err := migrate.New("file://../../../migrations", url)
+	require.NoError(t, err)
+	if err := migration.Up(); err != nil && !errors.Is(err, migrate.ErrNoChange) {
+		require.NoError(t, err)
+	}
+	migration.Close()
+
+	pool, err := pgxpool.New(context.Background(), url)
+	require.NoError(t, err)
+	t.Cleanup(pool.Close)
+	return New(pool)
+}
+
+// newTestAccount creates a user with an account of the currency and deposits balance, given in minor units, into it.
+func newTestAccount(t *testing.T, q *Queries, symbol string, balance int64) *domain.Account {
+	t.Helper()
+	ctx := context.Background()
+
+	email := fmt.Sprintf("test-%d@example.com", time.Now().UnixNano())
+	user, err := q.CreateUser(ctx, &domain.UserInfo{Name: "Test User", Email: email, Password: "hash"})
+	require.NoError(t, err)
+	account, err := q.CreateAccount(ctx, user.Id, domain.Currency{Symbol: symbol})
+	require.NoError(t, err)
+
+	if balance > 0 {
+		require.NoError(t, q.Transaction(ctx, account.Id, domain.NewMoney(balance, account.Cur), domain.Deposit))
+		account.Amount = domain.NewMoney(balance, account.Cur)
+		account.Available = account.Amount
+	}
+	return account
+}
@@ -0,0 +1,169 @@
+package queries
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"time"
+
+	"bank-api/internal/domain"
+
+	"github.com/jackc/pgx/v5"
+	"github.com/jackc/pgx/v5/pgconn"
+)
+
+const recentEmailVerification = `
+SELECT EXISTS (
+	SELECT 1 FROM email_verification
+	WHERE user_id = $1 AND created_at > $2
+)
+`
+
+// A new verification voids the ones sent before, so only the latest email works.
+const voidEmailVerifications = `
+UPDATE email_verification SET used_at = $2
+WHERE user_id = $1 AND used_at IS NULL
+`
+
+const setPendingEmail = `
+UPDATE "user" SET pending_email = NULLIF($2, email)
+WHERE id = $1
+`
+
+const createEmailVerification = `
+INSERT INTO email_verification (user_id, email, token_hash, expires_at, created_at)
+VALUES ($1, $2, $3, $4, $5)
+RETURNING id
+`
+
+// CreateEmailVerification stores the verification, created at v.CreatedAt, and voids the earlier ones of the user.
+// If v.Email isn't the email of the user, it becomes their pending email. It returns false, and changes nothing,
+// if the user got a verification less than interval before.
+func (q *Queries) CreateEmailVerification(ctx context.Context, v *domain.EmailVerification, interval time.Duration) (bool, error) {
+	var created bool
+	err := q.inTx(ctx, func(tx pgx.Tx) error {
+		var err error
+		created, err = storeEmailVerification(ctx, tx, v, interval)
+		return err
+	})
+	if err != nil {
+		return false, fmt.Errorf("error creating email verification: %w", err)
+	}
+	return created, nil
+}
+
+func storeEmailVerification(ctx context.Context, tx pgx.Tx, v *domain.EmailVerification, interval time.Duration) (bool, error) {
+	var recent bool
+	if err := tx.QueryRow(ctx, recentEmailVerification, v.UserId, v.CreatedAt.Add(-interval).UTC()).Scan(&recent); err != nil {
+		return false, err
+	}
+	if recent {
+		return false, nil
+	}
+	if _, err := tx.Exec(ctx, voidEmailVerifications, v.UserId, v.CreatedAt.UTC()); err != nil {
+		return false, err
+	}
+	if _, err := tx.Exec(ctx, setPendingEmail, v.UserId, v.Email); err != nil {
+		return false, err
+	}
+	if err := tx.QueryRow(ctx, createEmailVerification, v.UserId, v.Email, v.Hash, v.ExpiresAt.UTC(), v.CreatedAt.UTC()).Scan(&v.Id); err != nil {
+		return false, err
+	}
+	return true, nil
+}
+
+const useEmailVerification = `
+UPDATE email_verification SET used_at = $2
+WHERE token_hash = $1 AND used_at IS NULL AND expires_at > $2
+RETURNING user_id, email
+`
+
+// The email verified is either the email of the user or their pending email, which then replaces it.
+const verifyEmail = `
+UPDATE "user" SET
+    email = $2,
+    pending_email = CASE WHEN pending_email = $2 THEN NULL ELSE pending_email END,
+    email_verified_at = $3
+WHERE id = $1 AND (email = $2 OR pending_email = $2)
+`
+
+// VerifyEmail uses the verification with the hash and marks its email verified, and returns the id of its user.
+// It returns 0 if there is no such verification, it is used or expired at now, or its email is neither the email
+// nor the pending email of the user anymore. It returns domain.ErrEmailTaken, and changes nothing, if another
+// user has the email by now.
+func (q *Queries) VerifyEmail(ctx context.Context, hash []byte, now time.Time) (int, error) {
+	var userId int
+	err := q.inTx(ctx, func(tx pgx.Tx) error {
+		var email string
+		err := tx.QueryRow(ctx, useEmailVerification, hash, now.UTC()).Scan(&userId, &email)
+		if errors.Is(err, pgx.ErrNoRows) {
+			userId = 0
+			return nil
+		}
+		if err != nil {
+			return err
+		}
+
+		tag, err := tx.Exec(ctx, verifyEmail, userId, email, now.UTC())
+		var pgErr *pgconn.PgError
+		if errors.As(err, &pgErr) && pgErr.Code == uniqueViolation {
+			return domain.ErrEmailTaken
+		}
+		if err != nil {
+			return err
+		}
+		if tag.RowsAffected() == 0 {
+			userId = 0
+		}
+		return nil
+	})
+	if err != nil {
+		return 0, fmt.Errorf("error verifying email: %w", err)
+	}
+	return userId, nil
+}
+
+const updateUserName = `
+UPDATE "user" SET name = $2
+WHERE id = $1
+`
+
+const clearPendingEmail = `
+UPDATE "user" SET pending_email = NULL
+WHERE id = $1
+`
+
+// ApplyUserUpdate changes the name and the email of the user as the update says, all at once. It returns false,
+// and changes nothing, if the update has a verification and the user got one less than interval before.
+// A cancelled change of email leaves the verification sent for it unusable, as its email is neither the email
+// nor the pending email of the user anymore.
+func (q *Queries) ApplyUserUpdate(ctx context.Context, userId int, update *domain.UserUpdate, interval time.Duration) (bool, error) {
+	var applied bool
+	err := q.inTx(ctx, func(tx pgx.Tx) error {
+		applied = true
+		if update.Verification != nil {
+			created, err := storeEmailVerification(ctx, tx, update.Verification, interval)
+			if err != nil {
+				return err
+			}
+			if applied = created; !applied {
+				return nil
+			}
+		}
+		if update.CancelEmailChange {
+			if _, err := tx.Exec(ctx, clearPendingEmail, userId); err != nil {
+				return err
+			}
+		}
+		if update.Name != "" {
+			if _, err := tx.Exec(ctx, updateUserName, userId, update.Name); err != nil {
+				return err
+			}
+		}
+		return nil
+	})
+	if err != nil {
+		return false, fmt.Errorf("error updating user: %w", err)
+	}
+	return applied, nil
+}
@@ -0,0 +1,64 @@
+package queries
+
+import (
+	"context"
+	"fmt"
+	"testing"
+	"time"
+
+	"bank-api/internal/domain"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+// TestApplyUserUpdate checks that an update whose verification is too recent doesn't change the name either,
+// and that cancelling the change of email leaves its verification unusable.
+func TestApplyUserUpdate(t *testing.T) {
+	q := testQueries(t)
+	ctx := context.Background()
+
+	account := newTestAccount(t, q, "USD", 0)
+	user, err := q.GetUser(ctx, account.UserId)
+	require.NoError(t, err)
+
+	verification := func(email string, token string) *domain.EmailVerification {
+		return &domain.EmailVerification{
+			UserId:    user.Id,
+			Email:     email,
+			Hash:      []byte(fmt.Sprintf("%s-%d", token, time.Now().UnixNano())),
+			ExpiresAt: time.Now().Add(time.Hour),
+			CreatedAt: time.Now(),
+		}
+	}
+
+	newEmail := fmt.Sprintf("new-%d@example.com", time.Now().UnixNano())
+	v := verification(newEmail, "first")
+	applied, err := q.ApplyUserUpdate(ctx, user.Id, &domain.UserUpdate{Name: "New Name", Verification: v}, time.Minute)
+	require.NoError(t, err)
+	assert.True(t, applied)
+
+	got, err := q.GetUser(ctx, user.Id)
+	require.NoError(t, err)
+	assert.Equal(t, "New Name", got.Name)
+	assert.Equal(t, newEmail, got.PendingEmail)
+	assert.Equal(t, user.Email, got.Email)
+
+	applied, err = q.ApplyUserUpdate(ctx, user.Id, &domain.UserUpdate{Name: "Other Name", Verification: verification(newEmail, "second")}, time.Minute)
+	require.NoError(t, err)
+	assert.False(t, applied)
+	got, err = q.GetUser(ctx, user.Id)
+	require.NoError(t, err)
+	assert.Equal(t, "New Name", got.Name)
+
+	applied, err = q.ApplyUserUpdate(ctx, user.Id, &domain.UserUpdate{CancelEmailChange: true}, time.Minute)
+	require.NoError(t, err)
+	assert.True(t, applied)
+	got, err = q.GetUser(ctx, user.Id)
+	require.NoError(t, err)
+	assert.Empty(t, got.PendingEmail)
+
+	userId, err := q.VerifyEmail(ctx, v.Hash, time.Now())
+	require.NoError(t, err)
+	assert.Zero(t, userId)
+}
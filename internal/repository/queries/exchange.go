@@ -0,0 +1,203 @@
+package queries
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"time"
+
+	"bank-api/internal/domain"
+
+	"github.com/jackc/pgx/v5"
+)
+
+var errInvalidRate = errors.New("invalid exchange rate")
+
+// rateSources holds the rate_id, rate_inverted, cross_rate_id and cross_rate_inverted columns,
+// which record the stored rates an applied rate is calculated from.
+type rateSources struct {
+	id            *int
+	inverted      bool
+	crossId       *int
+	crossInverted bool
+}
+
+func newRateSources(rate *domain.ExchangeRate) (rateSources, error) {
+	var columns rateSources
+	sources := rate.RateSources()
+	switch len(sources) {
+	case 2:
+		columns.crossId, columns.crossInverted = &sources[1].Id, sources[1].Inverted
+		fallthrough
+	case 1:
+		columns.id, columns.inverted = &sources[0].Id, sources[0].Inverted
+	case 0:
+	default:
+		return columns, fmt.Errorf("%w: derived from %d rates", errInvalidRate, len(sources))
+	}
+	return columns, nil
+}
+
+// setSources sets the sources of the rate from the columns.
+func (c *rateSources) setSources(rate *domain.ExchangeRate) {
+	var sources []domain.RateSource
+	if c.id != nil {
+		sources = append(sources, domain.RateSource{Id: *c.id, Inverted: c.inverted})
+	}
+	if c.crossId != nil {
+		sources = append(sources, domain.RateSource{Id: *c.crossId, Inverted: c.crossInverted})
+	}
+	rate.SetSources(sources)
+}
+
+const getRate = `
+SELECT exchange_rate.id, base.id, quote.id, exchange_rate.rate, exchange_rate.effective_at
+FROM exchange_rate
+JOIN currency base ON base.id = exchange_rate.base_currency_id
+JOIN currency quote ON quote.id = exchange_rate.quote_currency_id
+WHERE base.symbol = $1 AND quote.symbol = $2 AND exchange_rate.effective_at <= $3
+ORDER BY exchange_rate.effective_at DESC
+LIMIT 1
+`
+
+// GetRate returns the latest from -> to rate effective at the given time, or nil if there is none.
+func (q *Queries) GetRate(ctx context.Context, from domain.Currency, to domain.Currency, at time.Time) (*domain.ExchangeRate, error) {
+	rate := domain.ExchangeRate{From: from, To: to}
+	var value string
+	err := q.pool.QueryRow(ctx, getRate, from.Symbol, to.Symbol, at).Scan(&rate.Id, &rate.From.Id, &rate.To.Id, &value, &rate.EffectiveAt)
+	if errors.Is(err, pgx.ErrNoRows) {
+		return nil, nil
+	}
+	if err != nil {
+		return nil, fmt.Errorf("error getting exchange rate: %w", err)
+	}
+
+	var ok bool
+	if rate.Rate, ok = domain.ParseRate(value); !ok {
+		return nil, fmt.Errorf("error getting exchange rate %d: %w", rate.Id, errInvalidRate)
+	}
+	return &rate, nil
+}
+
+const upsertRate = `
+INSERT INTO exchange_rate (base_currency_id, quote_currency_id, rate, effective_at)
+VALUES ($1, $2, $3, $4)
+ON CONFLICT (base_currency_id, quote_currency_id, effective_at) DO UPDATE
+SET rate = EXCLUDED.rate
+`
+
+// UpsertRates stores the rates in one transaction, replacing the ones of the same pair and effective time.
+// Currencies of the rates must have their ids set.
+func (q *Queries) UpsertRates(ctx context.Context, rates []*domain.ExchangeRate) error {
+	return q.inTx(ctx, func(tx pgx.Tx) error {
+		batch := &pgx.Batch{}
+		for _, rate := range rates {
+			batch.Queue(upsertRate, rate.From.Id, rate.To.Id, rate.String(), rate.EffectiveAt.UTC())
+		}
+
+		results := tx.SendBatch(ctx, batch)
+		for _, rate := range rates {
+			if _, err := results.Exec(); err != nil {
+				results.Close()
+				return fmt.Errorf("error upserting %s/%s rate: %w", rate.From.Symbol, rate.To.Symbol, err)
+			}
+		}
+		return results.Close()
+	})
+}
+
+const createQuote = `
+INSERT INTO fx_quote (user_id, from_account_id, to_account_id, currency_id, amount, to_currency_id, to_amount, rate,
+                      rate_id, rate_inverted, cross_rate_id, cross_rate_inverted, expires_at)
+VALUES ($1, $2, $3, $4, $5, $6, $7, $8, $9, $10, $11, $12, $13)
+RETURNING id
+`
+
+func (q *Queries) CreateQuote(ctx context.Context, quote *domain.Quote) error {
+	sources, err := newRateSources(quote.Rate)
+	if err != nil {
+		return fmt.Errorf("error creating quote: %w", err)
+	}
+
+	err = q.pool.QueryRow(ctx, createQuote, quote.UserId, quote.FromAccountId, quote.ToAccountId, quote.Cur.Id, quote.Amount,
+		quote.ToCur.Id, quote.ToAmount, quote.Rate.String(), sources.id, sources.inverted, sources.crossId, sources.crossInverted,
+		quote.ExpiresAt.UTC()).Scan(&quote.Id)
+	if err != nil {
+		return fmt.Errorf("error creating quote: %w", err)
+	}
+	return nil
+}
+
+const getQuote = `
+SELECT fx_quote.user_id, fx_quote.from_account_id, fx_quote.to_account_id,
+       fx_quote.currency_id, cur.symbol, fx_quote.amount,
+       fx_quote.to_currency_id, to_cur.symbol, fx_quote.to_amount,
+       fx_quote.rate, fx_quote.rate_id, fx_quote.rate_inverted, fx_quote.cross_rate_id, fx_quote.cross_rate_inverted,
+       fx_quote.expires_at, fx_quote.used_at IS NOT NULL
+FROM fx_quote
+JOIN currency cur ON cur.id = fx_quote.currency_id
+JOIN currency to_cur ON to_cur.id = fx_quote.to_currency_id
+WHERE fx_quote.id = $1
+`
+
+// GetQuote returns the quote with the given id, or nil if there is none.
+func (q *Queries) GetQuote(ctx context.Context, id int) (*domain.Quote, error) {
+	quote := domain.Quote{Id: id}
+	var rate string
+	var sources rateSources
+	err := q.pool.QueryRow(ctx, getQuote, id).Scan(&quote.UserId, &quote.FromAccountId, &quote.ToAccountId,
+		&quote.Cur.Id, &quote.Cur.Symbol, &quote.Amount,
+		&quote.ToCur.Id, &quote.ToCur.Symbol, &quote.ToAmount,
+		&rate, &sources.id, &sources.inverted, &sources.crossId, &sources.crossInverted, &quote.ExpiresAt, &quote.Used)
+	if errors.Is(err, pgx.ErrNoRows) {
+		return nil, nil
+	}
+	if err != nil {
+		return nil, fmt.Errorf("error getting quote: %w", err)
+	}
+
+	if err := inCurrency(&quote.Amount, quote.Cur); err != nil {
+		return nil, err
+	}
+	if err := inCurrency(&quote.ToAmount, quote.ToCur); err != nil {
+		return nil, err
+	}
+
+	quote.Rate = &domain.ExchangeRate{From: quote.Cur, To: quote.ToCur}
+	sources.setSources(quote.Rate)
+	var ok bool
+	if quote.Rate.Rate, ok = domain.ParseRate(rate); !ok {
+		return nil, fmt.Errorf("error getting quote %d: %w", id, errInvalidRate)
+	}
+	return &quote, nil
+}
+
+const markQuoteUsed = `
+UPDATE fx_quote SET used_at = $2, transaction_id = $3
+WHERE id = $1 AND used_at IS NULL AND expires_at > $2
+`
+
+const getQuoteState = `
+SELECT used_at IS NOT NULL FROM fx_quote WHERE id = $1
+`
+
+// useQuote marks the quote as used by the transaction, failing if it has expired or has been used already.
+func useQuote(ctx context.Context, tx pgx.Tx, quoteId int, transactionId int) error {
+	now := time.Now().UTC()
+	tag, err := tx.Exec(ctx, markQuoteUsed, quoteId, now, transactionId)
+	if err != nil {
+		return fmt.Errorf("error using quote: %w", err)
+	}
+	if tag.RowsAffected() == 1 {
+		return nil
+	}
+
+	var used bool
+	if err := tx.QueryRow(ctx, getQuoteState, quoteId).Scan(&used); err != nil {
+		return fmt.Errorf("error getting quote state: %w", err)
+	}
+	if used {
+		return domain.ErrQuoteUsed
+	}
+	return domain.ErrQuoteExpired
+}
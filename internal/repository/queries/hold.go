@@ -0,0 +1,166 @@
+package queries
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"time"
+
+	"bank-api/internal/domain"
+
+	"github.com/jackc/pgx/v5"
+)
+
+// heldAmount is the sum of the active holds on the account of the outer query.
+// Holds stop counting once they expire, whether or not the sweeper has marked them expired yet.
+const heldAmount = `COALESCE((
+	SELECT SUM(hold.amount) FROM hold
+	WHERE hold.account_id = account.id AND hold.status = 'active' AND hold.expires_at > CURRENT_TIMESTAMP
+), 0)`
+
+const createHold = `
+INSERT INTO hold (account_id, to_account_id, currency_id, amount, expires_at)
+VALUES ($1, $2, $3, $4, CURRENT_TIMESTAMP + make_interval(secs => $5))
+RETURNING id, status, expires_at, created_at
+`
+
+// CreateHold reserves hold.Amount on the account if its available balance allows it, and sets the hold id, status and times.
+func (q *Queries) CreateHold(ctx context.Context, hold *domain.Hold, ttl time.Duration) error {
+	return q.inTx(ctx, func(tx pgx.Tx) error {
+		accounts, err := lockAccounts(ctx, tx, hold.AccountId)
+		if err != nil {
+			return err
+		}
+		account := accounts[hold.AccountId]
+
+		if account.currencyId != hold.Cur.Id {
+			return fmt.Errorf("error creating hold: %w", errCurrencyMismatch)
+		}
+		if account.frozen {
+			return domain.ErrAccountFrozen
+		}
+		if account.available().Cmp(hold.Amount) < 0 {
+			return domain.ErrInsufficientFunds
+		}
+
+		err = tx.QueryRow(ctx, createHold, hold.AccountId, nullId(hold.ToAccountId), hold.Cur.Id, hold.Amount, ttl.Seconds()).
+			Scan(&hold.Id, &hold.Status, &hold.ExpiresAt, &hold.Time)
+		if err != nil {
+			return fmt.Errorf("error creating hold: %w", err)
+		}
+		return nil
+	})
+}
+
+const getHold = `
+SELECT hold.account_id, hold.to_account_id, hold.currency_id, currency.symbol, hold.amount, hold.captured_amount,
+       hold.status, hold.expires_at, hold.created_at
+FROM hold
+JOIN currency ON currency.id = hold.currency_id
+WHERE hold.id = $1
+`
+
+// GetHold returns the hold with the given id, or nil if there is none.
+func (q *Queries) GetHold(ctx context.Context, id int) (*domain.Hold, error) {
+	hold := domain.Hold{Id: id}
+	var toAccountId *int
+	err := q.pool.QueryRow(ctx, getHold, id).Scan(&hold.AccountId, &toAccountId, &hold.Cur.Id, &hold.Cur.Symbol,
+		&hold.Amount, &hold.CapturedAmount, &hold.Status, &hold.ExpiresAt, &hold.Time)
+	if errors.Is(err, pgx.ErrNoRows) {
+		return nil, nil
+	}
+	if err != nil {
+		return nil, fmt.Errorf("error getting hold: %w", err)
+	}
+
+	if toAccountId != nil {
+		hold.ToAccountId = *toAccountId
+	}
+	if err := inCurrency(&hold.Amount, hold.Cur); err != nil {
+		return nil, err
+	}
+	if err := inCurrency(&hold.CapturedAmount, hold.Cur); err != nil {
+		return nil, err
+	}
+	return &hold, nil
+}
+
+const lockHold = `
+SELECT amount, status = 'active' AND expires_at > CURRENT_TIMESTAMP FROM hold
+WHERE id = $1
+FOR UPDATE
+`
+
+const captureHold = `
+UPDATE hold SET status = 'captured', captured_amount = $2, updated_at = CURRENT_TIMESTAMP
+WHERE id = $1
+`
+
+// CaptureHold moves capture.Amount of the hold to its destination and releases the rest of it.
+// The hold stops reserving money before the available balance is checked, so that it can be spent by its own capture.
+func (q *Queries) CaptureHold(ctx context.Context, capture *domain.Transaction) error {
+	transactionId, err := q.addTransfer(ctx, capture)
+	if err != nil {
+		return err
+	}
+
+	err = q.inTx(ctx, func(tx pgx.Tx) error {
+		var held domain.Money
+		var active bool
+		if err := tx.QueryRow(ctx, lockHold, capture.HoldId).Scan(&held, &active); err != nil {
+			return fmt.Errorf("error locking hold: %w", err)
+		}
+		if !active {
+			return domain.ErrHoldNotActive
+		}
+		if capture.Amount.Cmp(held) > 0 {
+			return domain.ErrCaptureExceedsHold
+		}
+
+		if _, err := tx.Exec(ctx, captureHold, capture.HoldId, capture.Amount); err != nil {
+			return fmt.Errorf("error capturing hold: %w", err)
+		}
+
+		if err := postTransfer(ctx, tx, transactionId, capture); err != nil {
+			return err
+		}
+
+		return setTransactionStatus(ctx, tx, transactionId, domain.StatusPending, domain.StatusPosted, "")
+	})
+	if err != nil {
+		return q.failTransaction(ctx, transactionId, err)
+	}
+
+	capture.Id, capture.Status = transactionId, domain.StatusPosted
+	return nil
+}
+
+const releaseHold = `
+UPDATE hold SET status = 'released', updated_at = CURRENT_TIMESTAMP
+WHERE id = $1 AND status = 'active' AND expires_at > CURRENT_TIMESTAMP
+`
+
+func (q *Queries) ReleaseHold(ctx context.Context, id int) error {
+	tag, err := q.pool.Exec(ctx, releaseHold, id)
+	if err != nil {
+		return fmt.Errorf("error releasing hold: %w", err)
+	}
+	if tag.RowsAffected() == 0 {
+		return domain.ErrHoldNotActive
+	}
+	return nil
+}
+
+const expireHolds = `
+UPDATE hold SET status = 'expired', updated_at = CURRENT_TIMESTAMP
+WHERE status = 'active' AND expires_at <= CURRENT_TIMESTAMP
+`
+
+// ExpireHolds marks the holds that are past their expiry as expired and returns how many there were.
+func (q *Queries) ExpireHolds(ctx context.Context) (int, error) {
+	tag, err := q.pool.Exec(ctx, expireHolds)
+	if err != nil {
+		return 0, fmt.Errorf("error expiring holds: %w", err)
+	}
+	return int(tag.RowsAffected()), nil
+}
@@ -0,0 +1,91 @@
+package queries
+
+import (
+	"context"
+	"errors"
+	"fmt"
+
+	"bank-api/internal/domain"
+
+	"github.com/jackc/pgx/v5"
+)
+
+const createIdempotencyKey = `
+INSERT INTO idempotency_key (user_id, key, request_hash, expires_at)
+VALUES ($1, $2, $3, $4)
+ON CONFLICT (user_id, key) DO UPDATE
+SET request_hash = EXCLUDED.request_hash,
+    status_code = NULL,
+    content_type = NULL,
+    body = NULL,
+    created_at = CURRENT_TIMESTAMP,
+    expires_at = EXCLUDED.expires_at
+WHERE idempotency_key.expires_at <= CURRENT_TIMESTAMP
+RETURNING user_id
+`
+
+// CreateIdempotencyKey claims the key for a new request. It reports false if the key is already taken and not expired yet.
+func (q *Queries) CreateIdempotencyKey(ctx context.Context, record *domain.IdempotencyRecord) (bool, error) {
+	var userId int
+	err := q.pool.QueryRow(ctx, createIdempotencyKey, record.UserId, record.Key, record.RequestHash, record.ExpiresAt.UTC()).Scan(&userId)
+	if errors.Is(err, pgx.ErrNoRows) {
+		return false, nil
+	}
+	if err != nil {
+		return false, fmt.Errorf("error creating idempotency key: %w", err)
+	}
+	return true, nil
+}
+
+const getIdempotencyKey = `
+SELECT request_hash, status_code, content_type, body, expires_at
+FROM idempotency_key
+WHERE user_id = $1 AND key = $2
+`
+
+func (q *Queries) GetIdempotencyKey(ctx context.Context, userId int, key string) (*domain.IdempotencyRecord, error) {
+	record := domain.IdempotencyRecord{UserId: userId, Key: key}
+	var statusCode *int
+	var contentType *string
+	var body []byte
+	err := q.pool.QueryRow(ctx, getIdempotencyKey, userId, key).Scan(&record.RequestHash, &statusCode, &contentType, &body, &record.ExpiresAt)
+	if errors.Is(err, pgx.ErrNoRows) {
+		return nil, nil
+	}
+	if err != nil {
+		return nil, fmt.Errorf("error getting idempotency key: %w", err)
+	}
+
+	if statusCode != nil {
+		record.Response = &domain.IdempotentResponse{StatusCode: *statusCode, Body: body}
+		if contentType != nil {
+			record.Response.ContentType = *contentType
+		}
+	}
+	return &record, nil
+}
+
+const saveIdempotentResponse = `
+UPDATE idempotency_key
+SET status_code = $3, content_type = $4, body = $5
+WHERE user_id = $1 AND key = $2
+`
+
+func (q *Queries) SaveIdempotentResponse(ctx context.Context, userId int, key string, resp *domain.IdempotentResponse) error {
+	if _, err := q.pool.Exec(ctx, saveIdempotentResponse, userId, key, resp.StatusCode, resp.ContentType, resp.Body); err != nil {
+		return fmt.Errorf("error saving idempotent response: %w", err)
+	}
+	return nil
+}
+
+const deleteIdempotencyKey = `
+DELETE FROM idempotency_key
+WHERE user_id = $1 AND key = $2
+`
+
+func (q *Queries) DeleteIdempotencyKey(ctx context.Context, userId int, key string) error {
+	if _, err := q.pool.Exec(ctx, deleteIdempotencyKey, userId, key); err != nil {
+		return fmt.Errorf("error deleting idempotency key: %w", err)
+	}
+	return nil
+}
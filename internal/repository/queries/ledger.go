@@ -0,0 +1,205 @@
+package queries
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"time"
+
+	"bank-api/internal/domain"
+
+	"github.com/jackc/pgx/v5"
+)
+
+const externalAccount = "external"
+
+var (
+	errUnbalancedEntry  = errors.New("journal entry is not balanced")
+	errCurrencyMismatch = errors.New("currency doesn't match the account")
+)
+
+const getSystemAccountId = `
+SELECT id FROM account
+WHERE kind = $1 AND currency_id = $2
+`
+
+func systemAccountId(ctx context.Context, tx pgx.Tx, kind string, currencyId int) (int, error) {
+	var id int
+	if err := tx.QueryRow(ctx, getSystemAccountId, kind, currencyId).Scan(&id); err != nil {
+		return 0, fmt.Errorf("error getting %s account: %w", kind, err)
+	}
+	return id, nil
+}
+
+const addJournalEntry = `
+INSERT INTO journal_entry (transaction_id, description)
+VALUES ($1, $2)
+RETURNING id, created_at
+`
+
+const addPosting = `
+INSERT INTO posting (entry_id, account_id, currency_id, amount)
+VALUES ($1, $2, $3, $4)
+`
+
+const applyPosting = `
+UPDATE account
+SET amount = amount + $2
+WHERE id = $1 AND kind = 'customer'
+`
+
+// postEntry records a balanced journal entry and applies its postings to the cached customer balances.
+// System account balances are never cached and are always derived from their postings.
+func postEntry(ctx context.Context, tx pgx.Tx, entry *domain.JournalEntry) error {
+	if !entry.Balanced() {
+		return errUnbalancedEntry
+	}
+
+	var transactionId *int
+	if entry.TransactionId != 0 {
+		transactionId = &entry.TransactionId
+	}
+	if err := tx.QueryRow(ctx, addJournalEntry, transactionId, entry.Description).Scan(&entry.Id, &entry.Time); err != nil {
+		return fmt.Errorf("error adding journal entry: %w", err)
+	}
+
+	for _, p := range entry.Postings {
+		if _, err := tx.Exec(ctx, addPosting, entry.Id, p.AccountId, p.Cur.Id, p.Amount); err != nil {
+			return fmt.Errorf("error adding posting: %w", err)
+		}
+		if _, err := tx.Exec(ctx, applyPosting, p.AccountId, p.Amount); err != nil {
+			return fmt.Errorf("error applying posting: %w", err)
+		}
+	}
+
+	return nil
+}
+
+const unbalancedCurrencies = `
+SELECT currency.id, currency.symbol, SUM(posting.amount)
+FROM posting
+JOIN currency ON currency.id = posting.currency_id
+GROUP BY currency.id, currency.symbol
+HAVING SUM(posting.amount) <> 0
+`
+
+const driftedAccounts = `
+SELECT account.id, account.amount, COALESCE(SUM(posting.amount), 0)
+FROM account
+LEFT JOIN posting ON posting.account_id = account.id
+WHERE account.kind = 'customer'
+GROUP BY account.id
+HAVING account.amount <> COALESCE(SUM(posting.amount), 0)
+`
+
+func (q *Queries) CheckLedger(ctx context.Context) (*domain.LedgerReport, error) {
+	var report domain.LedgerReport
+
+	rows, err := q.pool.Query(ctx, unbalancedCurrencies)
+	if err != nil {
+		return nil, fmt.Errorf("error checking ledger balance: %w", err)
+	}
+	for rows.Next() {
+		var imbalance domain.CurrencyImbalance
+		if err := rows.Scan(&imbalance.Cur.Id, &imbalance.Cur.Symbol, &imbalance.Sum); err != nil {
+			rows.Close()
+			return nil, fmt.Errorf("error getting currency imbalance: %w", err)
+		}
+		report.Imbalances = append(report.Imbalances, imbalance)
+	}
+	rows.Close()
+	if err := rows.Err(); err != nil {
+		return nil, fmt.Errorf("error checking ledger balance: %w", err)
+	}
+
+	rows, err = q.pool.Query(ctx, driftedAccounts)
+	if err != nil {
+		return nil, fmt.Errorf("error checking account balances: %w", err)
+	}
+	defer rows.Close()
+	for rows.Next() {
+		var drift domain.AccountDrift
+		if err := rows.Scan(&drift.AccountId, &drift.Balance, &drift.Posted); err != nil {
+			return nil, fmt.Errorf("error getting account drift: %w", err)
+		}
+		report.Drifts = append(report.Drifts, drift)
+	}
+	if err := rows.Err(); err != nil {
+		return nil, fmt.Errorf("error checking account balances: %w", err)
+	}
+
+	return &report, nil
+}
+
+const balanceAt = `
+SELECT account.currency_id, COALESCE((
+    SELECT SUM(posting.amount)
+    FROM posting
+    JOIN journal_entry ON journal_entry.id = posting.entry_id
+    LEFT JOIN transaction ON transaction.id = journal_entry.transaction_id
+    WHERE posting.account_id = account.id AND COALESCE(transaction.created_at, journal_entry.created_at) < $2
+), 0)
+FROM account
+WHERE account.id = $1
+`
+
+// GetBalanceAt returns the balance of the account right before at. Postings of a transaction
+// count from the time of the transaction, like it does in the history.
+func (q *Queries) GetBalanceAt(ctx context.Context, accountId int, at time.Time) (domain.Money, error) {
+	var cur domain.Currency
+	var balance domain.Money
+	if err := q.pool.QueryRow(ctx, balanceAt, accountId, at.UTC()).Scan(&cur.Id, &balance); err != nil {
+		return domain.Money{}, fmt.Errorf("error getting balance: %w", err)
+	}
+
+	cur, err := q.currency(ctx, cur.Id)
+	if err != nil {
+		return domain.Money{}, err
+	}
+	if err := inCurrency(&balance, cur); err != nil {
+		return domain.Money{}, err
+	}
+	return balance, nil
+}
+
+const listAdjustments = `
+SELECT journal_entry.id, journal_entry.description, SUM(posting.amount), journal_entry.created_at, posting.currency_id
+FROM posting
+JOIN journal_entry ON journal_entry.id = posting.entry_id
+WHERE posting.account_id = $1
+  AND journal_entry.transaction_id IS NULL
+  AND journal_entry.created_at >= $2 AND journal_entry.created_at < $3
+GROUP BY journal_entry.id, posting.currency_id
+HAVING SUM(posting.amount) <> 0
+ORDER BY journal_entry.created_at, journal_entry.id
+`
+
+// ListAdjustments returns the journal entries of the account between from (inclusive) and to (exclusive)
+// that aren't part of a transaction, oldest first. GetBalanceAt counts them at the time they were made.
+func (q *Queries) ListAdjustments(ctx context.Context, accountId int, from, to time.Time) ([]*domain.Adjustment, error) {
+	rows, err := q.pool.Query(ctx, listAdjustments, accountId, from.UTC(), to.UTC())
+	if err != nil {
+		return nil, fmt.Errorf("error listing adjustments: %w", err)
+	}
+	defer rows.Close()
+
+	var adjustments []*domain.Adjustment
+	for rows.Next() {
+		var adjustment domain.Adjustment
+		var cur domain.Currency
+		if err := rows.Scan(&adjustment.EntryId, &adjustment.Description, &adjustment.Amount, &adjustment.Time, &cur.Id); err != nil {
+			return nil, fmt.Errorf("error getting adjustment: %w", err)
+		}
+		if cur, err = q.currency(ctx, cur.Id); err != nil {
+			return nil, err
+		}
+		if err := inCurrency(&adjustment.Amount, cur); err != nil {
+			return nil, err
+		}
+		adjustments = append(adjustments, &adjustment)
+	}
+	if err := rows.Err(); err != nil {
+		return nil, fmt.Errorf("error listing adjustments: %w", err)
+	}
+	return adjustments, nil
+}
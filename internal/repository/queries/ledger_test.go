@@ -0,0 +1,61 @@
+package queries
+
+import (
+	"context"
+	"testing"
+	"time"
+
+	"bank-api/internal/domain"
+
+	"github.com/jackc/pgx/v5"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+// TestListAdjustments checks that the balances of a period add up to the closing one with both the
+// transactions and the journal entries without a transaction, like the opening balances of the ledger.
+func TestListAdjustments(t *testing.T) {
+	q := testQueries(t)
+	ctx := context.Background()
+	from := time.Now().Add(-time.Hour)
+	to := time.Now().Add(time.Hour)
+	account := newTestAccount(t, q, "USD", 10000)
+
+	amount := domain.NewMoney(2500, account.Cur)
+	err := q.inTx(ctx, func(tx pgx.Tx) error {
+		externalId, err := systemAccountId(ctx, tx, externalAccount, account.Cur.Id)
+		if err != nil {
+			return err
+		}
+		return postEntry(ctx, tx, &domain.JournalEntry{
+			Description: "opening balance",
+			Postings: []domain.Posting{
+				{AccountId: account.Id, Cur: account.Cur, Amount: amount},
+				{AccountId: externalId, Cur: account.Cur, Amount: amount.Neg()},
+			},
+		})
+	})
+	require.NoError(t, err)
+
+	adjustments, err := q.ListAdjustments(ctx, account.Id, from, to)
+	require.NoError(t, err)
+	require.Len(t, adjustments, 1)
+	assert.Equal(t, "opening balance", adjustments[0].Description)
+	assert.Equal(t, 0, amount.Cmp(adjustments[0].Amount))
+
+	opening, err := q.GetBalanceAt(ctx, account.Id, from)
+	require.NoError(t, err)
+	closing, err := q.GetBalanceAt(ctx, account.Id, to)
+	require.NoError(t, err)
+	transactions, err := q.ListTransactions(ctx, &domain.TransactionFilter{UserId: account.UserId, AccountId: account.Id, From: from, To: to, Limit: 10})
+	require.NoError(t, err)
+	for i, j := 0, len(transactions)-1; i < j; i, j = i+1, j-1 {
+		transactions[i], transactions[j] = transactions[j], transactions[i]
+	}
+
+	statement, err := domain.NewStatement(account, from, to, opening, closing, transactions, adjustments)
+	require.NoError(t, err)
+	require.Len(t, statement.Entries, 2)
+	last := statement.Entries[len(statement.Entries)-1]
+	assert.Equal(t, 0, closing.Cmp(last.Balance), "closing %s, last balance %s", closing, last.Balance)
+}
@@ -0,0 +1,67 @@
+package queries
+
+import (
+	"context"
+	"fmt"
+	"time"
+)
+
+const getLoginBlock = `
+SELECT MAX(blocked_until) FROM login_failure
+WHERE key = ANY($1) AND blocked_until > $2
+`
+
+// GetLoginBlock returns until when logins with any of the keys are blocked, or the zero time if none is.
+func (q *Queries) GetLoginBlock(ctx context.Context, keys []string, now time.Time) (time.Time, error) {
+	var until *time.Time
+	if err := q.pool.QueryRow(ctx, getLoginBlock, keys, now.UTC()).Scan(&until); err != nil {
+		return time.Time{}, fmt.Errorf("error getting login block: %w", err)
+	}
+	if until == nil {
+		return time.Time{}, nil
+	}
+	return *until, nil
+}
+
+// Failures older than the window are forgotten, so counting starts over.
+const recordLoginFailure = `
+INSERT INTO login_failure (key, failures, last_failure_at)
+VALUES ($1, 1, $2)
+ON CONFLICT (key) DO UPDATE SET
+    failures = CASE WHEN login_failure.last_failure_at > $2 - make_interval(secs => $3) THEN login_failure.failures + 1 ELSE 1 END,
+    last_failure_at = $2
+RETURNING failures
+`
+
+// RecordLoginFailure counts a failed login with the key and returns how many failures in a row there are now.
+func (q *Queries) RecordLoginFailure(ctx context.Context, key string, now time.Time, window time.Duration) (int, error) {
+	var failures int
+	if err := q.pool.QueryRow(ctx, recordLoginFailure, key, now.UTC(), window.Seconds()).Scan(&failures); err != nil {
+		return 0, fmt.Errorf("error recording login failure: %w", err)
+	}
+	return failures, nil
+}
+
+const blockLogin = `
+UPDATE login_failure SET blocked_until = GREATEST(blocked_until, $2)
+WHERE key = $1
+`
+
+func (q *Queries) BlockLogin(ctx context.Context, key string, until time.Time) error {
+	if _, err := q.pool.Exec(ctx, blockLogin, key, until.UTC()); err != nil {
+		return fmt.Errorf("error blocking login: %w", err)
+	}
+	return nil
+}
+
+const clearLoginFailures = `
+DELETE FROM login_failure
+WHERE key = $1
+`
+
+func (q *Queries) ClearLoginFailures(ctx context.Context, key string) error {
+	if _, err := q.pool.Exec(ctx, clearLoginFailures, key); err != nil {
+		return fmt.Errorf("error clearing login failures: %w", err)
+	}
+	return nil
+}
@@ -0,0 +1,156 @@
+package queries
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"time"
+
+	"bank-api/internal/domain"
+
+	"github.com/jackc/pgx/v5"
+)
+
+const recentPasswordResetToken = `
+SELECT EXISTS (
+	SELECT 1 FROM password_reset_token
+	WHERE user_id = $1 AND created_at > $2
+)
+`
+
+// A new token voids the ones sent before, so only the latest email works.
+const voidPasswordResetTokens = `
+UPDATE password_reset_token SET used_at = $2
+WHERE user_id = $1 AND used_at IS NULL
+`
+
+const createPasswordResetToken = `
+INSERT INTO password_reset_token (user_id, token_hash, expires_at, created_at)
+VALUES ($1, $2, $3, $4)
+RETURNING id
+`
+
+// CreatePasswordResetToken stores the token, created at token.CreatedAt, and voids the earlier ones of the user.
+// It returns false, and stores nothing, if the user got a token less than interval before.
+func (q *Queries) CreatePasswordResetToken(ctx context.Context, token *domain.PasswordResetToken, interval time.Duration) (bool, error) {
+	var created bool
+	err := q.inTx(ctx, func(tx pgx.Tx) error {
+		var recent bool
+		if err := tx.QueryRow(ctx, recentPasswordResetToken, token.UserId, token.CreatedAt.Add(-interval).UTC()).Scan(&recent); err != nil {
+			return err
+		}
+		if created = !recent; !created {
+			return nil
+		}
+		if _, err := tx.Exec(ctx, voidPasswordResetTokens, token.UserId, token.CreatedAt.UTC()); err != nil {
+			return err
+		}
+		return tx.QueryRow(ctx, createPasswordResetToken, token.UserId, token.Hash, token.ExpiresAt.UTC(), token.CreatedAt.UTC()).Scan(&token.Id)
+	})
+	if err != nil {
+		return false, fmt.Errorf("error creating password reset token: %w", err)
+	}
+	return created, nil
+}
+
+const usePasswordResetToken = `
+UPDATE password_reset_token SET used_at = $2
+WHERE token_hash = $1 AND used_at IS NULL AND expires_at > $2
+RETURNING user_id
+`
+
+const updatePassword = `
+UPDATE "user" SET password = $2
+WHERE id = $1
+`
+
+// ResetPassword uses the reset token with the hash and sets the password of its user, whose id it returns.
+// It returns 0, and changes nothing, if there is no such token or it is used or expired at now.
+func (q *Queries) ResetPassword(ctx context.Context, hash []byte, hashedPassword string, now time.Time) (int, error) {
+	var userId int
+	err := q.inTx(ctx, func(tx pgx.Tx) error {
+		err := tx.QueryRow(ctx, usePasswordResetToken, hash, now.UTC()).Scan(&userId)
+		if errors.Is(err, pgx.ErrNoRows) {
+			userId = 0
+			return nil
+		}
+		if err != nil {
+			return err
+		}
+		_, err = tx.Exec(ctx, updatePassword, userId, hashedPassword)
+		return err
+	})
+	if err != nil {
+		return 0, fmt.Errorf("error resetting password: %w", err)
+	}
+	return userId, nil
+}
+
+// UpdatePassword sets the password of the user and voids the reset tokens they were sent.
+func (q *Queries) UpdatePassword(ctx context.Context, userId int, hashedPassword string, now time.Time) error {
+	err := q.inTx(ctx, func(tx pgx.Tx) error {
+		if _, err := tx.Exec(ctx, updatePassword, userId, hashedPassword); err != nil {
+			return err
+		}
+		_, err := tx.Exec(ctx, voidPasswordResetTokens, userId, now.UTC())
+		return err
+	})
+	if err != nil {
+		return fmt.Errorf("error updating password: %w", err)
+	}
+	return nil
+}
+
+// A request for an email that is already waiting is the same request, so a flood of them takes no room.
+const requestPasswordReset = `
+INSERT INTO password_reset_request (email, requested_at)
+VALUES ($1, $2)
+ON CONFLICT (email) DO NOTHING
+`
+
+// RequestPasswordReset stores a request to email a reset token to the email, requested at now.
+func (q *Queries) RequestPasswordReset(ctx context.Context, email string, now time.Time) error {
+	if _, err := q.pool.Exec(ctx, requestPasswordReset, email, now.UTC()); err != nil {
+		return fmt.Errorf("error requesting password reset: %w", err)
+	}
+	return nil
+}
+
+const listPasswordResetRequests = `
+SELECT email FROM password_reset_request
+ORDER BY requested_at, email
+LIMIT $1
+`
+
+func (q *Queries) ListPasswordResetRequests(ctx context.Context, limit int) ([]string, error) {
+	rows, err := q.pool.Query(ctx, listPasswordResetRequests, limit)
+	if err != nil {
+		return nil, fmt.Errorf("error listing password reset requests: %w", err)
+	}
+	defer rows.Close()
+
+	var emails []string
+	for rows.Next() {
+		var email string
+		if err := rows.Scan(&email); err != nil {
+			return nil, fmt.Errorf("error scanning password reset request: %w", err)
+		}
+		emails = append(emails, email)
+	}
+	if err := rows.Err(); err != nil {
+		return nil, fmt.Errorf("error listing password reset requests: %w", err)
+	}
+	return emails, nil
+}
+
+const deletePasswordResetRequest = `
+DELETE FROM password_reset_request
+WHERE email = $1
+`
+
+func (q *Queries) DeletePasswordResetRequest(ctx context.Context, email string) error {
+	if _, err := q.pool.Exec(ctx, deletePasswordResetRequest, email); err != nil {
+		return fmt.Errorf("error deleting password reset request: %w", err)
+	}
+	return nil
+}
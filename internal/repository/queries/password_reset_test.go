@@ -0,0 +1,37 @@
+package queries
+
+import (
+	"context"
+	"fmt"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+// TestPasswordResetRequests checks that a request for an email that is already waiting isn't stored again.
+func TestPasswordResetRequests(t *testing.T) {
+	q := testQueries(t)
+	ctx := context.Background()
+
+	email := fmt.Sprintf("reset-%d@example.com", time.Now().UnixNano())
+	require.NoError(t, q.RequestPasswordReset(ctx, email, time.Now()))
+	require.NoError(t, q.RequestPasswordReset(ctx, email, time.Now()))
+
+	count := func() int {
+		emails, err := q.ListPasswordResetRequests(ctx, 1000000)
+		require.NoError(t, err)
+		var n int
+		for _, e := range emails {
+			if e == email {
+				n++
+			}
+		}
+		return n
+	}
+	assert.Equal(t, 1, count())
+
+	require.NoError(t, q.DeletePasswordResetRequest(ctx, email))
+	assert.Equal(t, 0, count())
+}
@@ -1,11 +1,30 @@
 package queries
 
-import "github.com/jackc/pgx/v5/pgxpool"
+import (
+	"fmt"
+
+	"bank-api/internal/domain"
+
+	"github.com/jackc/pgx/v5/pgxpool"
+)
 
 type Queries struct {
-	pool *pgxpool.Pool
+	pool       *pgxpool.Pool
+	currencies *currencyCatalog
 }
 
 func New(pgxPool *pgxpool.Pool) *Queries {
-	return &Queries{pool: pgxPool}
+	q := &Queries{pool: pgxPool}
+	q.currencies = newCurrencyCatalog(q.loadCurrencies)
+	return q
+}
+
+// inCurrency converts an amount read from a NUMERIC column to the minor units of its currency.
+func inCurrency(m *domain.Money, cur domain.Currency) error {
+	converted, err := m.In(cur)
+	if err != nil {
+		return fmt.Errorf("error converting amount to %s: %w", cur.Symbol, err)
+	}
+	*m = converted
+	return nil
 }
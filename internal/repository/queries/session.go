@@ -0,0 +1,132 @@
+package queries
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"time"
+
+	"bank-api/internal/domain"
+
+	"github.com/jackc/pgx/v5"
+)
+
+const createSession = `
+INSERT INTO session (user_id)
+VALUES ($1)
+RETURNING id, created_at
+`
+
+func (q *Queries) CreateSession(ctx context.Context, session *domain.Session) error {
+	if err := q.pool.QueryRow(ctx, createSession, session.UserId).Scan(&session.Id, &session.CreatedAt); err != nil {
+		return fmt.Errorf("error creating session: %w", err)
+	}
+	return nil
+}
+
+const getSession = `
+SELECT id, user_id, revoked_at, created_at FROM session
+WHERE id = $1
+`
+
+// GetSession returns the session with the given id, or nil if there is none.
+func (q *Queries) GetSession(ctx context.Context, id int) (*domain.Session, error) {
+	var session domain.Session
+	var revokedAt *time.Time
+	err := q.pool.QueryRow(ctx, getSession, id).Scan(&session.Id, &session.UserId, &revokedAt, &session.CreatedAt)
+	if errors.Is(err, pgx.ErrNoRows) {
+		return nil, nil
+	}
+	if err != nil {
+		return nil, fmt.Errorf("error getting session: %w", err)
+	}
+	if revokedAt != nil {
+		session.RevokedAt = *revokedAt
+	}
+	return &session, nil
+}
+
+const revokeSession = `
+UPDATE session SET revoked_at = CURRENT_TIMESTAMP
+WHERE id = $1 AND revoked_at IS NULL
+`
+
+func (q *Queries) RevokeSession(ctx context.Context, id int) error {
+	if _, err := q.pool.Exec(ctx, revokeSession, id); err != nil {
+		return fmt.Errorf("error revoking session: %w", err)
+	}
+	return nil
+}
+
+const revokeUserSessions = `
+UPDATE session SET revoked_at = CURRENT_TIMESTAMP
+WHERE user_id = $1 AND id <> $2 AND revoked_at IS NULL
+RETURNING id
+`
+
+// RevokeUserSessions revokes all the sessions of the user but the one with exceptId, if not 0, and returns the ids
+// of the ones it revoked.
+func (q *Queries) RevokeUserSessions(ctx context.Context, userId int, exceptId int) ([]int, error) {
+	rows, err := q.pool.Query(ctx, revokeUserSessions, userId, exceptId)
+	if err != nil {
+		return nil, fmt.Errorf("error revoking sessions: %w", err)
+	}
+	defer rows.Close()
+
+	var ids []int
+	for rows.Next() {
+		var id int
+		if err := rows.Scan(&id); err != nil {
+			return nil, fmt.Errorf("error scanning session id: %w", err)
+		}
+		ids = append(ids, id)
+	}
+	if err := rows.Err(); err != nil {
+		return nil, fmt.Errorf("error revoking sessions: %w", err)
+	}
+	return ids, nil
+}
+
+const createRefreshToken = `
+INSERT INTO refresh_token (session_id, token_hash, expires_at)
+VALUES ($1, $2, $3)
+RETURNING id
+`
+
+func (q *Queries) CreateRefreshToken(ctx context.Context, token *domain.RefreshToken) error {
+	if err := q.pool.QueryRow(ctx, createRefreshToken, token.SessionId, token.Hash, token.ExpiresAt.UTC()).Scan(&token.Id); err != nil {
+		return fmt.Errorf("error creating refresh token: %w", err)
+	}
+	return nil
+}
+
+// The row lock makes concurrent uses of the same token wait for each other, so only one of them sees it unused.
+const useRefreshToken = `
+WITH old AS (
+	SELECT id, session_id, expires_at, used_at FROM refresh_token
+	WHERE token_hash = $1
+	FOR UPDATE
+)
+UPDATE refresh_token SET used_at = COALESCE(old.used_at, $2)
+FROM old
+WHERE refresh_token.id = old.id
+RETURNING old.id, old.session_id, old.expires_at, old.used_at
+`
+
+// UseRefreshToken marks the token with the given hash used at now and returns it as it was before, so a UsedAt
+// that is set means the token had already been used. It returns nil if there is no such token.
+func (q *Queries) UseRefreshToken(ctx context.Context, hash []byte, now time.Time) (*domain.RefreshToken, error) {
+	token := domain.RefreshToken{Hash: hash}
+	var usedAt *time.Time
+	err := q.pool.QueryRow(ctx, useRefreshToken, hash, now.UTC()).Scan(&token.Id, &token.SessionId, &token.ExpiresAt, &usedAt)
+	if errors.Is(err, pgx.ErrNoRows) {
+		return nil, nil
+	}
+	if err != nil {
+		return nil, fmt.Errorf("error using refresh token: %w", err)
+	}
+	if usedAt != nil {
+		token.UsedAt = *usedAt
+	}
+	return &token, nil
+}
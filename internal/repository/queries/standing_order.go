@@ -0,0 +1,243 @@
+package queries
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"time"
+
+	"bank-api/internal/domain"
+
+	"github.com/jackc/pgx/v5"
+)
+
+const standingOrderColumns = `id, user_id, from_account_id, to_account_id, currency_id, amount, frequency,
+       start_at, end_at, max_occurrences, occurrences, attempts, next_run_at, status, created_at`
+
+const createStandingOrder = `
+INSERT INTO standing_order (user_id, from_account_id, to_account_id, currency_id, amount, frequency,
+                            start_at, end_at, max_occurrences, next_run_at)
+VALUES ($1, $2, $3, $4, $5, $6, $7, $8, $9, $10)
+RETURNING id, status, created_at
+`
+
+func (q *Queries) CreateStandingOrder(ctx context.Context, o *domain.StandingOrder) error {
+	err := q.pool.QueryRow(ctx, createStandingOrder, o.UserId, o.FromAccountId, o.ToAccountId, o.Cur.Id, o.Amount, o.Frequency,
+		o.StartAt.UTC(), nullTime(o.EndAt), nullId(o.MaxOccurrences), o.NextRunAt.UTC()).Scan(&o.Id, &o.Status, &o.Time)
+	if err != nil {
+		return fmt.Errorf("error creating standing order: %w", err)
+	}
+	return nil
+}
+
+const getStandingOrder = `
+SELECT ` + standingOrderColumns + ` FROM standing_order
+WHERE id = $1
+`
+
+// GetStandingOrder returns the standing order with the given id, or nil if there is none.
+func (q *Queries) GetStandingOrder(ctx context.Context, id int) (*domain.StandingOrder, error) {
+	o, err := q.scanStandingOrder(ctx, q.pool.QueryRow(ctx, getStandingOrder, id))
+	if errors.Is(err, pgx.ErrNoRows) {
+		return nil, nil
+	}
+	if err != nil {
+		return nil, fmt.Errorf("error getting standing order: %w", err)
+	}
+	return o, nil
+}
+
+const listStandingOrders = `
+SELECT ` + standingOrderColumns + ` FROM standing_order
+WHERE user_id = $1
+ORDER BY id
+`
+
+func (q *Queries) ListStandingOrders(ctx context.Context, userId int) ([]*domain.StandingOrder, error) {
+	rows, err := q.pool.Query(ctx, listStandingOrders, userId)
+	if err != nil {
+		return nil, fmt.Errorf("error listing standing orders: %w", err)
+	}
+	return q.scanStandingOrders(ctx, rows)
+}
+
+// The lease is kept when the owner changes the order, so that no other scheduler claims it while
+// an execution is still running.
+const updateStandingOrder = `
+UPDATE standing_order
+SET amount = $2, frequency = $3, start_at = $4, end_at = $5, max_occurrences = $6, occurrences = $7, attempts = $8,
+    next_run_at = $9, status = $10, updated_at = CURRENT_TIMESTAMP
+WHERE id = $1 AND status = 'active'
+`
+
+// UpdateStandingOrder saves the schedule and the status of an active standing order.
+func (q *Queries) UpdateStandingOrder(ctx context.Context, o *domain.StandingOrder) error {
+	tag, err := q.pool.Exec(ctx, updateStandingOrder, o.Id, o.Amount, o.Frequency, o.StartAt.UTC(), nullTime(o.EndAt),
+		nullId(o.MaxOccurrences), o.Occurrences, o.Attempts, o.NextRunAt.UTC(), o.Status)
+	if err != nil {
+		return fmt.Errorf("error updating standing order: %w", err)
+	}
+	if tag.RowsAffected() == 0 {
+		return domain.ErrStandingOrderNotActive
+	}
+	return nil
+}
+
+// Orders whose lease has run out are claimed again, so an instance that stopped in the middle of
+// executing them doesn't keep them forever.
+const claimDueStandingOrders = `
+UPDATE standing_order
+SET locked_until = $2
+WHERE id IN (
+	SELECT id FROM standing_order
+	WHERE status = 'active' AND next_run_at <= $1 AND (locked_until IS NULL OR locked_until <= $1)
+	ORDER BY next_run_at
+	LIMIT $3
+	FOR UPDATE SKIP LOCKED
+)
+RETURNING ` + standingOrderColumns
+
+// ClaimDueStandingOrders leases up to limit active orders that are due at now, so that no other
+// scheduler executes them until the lease ends or their execution is recorded.
+func (q *Queries) ClaimDueStandingOrders(ctx context.Context, now time.Time, lease time.Duration, limit int) ([]*domain.StandingOrder, error) {
+	rows, err := q.pool.Query(ctx, claimDueStandingOrders, now.UTC(), now.Add(lease).UTC(), limit)
+	if err != nil {
+		return nil, fmt.Errorf("error claiming standing orders: %w", err)
+	}
+	return q.scanStandingOrders(ctx, rows)
+}
+
+// An execution is unique per attempt of an occurrence, so the execution that a transfer records
+// together with itself keeps the same attempt from being paid again.
+const createStandingOrderExecution = `
+INSERT INTO standing_order_execution (standing_order_id, scheduled_at, attempt, transaction_id, status, failure_reason)
+VALUES ($1, $2, $3, $4, $5, $6)
+ON CONFLICT (standing_order_id, scheduled_at, attempt) DO NOTHING
+RETURNING id, created_at
+`
+
+// addTransferExecution records the execution as succeeded by the transfer, or fails with
+// domain.ErrAlreadyExecuted if the attempt is recorded already.
+func addTransferExecution(ctx context.Context, tx pgx.Tx, e *domain.StandingOrderExecution, transactionId int) error {
+	err := tx.QueryRow(ctx, createStandingOrderExecution, e.StandingOrderId, e.ScheduledAt.UTC(), e.Attempt,
+		transactionId, domain.ExecutionSucceeded, "").Scan(&e.Id, &e.Time)
+	if errors.Is(err, pgx.ErrNoRows) {
+		return domain.ErrAlreadyExecuted
+	}
+	if err != nil {
+		return fmt.Errorf("error recording standing order execution: %w", err)
+	}
+	e.TransactionId, e.Status = transactionId, domain.ExecutionSucceeded
+	return nil
+}
+
+// The progress is only saved if the owner didn't reschedule the order while it was executing,
+// that is if it is still at the occurrence and attempt that was executed.
+const advanceStandingOrder = `
+UPDATE standing_order
+SET occurrences = $2, attempts = $3, next_run_at = $4, status = $5, updated_at = CURRENT_TIMESTAMP
+WHERE id = $1 AND status = 'active' AND next_run_at = $6 AND attempts = $7
+`
+
+const releaseStandingOrder = `
+UPDATE standing_order SET locked_until = NULL WHERE id = $1
+`
+
+// RecordStandingOrderExecution logs the execution, unless its transfer logged it already, saves the progress
+// of the order unless it was changed or cancelled since it was claimed, and releases its lease.
+func (q *Queries) RecordStandingOrderExecution(ctx context.Context, o *domain.StandingOrder, e *domain.StandingOrderExecution) error {
+	err := q.inTx(ctx, func(tx pgx.Tx) error {
+		err := tx.QueryRow(ctx, createStandingOrderExecution, e.StandingOrderId, e.ScheduledAt.UTC(), e.Attempt,
+			nullId(e.TransactionId), e.Status, e.FailureReason).Scan(&e.Id, &e.Time)
+		if err != nil && !errors.Is(err, pgx.ErrNoRows) {
+			return err
+		}
+		_, err = tx.Exec(ctx, advanceStandingOrder, o.Id, o.Occurrences, o.Attempts, o.NextRunAt.UTC(), o.Status,
+			e.ScheduledAt.UTC(), e.Attempt-1)
+		if err != nil {
+			return err
+		}
+		_, err = tx.Exec(ctx, releaseStandingOrder, o.Id)
+		return err
+	})
+	if err != nil {
+		return fmt.Errorf("error recording standing order execution: %w", err)
+	}
+	return nil
+}
+
+const listStandingOrderExecutions = `
+SELECT id, standing_order_id, scheduled_at, attempt, transaction_id, status, failure_reason, created_at
+FROM standing_order_execution
+WHERE standing_order_id = $1
+ORDER BY id DESC
+`
+
+// ListStandingOrderExecutions returns the executions of the standing order, newest first.
+func (q *Queries) ListStandingOrderExecutions(ctx context.Context, orderId int) ([]*domain.StandingOrderExecution, error) {
+	rows, err := q.pool.Query(ctx, listStandingOrderExecutions, orderId)
+	if err != nil {
+		return nil, fmt.Errorf("error listing standing order executions: %w", err)
+	}
+	defer rows.Close()
+
+	var executions []*domain.StandingOrderExecution
+	for rows.Next() {
+		var e domain.StandingOrderExecution
+		var transactionId *int
+		if err := rows.Scan(&e.Id, &e.StandingOrderId, &e.ScheduledAt, &e.Attempt, &transactionId, &e.Status, &e.FailureReason, &e.Time); err != nil {
+			return nil, fmt.Errorf("error scanning standing order execution: %w", err)
+		}
+		if transactionId != nil {
+			e.TransactionId = *transactionId
+		}
+		executions = append(executions, &e)
+	}
+	if err := rows.Err(); err != nil {
+		return nil, fmt.Errorf("error listing standing order executions: %w", err)
+	}
+	return executions, nil
+}
+
+func (q *Queries) scanStandingOrders(ctx context.Context, rows pgx.Rows) ([]*domain.StandingOrder, error) {
+	defer rows.Close()
+
+	var orders []*domain.StandingOrder
+	for rows.Next() {
+		o, err := q.scanStandingOrder(ctx, rows)
+		if err != nil {
+			return nil, fmt.Errorf("error scanning standing order: %w", err)
+		}
+		orders = append(orders, o)
+	}
+	if err := rows.Err(); err != nil {
+		return nil, fmt.Errorf("error listing standing orders: %w", err)
+	}
+	return orders, nil
+}
+
+// scanStandingOrder scans a row of standingOrderColumns.
+func (q *Queries) scanStandingOrder(ctx context.Context, row pgx.Row) (*domain.StandingOrder, error) {
+	var o domain.StandingOrder
+	var endAt *time.Time
+	var maxOccurrences *int
+	err := row.Scan(&o.Id, &o.UserId, &o.FromAccountId, &o.ToAccountId, &o.Cur.Id, &o.Amount, &o.Frequency,
+		&o.StartAt, &endAt, &maxOccurrences, &o.Occurrences, &o.Attempts, &o.NextRunAt, &o.Status, &o.Time)
+	if err != nil {
+		return nil, err
+	}
+
+	if endAt != nil {
+		o.EndAt = *endAt
+	}
+	if maxOccurrences != nil {
+		o.MaxOccurrences = *maxOccurrences
+	}
+	if o.Cur, err = q.currency(ctx, o.Cur.Id); err != nil {
+		return nil, err
+	}
+	if err := inCurrency(&o.Amount, o.Cur); err != nil {
+		return nil, err
+	}
+	return &o, nil
+}
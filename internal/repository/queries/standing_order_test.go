@@ -0,0 +1,53 @@
+package queries
+
+import (
+	"context"
+	"testing"
+	"time"
+
+	"bank-api/internal/domain"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+// TestTransfer_Execution runs the same attempt of a standing order twice, as a scheduler does when the first
+// one stopped after the transfer, and checks that the money moves once.
+func TestTransfer_Execution(t *testing.T) {
+	q := testQueries(t)
+	ctx := context.Background()
+	from := newTestAccount(t, q, "USD", 10000)
+	to := newTestAccount(t, q, "USD", 0)
+
+	amount := domain.NewMoney(1000, from.Cur)
+	due := time.Now().UTC().Truncate(time.Second)
+	o := &domain.StandingOrder{UserId: from.UserId, FromAccountId: from.Id, ToAccountId: to.Id, Cur: from.Cur, Amount: amount,
+		Frequency: domain.FrequencyDaily, StartAt: due, NextRunAt: due}
+	require.NoError(t, q.CreateStandingOrder(ctx, o))
+
+	transfer := func() error {
+		return q.Transfer(ctx, &domain.Transaction{
+			FromAccountId: from.Id, ToAccountId: to.Id, Cur: from.Cur, Amount: amount, ToCur: to.Cur, ToAmount: amount,
+			Execution: &domain.StandingOrderExecution{StandingOrderId: o.Id, ScheduledAt: due, Attempt: 1},
+		})
+	}
+	require.NoError(t, transfer())
+	assert.ErrorIs(t, transfer(), domain.ErrAlreadyExecuted)
+
+	got, err := q.GetAccount(ctx, from.Id)
+	require.NoError(t, err)
+	assert.Equal(t, 0, got.Amount.Cmp(domain.NewMoney(9000, from.Cur)), "balance: %s", got.Amount)
+
+	// Recording the execution afterwards keeps the one logged by the transfer.
+	o.Advance()
+	e := &domain.StandingOrderExecution{StandingOrderId: o.Id, ScheduledAt: due, Attempt: 1, Status: domain.ExecutionSucceeded}
+	require.NoError(t, q.RecordStandingOrderExecution(ctx, o, e))
+	executions, err := q.ListStandingOrderExecutions(ctx, o.Id)
+	require.NoError(t, err)
+	require.Len(t, executions, 1)
+	assert.NotZero(t, executions[0].TransactionId)
+
+	order, err := q.GetStandingOrder(ctx, o.Id)
+	require.NoError(t, err)
+	assert.Equal(t, 1, order.Occurrences)
+}
@@ -0,0 +1,126 @@
+package queries
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"time"
+
+	"bank-api/internal/domain"
+
+	"github.com/jackc/pgx/v5"
+)
+
+// missingStatements lists, for every customer account, the months from the one of its first entry up to
+// the one before $1 that have no stored statement, skipping the months the account had no money and no entries in.
+const missingStatements = `
+WITH booked AS (
+    SELECT posting.account_id, posting.amount, COALESCE(transaction.created_at, journal_entry.created_at) AS at
+    FROM posting
+    JOIN account ON account.id = posting.account_id AND account.kind = 'customer'
+    JOIN journal_entry ON journal_entry.id = posting.entry_id
+    LEFT JOIN transaction ON transaction.id = journal_entry.transaction_id
+    WHERE COALESCE(transaction.created_at, journal_entry.created_at) < $1
+),
+period AS (
+    SELECT first.account_id, month
+    FROM (SELECT account_id, date_trunc('month', MIN(at)) AS first_month FROM booked GROUP BY account_id) first,
+         generate_series(first.first_month, $1 - INTERVAL '1 month', INTERVAL '1 month') AS month
+)
+SELECT period.account_id, period.month FROM period
+WHERE NOT EXISTS (SELECT 1 FROM statement WHERE statement.account_id = period.account_id AND statement.period_start = period.month)
+  AND (EXISTS (SELECT 1 FROM booked WHERE booked.account_id = period.account_id AND booked.at >= period.month AND booked.at < period.month + INTERVAL '1 month')
+       OR (SELECT COALESCE(SUM(amount), 0) FROM booked WHERE booked.account_id = period.account_id AND booked.at < period.month) <> 0)
+ORDER BY period.month, period.account_id
+`
+
+// MissingStatements returns the monthly statements, without their content, that the customer accounts
+// should have for the months before the one starting at before but that aren't stored.
+// Months in which an account had no money and no entries need no statement and aren't returned.
+func (q *Queries) MissingStatements(ctx context.Context, before time.Time) ([]*domain.StatementFile, error) {
+	rows, err := q.pool.Query(ctx, missingStatements, before.UTC())
+	if err != nil {
+		return nil, fmt.Errorf("error getting missing statements: %w", err)
+	}
+	defer rows.Close()
+
+	var files []*domain.StatementFile
+	for rows.Next() {
+		var file domain.StatementFile
+		if err := rows.Scan(&file.AccountId, &file.From); err != nil {
+			return nil, fmt.Errorf("error scanning missing statement: %w", err)
+		}
+		file.To = file.From.AddDate(0, 1, 0)
+		files = append(files, &file)
+	}
+	if err := rows.Err(); err != nil {
+		return nil, fmt.Errorf("error getting missing statements: %w", err)
+	}
+	return files, nil
+}
+
+const saveStatementFile = `
+INSERT INTO statement (account_id, period_start, period_end, content)
+VALUES ($1, $2, $3, $4)
+ON CONFLICT (account_id, period_start) DO NOTHING
+RETURNING id, created_at
+`
+
+// SaveStatementFile stores the statement unless the account already has one for the period,
+// in which case the stored one is kept and file.Id stays 0.
+func (q *Queries) SaveStatementFile(ctx context.Context, file *domain.StatementFile) error {
+	err := q.pool.QueryRow(ctx, saveStatementFile, file.AccountId, file.From.UTC(), file.To.UTC(), file.Content).Scan(&file.Id, &file.CreatedAt)
+	if errors.Is(err, pgx.ErrNoRows) {
+		return nil
+	}
+	if err != nil {
+		return fmt.Errorf("error saving statement: %w", err)
+	}
+	return nil
+}
+
+const listStatementFiles = `
+SELECT id, account_id, period_start, period_end, created_at FROM statement
+WHERE account_id = $1
+ORDER BY period_start DESC
+`
+
+// ListStatementFiles returns the stored statements of the account, newest first, without their content.
+func (q *Queries) ListStatementFiles(ctx context.Context, accountId int) ([]*domain.StatementFile, error) {
+	rows, err := q.pool.Query(ctx, listStatementFiles, accountId)
+	if err != nil {
+		return nil, fmt.Errorf("error listing statements: %w", err)
+	}
+	defer rows.Close()
+
+	var files []*domain.StatementFile
+	for rows.Next() {
+		var file domain.StatementFile
+		if err := rows.Scan(&file.Id, &file.AccountId, &file.From, &file.To, &file.CreatedAt); err != nil {
+			return nil, fmt.Errorf("error scanning statement: %w", err)
+		}
+		files = append(files, &file)
+	}
+	if err := rows.Err(); err != nil {
+		return nil, fmt.Errorf("error listing statements: %w", err)
+	}
+	return files, nil
+}
+
+const getStatementFile = `
+SELECT id, account_id, period_start, period_end, content, created_at FROM statement
+WHERE id = $1
+`
+
+// GetStatementFile returns the stored statement with the given id, or nil if there is none.
+func (q *Queries) GetStatementFile(ctx context.Context, id int) (*domain.StatementFile, error) {
+	var file domain.StatementFile
+	err := q.pool.QueryRow(ctx, getStatementFile, id).Scan(&file.Id, &file.AccountId, &file.From, &file.To, &file.Content, &file.CreatedAt)
+	if errors.Is(err, pgx.ErrNoRows) {
+		return nil, nil
+	}
+	if err != nil {
+		return nil, fmt.Errorf("error getting statement: %w", err)
+	}
+	return &file, nil
+}
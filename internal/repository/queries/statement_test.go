@@ -0,0 +1,50 @@
+package queries
+
+import (
+	"context"
+	"testing"
+	"time"
+
+	"bank-api/internal/domain"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+// TestMissingStatements checks that an account gets a statement for every month since its first entry,
+// including the ones after it without entries, and that empty accounts get none.
+func TestMissingStatements(t *testing.T) {
+	q := testQueries(t)
+	ctx := context.Background()
+
+	now := time.Now().UTC()
+	month := time.Date(now.Year(), now.Month(), 1, 0, 0, 0, 0, time.UTC)
+	account := newTestAccount(t, q, "USD", 10000)
+	empty := newTestAccount(t, q, "USD", 0)
+
+	// The deposit was made two months ago.
+	_, err := q.pool.Exec(ctx, `
+UPDATE transaction SET created_at = $2
+WHERE to_account_id = $1 OR from_account_id = $1`, account.Id, month.AddDate(0, -2, 1))
+	require.NoError(t, err)
+
+	forAccount := func(id int) []time.Time {
+		files, err := q.MissingStatements(ctx, month)
+		require.NoError(t, err)
+		var months []time.Time
+		for _, f := range files {
+			if f.AccountId == id {
+				assert.Equal(t, f.From.AddDate(0, 1, 0), f.To)
+				months = append(months, f.From)
+			}
+		}
+		return months
+	}
+
+	assert.Equal(t, []time.Time{month.AddDate(0, -2, 0), month.AddDate(0, -1, 0)}, forAccount(account.Id))
+	assert.Empty(t, forAccount(empty.Id))
+
+	file := &domain.StatementFile{AccountId: account.Id, From: month.AddDate(0, -2, 0), To: month.AddDate(0, -1, 0), Content: []byte("%PDF-")}
+	require.NoError(t, q.SaveStatementFile(ctx, file))
+	assert.Equal(t, []time.Time{month.AddDate(0, -1, 0)}, forAccount(account.Id))
+}
@@ -3,156 +3,449 @@ package queries
 import (
 	"context"
 	"database/sql"
+	"errors"
 	"fmt"
+	"time"
 
 	"bank-api/internal/domain"
+
+	"github.com/jackc/pgx/v5"
 )
 
 const addTransactionEntry = `
-INSERT INTO transaction (from_account_id, to_account_id, currency_id, amount)
-VALUES ($1, $2, $3, $4)
+INSERT INTO transaction (from_account_id, to_account_id, currency_id, amount, to_currency_id, to_amount, rate,
+                         rate_id, rate_inverted, cross_rate_id, cross_rate_inverted, reverses_id, hold_id, status)
+VALUES ($1, $2, $3, $4, $5, $6, $7, $8, $9, $10, $11, $12, $13, 'pending')
+RETURNING id, created_at
 `
 
-const getCurrencyByAccountId = `
-SELECT currency_id FROM account
-WHERE id = $1
+const addAccountTransactionEntry = `
+INSERT INTO transaction (from_account_id, to_account_id, currency_id, amount, to_currency_id, to_amount, status)
+SELECT $1::INT, $2::INT, currency_id, $3::NUMERIC, currency_id, $3::NUMERIC, 'pending'
+FROM account
+WHERE id = $4 AND kind = 'customer'
+RETURNING id
 `
 
-func (q *Queries) Transaction(ctx context.Context, accountId int, amount int, t domain.TransactionType) error {
-	tx, err := q.pool.Begin(ctx)
-	if err != nil {
-		return fmt.Errorf("error starting transaction: %w", err)
-	}
+const fxAccount = "fx"
+
+const updateTransactionStatus = `
+UPDATE transaction SET status = $3, failure_reason = $4, updated_at = CURRENT_TIMESTAMP
+WHERE id = $1 AND status = $2
+`
 
-	var accountBalance int
-	if err = tx.QueryRow(ctx, getBalance, accountId).Scan(&accountBalance); err != nil {
-		tx.Rollback(ctx)
-		return fmt.Errorf("error getting account balance: %w", err)
+// setTransactionStatus moves the transaction from one status to another,
+// failing with domain.ErrInvalidStatusTransition if it isn't in the from status or can't leave it for the new one.
+func setTransactionStatus(ctx context.Context, db execer, id int, from domain.TransactionStatus, to domain.TransactionStatus, reason string) error {
+	if !from.CanTransitionTo(to) {
+		return fmt.Errorf("%w: %s -> %s", domain.ErrInvalidStatusTransition, from, to)
 	}
-	if t == domain.Withdraw {
-		_, err = tx.Exec(ctx, updateAccount, accountId, accountBalance-amount)
-	} else {
-		_, err = tx.Exec(ctx, updateAccount, accountId, accountBalance+amount)
+
+	var failureReason *string
+	if reason != "" {
+		failureReason = &reason
 	}
+	tag, err := db.Exec(ctx, updateTransactionStatus, id, from, to, failureReason)
 	if err != nil {
-		tx.Rollback(ctx)
-		return fmt.Errorf("error updating account balance: %w", err)
+		return fmt.Errorf("error updating transaction status: %w", err)
+	}
+	if tag.RowsAffected() == 0 {
+		return fmt.Errorf("%w: transaction %d is not %s", domain.ErrInvalidStatusTransition, id, from)
 	}
+	return nil
+}
 
-	var currencyId int
-	if err := tx.QueryRow(ctx, getCurrencyByAccountId, accountId).Scan(&currencyId); err != nil {
-		tx.Rollback(ctx)
-		return fmt.Errorf("error getting currency id: %w", err)
+// failureReasons are the errors whose messages are safe to show as the reason of a failed transaction.
+var failureReasons = []error{
+	domain.ErrInsufficientFunds, domain.ErrAccountFrozen,
+	domain.ErrQuoteExpired, domain.ErrQuoteUsed,
+	domain.ErrNotReversible, domain.ErrReversalExceedsAmount,
+	domain.ErrHoldNotActive, domain.ErrCaptureExceedsHold,
+	domain.ErrAlreadyExecuted,
+}
+
+// failTransaction records why a pending transaction wasn't posted. It returns err, joined with the error
+// of marking the transaction failed if there is one.
+func (q *Queries) failTransaction(ctx context.Context, id int, err error) error {
+	reason := "internal error"
+	for _, known := range failureReasons {
+		if errors.Is(err, known) {
+			reason = known.Error()
+			break
+		}
 	}
 
+	failErr := setTransactionStatus(context.WithoutCancel(ctx), q.pool, id, domain.StatusPending, domain.StatusFailed, reason)
+	if failErr != nil {
+		return errors.Join(err, fmt.Errorf("error marking transaction %d failed: %w", id, failErr))
+	}
+	return err
+}
+
+// abandonedReason is the failure reason of a transaction left pending, e.g. by a crash before it was posted.
+const abandonedReason = "abandoned"
+
+const failStalePendingTransactions = `
+UPDATE transaction SET status = 'failed', failure_reason = $2, updated_at = CURRENT_TIMESTAMP
+WHERE status = 'pending' AND created_at < $1
+`
+
+// FailStalePendingTransactions marks the transactions still pending since before the given time failed and
+// returns how many there were. A transaction posted concurrently stays posted, and one that is posted later
+// is rolled back, as it can't leave the failed status.
+func (q *Queries) FailStalePendingTransactions(ctx context.Context, before time.Time) (int, error) {
+	tag, err := q.pool.Exec(ctx, failStalePendingTransactions, before.UTC(), abandonedReason)
+	if err != nil {
+		return 0, fmt.Errorf("error failing pending transactions: %w", err)
+	}
+	return int(tag.RowsAffected()), nil
+}
+
+func (q *Queries) Transaction(ctx context.Context, accountId int, amount domain.Money, t domain.TransactionType) error {
+	var from, to *int
 	if t == domain.Withdraw {
-		_, err = tx.Exec(ctx, addTransactionEntry, accountId, nil, currencyId, amount)
-	} else if t == domain.Deposit {
-		_, err = tx.Exec(ctx, addTransactionEntry, nil, accountId, currencyId, amount)
+		from = &accountId
+	} else {
+		to = &accountId
 	}
+
+	var transactionId int
+	err := q.pool.QueryRow(ctx, addAccountTransactionEntry, from, to, amount, accountId).Scan(&transactionId)
 	if err != nil {
-		tx.Rollback(ctx)
 		return fmt.Errorf("error adding transaction entry: %w", err)
 	}
 
-	if err := tx.Commit(ctx); err != nil {
-		return fmt.Errorf("error committing transaction: %w", err)
-	}
+	err = q.inTx(ctx, func(tx pgx.Tx) error {
+		accounts, err := lockAccounts(ctx, tx, accountId)
+		if err != nil {
+			return err
+		}
+		account := accounts[accountId]
+
+		if account.frozen {
+			return domain.ErrAccountFrozen
+		}
+		if t == domain.Withdraw && account.available().Cmp(amount) < 0 {
+			return domain.ErrInsufficientFunds
+		}
+
+		externalId, err := systemAccountId(ctx, tx, externalAccount, account.currencyId)
+		if err != nil {
+			return err
+		}
+
+		// The closure may be retried, so amount itself must stay untouched.
+		change := amount
+		if t == domain.Withdraw {
+			change = amount.Neg()
+		}
+		cur := domain.Currency{Id: account.currencyId}
+		entry := &domain.JournalEntry{
+			TransactionId: transactionId,
+			Postings: []domain.Posting{
+				{AccountId: accountId, Cur: cur, Amount: change},
+				{AccountId: externalId, Cur: cur, Amount: change.Neg()},
+			},
+		}
+		if err := postEntry(ctx, tx, entry); err != nil {
+			return fmt.Errorf("error posting journal entry: %w", err)
+		}
 
+		return setTransactionStatus(ctx, tx, transactionId, domain.StatusPending, domain.StatusPosted, "")
+	})
+	if err != nil {
+		return q.failTransaction(ctx, transactionId, err)
+	}
 	return nil
 }
 
-const getBalance = `
-SELECT amount FROM account
-WHERE id = $1 
-`
+// Transfer moves t.Amount of t.Cur from one account and t.ToAmount of t.ToCur to the other one.
+func (q *Queries) Transfer(ctx context.Context, t *domain.Transaction) error {
+	transactionId, err := q.addTransfer(ctx, t)
+	if err != nil {
+		return err
+	}
+
+	err = q.inTx(ctx, func(tx pgx.Tx) error {
+		if t.QuoteId != 0 {
+			if err := useQuote(ctx, tx, t.QuoteId, transactionId); err != nil {
+				return err
+			}
+		}
 
-func (q *Queries) Transfer(ctx context.Context, fromAccountId int, toAccountId int, amount int) error {
-	tx, err := q.pool.Begin(ctx)
+		if err := postTransfer(ctx, tx, transactionId, t); err != nil {
+			return err
+		}
+
+		if err := setTransactionStatus(ctx, tx, transactionId, domain.StatusPending, domain.StatusPosted, ""); err != nil {
+			return err
+		}
+
+		if t.Execution != nil {
+			return addTransferExecution(ctx, tx, t.Execution, transactionId)
+		}
+		return nil
+	})
 	if err != nil {
-		return fmt.Errorf("error starting transaction: %w", err)
+		return q.failTransaction(ctx, transactionId, err)
 	}
 
-	var fromAccountBalance int
-	if err = tx.QueryRow(ctx, getBalance, fromAccountId).Scan(&fromAccountBalance); err != nil {
-		tx.Rollback(ctx)
-		return fmt.Errorf("error getting account balance: %w", err)
+	t.Id, t.Status = transactionId, domain.StatusPosted
+	return nil
+}
+
+// addTransfer records a pending transfer, sets its time and returns its id.
+func (q *Queries) addTransfer(ctx context.Context, t *domain.Transaction) (int, error) {
+	var rate *string
+	var sources rateSources
+	if t.Rate != nil {
+		value := t.Rate.String()
+		rate = &value
+		var err error
+		if sources, err = newRateSources(t.Rate); err != nil {
+			return 0, fmt.Errorf("error adding transaction entry: %w", err)
+		}
 	}
 
-	fromAccountBalance -= amount
+	var transactionId int
+	err := q.pool.QueryRow(ctx, addTransactionEntry, t.FromAccountId, nullId(t.ToAccountId), t.Cur.Id, t.Amount, t.ToCur.Id, t.ToAmount,
+		rate, sources.id, sources.inverted, sources.crossId, sources.crossInverted,
+		nullId(t.ReversesId), nullId(t.HoldId)).Scan(&transactionId, &t.Time)
+	if err != nil {
+		return 0, fmt.Errorf("error adding transaction entry: %w", err)
+	}
+	return transactionId, nil
+}
 
-	if _, err = tx.Exec(ctx, updateAccount, fromAccountId, fromAccountBalance); err != nil {
-		tx.Rollback(ctx)
-		return fmt.Errorf("error updating account balance: %w", err)
+// nullId stores a zero id as NULL.
+func nullId(id int) *int {
+	if id == 0 {
+		return nil
 	}
+	return &id
+}
 
-	var toAccountBalance int
-	if err = tx.QueryRow(ctx, getBalance, toAccountId).Scan(&toAccountBalance); err != nil {
-		tx.Rollback(ctx)
-		return fmt.Errorf("error getting account balance: %w", err)
+// postTransfer locks both accounts of the transfer, checks the available balance and posts the journal entry.
+// A transfer without ToAccountId takes the money out of the bank, to the external account of its currency.
+// When the currencies differ the conversion goes through the fx system accounts of both currencies,
+// so that postings stay balanced in each of them.
+func postTransfer(ctx context.Context, tx pgx.Tx, transactionId int, t *domain.Transaction) error {
+	ids := []int{t.FromAccountId}
+	if t.ToAccountId != 0 {
+		ids = append(ids, t.ToAccountId)
 	}
-	toAccountBalance += amount
+	accounts, err := lockAccounts(ctx, tx, ids...)
+	if err != nil {
+		return err
+	}
+	from := accounts[t.FromAccountId]
 
-	if _, err := tx.Exec(ctx, updateAccount, toAccountId, toAccountBalance); err != nil {
-		tx.Rollback(ctx)
-		return fmt.Errorf("error updating account balance: %w", err)
+	toAccountId := t.ToAccountId
+	if toAccountId == 0 {
+		if t.Cur.Id != t.ToCur.Id {
+			return fmt.Errorf("error transferring money: %w", errCurrencyMismatch)
+		}
+		if toAccountId, err = systemAccountId(ctx, tx, externalAccount, t.ToCur.Id); err != nil {
+			return err
+		}
+	} else if accounts[t.ToAccountId].currencyId != t.ToCur.Id {
+		return fmt.Errorf("error transferring money: %w", errCurrencyMismatch)
+	}
+	if from.currencyId != t.Cur.Id {
+		return fmt.Errorf("error transferring money: %w", errCurrencyMismatch)
+	}
+	for _, account := range accounts {
+		if account.frozen {
+			return domain.ErrAccountFrozen
+		}
+	}
+	if from.available().Cmp(t.Amount) < 0 {
+		return domain.ErrInsufficientFunds
 	}
 
-	var currencyId int
-	if err := tx.QueryRow(ctx, getCurrencyByAccountId, fromAccountId).Scan(&currencyId); err != nil {
-		tx.Rollback(ctx)
-		return fmt.Errorf("error getting currency id: %w", err)
+	entry := &domain.JournalEntry{TransactionId: transactionId}
+	if t.Cur.Id == t.ToCur.Id {
+		entry.Postings = []domain.Posting{
+			{AccountId: t.FromAccountId, Cur: t.Cur, Amount: t.Amount.Neg()},
+			{AccountId: toAccountId, Cur: t.ToCur, Amount: t.ToAmount},
+		}
+	} else {
+		fxFromId, err := systemAccountId(ctx, tx, fxAccount, t.Cur.Id)
+		if err != nil {
+			return err
+		}
+		fxToId, err := systemAccountId(ctx, tx, fxAccount, t.ToCur.Id)
+		if err != nil {
+			return err
+		}
+		entry.Postings = []domain.Posting{
+			{AccountId: t.FromAccountId, Cur: t.Cur, Amount: t.Amount.Neg()},
+			{AccountId: fxFromId, Cur: t.Cur, Amount: t.Amount},
+			{AccountId: fxToId, Cur: t.ToCur, Amount: t.ToAmount.Neg()},
+			{AccountId: toAccountId, Cur: t.ToCur, Amount: t.ToAmount},
+		}
+	}
+	if err := postEntry(ctx, tx, entry); err != nil {
+		return fmt.Errorf("error posting journal entry: %w", err)
 	}
-	_, err = tx.Exec(ctx, addTransactionEntry, fromAccountId, toAccountId, currencyId, amount)
+	return nil
+}
+
+const lockTransaction = `
+SELECT status FROM transaction
+WHERE id = $1
+FOR UPDATE
+`
+
+const reversedAmounts = `
+SELECT COALESCE(SUM(amount), 0), COALESCE(SUM(to_amount), 0) FROM transaction
+WHERE reverses_id = $1 AND status = 'posted'
+`
+
+const setTransactionToAmount = `
+UPDATE transaction SET to_amount = $2
+WHERE id = $1
+`
+
+// Reverse posts the reversal of the original transfer. The original is locked while the reversal is posted,
+// so concurrent reversals can't return more than it moved; it becomes reversed once fully returned.
+// The refund is calculated from the reversals posted so far and stored into reversal.ToAmount.
+func (q *Queries) Reverse(ctx context.Context, original *domain.Transaction, reversal *domain.Transaction) error {
+	transactionId, err := q.addTransfer(ctx, reversal)
 	if err != nil {
-		tx.Rollback(ctx)
-		return fmt.Errorf("error adding transaction entry: %w", err)
+		return err
 	}
 
-	if err := tx.Commit(ctx); err != nil {
-		return fmt.Errorf("error committing transaction: %w", err)
+	var refund domain.Money
+	err = q.inTx(ctx, func(tx pgx.Tx) error {
+		var status domain.TransactionStatus
+		if err := tx.QueryRow(ctx, lockTransaction, original.Id).Scan(&status); err != nil {
+			return fmt.Errorf("error locking transaction: %w", err)
+		}
+		if status != domain.StatusPosted {
+			return domain.ErrNotReversible
+		}
+
+		locked := *original
+		if err := tx.QueryRow(ctx, reversedAmounts, original.Id).Scan(&locked.ReversedAmount, &locked.RefundedAmount); err != nil {
+			return fmt.Errorf("error getting reversed amounts: %w", err)
+		}
+		if err := inCurrency(&locked.ReversedAmount, locked.ToCur); err != nil {
+			return err
+		}
+		if err := inCurrency(&locked.RefundedAmount, locked.Cur); err != nil {
+			return err
+		}
+
+		refund, err = locked.Refund(reversal.Amount)
+		if err != nil {
+			return err
+		}
+		if _, err := tx.Exec(ctx, setTransactionToAmount, transactionId, refund); err != nil {
+			return fmt.Errorf("error updating transaction: %w", err)
+		}
+
+		posted := *reversal
+		posted.ToAmount = refund
+		if err := postTransfer(ctx, tx, transactionId, &posted); err != nil {
+			return err
+		}
+		if err := setTransactionStatus(ctx, tx, transactionId, domain.StatusPending, domain.StatusPosted, ""); err != nil {
+			return err
+		}
+
+		reversed, err := locked.ReversedAmount.Add(reversal.Amount)
+		if err != nil {
+			return err
+		}
+		if reversed.Cmp(locked.ToAmount) == 0 {
+			return setTransactionStatus(ctx, tx, original.Id, domain.StatusPosted, domain.StatusReversed, "")
+		}
+		return nil
+	})
+	if err != nil {
+		return q.failTransaction(ctx, transactionId, err)
 	}
 
+	reversal.Id, reversal.Status, reversal.ToAmount = transactionId, domain.StatusPosted, refund
 	return nil
 }
 
-const listTransactions = `
-SELECT from_account_id, to_account_id, currency_id, amount, created_at FROM transaction
-WHERE from_account_id = $1 OR to_account_id = $1
+const selectTransactions = `
+SELECT transaction.id, from_account_id, to_account_id, currency_id, amount, to_currency_id, to_amount, rate,
+       rate_id, rate_inverted, cross_rate_id, cross_rate_inverted, status, failure_reason, reverses_id, reversals.reversed, reversals.refunded, hold_id, created_at
+FROM transaction,
+     LATERAL (SELECT COALESCE(SUM(r.amount), 0) AS reversed, COALESCE(SUM(r.to_amount), 0) AS refunded
+              FROM transaction r
+              WHERE r.reverses_id = transaction.id AND r.status = 'posted') reversals
 `
 
-func (q *Queries) ListTransactions(ctx context.Context, accountId int) ([]*domain.Transaction, error) {
-	rows, err := q.pool.Query(ctx, listTransactions, accountId)
+const getTransaction = selectTransactions + `
+WHERE transaction.id = $1
+`
+
+// GetTransaction returns the transaction with the given id, or nil if there is none.
+func (q *Queries) GetTransaction(ctx context.Context, id int) (*domain.Transaction, error) {
+	transaction, err := q.scanTransaction(ctx, q.pool.QueryRow(ctx, getTransaction, id))
+	if errors.Is(err, pgx.ErrNoRows) {
+		return nil, nil
+	}
+	if err != nil {
+		return nil, fmt.Errorf("error getting transaction: %w", err)
+	}
+	return transaction, nil
+}
+
+const listTransactions = selectTransactions + `
+WHERE (from_account_id IN (SELECT id FROM account WHERE user_id = $1) OR to_account_id IN (SELECT id FROM account WHERE user_id = $1))
+  AND ($2::INT IS NULL OR from_account_id = $2 OR to_account_id = $2)
+  AND ($3::TIMESTAMP IS NULL OR created_at >= $3)
+  AND ($4::TIMESTAMP IS NULL OR created_at < $4)
+  AND ($5::TEXT IS NULL OR $5 = CASE
+                                   WHEN from_account_id IS NULL THEN 'deposit'
+                                   WHEN to_account_id IS NULL THEN 'withdraw'
+                                   ELSE 'transfer' END)
+  AND ($6::NUMERIC IS NULL OR amount >= $6)
+  AND ($7::NUMERIC IS NULL OR amount <= $7)
+  AND ($8::INT IS NULL OR currency_id = $8)
+  AND ($9::INT IS NULL OR from_account_id = $9 OR to_account_id = $9)
+  AND ($10::TIMESTAMP IS NULL OR (created_at, transaction.id) < ($10, $11::INT))
+ORDER BY created_at DESC, transaction.id DESC
+LIMIT $12
+`
+
+// ListTransactions returns the transactions matching filter, newest first.
+func (q *Queries) ListTransactions(ctx context.Context, filter *domain.TransactionFilter) ([]*domain.Transaction, error) {
+	var txType *string
+	if filter.Type != nil {
+		name := filter.Type.String()
+		txType = &name
+	}
+	var afterTime *time.Time
+	var afterId int
+	if filter.After != nil {
+		afterTime = nullTime(filter.After.Time)
+		afterId = filter.After.Id
+	}
+
+	rows, err := q.pool.Query(ctx, listTransactions, filter.UserId, nullId(filter.AccountId), nullTime(filter.From), nullTime(filter.To),
+		txType, filter.MinAmount, filter.MaxAmount, nullId(filter.Cur.Id), nullId(filter.CounterpartyId), afterTime, afterId, filter.Limit)
 	if err != nil {
 		return nil, fmt.Errorf("error getting transactions: %w", err)
 	}
+	return q.scanTransactions(ctx, rows)
+}
+
+func (q *Queries) scanTransactions(ctx context.Context, rows pgx.Rows) ([]*domain.Transaction, error) {
 	defer rows.Close()
 
 	var transactions []*domain.Transaction
 	for rows.Next() {
-		var transaction domain.Transaction
-		var from, to sql.NullInt64
-		if err := rows.Scan(&from, &to, &transaction.Cur.Id, &transaction.Amount, &transaction.Time); err != nil {
-			return nil, fmt.Errorf("error getting transaction: %w", err)
-		}
-		if !from.Valid {
-			transaction.Type = domain.Deposit
-			transaction.ToAccountId = int(to.Int64)
-		} else if !to.Valid {
-			transaction.Type = domain.Withdraw
-			transaction.FromAccountId = int(from.Int64)
-		} else {
-			transaction.Type = domain.Transfer
-			transaction.FromAccountId = int(from.Int64)
-			transaction.ToAccountId = int(to.Int64)
-		}
-
-		transaction.Cur.Symbol, err = q.GetCurrencySymbol(ctx, transaction.Cur.Id)
+		transaction, err := q.scanTransaction(ctx, rows)
 		if err != nil {
-			return nil, fmt.Errorf("error getting currency symbol: %w", err)
+			return nil, fmt.Errorf("error getting transaction: %w", err)
 		}
-
-		transactions = append(transactions, &transaction)
+		transactions = append(transactions, transaction)
 	}
 	if err := rows.Err(); err != nil {
 		return nil, fmt.Errorf("error getting transactions: %w", err)
@@ -160,3 +453,70 @@ func (q *Queries) ListTransactions(ctx context.Context, accountId int) ([]*domai
 
 	return transactions, nil
 }
+
+// nullTime passes a zero time as NULL. Timestamps are stored without a time zone, in UTC.
+func nullTime(t time.Time) *time.Time {
+	if t.IsZero() {
+		return nil
+	}
+	t = t.UTC()
+	return &t
+}
+
+// scanTransaction scans a row of selectTransactions.
+func (q *Queries) scanTransaction(ctx context.Context, row pgx.Row) (*domain.Transaction, error) {
+	var transaction domain.Transaction
+	var from, to, reversesId, holdId sql.NullInt64
+	var rate, failureReason sql.NullString
+	var sources rateSources
+	err := row.Scan(&transaction.Id, &from, &to, &transaction.Cur.Id, &transaction.Amount, &transaction.ToCur.Id, &transaction.ToAmount,
+		&rate, &sources.id, &sources.inverted, &sources.crossId, &sources.crossInverted, &transaction.Status, &failureReason, &reversesId, &transaction.ReversedAmount, &transaction.RefundedAmount, &holdId, &transaction.Time)
+	if err != nil {
+		return nil, err
+	}
+	if !from.Valid {
+		transaction.Type = domain.Deposit
+		transaction.ToAccountId = int(to.Int64)
+	} else if !to.Valid {
+		transaction.Type = domain.Withdraw
+		transaction.FromAccountId = int(from.Int64)
+	} else {
+		transaction.Type = domain.Transfer
+		transaction.FromAccountId = int(from.Int64)
+		transaction.ToAccountId = int(to.Int64)
+	}
+	transaction.FailureReason = failureReason.String
+	transaction.ReversesId = int(reversesId.Int64)
+	transaction.HoldId = int(holdId.Int64)
+
+	if transaction.Cur, err = q.currency(ctx, transaction.Cur.Id); err != nil {
+		return nil, err
+	}
+	if err := inCurrency(&transaction.Amount, transaction.Cur); err != nil {
+		return nil, err
+	}
+	if err := inCurrency(&transaction.RefundedAmount, transaction.Cur); err != nil {
+		return nil, err
+	}
+
+	if transaction.ToCur, err = q.currency(ctx, transaction.ToCur.Id); err != nil {
+		return nil, err
+	}
+	if err := inCurrency(&transaction.ToAmount, transaction.ToCur); err != nil {
+		return nil, err
+	}
+	if err := inCurrency(&transaction.ReversedAmount, transaction.ToCur); err != nil {
+		return nil, err
+	}
+
+	if rate.Valid {
+		transaction.Rate = &domain.ExchangeRate{From: transaction.Cur, To: transaction.ToCur}
+		sources.setSources(transaction.Rate)
+		var ok bool
+		if transaction.Rate.Rate, ok = domain.ParseRate(rate.String); !ok {
+			return nil, fmt.Errorf("error getting transaction rate: %w", errInvalidRate)
+		}
+	}
+
+	return &transaction, nil
+}
@@ -0,0 +1,88 @@
+package queries
+
+import (
+	"context"
+	"database/sql"
+	"testing"
+	"time"
+
+	"bank-api/internal/domain"
+
+	"github.com/jackc/pgx/v5"
+	"github.com/jackc/pgx/v5/pgconn"
+)
+
+// transactionRows fakes the rows of selectTransactions.
+type transactionRows struct {
+	pgx.Rows
+	n, i int
+}
+
+func (r *transactionRows) Next() bool {
+	r.i++
+	return r.i <= r.n
+}
+
+func (r *transactionRows) Scan(dest ...any) error {
+	rateId := 5
+	values := []any{r.i, int64(1), int64(2), 1, "10.00", 3, "0.13", "0.013", &rateId, false, nil, false,
+		domain.StatusPosted, nil, nil, "0", "0", nil, time.Now()}
+	for i, v := range values {
+		switch d := dest[i].(type) {
+		case *int:
+			*d = v.(int)
+		case **int:
+			if v != nil {
+				*d = v.(*int)
+			}
+		case *bool:
+			*d = v.(bool)
+		case *sql.NullInt64:
+			if v != nil {
+				*d = sql.NullInt64{Int64: v.(int64), Valid: true}
+			}
+		case *sql.NullString:
+			if v != nil {
+				*d = sql.NullString{String: v.(string), Valid: true}
+			}
+		case *domain.Money:
+			if err := d.Scan(v); err != nil {
+				return err
+			}
+		case *domain.TransactionStatus:
+			*d = v.(domain.TransactionStatus)
+		case *time.Time:
+			*d = v.(time.Time)
+		}
+	}
+	return nil
+}
+
+func (r *transactionRows) Close()                        {}
+func (r *transactionRows) Err() error                    { return nil }
+func (r *transactionRows) CommandTag() pgconn.CommandTag { return pgconn.CommandTag{} }
+
+// BenchmarkListTransactions scans a 10k row history page and reports how many queries it took
+// to resolve the currencies of the transactions.
+func BenchmarkListTransactions(b *testing.B) {
+	loader := &countingLoader{currencies: []domain.Currency{{Id: 1, Symbol: "RUB"}, {Id: 3, Symbol: "USD"}}}
+	q := &Queries{currencies: newCurrencyCatalog(loader.load)}
+	ctx := context.Background()
+
+	if err := q.RefreshCurrencies(ctx); err != nil {
+		b.Fatal(err)
+	}
+	loader.queries = 0
+
+	b.ResetTimer()
+	for i := 0; i < b.N; i++ {
+		transactions, err := q.scanTransactions(ctx, &transactionRows{n: 10000})
+		if err != nil {
+			b.Fatal(err)
+		}
+		if len(transactions) != 10000 {
+			b.Fatalf("scanned %d transactions", len(transactions))
+		}
+	}
+	b.ReportMetric(float64(loader.queries)/float64(b.N), "queries/op")
+}
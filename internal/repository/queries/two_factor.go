@@ -0,0 +1,182 @@
+package queries
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"time"
+
+	"bank-api/internal/domain"
+
+	"github.com/jackc/pgx/v5"
+)
+
+const getTwoFactor = `
+SELECT user_id, secret, last_step, confirmed_at, created_at FROM two_factor
+WHERE user_id = $1
+`
+
+// GetTwoFactor returns the 2FA enrollment of the user, or nil if there is none.
+func (q *Queries) GetTwoFactor(ctx context.Context, userId int) (*domain.TwoFactor, error) {
+	var tf domain.TwoFactor
+	var confirmedAt *time.Time
+	err := q.pool.QueryRow(ctx, getTwoFactor, userId).Scan(&tf.UserId, &tf.Secret, &tf.LastStep, &confirmedAt, &tf.CreatedAt)
+	if errors.Is(err, pgx.ErrNoRows) {
+		return nil, nil
+	}
+	if err != nil {
+		return nil, fmt.Errorf("error getting two-factor enrollment: %w", err)
+	}
+	if confirmedAt != nil {
+		tf.ConfirmedAt = *confirmedAt
+	}
+	return &tf, nil
+}
+
+// An unconfirmed enrollment is replaced, a confirmed one is left alone.
+const enrollTwoFactor = `
+INSERT INTO two_factor (user_id, secret)
+VALUES ($1, $2)
+ON CONFLICT (user_id) DO UPDATE SET secret = excluded.secret, last_step = 0, created_at = CURRENT_TIMESTAMP
+WHERE two_factor.confirmed_at IS NULL
+`
+
+// EnrollTwoFactor saves an unconfirmed enrollment with the secret, replacing an unconfirmed one.
+// It returns domain.ErrTwoFactorEnabled if the user has 2FA enabled already.
+func (q *Queries) EnrollTwoFactor(ctx context.Context, userId int, secret []byte) error {
+	tag, err := q.pool.Exec(ctx, enrollTwoFactor, userId, secret)
+	if err != nil {
+		return fmt.Errorf("error enrolling two-factor authentication: %w", err)
+	}
+	if tag.RowsAffected() == 0 {
+		return domain.ErrTwoFactorEnabled
+	}
+	return nil
+}
+
+const confirmTwoFactor = `
+UPDATE two_factor SET confirmed_at = $2, last_step = $3
+WHERE user_id = $1 AND confirmed_at IS NULL
+`
+
+const createRecoveryCode = `
+INSERT INTO two_factor_recovery_code (user_id, code_hash)
+VALUES ($1, $2)
+`
+
+// ConfirmTwoFactor enables 2FA with the time step of the confirming code and the hashes of the recovery codes.
+// It returns domain.ErrTwoFactorEnabled if 2FA is enabled already.
+func (q *Queries) ConfirmTwoFactor(ctx context.Context, userId int, step int64, recoveryCodes [][]byte, now time.Time) error {
+	err := q.inTx(ctx, func(tx pgx.Tx) error {
+		tag, err := tx.Exec(ctx, confirmTwoFactor, userId, now.UTC(), step)
+		if err != nil {
+			return err
+		}
+		if tag.RowsAffected() == 0 {
+			return domain.ErrTwoFactorEnabled
+		}
+
+		batch := &pgx.Batch{}
+		for _, hash := range recoveryCodes {
+			batch.Queue(createRecoveryCode, userId, hash)
+		}
+		return tx.SendBatch(ctx, batch).Close()
+	})
+	if errors.Is(err, domain.ErrTwoFactorEnabled) {
+		return err
+	}
+	if err != nil {
+		return fmt.Errorf("error confirming two-factor authentication: %w", err)
+	}
+	return nil
+}
+
+const deleteTwoFactor = `
+DELETE FROM two_factor
+WHERE user_id = $1
+`
+
+// DeleteTwoFactor disables 2FA together with the recovery codes.
+func (q *Queries) DeleteTwoFactor(ctx context.Context, userId int) error {
+	if _, err := q.pool.Exec(ctx, deleteTwoFactor, userId); err != nil {
+		return fmt.Errorf("error deleting two-factor enrollment: %w", err)
+	}
+	return nil
+}
+
+const useTwoFactorStep = `
+UPDATE two_factor SET last_step = $2
+WHERE user_id = $1 AND confirmed_at IS NOT NULL AND last_step < $2
+`
+
+// UseTwoFactorStep records that a code of the time step was accepted. It returns false if a code of that
+// step or a later one was accepted before, so each code works only once.
+func (q *Queries) UseTwoFactorStep(ctx context.Context, userId int, step int64) (bool, error) {
+	tag, err := q.pool.Exec(ctx, useTwoFactorStep, userId, step)
+	if err != nil {
+		return false, fmt.Errorf("error using two-factor code: %w", err)
+	}
+	return tag.RowsAffected() == 1, nil
+}
+
+const useRecoveryCode = `
+UPDATE two_factor_recovery_code SET used_at = $3
+WHERE user_id = $1 AND code_hash = $2 AND used_at IS NULL
+`
+
+// UseRecoveryCode marks the recovery code with the given hash used. It returns false if there is no such
+// unused code.
+func (q *Queries) UseRecoveryCode(ctx context.Context, userId int, hash []byte, now time.Time) (bool, error) {
+	tag, err := q.pool.Exec(ctx, useRecoveryCode, userId, hash, now.UTC())
+	if err != nil {
+		return false, fmt.Errorf("error using recovery code: %w", err)
+	}
+	return tag.RowsAffected() == 1, nil
+}
+
+const createTwoFactorChallenge = `
+INSERT INTO two_factor_challenge (user_id, token_hash, expires_at)
+VALUES ($1, $2, $3)
+RETURNING id
+`
+
+func (q *Queries) CreateTwoFactorChallenge(ctx context.Context, c *domain.TwoFactorChallenge) error {
+	if err := q.pool.QueryRow(ctx, createTwoFactorChallenge, c.UserId, c.Hash, c.ExpiresAt.UTC()).Scan(&c.Id); err != nil {
+		return fmt.Errorf("error creating two-factor challenge: %w", err)
+	}
+	return nil
+}
+
+const attemptTwoFactorChallenge = `
+UPDATE two_factor_challenge SET attempts = attempts + 1
+WHERE token_hash = $1 AND used_at IS NULL AND expires_at > $2 AND attempts < $3
+RETURNING id, user_id, attempts, expires_at
+`
+
+// AttemptTwoFactorChallenge counts an attempt to answer the challenge with the given hash and returns it.
+// It returns nil if there is no such challenge, or it is used, expired or out of attempts.
+func (q *Queries) AttemptTwoFactorChallenge(ctx context.Context, hash []byte, now time.Time, maxAttempts int) (*domain.TwoFactorChallenge, error) {
+	c := domain.TwoFactorChallenge{Hash: hash}
+	err := q.pool.QueryRow(ctx, attemptTwoFactorChallenge, hash, now.UTC(), maxAttempts).Scan(&c.Id, &c.UserId, &c.Attempts, &c.ExpiresAt)
+	if errors.Is(err, pgx.ErrNoRows) {
+		return nil, nil
+	}
+	if err != nil {
+		return nil, fmt.Errorf("error attempting two-factor challenge: %w", err)
+	}
+	return &c, nil
+}
+
+const useTwoFactorChallenge = `
+UPDATE two_factor_challenge SET used_at = $2
+WHERE id = $1 AND used_at IS NULL
+`
+
+// UseTwoFactorChallenge marks the challenge answered. It returns false if it was answered already.
+func (q *Queries) UseTwoFactorChallenge(ctx context.Context, id int, now time.Time) (bool, error) {
+	tag, err := q.pool.Exec(ctx, useTwoFactorChallenge, id, now.UTC())
+	if err != nil {
+		return false, fmt.Errorf("error using two-factor challenge: %w", err)
+	}
+	return tag.RowsAffected() == 1, nil
+}
@@ -0,0 +1,106 @@
+package queries
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"math/rand"
+	"time"
+
+	"bank-api/internal/domain"
+
+	"github.com/jackc/pgx/v5"
+	"github.com/jackc/pgx/v5/pgconn"
+)
+
+const (
+	maxTxAttempts    = 5
+	txRetryBaseDelay = 10 * time.Millisecond
+
+	serializationFailure = "40001"
+	deadlockDetected     = "40P01"
+	uniqueViolation      = "23505"
+)
+
+// execer is implemented by both the pool and a transaction.
+type execer interface {
+	Exec(ctx context.Context, sql string, arguments ...any) (pgconn.CommandTag, error)
+}
+
+// inTx runs fn in a serializable transaction, retrying it with exponential backoff
+// when Postgres aborts it because of a serialization failure or a deadlock.
+func (q *Queries) inTx(ctx context.Context, fn func(tx pgx.Tx) error) error {
+	for attempt := 1; ; attempt++ {
+		err := pgx.BeginTxFunc(ctx, q.pool, pgx.TxOptions{IsoLevel: pgx.Serializable}, fn)
+		if err == nil || !isRetryable(err) || attempt == maxTxAttempts {
+			return err
+		}
+
+		delay := txRetryBaseDelay << (attempt - 1)
+		delay += time.Duration(rand.Int63n(int64(delay)))
+		select {
+		case <-ctx.Done():
+			return ctx.Err()
+		case <-time.After(delay):
+		}
+	}
+}
+
+func isRetryable(err error) bool {
+	var pgErr *pgconn.PgError
+	if !errors.As(err, &pgErr) {
+		return false
+	}
+	return pgErr.Code == serializationFailure || pgErr.Code == deadlockDetected
+}
+
+type lockedAccount struct {
+	currencyId int
+	balance    domain.Money
+	held       domain.Money
+	frozen     bool
+}
+
+// available is the part of the balance that isn't reserved by holds.
+func (a *lockedAccount) available() domain.Money {
+	available, _ := a.balance.Sub(a.held)
+	return available
+}
+
+const lockAccountsForUpdate = `
+SELECT id, currency_id, amount, ` + heldAmount + `, frozen_at IS NOT NULL FROM account
+WHERE id = ANY($1) AND kind = 'customer'
+ORDER BY id
+FOR UPDATE
+`
+
+// lockAccounts locks the accounts in ascending id order, so that concurrent transactions
+// touching the same accounts always wait for each other instead of deadlocking.
+func lockAccounts(ctx context.Context, tx pgx.Tx, ids ...int) (map[int]*lockedAccount, error) {
+	rows, err := tx.Query(ctx, lockAccountsForUpdate, ids)
+	if err != nil {
+		return nil, fmt.Errorf("error locking accounts: %w", err)
+	}
+	defer rows.Close()
+
+	accounts := make(map[int]*lockedAccount, len(ids))
+	for rows.Next() {
+		var id int
+		var account lockedAccount
+		if err := rows.Scan(&id, &account.currencyId, &account.balance, &account.held, &account.frozen); err != nil {
+			return nil, fmt.Errorf("error locking account: %w", err)
+		}
+		accounts[id] = &account
+	}
+	if err := rows.Err(); err != nil {
+		return nil, fmt.Errorf("error locking accounts: %w", err)
+	}
+
+	for _, id := range ids {
+		if _, ok := accounts[id]; !ok {
+			return nil, fmt.Errorf("error locking account %d: %w", id, pgx.ErrNoRows)
+		}
+	}
+
+	return accounts, nil
+}
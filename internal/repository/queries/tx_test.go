@@ -0,0 +1,178 @@
+package queries
+
+import (
+	"context"
+	"errors"
+	"math/rand"
+	"sync"
+	"testing"
+	"time"
+
+	"bank-api/internal/domain"
+
+	"github.com/jackc/pgx/v5"
+	"github.com/jackc/pgx/v5/pgconn"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestInTx_Retry(t *testing.T) {
+	q := testQueries(t)
+	ctx := context.Background()
+
+	calls := 0
+	err := q.inTx(ctx, func(tx pgx.Tx) error {
+		calls++
+		if calls < 3 {
+			return &pgconn.PgError{Code: serializationFailure}
+		}
+		return nil
+	})
+	assert.NoError(t, err)
+	assert.Equal(t, 3, calls)
+
+	calls = 0
+	err = q.inTx(ctx, func(tx pgx.Tx) error {
+		calls++
+		return &pgconn.PgError{Code: deadlockDetected}
+	})
+	assert.True(t, isRetryable(err))
+	assert.Equal(t, maxTxAttempts, calls)
+
+	// Other errors aren't retried.
+	calls = 0
+	err = q.inTx(ctx, func(tx pgx.Tx) error {
+		calls++
+		return domain.ErrInsufficientFunds
+	})
+	assert.ErrorIs(t, err, domain.ErrInsufficientFunds)
+	assert.Equal(t, 1, calls)
+}
+
+// TestTransaction_Retried makes a withdrawal wait for the lock of its account while another transaction
+// updates the account, so that its first attempt fails with a serialization failure, and checks that
+// the retry takes the money out just once.
+func TestTransaction_Retried(t *testing.T) {
+	q := testQueries(t)
+	ctx := context.Background()
+	account := newTestAccount(t, q, "USD", 10000)
+
+	blocker, err := q.pool.Begin(ctx)
+	require.NoError(t, err)
+	defer blocker.Rollback(ctx)
+	_, err = blocker.Exec(ctx, `UPDATE account SET amount = amount WHERE id = $1`, account.Id)
+	require.NoError(t, err)
+
+	done := make(chan error, 1)
+	go func() {
+		done <- q.Transaction(ctx, account.Id, domain.NewMoney(3000, account.Cur), domain.Withdraw)
+	}()
+	require.Eventually(t, func() bool {
+		var waiting int
+		err := q.pool.QueryRow(ctx, `SELECT count(*) FROM pg_stat_activity WHERE wait_event_type = 'Lock' AND datname = current_database()`).Scan(&waiting)
+		return err == nil && waiting > 0
+	}, 5*time.Second, 10*time.Millisecond)
+	require.NoError(t, blocker.Commit(ctx))
+	require.NoError(t, <-done)
+
+	got, err := q.GetAccount(ctx, account.Id)
+	require.NoError(t, err)
+	assert.Equal(t, 0, got.Amount.Cmp(domain.NewMoney(7000, account.Cur)), "balance: %s", got.Amount)
+}
+
+// TestTransfer_Concurrent sends money back and forth between a few accounts from many connections.
+// Transfers in opposite directions lock the same rows, so without ordered locks they deadlock,
+// and without the locks or the serializable retries balances go negative or money appears.
+func TestTransfer_Concurrent(t *testing.T) {
+	const (
+		accounts  = 4
+		workers   = 8
+		transfers = 50
+		initial   = 10000
+	)
+
+	q := testQueries(t)
+	ctx := context.Background()
+
+	ids := make([]int, accounts)
+	var cur domain.Currency
+	for i := range ids {
+		account := newTestAccount(t, q, "USD", initial)
+		ids[i], cur = account.Id, account.Cur
+	}
+
+	var wg sync.WaitGroup
+	errs := make(chan error, workers*transfers)
+	for w := 0; w < workers; w++ {
+		wg.Add(1)
+		go func(seed int64) {
+			defer wg.Done()
+			rnd := rand.New(rand.NewSource(seed))
+			for i := 0; i < transfers; i++ {
+				from := rnd.Intn(accounts)
+				to := (from + 1 + rnd.Intn(accounts-1)) % accounts
+				amount := domain.NewMoney(int64(rnd.Intn(5000)+1), cur)
+				err := q.Transfer(ctx, &domain.Transaction{
+					FromAccountId: ids[from],
+					ToAccountId:   ids[to],
+					Cur:           cur,
+					Amount:        amount,
+					ToCur:         cur,
+					ToAmount:      amount,
+				})
+				if err != nil && !errors.Is(err, domain.ErrInsufficientFunds) {
+					errs <- err
+				}
+			}
+		}(int64(w))
+	}
+	wg.Wait()
+	close(errs)
+
+	for err := range errs {
+		assert.NoError(t, err)
+	}
+
+	total := domain.Money{}
+	for _, id := range ids {
+		account, err := q.GetAccount(ctx, id)
+		require.NoError(t, err)
+		assert.GreaterOrEqual(t, account.Amount.Sign(), 0, "account %d is overdrawn", id)
+		total, _ = total.Add(account.Amount)
+	}
+	expected := domain.NewMoney(initial*accounts, cur)
+	assert.Equal(t, 0, expected.Cmp(total), "money was created or lost: expected %s, got %s", expected, total)
+}
+
+func TestFailStalePendingTransactions(t *testing.T) {
+	q := testQueries(t)
+	ctx := context.Background()
+	from := newTestAccount(t, q, "USD", 10000)
+	to := newTestAccount(t, q, "USD", 0)
+
+	// A transfer recorded but never posted, as if the instance crashed in between.
+	amount := domain.NewMoney(1000, from.Cur)
+	stale := &domain.Transaction{FromAccountId: from.Id, ToAccountId: to.Id, Cur: from.Cur, Amount: amount, ToCur: to.Cur, ToAmount: amount}
+	staleId, err := q.addTransfer(ctx, stale)
+	require.NoError(t, err)
+	posted := &domain.Transaction{FromAccountId: from.Id, ToAccountId: to.Id, Cur: from.Cur, Amount: amount, ToCur: to.Cur, ToAmount: amount}
+	require.NoError(t, q.Transfer(ctx, posted))
+
+	n, err := q.FailStalePendingTransactions(ctx, time.Now().Add(time.Minute))
+	require.NoError(t, err)
+	assert.GreaterOrEqual(t, n, 1)
+
+	got, err := q.GetTransaction(ctx, staleId)
+	require.NoError(t, err)
+	assert.Equal(t, domain.StatusFailed, got.Status)
+	assert.Equal(t, abandonedReason, got.FailureReason)
+	got, err = q.GetTransaction(ctx, posted.Id)
+	require.NoError(t, err)
+	assert.Equal(t, domain.StatusPosted, got.Status)
+
+	// Posting it now fails instead of moving the money.
+	err = q.inTx(ctx, func(tx pgx.Tx) error {
+		return setTransactionStatus(ctx, tx, staleId, domain.StatusPending, domain.StatusPosted, "")
+	})
+	assert.ErrorIs(t, err, domain.ErrInvalidStatusTransition)
+}
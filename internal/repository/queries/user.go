@@ -2,36 +2,76 @@ package queries
 
 import (
 	"context"
+	"errors"
 	"fmt"
+	"time"
 
 	"bank-api/internal/domain"
+
+	"github.com/jackc/pgx/v5"
 )
 
+const userColumns = `id, name, email, password, role, email_verified_at, pending_email, created_at`
+
 const createUser = `
 INSERT INTO "user" (name, email, password)
-VALUES ($1, $2, $3) RETURNING id, name, email, password, created_at
-`
+VALUES ($1, $2, $3) RETURNING ` + userColumns
 
 func (q *Queries) CreateUser(ctx context.Context, newUserInfo *domain.UserInfo) (*domain.User, error) {
-	var user domain.User
-	err := q.pool.QueryRow(ctx, createUser, newUserInfo.Name, newUserInfo.Email, newUserInfo.Password).Scan(&user.Id, &user.Name, &user.Email, &user.HashedPassword, &user.CreatedAt)
+	user, err := scanUser(q.pool.QueryRow(ctx, createUser, newUserInfo.Name, newUserInfo.Email, newUserInfo.Password))
 	if err != nil {
 		return nil, fmt.Errorf("error creating user: %w", err)
 	}
-	return &user, nil
+	return user, nil
 }
 
 const getUser = `
-SELECT id, name, email, password,created_at
+SELECT ` + userColumns + `
 FROM "user"
 WHERE id = $1
 `
 
 func (q *Queries) GetUser(ctx context.Context, id int) (*domain.User, error) {
-	var user domain.User
-	if err := q.pool.QueryRow(ctx, getUser, id).Scan(&user.Id, &user.Name, &user.Email, &user.HashedPassword, &user.CreatedAt); err != nil {
+	user, err := scanUser(q.pool.QueryRow(ctx, getUser, id))
+	if err != nil {
 		return nil, fmt.Errorf("error getting user: %w", err)
 	}
+	return user, nil
+}
+
+const getUserByEmail = `
+SELECT ` + userColumns + `
+FROM "user"
+WHERE email = $1
+`
+
+// GetUserByEmail returns the user with the given email, or nil if there is none.
+func (q *Queries) GetUserByEmail(ctx context.Context, email string) (*domain.User, error) {
+	user, err := scanUser(q.pool.QueryRow(ctx, getUserByEmail, email))
+	if errors.Is(err, pgx.ErrNoRows) {
+		return nil, nil
+	}
+	if err != nil {
+		return nil, fmt.Errorf("error getting user by email: %w", err)
+	}
+	return user, nil
+}
+
+// scanUser scans the userColumns of a row.
+func scanUser(row pgx.Row) (*domain.User, error) {
+	var user domain.User
+	var verifiedAt *time.Time
+	var pendingEmail *string
+	err := row.Scan(&user.Id, &user.Name, &user.Email, &user.HashedPassword, &user.Role, &verifiedAt, &pendingEmail, &user.CreatedAt)
+	if err != nil {
+		return nil, err
+	}
+	if verifiedAt != nil {
+		user.EmailVerifiedAt = *verifiedAt
+	}
+	if pendingEmail != nil {
+		user.PendingEmail = *pendingEmail
+	}
 	return &user, nil
 }
 
@@ -83,17 +123,17 @@ func (q *Queries) UserExistsById(ctx context.Context, id int) (bool, error) {
 
 const UpdateUser = `
 UPDATE "user"
-SET name = $2, email = $3
+SET name = $2
 WHERE id = $1
-RETURNING id, name, email, created_at
-`
+RETURNING ` + userColumns
 
+// UpdateUser changes the name of the user. The email only changes once the new one is verified.
 func (q *Queries) UpdateUser(ctx context.Context, id int, userInfo *domain.UserInfo) (*domain.User, error) {
-	var user domain.User
-	if err := q.pool.QueryRow(ctx, UpdateUser, id, userInfo.Name, userInfo.Email).Scan(&user.Id, &user.Name, &user.Email, &user.CreatedAt); err != nil {
+	user, err := scanUser(q.pool.QueryRow(ctx, UpdateUser, id, userInfo.Name))
+	if err != nil {
 		return nil, fmt.Errorf("error while updating user: %w", err)
 	}
-	return &user, nil
+	return user, nil
 }
 
 const DeleteUser = `
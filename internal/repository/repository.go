@@ -2,6 +2,7 @@ package repository
 
 import (
 	"context"
+	"time"
 
 	"bank-api/internal/domain"
 	"bank-api/internal/repository/queries"
@@ -16,24 +17,149 @@ type UserRepository interface {
 	UserExistsByEmail(ctx context.Context, email string) (bool, error)
 	UserExistsById(ctx context.Context, id int) (bool, error)
 	GetUserIdByEmail(ctx context.Context, email string) (int, error)
+	// GetUserByEmail returns nil if there is no user with the email.
+	GetUserByEmail(ctx context.Context, email string) (*domain.User, error)
 	UpdateUser(ctx context.Context, id int, userInfo *domain.UserInfo) (*domain.User, error)
 	DeleteUser(ctx context.Context, id int) error
+
+	GetTwoFactor(ctx context.Context, userId int) (*domain.TwoFactor, error)
+	EnrollTwoFactor(ctx context.Context, userId int, secret []byte) error
+	ConfirmTwoFactor(ctx context.Context, userId int, step int64, recoveryCodes [][]byte, now time.Time) error
+	DeleteTwoFactor(ctx context.Context, userId int) error
+	// UseTwoFactorStep returns false if a code of the step, or a later one, was already accepted.
+	UseTwoFactorStep(ctx context.Context, userId int, step int64) (bool, error)
+	UseRecoveryCode(ctx context.Context, userId int, hash []byte, now time.Time) (bool, error)
+	CreateTwoFactorChallenge(ctx context.Context, c *domain.TwoFactorChallenge) error
+	// AttemptTwoFactorChallenge counts an attempt and returns the challenge, or nil if it can't be answered anymore.
+	AttemptTwoFactorChallenge(ctx context.Context, hash []byte, now time.Time, maxAttempts int) (*domain.TwoFactorChallenge, error)
+	UseTwoFactorChallenge(ctx context.Context, id int, now time.Time) (bool, error)
+
+	// GetLoginBlock returns the zero time if logins with none of the keys are blocked at now.
+	GetLoginBlock(ctx context.Context, keys []string, now time.Time) (time.Time, error)
+	RecordLoginFailure(ctx context.Context, key string, now time.Time, window time.Duration) (int, error)
+	BlockLogin(ctx context.Context, key string, until time.Time) error
+	ClearLoginFailures(ctx context.Context, key string) error
+
+	// UpdatePassword also voids the password reset tokens of the user.
+	UpdatePassword(ctx context.Context, userId int, hashedPassword string, now time.Time) error
+	// CreatePasswordResetToken returns false if the user got a token less than interval before.
+	CreatePasswordResetToken(ctx context.Context, token *domain.PasswordResetToken, interval time.Duration) (bool, error)
+	// ResetPassword returns the id of the user whose password it set, or 0 if the token can't be used.
+	ResetPassword(ctx context.Context, hash []byte, hashedPassword string, now time.Time) (int, error)
+	// RequestPasswordReset stores a request for the email, whether or not it's the email of a user.
+	RequestPasswordReset(ctx context.Context, email string, now time.Time) error
+	// ListPasswordResetRequests returns the emails of up to limit requests, the oldest first.
+	ListPasswordResetRequests(ctx context.Context, limit int) ([]string, error)
+	DeletePasswordResetRequest(ctx context.Context, email string) error
+
+	// CreateEmailVerification makes v.Email the pending email of the user if it isn't their email, and returns
+	// false if the user got a verification less than interval before.
+	CreateEmailVerification(ctx context.Context, v *domain.EmailVerification, interval time.Duration) (bool, error)
+	// VerifyEmail returns the id of the user whose email it verified, or 0 if the token can't be used.
+	VerifyEmail(ctx context.Context, hash []byte, now time.Time) (int, error)
+	// ApplyUserUpdate returns false, and changes nothing, if the update has a verification and the user got one
+	// less than interval before.
+	ApplyUserUpdate(ctx context.Context, userId int, update *domain.UserUpdate, interval time.Duration) (bool, error)
 }
 
 type AccountRepository interface {
 	UserExistsById(ctx context.Context, id int) (bool, error)
 	GetCurrencyId(ctx context.Context, cur domain.Currency) (int, error)
+	// RefreshCurrencies reloads the in-memory currency catalog that currency lookups are served from.
+	RefreshCurrencies(ctx context.Context) error
 	CurrencyExists(ctx context.Context, cur domain.Currency) (bool, error)
 	AccountExists(ctx context.Context, id int) (bool, error)
 	CreateAccount(ctx context.Context, userId int, cur domain.Currency) (*domain.Account, error)
 	GetAccount(ctx context.Context, id int) (*domain.Account, error)
-	UpdateAccount(ctx context.Context, id int, amount int) (*domain.Account, error)
+	UpdateAccount(ctx context.Context, id int, amount domain.Money) (*domain.Account, error)
 	DeleteAccount(ctx context.Context, id int) error
 
-	Transaction(ctx context.Context, accountId int, amount int, t domain.TransactionType) error
-	Transfer(ctx context.Context, fromAccountId int, toAccountId int, amount int) error
+	Transaction(ctx context.Context, accountId int, amount domain.Money, t domain.TransactionType) error
+	Transfer(ctx context.Context, t *domain.Transaction) error
+	Reverse(ctx context.Context, original *domain.Transaction, reversal *domain.Transaction) error
+	// FailStalePendingTransactions marks the transactions pending since before the time failed and returns how many there were.
+	FailStalePendingTransactions(ctx context.Context, before time.Time) (int, error)
+
+	GetTransaction(ctx context.Context, id int) (*domain.Transaction, error)
+	ListTransactions(ctx context.Context, filter *domain.TransactionFilter) ([]*domain.Transaction, error)
+
+	CreateHold(ctx context.Context, hold *domain.Hold, ttl time.Duration) error
+	GetHold(ctx context.Context, id int) (*domain.Hold, error)
+	CaptureHold(ctx context.Context, capture *domain.Transaction) error
+	ReleaseHold(ctx context.Context, id int) error
+	ExpireHolds(ctx context.Context) (int, error)
+
+	CheckLedger(ctx context.Context) (*domain.LedgerReport, error)
+	// GetBalanceAt returns the balance the account had right before at.
+	GetBalanceAt(ctx context.Context, accountId int, at time.Time) (domain.Money, error)
+	// ListAdjustments returns the journal entries of the account between from and to that aren't part of a transaction.
+	ListAdjustments(ctx context.Context, accountId int, from, to time.Time) ([]*domain.Adjustment, error)
+}
+
+type IdempotencyRepository interface {
+	CreateIdempotencyKey(ctx context.Context, record *domain.IdempotencyRecord) (bool, error)
+	GetIdempotencyKey(ctx context.Context, userId int, key string) (*domain.IdempotencyRecord, error)
+	SaveIdempotentResponse(ctx context.Context, userId int, key string, resp *domain.IdempotentResponse) error
+	DeleteIdempotencyKey(ctx context.Context, userId int, key string) error
+}
+
+type ExchangeRepository interface {
+	ListCurrencies(ctx context.Context) ([]domain.Currency, error)
+	GetRate(ctx context.Context, from domain.Currency, to domain.Currency, at time.Time) (*domain.ExchangeRate, error)
+	UpsertRates(ctx context.Context, rates []*domain.ExchangeRate) error
+	CreateQuote(ctx context.Context, quote *domain.Quote) error
+	GetQuote(ctx context.Context, id int) (*domain.Quote, error)
+}
 
-	ListTransactions(ctx context.Context, accountId int) ([]*domain.Transaction, error)
+type StatementRepository interface {
+	MissingStatements(ctx context.Context, before time.Time) ([]*domain.StatementFile, error)
+	SaveStatementFile(ctx context.Context, file *domain.StatementFile) error
+	ListStatementFiles(ctx context.Context, accountId int) ([]*domain.StatementFile, error)
+	GetStatementFile(ctx context.Context, id int) (*domain.StatementFile, error)
+}
+
+type StandingOrderRepository interface {
+	CreateStandingOrder(ctx context.Context, o *domain.StandingOrder) error
+	GetStandingOrder(ctx context.Context, id int) (*domain.StandingOrder, error)
+	ListStandingOrders(ctx context.Context, userId int) ([]*domain.StandingOrder, error)
+	UpdateStandingOrder(ctx context.Context, o *domain.StandingOrder) error
+	ClaimDueStandingOrders(ctx context.Context, now time.Time, lease time.Duration, limit int) ([]*domain.StandingOrder, error)
+	RecordStandingOrderExecution(ctx context.Context, o *domain.StandingOrder, e *domain.StandingOrderExecution) error
+	ListStandingOrderExecutions(ctx context.Context, orderId int) ([]*domain.StandingOrderExecution, error)
+}
+
+type SessionRepository interface {
+	CreateSession(ctx context.Context, session *domain.Session) error
+	GetSession(ctx context.Context, id int) (*domain.Session, error)
+	RevokeSession(ctx context.Context, id int) error
+	// RevokeUserSessions revokes the sessions of the user but exceptId, and returns the ids of the ones it revoked.
+	RevokeUserSessions(ctx context.Context, userId int, exceptId int) ([]int, error)
+	CreateRefreshToken(ctx context.Context, token *domain.RefreshToken) error
+	// UseRefreshToken marks the token used and returns it as it was before, or nil if there is no such token.
+	UseRefreshToken(ctx context.Context, hash []byte, now time.Time) (*domain.RefreshToken, error)
+}
+
+type APIKeyRepository interface {
+	CreateAPIKey(ctx context.Context, k *domain.APIKey) error
+	GetAPIKey(ctx context.Context, id int) (*domain.APIKey, error)
+	GetAPIKeyByPrefix(ctx context.Context, prefix string) (*domain.APIKey, error)
+	ListAPIKeys(ctx context.Context, userId int) ([]*domain.APIKey, error)
+	RevokeAPIKey(ctx context.Context, id int, now time.Time) error
+	TouchAPIKey(ctx context.Context, id int, now time.Time) error
+}
+
+// AdminRepository is what staff see and change through the admin API. Changes are recorded in the audit log
+// in the same transaction as the change itself.
+type AdminRepository interface {
+	SearchUsers(ctx context.Context, filter *domain.UserFilter) ([]*domain.User, error)
+	ListUserAccounts(ctx context.Context, userId int) ([]*domain.Account, error)
+	SetUserRole(ctx context.Context, userId int, role domain.Role, entry *domain.AuditEntry) error
+	// FreezeAccount returns false if the account is already frozen.
+	FreezeAccount(ctx context.Context, accountId int, reason string, now time.Time, entry *domain.AuditEntry) (bool, error)
+	// UnfreezeAccount returns false if the account isn't frozen.
+	UnfreezeAccount(ctx context.Context, accountId int, entry *domain.AuditEntry) (bool, error)
+	RecordAudit(ctx context.Context, entry *domain.AuditEntry) error
+	ListAuditEntries(ctx context.Context, filter *domain.AuditFilter) ([]*domain.AuditEntry, error)
 }
 
 type repo struct {
@@ -42,12 +168,35 @@ type repo struct {
 	logger *zap.SugaredLogger
 }
 
-func New(pgxPool *pgxpool.Pool, logger *zap.SugaredLogger) (UserRepository, AccountRepository) {
+// Repositories are the repositories of every part of the service, all backed by the same database.
+type Repositories struct {
+	Users          UserRepository
+	Accounts       AccountRepository
+	Idempotency    IdempotencyRepository
+	Exchange       ExchangeRepository
+	Statements     StatementRepository
+	StandingOrders StandingOrderRepository
+	Sessions       SessionRepository
+	APIKeys        APIKeyRepository
+	Admin          AdminRepository
+}
+
+func New(pgxPool *pgxpool.Pool, logger *zap.SugaredLogger) *Repositories {
 	r := &repo{
 		Queries: queries.New(pgxPool),
 		pool:    pgxPool,
 		logger:  logger,
 	}
 
-	return r, r
+	return &Repositories{
+		Users:          r,
+		Accounts:       r,
+		Idempotency:    r,
+		Exchange:       r,
+		Statements:     r,
+		StandingOrders: r,
+		Sessions:       r,
+		APIKeys:        r,
+		Admin:          r,
+	}
 }
@@ -1,6 +1,7 @@
 package router
 
 import (
+	"bank-api/internal/domain"
 	"bank-api/internal/handlers"
 	"bank-api/internal/middleware"
 
@@ -8,34 +9,103 @@ import (
 	httpSwagger "github.com/swaggo/http-swagger"
 )
 
-func NewRouter(h *handlers.Handler) *gin.Engine {
+// NewRouter serves the routes of the handler. The 2FA routes are only served with twoFactor enabled.
+func NewRouter(h *handlers.Handler, trustedProxies []string, twoFactor bool) (*gin.Engine, error) {
 	r := gin.Default()
+	// The client IP address is what rate limits and login lockouts count by, so it mustn't be spoofed.
+	if err := r.SetTrustedProxies(trustedProxies); err != nil {
+		return nil, err
+	}
 
 	r.Use(middleware.RateLimiter(1000))
 
+	r.GET("/.well-known/jwks.json", h.JWKS())
+
 	r.POST("/user/signup", h.SignUp())
 	r.POST("/user/login", h.Login())
+	if twoFactor {
+		r.POST("/user/login/2fa", h.LoginTwoFactor())
+	}
+	r.POST("/user/password/reset", h.RequestPasswordReset())
+	r.POST("/user/password/reset/confirm", h.ResetPassword())
+	r.POST("/user/email/verify", h.VerifyEmail())
+	r.POST("/user/token/refresh", middleware.RequireCSRF(handlers.RefreshCookie), h.RefreshToken())
 
-	auth := r.Group("/")
+	jwt := &middleware.Jwt{Tokens: h.JWT, Sessions: h.Sessions()}
+	keys := &middleware.APIKey{Jwt: jwt, Keys: h.APIKeys()}
 
-	jwt := middleware.Jwt{Secret: h.JwtSecret}
+	// Routes only users logged in with a session can use.
+	auth := r.Group("/")
 	auth.Use(jwt.RequireAuth)
 	{
 		auth.GET("user", h.GetUser())
 		auth.PUT("user", h.UpdateUser())
 		auth.PATCH("user", h.UpdateUser())
 		auth.DELETE("user", h.DeleteUser())
+		auth.POST("user/logout", h.Logout())
+		auth.POST("user/password", h.ChangePassword())
+		auth.POST("user/email/resend", h.ResendVerification())
+		if twoFactor {
+			auth.POST("user/2fa/enroll", h.EnrollTwoFactor())
+			auth.POST("user/2fa/confirm", h.ConfirmTwoFactor())
+			auth.DELETE("user/2fa", h.DisableTwoFactor())
+		}
+
+		auth.POST("api-keys", h.NewAPIKey())
+		auth.GET("api-keys", h.ListAPIKeys())
+		auth.DELETE("api-keys/:id", h.RevokeAPIKey())
+	}
+
+	// Routes API keys with the scope of the route can use as well. Moving money needs a verified email.
+	scope := keys.RequireScope
+	verified := middleware.RequireVerifiedEmail(h.Emails())
+	api := r.Group("/")
+	{
+		api.POST("account", scope(domain.ScopeAccountsWrite), h.NewAccount())
+		api.GET("account/:id", scope(domain.ScopeAccountsRead), h.GetAccount())
+		api.DELETE("account/:id", scope(domain.ScopeAccountsWrite), h.DeleteAccount())
+		api.GET("account/:id/transactions", scope(domain.ScopeHistoryRead), h.ListAccountTransactions())
+		api.GET("account/:id/statement", scope(domain.ScopeAccountsRead), h.GetStatement())
+		api.GET("account/:id/statements", scope(domain.ScopeAccountsRead), h.ListStatements())
+		api.GET("account/:id/statements/:statementId", scope(domain.ScopeAccountsRead), h.DownloadStatement())
 
-		auth.POST("account", h.NewAccount())
-		auth.GET("account/:id", h.GetAccount())
-		auth.DELETE("account/:id", h.DeleteAccount())
+		api.POST("account/:id/deposit", scope(domain.ScopeTransactionsWrite), verified, h.Idempotent(), h.Deposit())
+		api.POST("account/:id/withdraw", scope(domain.ScopeTransactionsWrite), verified, h.Idempotent(), h.Withdraw())
+		api.POST("account/:id/holds", scope(domain.ScopeTransactionsWrite), verified, h.Idempotent(), h.NewHold())
 
-		auth.POST("account/:id/deposit", h.Deposit())
-		auth.POST("account/:id/withdraw", h.Withdraw())
+		api.POST("account/transfer", scope(domain.ScopeTransactionsWrite), verified, h.Idempotent(), h.Transfer())
+
+		api.POST("fx/quotes", scope(domain.ScopeTransactionsWrite), h.NewQuote())
+
+		api.POST("transactions/:id/reverse", scope(domain.ScopeTransactionsWrite), verified, h.Idempotent(), h.ReverseTransaction())
+
+		api.POST("holds/:id/capture", scope(domain.ScopeTransactionsWrite), verified, h.Idempotent(), h.CaptureHold())
+		api.POST("holds/:id/release", scope(domain.ScopeTransactionsWrite), h.ReleaseHold())
+
+		api.POST("standing-orders", scope(domain.ScopeStandingOrdersWrite), verified, h.Idempotent(), h.NewStandingOrder())
+		api.GET("standing-orders", scope(domain.ScopeStandingOrdersRead), h.ListStandingOrders())
+		api.PATCH("standing-orders/:id", scope(domain.ScopeStandingOrdersWrite), verified, h.UpdateStandingOrder())
+		api.DELETE("standing-orders/:id", scope(domain.ScopeStandingOrdersWrite), h.CancelStandingOrder())
+		api.GET("standing-orders/:id/executions", scope(domain.ScopeStandingOrdersRead), h.ListStandingOrderExecutions())
+
+		api.GET("history", scope(domain.ScopeHistoryRead), h.ListTransactions())
+	}
+
+	// Routes of staff, for the roles with the permission of the route and system keys with its admin scope.
+	perm := middleware.RequirePermission
+	admin := r.Group("/admin")
+	admin.Use(keys.RequireSystemKey)
+	{
+		admin.GET("users", perm(domain.PermUsersRead), h.SearchUsers())
+		admin.GET("users/:id", perm(domain.PermUsersRead), h.AdminGetUser())
+		admin.PUT("users/:id/role", perm(domain.PermUsersManage), h.SetUserRole())
 
-		auth.POST("account/transfer", h.Transfer())
+		admin.GET("accounts/:id", perm(domain.PermAccountsRead), h.AdminGetAccount())
+		admin.GET("accounts/:id/transactions", perm(domain.PermAccountsRead), h.AdminListAccountTransactions())
+		admin.POST("accounts/:id/freeze", perm(domain.PermAccountsFreeze), h.FreezeAccount())
+		admin.POST("accounts/:id/unfreeze", perm(domain.PermAccountsFreeze), h.UnfreezeAccount())
 
-		auth.GET("history", h.ListTransactions())
+		admin.GET("audit-log", perm(domain.PermAuditRead), h.ListAuditLog())
 	}
 
 	r.GET("/swagger/*any", gin.WrapH(httpSwagger.Handler(
@@ -43,5 +113,5 @@ func NewRouter(h *handlers.Handler) *gin.Engine {
 	)))
 	r.StaticFile("/swagger.yaml", "./docs/openapi.yaml")
 
-	return r
+	return r, nil
 }
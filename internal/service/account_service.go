@@ -13,12 +13,13 @@ var (
 	ErrInvalidAccount = errors.New("invalid account")
 	ErrNoSuchAccount  = errors.New("no such account")
 	ErrNoSuchCurrency = errors.New("no such currency")
+	ErrAccountFrozen  = errors.New("account is frozen")
 )
 
 type AccountService interface {
 	CreateAccount(ctx context.Context, userId int, cur domain.Currency) (*domain.Account, error)
 	GetAccount(ctx context.Context, userId int, accountId int) (*domain.Account, error)
-	UpdateAccount(ctx context.Context, userId int, accountId int, amount int) (*domain.Account, error)
+	UpdateAccount(ctx context.Context, userId int, accountId int, amount domain.Money) (*domain.Account, error)
 	DeleteAccount(ctx context.Context, userId int, accountId int) error
 }
 
@@ -90,7 +91,7 @@ func (s *accountService) GetAccount(ctx context.Context, userId int, accountId i
 	return account, nil
 }
 
-func (s *accountService) UpdateAccount(ctx context.Context, userId int, accountId int, amount int) (*domain.Account, error) {
+func (s *accountService) UpdateAccount(ctx context.Context, userId int, accountId int, amount domain.Money) (*domain.Account, error) {
 	ok, err := s.repo.AccountExists(ctx, accountId)
 	if err != nil {
 		return nil, fmt.Errorf("can't check if account exists: %w", err)
@@ -115,6 +116,15 @@ func (s *accountService) UpdateAccount(ctx context.Context, userId int, accountI
 	if account.UserId != userId {
 		return nil, ErrInvalidAccount
 	}
+
+	amount, err = amountIn(amount, account.Cur)
+	if err != nil {
+		return nil, err
+	}
+	if amount.Sign() < 0 {
+		return nil, ErrInvalidAmount
+	}
+
 	account, err = s.repo.UpdateAccount(ctx, accountId, amount)
 	if err != nil {
 		return nil, fmt.Errorf("can't update account: %w", err)
@@ -11,13 +11,21 @@ import (
 	"go.uber.org/mock/gomock"
 )
 
+var rub = domain.Currency{Id: 1, Symbol: "RUB"}
+
+func money(t *testing.T, s string) domain.Money {
+	m, err := domain.ParseMoney(s)
+	assert.NoError(t, err)
+	return m
+}
+
 func TestCreateAccount(t *testing.T) {
 	mockRepo := mocks.NewMockAccountRepository(gomock.NewController(t))
 
 	mockRepo.EXPECT().CurrencyExists(gomock.Any(), domain.Currency{Symbol: "RUB"}).Return(true, nil)
 	mockRepo.EXPECT().UserExistsById(gomock.Any(), 1).Return(true, nil)
 	mockRepo.EXPECT().GetCurrencyId(gomock.Any(), domain.Currency{Symbol: "RUB"}).Return(1, nil)
-	mockRepo.EXPECT().CreateAccount(gomock.Any(), 1, domain.Currency{Id: 1, Symbol: "RUB"}).Return(&domain.Account{Id: 1, UserId: 1, Cur: domain.Currency{Id: 1, Symbol: "RUB"}, Amount: 0}, nil)
+	mockRepo.EXPECT().CreateAccount(gomock.Any(), 1, domain.Currency{Id: 1, Symbol: "RUB"}).Return(&domain.Account{Id: 1, UserId: 1, Cur: domain.Currency{Id: 1, Symbol: "RUB"}, Amount: domain.Money{}}, nil)
 
 	s := NewAccountService(mockRepo)
 
@@ -27,7 +35,7 @@ func TestCreateAccount(t *testing.T) {
 	assert.Equal(t, 1, account.Id)
 	assert.Equal(t, 1, account.UserId)
 	assert.Equal(t, domain.Currency{Id: 1, Symbol: "RUB"}, account.Cur)
-	assert.Equal(t, 0, account.Amount)
+	assert.True(t, account.Amount.IsZero())
 }
 
 func TestCreateAccount_NoSuchCurrency(t *testing.T) {
@@ -50,7 +58,7 @@ func TestGetAccount(t *testing.T) {
 	mockRepo.EXPECT().GetAccount(gomock.Any(), 1).Return(&domain.Account{Id: 1, UserId: 1, Cur: domain.Currency{
 		Id:     1,
 		Symbol: "RUB",
-	}, Amount: 0}, nil)
+	}, Amount: domain.Money{}}, nil)
 
 	s := NewAccountService(mockRepo)
 
@@ -63,7 +71,7 @@ func TestGetAccount(t *testing.T) {
 		Id:     1,
 		Symbol: "RUB",
 	}, account.Cur)
-	assert.Equal(t, 0, account.Amount)
+	assert.True(t, account.Amount.IsZero())
 }
 
 func TestGetAccount_WrongUser(t *testing.T) {
@@ -77,7 +85,7 @@ func TestGetAccount_WrongUser(t *testing.T) {
 	mockRepo.EXPECT().GetAccount(gomock.Any(), 1).Return(&domain.Account{Id: 1, UserId: 2, Cur: domain.Currency{
 		Id:     1,
 		Symbol: "RUB",
-	}, Amount: 0}, nil)
+	}, Amount: domain.Money{}}, nil)
 
 	s := NewAccountService(mockRepo)
 
@@ -94,16 +102,16 @@ func TestUpdateAccount(t *testing.T) {
 	mockRepo.EXPECT().GetAccount(gomock.Any(), 1).Return(&domain.Account{Id: 1, UserId: 1, Cur: domain.Currency{
 		Id:     1,
 		Symbol: "RUB",
-	}, Amount: 0}, nil)
+	}, Amount: domain.Money{}}, nil)
 
-	mockRepo.EXPECT().UpdateAccount(gomock.Any(), 1, 100).Return(&domain.Account{Id: 1, UserId: 1, Cur: domain.Currency{
+	mockRepo.EXPECT().UpdateAccount(gomock.Any(), 1, domain.NewMoney(10000, rub)).Return(&domain.Account{Id: 1, UserId: 1, Cur: domain.Currency{
 		Id:     1,
 		Symbol: "RUB",
-	}, Amount: 100}, nil)
+	}, Amount: domain.NewMoney(10000, rub)}, nil)
 
 	s := NewAccountService(mockRepo)
 
-	account, err := s.UpdateAccount(context.Background(), 1, 1, 100)
+	account, err := s.UpdateAccount(context.Background(), 1, 1, money(t, "100"))
 	assert.NotNil(t, account)
 	assert.NoError(t, err)
 	assert.Equal(t, 1, account.Id)
@@ -112,7 +120,7 @@ func TestUpdateAccount(t *testing.T) {
 		Id:     1,
 		Symbol: "RUB",
 	}, account.Cur)
-	assert.Equal(t, 100, account.Amount)
+	assert.Equal(t, "100.00", account.Amount.String())
 }
 
 func TestUpdateAccount_WrongUser(t *testing.T) {
@@ -123,15 +131,32 @@ func TestUpdateAccount_WrongUser(t *testing.T) {
 	mockRepo.EXPECT().GetAccount(gomock.Any(), 1).Return(&domain.Account{Id: 1, UserId: 2, Cur: domain.Currency{
 		Id:     1,
 		Symbol: "RUB",
-	}, Amount: 0}, nil)
+	}, Amount: domain.Money{}}, nil)
 
 	s := NewAccountService(mockRepo)
 
-	account, err := s.UpdateAccount(context.Background(), 1, 1, 100)
+	account, err := s.UpdateAccount(context.Background(), 1, 1, money(t, "100"))
 	assert.Nil(t, account)
 	assert.ErrorIs(t, ErrInvalidAccount, err)
 }
 
+func TestUpdateAccount_TooPrecise(t *testing.T) {
+	mockRepo := mocks.NewMockAccountRepository(gomock.NewController(t))
+
+	mockRepo.EXPECT().AccountExists(gomock.Any(), 1).Return(true, nil)
+	mockRepo.EXPECT().UserExistsById(gomock.Any(), 1).Return(true, nil)
+	mockRepo.EXPECT().GetAccount(gomock.Any(), 1).Return(&domain.Account{Id: 1, UserId: 1, Cur: domain.Currency{
+		Id:     5,
+		Symbol: "JPY",
+	}, Amount: domain.Money{}}, nil)
+
+	s := NewAccountService(mockRepo)
+
+	account, err := s.UpdateAccount(context.Background(), 1, 1, money(t, "100.5"))
+	assert.Nil(t, account)
+	assert.ErrorIs(t, err, ErrInvalidAmount)
+}
+
 func TestDeleteAccount(t *testing.T) {
 	mockRepo := mocks.NewMockAccountRepository(gomock.NewController(t))
 
@@ -140,7 +165,7 @@ func TestDeleteAccount(t *testing.T) {
 	mockRepo.EXPECT().GetAccount(gomock.Any(), 1).Return(&domain.Account{Id: 1, UserId: 1, Cur: domain.Currency{
 		Id:     1,
 		Symbol: "RUB",
-	}, Amount: 0}, nil)
+	}, Amount: domain.Money{}}, nil)
 	mockRepo.EXPECT().DeleteAccount(gomock.Any(), 1).Return(nil)
 
 	s := NewAccountService(mockRepo)
@@ -157,7 +182,7 @@ func TestDeleteAccount_WrongUser(t *testing.T) {
 	mockRepo.EXPECT().GetAccount(gomock.Any(), 1).Return(&domain.Account{Id: 1, UserId: 2, Cur: domain.Currency{
 		Id:     1,
 		Symbol: "RUB",
-	}, Amount: 0}, nil)
+	}, Amount: domain.Money{}}, nil)
 
 	s := NewAccountService(mockRepo)
 
@@ -0,0 +1,305 @@
+package service
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"strings"
+	"time"
+	"unicode/utf8"
+
+	"bank-api/internal/domain"
+	"bank-api/internal/repository"
+)
+
+const (
+	defaultAdminLimit = 50
+	maxAdminLimit     = 100
+	maxFreezeReason   = 255
+)
+
+// Actions recorded in the audit log.
+const (
+	AuditSearchUsers     = "user.search"
+	AuditViewUser        = "user.view"
+	AuditSetUserRole     = "user.set_role"
+	AuditViewAccount     = "account.view"
+	AuditViewHistory     = "account.history"
+	AuditFreezeAccount   = "account.freeze"
+	AuditUnfreezeAccount = "account.unfreeze"
+	AuditViewAuditLog    = "audit.view"
+)
+
+var (
+	ErrForbidden            = errors.New("forbidden")
+	ErrInvalidRole          = errors.New("invalid role")
+	ErrOwnRole              = errors.New("can't change own role")
+	ErrInvalidLimit         = errors.New("invalid limit")
+	ErrInvalidFreezeReason  = errors.New("invalid freeze reason")
+	ErrAccountAlreadyFrozen = errors.New("account is already frozen")
+	ErrAccountNotFrozen     = errors.New("account isn't frozen")
+)
+
+// AdminService is what staff can do with the data of any user. Every method checks that the actor's role allows
+// it, whatever the route already checked, and records in the audit log who did it.
+type AdminService interface {
+	SearchUsers(ctx context.Context, actor *domain.Principal, filter *domain.UserFilter) ([]*domain.User, error)
+	// GetUser returns the user with their accounts.
+	GetUser(ctx context.Context, actor *domain.Principal, userId int) (*domain.User, []*domain.Account, error)
+	// SetUserRole changes the role of the user and logs them out everywhere, so their tokens don't keep the old one.
+	SetUserRole(ctx context.Context, actor *domain.Principal, userId int, role domain.Role) (*domain.User, error)
+	GetAccount(ctx context.Context, actor *domain.Principal, accountId int) (*domain.Account, error)
+	// ListAccountTransactions is TransactionService.ListTransactions for the account filter.AccountId of any user.
+	ListAccountTransactions(ctx context.Context, actor *domain.Principal, filter *domain.TransactionFilter) ([]*domain.Transaction, *domain.Cursor, error)
+	// FreezeAccount stops the account from sending or receiving money until it is unfrozen.
+	FreezeAccount(ctx context.Context, actor *domain.Principal, accountId int, reason string) (*domain.Account, error)
+	UnfreezeAccount(ctx context.Context, actor *domain.Principal, accountId int) (*domain.Account, error)
+	ListAuditEntries(ctx context.Context, actor *domain.Principal, filter *domain.AuditFilter) ([]*domain.AuditEntry, error)
+}
+
+type adminService struct {
+	repo     repository.AdminRepository
+	users    repository.UserRepository
+	accounts repository.AccountRepository
+	tr       TransactionService
+	tokens   TokenService
+}
+
+func NewAdminService(repo repository.AdminRepository, users repository.UserRepository, accounts repository.AccountRepository, tr TransactionService, tokens TokenService) AdminService {
+	return &adminService{repo: repo, users: users, accounts: accounts, tr: tr, tokens: tokens}
+}
+
+func (s *adminService) SearchUsers(ctx context.Context, actor *domain.Principal, filter *domain.UserFilter) ([]*domain.User, error) {
+	if !actor.Can(domain.PermUsersRead) {
+		return nil, ErrForbidden
+	}
+	page := *filter
+	page.Query = strings.TrimSpace(page.Query)
+	if page.Role != "" && !page.Role.Valid() {
+		return nil, ErrInvalidRole
+	}
+	var ok bool
+	if page.Limit, ok = pageLimit(page.Limit); !ok {
+		return nil, ErrInvalidLimit
+	}
+
+	err := s.audit(ctx, actor, AuditSearchUsers, "user", 0, map[string]any{"query": page.Query, "role": page.Role})
+	if err != nil {
+		return nil, err
+	}
+
+	users, err := s.repo.SearchUsers(ctx, &page)
+	if err != nil {
+		return nil, fmt.Errorf("can't search users: %w", err)
+	}
+	return users, nil
+}
+
+func (s *adminService) GetUser(ctx context.Context, actor *domain.Principal, userId int) (*domain.User, []*domain.Account, error) {
+	if !actor.Can(domain.PermUsersRead) {
+		return nil, nil, ErrForbidden
+	}
+	user, err := s.getUser(ctx, userId)
+	if err != nil {
+		return nil, nil, err
+	}
+
+	if err := s.audit(ctx, actor, AuditViewUser, "user", userId, nil); err != nil {
+		return nil, nil, err
+	}
+
+	accounts, err := s.repo.ListUserAccounts(ctx, userId)
+	if err != nil {
+		return nil, nil, fmt.Errorf("can't list accounts: %w", err)
+	}
+	return user, accounts, nil
+}
+
+func (s *adminService) SetUserRole(ctx context.Context, actor *domain.Principal, userId int, role domain.Role) (*domain.User, error) {
+	if !actor.Can(domain.PermUsersManage) {
+		return nil, ErrForbidden
+	}
+	if !role.Valid() {
+		return nil, ErrInvalidRole
+	}
+	// Staff can't promote themselves, nor lock the last admin out by accident.
+	if actor.UserId == userId {
+		return nil, ErrOwnRole
+	}
+	user, err := s.getUser(ctx, userId)
+	if err != nil {
+		return nil, err
+	}
+
+	entry := auditEntry(actor, AuditSetUserRole, "user", userId, map[string]any{"from": user.Role, "to": role})
+	if err := s.repo.SetUserRole(ctx, userId, role, entry); err != nil {
+		return nil, fmt.Errorf("can't set role: %w", err)
+	}
+	if err := s.tokens.RevokeUserSessions(ctx, userId); err != nil {
+		return nil, err
+	}
+
+	user.Role = role
+	return user, nil
+}
+
+func (s *adminService) GetAccount(ctx context.Context, actor *domain.Principal, accountId int) (*domain.Account, error) {
+	if !actor.Can(domain.PermAccountsRead) {
+		return nil, ErrForbidden
+	}
+	account, err := s.getAccount(ctx, accountId)
+	if err != nil {
+		return nil, err
+	}
+
+	if err := s.audit(ctx, actor, AuditViewAccount, "account", accountId, nil); err != nil {
+		return nil, err
+	}
+	return account, nil
+}
+
+func (s *adminService) ListAccountTransactions(ctx context.Context, actor *domain.Principal, filter *domain.TransactionFilter) ([]*domain.Transaction, *domain.Cursor, error) {
+	if !actor.Can(domain.PermAccountsRead) {
+		return nil, nil, ErrForbidden
+	}
+	account, err := s.getAccount(ctx, filter.AccountId)
+	if err != nil {
+		return nil, nil, err
+	}
+
+	if err := s.audit(ctx, actor, AuditViewHistory, "account", account.Id, nil); err != nil {
+		return nil, nil, err
+	}
+
+	page := *filter
+	page.UserId = account.UserId
+	return s.tr.ListTransactions(ctx, &page)
+}
+
+func (s *adminService) FreezeAccount(ctx context.Context, actor *domain.Principal, accountId int, reason string) (*domain.Account, error) {
+	if !actor.Can(domain.PermAccountsFreeze) {
+		return nil, ErrForbidden
+	}
+	reason = strings.TrimSpace(reason)
+	if reason == "" || utf8.RuneCountInString(reason) > maxFreezeReason {
+		return nil, ErrInvalidFreezeReason
+	}
+	if _, err := s.getAccount(ctx, accountId); err != nil {
+		return nil, err
+	}
+
+	entry := auditEntry(actor, AuditFreezeAccount, "account", accountId, map[string]any{"reason": reason})
+	frozen, err := s.repo.FreezeAccount(ctx, accountId, reason, time.Now(), entry)
+	if err != nil {
+		return nil, fmt.Errorf("can't freeze account: %w", err)
+	}
+	if !frozen {
+		return nil, ErrAccountAlreadyFrozen
+	}
+
+	return s.getAccount(ctx, accountId)
+}
+
+func (s *adminService) UnfreezeAccount(ctx context.Context, actor *domain.Principal, accountId int) (*domain.Account, error) {
+	if !actor.Can(domain.PermAccountsFreeze) {
+		return nil, ErrForbidden
+	}
+	if _, err := s.getAccount(ctx, accountId); err != nil {
+		return nil, err
+	}
+
+	unfrozen, err := s.repo.UnfreezeAccount(ctx, accountId, auditEntry(actor, AuditUnfreezeAccount, "account", accountId, nil))
+	if err != nil {
+		return nil, fmt.Errorf("can't unfreeze account: %w", err)
+	}
+	if !unfrozen {
+		return nil, ErrAccountNotFrozen
+	}
+
+	return s.getAccount(ctx, accountId)
+}
+
+func (s *adminService) ListAuditEntries(ctx context.Context, actor *domain.Principal, filter *domain.AuditFilter) ([]*domain.AuditEntry, error) {
+	if !actor.Can(domain.PermAuditRead) {
+		return nil, ErrForbidden
+	}
+	page := *filter
+	var ok bool
+	if page.Limit, ok = pageLimit(page.Limit); !ok {
+		return nil, ErrInvalidLimit
+	}
+
+	if err := s.audit(ctx, actor, AuditViewAuditLog, "audit", 0, nil); err != nil {
+		return nil, err
+	}
+
+	entries, err := s.repo.ListAuditEntries(ctx, &page)
+	if err != nil {
+		return nil, fmt.Errorf("can't list audit entries: %w", err)
+	}
+	return entries, nil
+}
+
+func (s *adminService) getUser(ctx context.Context, userId int) (*domain.User, error) {
+	ok, err := s.users.UserExistsById(ctx, userId)
+	if err != nil {
+		return nil, fmt.Errorf("can't check if such a user exists: %w", err)
+	}
+	if !ok {
+		return nil, ErrNoSuchUser
+	}
+
+	user, err := s.users.GetUser(ctx, userId)
+	if err != nil {
+		return nil, fmt.Errorf("can't get user: %w", err)
+	}
+	return user, nil
+}
+
+func (s *adminService) getAccount(ctx context.Context, accountId int) (*domain.Account, error) {
+	ok, err := s.accounts.AccountExists(ctx, accountId)
+	if err != nil {
+		return nil, fmt.Errorf("can't check if such an account exists: %w", err)
+	}
+	if !ok {
+		return nil, ErrNoSuchAccount
+	}
+
+	account, err := s.accounts.GetAccount(ctx, accountId)
+	if err != nil {
+		return nil, fmt.Errorf("can't get account: %w", err)
+	}
+	return account, nil
+}
+
+// audit records an action that doesn't change anything, before its result is shown, so nothing is seen unrecorded.
+func (s *adminService) audit(ctx context.Context, actor *domain.Principal, action string, targetType string, targetId int, details map[string]any) error {
+	if err := s.repo.RecordAudit(ctx, auditEntry(actor, action, targetType, targetId, details)); err != nil {
+		return fmt.Errorf("can't record audit entry: %w", err)
+	}
+	return nil
+}
+
+func auditEntry(actor *domain.Principal, action string, targetType string, targetId int, details map[string]any) *domain.AuditEntry {
+	return &domain.AuditEntry{
+		ActorUserId:    actor.UserId,
+		ActorSessionId: actor.SessionId,
+		ActorRole:      actor.Role,
+		ActorAPIKeyId:  actor.APIKeyId,
+		Action:         action,
+		TargetType:     targetType,
+		TargetId:       targetId,
+		Details:        details,
+	}
+}
+
+// pageLimit returns the default limit for zero, and false if limit is out of range.
+func pageLimit(limit int) (int, bool) {
+	if limit == 0 {
+		return defaultAdminLimit, true
+	}
+	if limit < 0 || limit > maxAdminLimit {
+		return 0, false
+	}
+	return limit, true
+}
@@ -0,0 +1,152 @@
+package service
+
+import (
+	"context"
+	"errors"
+	"testing"
+	"time"
+
+	"bank-api/internal/domain"
+	"bank-api/mocks"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"go.uber.org/mock/gomock"
+)
+
+func TestAdminService_Forbidden(t *testing.T) {
+	ctrl := gomock.NewController(t)
+	mockAccounts := mocks.NewMockAccountRepository(ctrl)
+	s := NewAdminService(mocks.NewMockAdminRepository(ctrl), mocks.NewMockUserRepository(ctrl), mockAccounts,
+		NewTransactionService(mockAccounts, nil, nil, 0, 0), nil)
+	ctx := context.Background()
+
+	customer := &domain.Principal{UserId: 1, SessionId: 5, Role: domain.RoleCustomer}
+	auditor := &domain.Principal{UserId: 2, SessionId: 6, Role: domain.RoleAuditor}
+	support := &domain.Principal{UserId: 3, SessionId: 7, Role: domain.RoleSupport}
+
+	// No repository call is expected: the permission is checked first.
+	_, err := s.SearchUsers(ctx, customer, &domain.UserFilter{})
+	assert.ErrorIs(t, err, ErrForbidden)
+	_, err = s.GetAccount(ctx, customer, 10)
+	assert.ErrorIs(t, err, ErrForbidden)
+	_, err = s.FreezeAccount(ctx, auditor, 10, "fraud")
+	assert.ErrorIs(t, err, ErrForbidden)
+	_, err = s.UnfreezeAccount(ctx, auditor, 10)
+	assert.ErrorIs(t, err, ErrForbidden)
+	_, err = s.SetUserRole(ctx, auditor, 1, domain.RoleAdmin)
+	assert.ErrorIs(t, err, ErrForbidden)
+	_, err = s.SetUserRole(ctx, support, 1, domain.RoleAdmin)
+	assert.ErrorIs(t, err, ErrForbidden)
+	_, err = s.ListAuditEntries(ctx, support, &domain.AuditFilter{})
+	assert.ErrorIs(t, err, ErrForbidden)
+}
+
+func TestAdminService_FreezeAccount(t *testing.T) {
+	ctrl := gomock.NewController(t)
+	mockAdmin := mocks.NewMockAdminRepository(ctrl)
+	mockUsers := mocks.NewMockUserRepository(ctrl)
+	mockAccounts := mocks.NewMockAccountRepository(ctrl)
+	mockSessions := mocks.NewMockSessionRepository(ctrl)
+	tokens := NewTokenService(mockSessions, mockUsers, testJWT, 15*time.Minute, time.Hour, time.Minute)
+	s := NewAdminService(mockAdmin, mockUsers, mockAccounts, NewTransactionService(mockAccounts, nil, nil, 0, 0), tokens)
+	ctx := context.Background()
+	actor := &domain.Principal{UserId: 3, SessionId: 7, Role: domain.RoleSupport}
+
+	mockAccounts.EXPECT().AccountExists(gomock.Any(), 10).Return(true, nil).AnyTimes()
+	mockAccounts.EXPECT().GetAccount(gomock.Any(), 10).Return(&domain.Account{Id: 10, UserId: 1, FrozenAt: time.Now()}, nil).AnyTimes()
+
+	var entry *domain.AuditEntry
+	mockAdmin.EXPECT().FreezeAccount(gomock.Any(), 10, "card reported stolen", gomock.Any(), gomock.Any()).
+		DoAndReturn(func(_ context.Context, _ int, _ string, _ time.Time, e *domain.AuditEntry) (bool, error) {
+			entry = e
+			return true, nil
+		})
+
+	account, err := s.FreezeAccount(ctx, actor, 10, " card reported stolen ")
+	require.NoError(t, err)
+	assert.True(t, account.Frozen())
+	assert.Equal(t, &domain.AuditEntry{
+		ActorUserId:    3,
+		ActorSessionId: 7,
+		ActorRole:      domain.RoleSupport,
+		Action:         AuditFreezeAccount,
+		TargetType:     "account",
+		TargetId:       10,
+		Details:        map[string]any{"reason": "card reported stolen"},
+	}, entry)
+
+	mockAdmin.EXPECT().FreezeAccount(gomock.Any(), 10, gomock.Any(), gomock.Any(), gomock.Any()).Return(false, nil)
+	_, err = s.FreezeAccount(ctx, actor, 10, "again")
+	assert.ErrorIs(t, err, ErrAccountAlreadyFrozen)
+
+	_, err = s.FreezeAccount(ctx, actor, 10, " ")
+	assert.ErrorIs(t, err, ErrInvalidFreezeReason)
+}
+
+func TestAdminService_SetUserRole(t *testing.T) {
+	ctrl := gomock.NewController(t)
+	mockAdmin := mocks.NewMockAdminRepository(ctrl)
+	mockUsers := mocks.NewMockUserRepository(ctrl)
+	mockAccounts := mocks.NewMockAccountRepository(ctrl)
+	mockSessions := mocks.NewMockSessionRepository(ctrl)
+	tokens := NewTokenService(mockSessions, mockUsers, testJWT, 15*time.Minute, time.Hour, time.Minute)
+	s := NewAdminService(mockAdmin, mockUsers, mockAccounts, NewTransactionService(mockAccounts, nil, nil, 0, 0), tokens)
+	ctx := context.Background()
+	actor := &domain.Principal{UserId: 3, SessionId: 7, Role: domain.RoleAdmin}
+
+	mockUsers.EXPECT().UserExistsById(gomock.Any(), 1).Return(true, nil)
+	mockUsers.EXPECT().GetUser(gomock.Any(), 1).Return(&domain.User{Id: 1, Role: domain.RoleCustomer}, nil)
+	var entry *domain.AuditEntry
+	mockAdmin.EXPECT().SetUserRole(gomock.Any(), 1, domain.RoleSupport, gomock.Any()).
+		DoAndReturn(func(_ context.Context, _ int, _ domain.Role, e *domain.AuditEntry) error {
+			entry = e
+			return nil
+		})
+	mockSessions.EXPECT().RevokeUserSessions(gomock.Any(), 1, 0).Return([]int{8}, nil)
+
+	user, err := s.SetUserRole(ctx, actor, 1, domain.RoleSupport)
+	require.NoError(t, err)
+	assert.Equal(t, domain.RoleSupport, user.Role)
+	assert.Equal(t, AuditSetUserRole, entry.Action)
+	assert.Equal(t, 3, entry.ActorUserId)
+	assert.Equal(t, map[string]any{"from": domain.RoleCustomer, "to": domain.RoleSupport}, entry.Details)
+
+	_, err = s.SetUserRole(ctx, actor, 3, domain.RoleCustomer)
+	assert.ErrorIs(t, err, ErrOwnRole)
+	_, err = s.SetUserRole(ctx, actor, 1, domain.Role("root"))
+	assert.ErrorIs(t, err, ErrInvalidRole)
+}
+
+func TestAdminService_ViewIsAudited(t *testing.T) {
+	ctrl := gomock.NewController(t)
+	mockAdmin := mocks.NewMockAdminRepository(ctrl)
+	mockUsers := mocks.NewMockUserRepository(ctrl)
+	mockAccounts := mocks.NewMockAccountRepository(ctrl)
+	mockSessions := mocks.NewMockSessionRepository(ctrl)
+	tokens := NewTokenService(mockSessions, mockUsers, testJWT, 15*time.Minute, time.Hour, time.Minute)
+	s := NewAdminService(mockAdmin, mockUsers, mockAccounts, NewTransactionService(mockAccounts, nil, nil, 0, 0), tokens)
+	ctx := context.Background()
+	actor := &domain.Principal{UserId: 2, SessionId: 6, Role: domain.RoleAuditor}
+
+	mockAccounts.EXPECT().AccountExists(gomock.Any(), 10).Return(true, nil).Times(2)
+	mockAccounts.EXPECT().GetAccount(gomock.Any(), 10).Return(&domain.Account{Id: 10, UserId: 1}, nil).Times(2)
+
+	mockAdmin.EXPECT().RecordAudit(gomock.Any(), &domain.AuditEntry{
+		ActorUserId:    2,
+		ActorSessionId: 6,
+		ActorRole:      domain.RoleAuditor,
+		Action:         AuditViewAccount,
+		TargetType:     "account",
+		TargetId:       10,
+	}).Return(nil)
+	account, err := s.GetAccount(ctx, actor, 10)
+	require.NoError(t, err)
+	assert.Equal(t, 10, account.Id)
+
+	// Nothing is shown that couldn't be recorded.
+	mockAdmin.EXPECT().RecordAudit(gomock.Any(), gomock.Any()).Return(errors.New("db is down"))
+	account, err = s.GetAccount(ctx, actor, 10)
+	assert.Error(t, err)
+	assert.Nil(t, account)
+}
@@ -0,0 +1,132 @@
+package service
+
+import (
+	"context"
+	"crypto/rand"
+	"crypto/subtle"
+	"encoding/hex"
+	"errors"
+	"fmt"
+	"strings"
+	"time"
+	"unicode/utf8"
+
+	"bank-api/internal/domain"
+	"bank-api/internal/repository"
+)
+
+const (
+	// apiKeyPrefix starts every key, so leaked keys are easy to find.
+	apiKeyPrefix     = "bk_"
+	maxAPIKeyNameLen = 100
+	// apiKeyTouchInterval is how stale the last-used time of a key may get, so not every request writes it.
+	apiKeyTouchInterval = time.Minute
+)
+
+var (
+	ErrNoSuchAPIKey      = errors.New("no such api key")
+	ErrInvalidAPIKeyName = errors.New("invalid api key name")
+	ErrInvalidScope      = errors.New("invalid scope")
+)
+
+type APIKeyService interface {
+	// CreateAPIKey creates a key of the user, or a system key if userId is 0. Keys of users have scopes
+	// of domain.Scopes, and system keys admin scopes. The key itself is returned only here; just its hash is stored.
+	CreateAPIKey(ctx context.Context, userId int, name string, scopes []domain.Scope) (*domain.APIKey, string, error)
+	ListAPIKeys(ctx context.Context, userId int) ([]*domain.APIKey, error)
+	RevokeAPIKey(ctx context.Context, userId int, id int) error
+	// AuthenticateAPIKey returns the principal the key acts as, or nil if the key is unknown or revoked.
+	AuthenticateAPIKey(ctx context.Context, key string) (*domain.Principal, error)
+}
+
+type apiKeyService struct {
+	repo repository.APIKeyRepository
+}
+
+func NewAPIKeyService(repo repository.APIKeyRepository) APIKeyService {
+	return &apiKeyService{repo: repo}
+}
+
+func (s *apiKeyService) CreateAPIKey(ctx context.Context, userId int, name string, scopes []domain.Scope) (*domain.APIKey, string, error) {
+	name = strings.TrimSpace(name)
+	if name == "" || utf8.RuneCountInString(name) > maxAPIKeyNameLen {
+		return nil, "", ErrInvalidAPIKeyName
+	}
+	if len(scopes) == 0 {
+		return nil, "", ErrInvalidScope
+	}
+	for _, scope := range scopes {
+		if (userId != 0 && !scope.Valid()) || (userId == 0 && !scope.Admin()) {
+			return nil, "", ErrInvalidScope
+		}
+	}
+
+	raw := make([]byte, 6)
+	if _, err := rand.Read(raw); err != nil {
+		return nil, "", fmt.Errorf("can't generate api key prefix: %w", err)
+	}
+	prefix := hex.EncodeToString(raw)
+	secret, err := newToken()
+	if err != nil {
+		return nil, "", fmt.Errorf("can't generate api key: %w", err)
+	}
+
+	k := &domain.APIKey{
+		UserId: userId,
+		Name:   name,
+		Prefix: prefix,
+		Hash:   hashToken(secret),
+		Scopes: scopes,
+	}
+	if err := s.repo.CreateAPIKey(ctx, k); err != nil {
+		return nil, "", fmt.Errorf("can't create api key: %w", err)
+	}
+	return k, apiKeyPrefix + prefix + "_" + secret, nil
+}
+
+func (s *apiKeyService) ListAPIKeys(ctx context.Context, userId int) ([]*domain.APIKey, error) {
+	keys, err := s.repo.ListAPIKeys(ctx, userId)
+	if err != nil {
+		return nil, fmt.Errorf("can't list api keys: %w", err)
+	}
+	return keys, nil
+}
+
+func (s *apiKeyService) RevokeAPIKey(ctx context.Context, userId int, id int) error {
+	k, err := s.repo.GetAPIKey(ctx, id)
+	if err != nil {
+		return fmt.Errorf("can't get api key: %w", err)
+	}
+	if k == nil || k.UserId != userId {
+		return ErrNoSuchAPIKey
+	}
+
+	if err := s.repo.RevokeAPIKey(ctx, id, time.Now()); err != nil {
+		return fmt.Errorf("can't revoke api key: %w", err)
+	}
+	return nil
+}
+
+func (s *apiKeyService) AuthenticateAPIKey(ctx context.Context, key string) (*domain.Principal, error) {
+	prefix, secret, ok := strings.Cut(strings.TrimPrefix(key, apiKeyPrefix), "_")
+	if !ok || !strings.HasPrefix(key, apiKeyPrefix) {
+		return nil, nil
+	}
+
+	k, err := s.repo.GetAPIKeyByPrefix(ctx, prefix)
+	if err != nil {
+		return nil, fmt.Errorf("can't get api key: %w", err)
+	}
+	if k == nil || k.Revoked() || subtle.ConstantTimeCompare(k.Hash, hashToken(secret)) != 1 {
+		return nil, nil
+	}
+
+	now := time.Now()
+	if now.Sub(k.LastUsedAt) >= apiKeyTouchInterval {
+		if err := s.repo.TouchAPIKey(ctx, k.Id, now); err != nil {
+			return nil, fmt.Errorf("can't update api key last use: %w", err)
+		}
+	}
+
+	return &domain.Principal{UserId: k.UserId, APIKeyId: k.Id, Scopes: k.Scopes}, nil
+}
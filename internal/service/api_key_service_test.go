@@ -0,0 +1,94 @@
+package service
+
+import (
+	"context"
+	"strings"
+	"testing"
+	"time"
+
+	"bank-api/internal/domain"
+	"bank-api/mocks"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"go.uber.org/mock/gomock"
+)
+
+func TestCreateAndAuthenticateAPIKey(t *testing.T) {
+	mockRepo := mocks.NewMockAPIKeyRepository(gomock.NewController(t))
+	s := NewAPIKeyService(mockRepo)
+
+	var stored *domain.APIKey
+	mockRepo.EXPECT().CreateAPIKey(gomock.Any(), gomock.Any()).DoAndReturn(func(_ context.Context, k *domain.APIKey) error {
+		k.Id = 3
+		stored = k
+		return nil
+	})
+
+	scopes := []domain.Scope{domain.ScopeAccountsRead, domain.ScopeHistoryRead}
+	k, key, err := s.CreateAPIKey(context.Background(), 1, " reports ", scopes)
+	require.NoError(t, err)
+	assert.Equal(t, "reports", k.Name)
+	assert.True(t, strings.HasPrefix(key, "bk_"+k.Prefix+"_"))
+	assert.NotContains(t, string(stored.Hash), key)
+
+	mockRepo.EXPECT().GetAPIKeyByPrefix(gomock.Any(), k.Prefix).Return(stored, nil).AnyTimes()
+	mockRepo.EXPECT().TouchAPIKey(gomock.Any(), 3, gomock.Any()).Return(nil)
+
+	principal, err := s.AuthenticateAPIKey(context.Background(), key)
+	require.NoError(t, err)
+	assert.Equal(t, &domain.Principal{UserId: 1, APIKeyId: 3, Scopes: scopes}, principal)
+
+	// A key used a moment ago isn't touched again.
+	stored.LastUsedAt = time.Now()
+	_, err = s.AuthenticateAPIKey(context.Background(), key)
+	assert.NoError(t, err)
+
+	for name, wrong := range map[string]string{
+		"wrong secret": "bk_" + k.Prefix + "_guess",
+		"no prefix":    strings.TrimPrefix(key, "bk_"),
+		"garbage":      "garbage",
+	} {
+		principal, err := s.AuthenticateAPIKey(context.Background(), wrong)
+		assert.NoError(t, err, name)
+		assert.Nil(t, principal, name)
+	}
+
+	stored.RevokedAt = time.Now()
+	principal, err = s.AuthenticateAPIKey(context.Background(), key)
+	assert.NoError(t, err)
+	assert.Nil(t, principal)
+}
+
+func TestCreateAPIKey_Invalid(t *testing.T) {
+	s := NewAPIKeyService(mocks.NewMockAPIKeyRepository(gomock.NewController(t)))
+
+	_, _, err := s.CreateAPIKey(context.Background(), 1, " ", []domain.Scope{domain.ScopeAccountsRead})
+	assert.ErrorIs(t, err, ErrInvalidAPIKeyName)
+	_, _, err = s.CreateAPIKey(context.Background(), 1, "reports", nil)
+	assert.ErrorIs(t, err, ErrInvalidScope)
+	_, _, err = s.CreateAPIKey(context.Background(), 1, "reports", []domain.Scope{"accounts:delete-all"})
+	assert.ErrorIs(t, err, ErrInvalidScope)
+
+	// Admin scopes are only for system keys, and system keys only have admin scopes.
+	_, _, err = s.CreateAPIKey(context.Background(), 1, "reports", []domain.Scope{domain.AdminScope(domain.PermUsersRead)})
+	assert.ErrorIs(t, err, ErrInvalidScope)
+	_, _, err = s.CreateAPIKey(context.Background(), 0, "reports", []domain.Scope{domain.ScopeAccountsRead})
+	assert.ErrorIs(t, err, ErrInvalidScope)
+	_, _, err = s.CreateAPIKey(context.Background(), 0, "reports", []domain.Scope{"admin:accounts:delete-all"})
+	assert.ErrorIs(t, err, ErrInvalidScope)
+}
+
+func TestRevokeAPIKey(t *testing.T) {
+	mockRepo := mocks.NewMockAPIKeyRepository(gomock.NewController(t))
+	s := NewAPIKeyService(mockRepo)
+
+	mockRepo.EXPECT().GetAPIKey(gomock.Any(), 3).Return(&domain.APIKey{Id: 3, UserId: 1}, nil).Times(2)
+	mockRepo.EXPECT().RevokeAPIKey(gomock.Any(), 3, gomock.Any()).Return(nil)
+
+	assert.ErrorIs(t, s.RevokeAPIKey(context.Background(), 2, 3), ErrNoSuchAPIKey)
+	assert.NoError(t, s.RevokeAPIKey(context.Background(), 1, 3))
+
+	mockRepo.EXPECT().GetAPIKey(gomock.Any(), 4).Return(nil, nil)
+	assert.ErrorIs(t, s.RevokeAPIKey(context.Background(), 1, 4), ErrNoSuchAPIKey)
+}
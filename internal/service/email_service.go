@@ -0,0 +1,218 @@
+package service
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"time"
+
+	"bank-api/internal/domain"
+	"bank-api/internal/repository"
+	"bank-api/pkg/mail"
+	"bank-api/pkg/validate"
+)
+
+var (
+	ErrEmailTaken               = errors.New("email is taken")
+	ErrEmailAlreadyVerified     = errors.New("email is already verified")
+	ErrInvalidVerificationToken = errors.New("invalid email verification token")
+	ErrVerificationThrottled    = errors.New("verification email was sent too recently")
+)
+
+type EmailVerificationConfig struct {
+	// TTL is how long a verification token can be used.
+	TTL time.Duration
+	// Interval is how long after a verification email another one can be sent to the same user.
+	Interval time.Duration
+	// URL, if set, is where the email links to, with the token appended.
+	URL string
+}
+
+// EmailService verifies that users own their email. Until they do, they can't move money.
+type EmailService interface {
+	// SendVerification emails a verification token to the email the user is changing to, if any, or else to
+	// their email if it isn't verified yet.
+	SendVerification(ctx context.Context, userId int) error
+	// UpdateUser changes the name of the user right away and sends a verification to their new email, checking
+	// both before it changes either. Their email stays the same, and keeps working for logins, until the new one
+	// is verified. Changing back to their email cancels the change.
+	UpdateUser(ctx context.Context, userId int, info *domain.UserInfo) (*domain.User, error)
+	// VerifyEmail marks the email of the token verified, replacing the email of the user if it's the one they
+	// are changing to, and returns the user.
+	VerifyEmail(ctx context.Context, token string) (*domain.User, error)
+	EmailVerified(ctx context.Context, userId int) (bool, error)
+}
+
+type emailService struct {
+	repo   repository.UserRepository
+	mailer mail.Mailer
+	cfg    EmailVerificationConfig
+}
+
+func NewEmailService(repo repository.UserRepository, mailer mail.Mailer, cfg EmailVerificationConfig) EmailService {
+	return &emailService{repo: repo, mailer: mailer, cfg: cfg}
+}
+
+func (s *emailService) SendVerification(ctx context.Context, userId int) error {
+	user, err := s.repo.GetUser(ctx, userId)
+	if err != nil {
+		return fmt.Errorf("can't get user: %w", err)
+	}
+
+	switch {
+	case user.PendingEmail != "":
+		return s.send(ctx, user, user.PendingEmail)
+	case !user.EmailVerified():
+		return s.send(ctx, user, user.Email)
+	default:
+		return ErrEmailAlreadyVerified
+	}
+}
+
+func (s *emailService) UpdateUser(ctx context.Context, userId int, info *domain.UserInfo) (*domain.User, error) {
+	if info.Name == "" && info.Email == "" {
+		return nil, ErrEmptyUserInfo
+	}
+	if info.Name != "" {
+		if err := validate.Name(info.Name); err != nil {
+			return nil, err
+		}
+	}
+	if info.Email != "" {
+		if err := validate.Email(info.Email); err != nil {
+			return nil, err
+		}
+	}
+
+	user, err := s.repo.GetUser(ctx, userId)
+	if err != nil {
+		return nil, fmt.Errorf("can't get user: %w", err)
+	}
+
+	update := &domain.UserUpdate{Name: info.Name}
+	var token string
+	switch info.Email {
+	case "":
+	case user.Email:
+		update.CancelEmailChange = user.PendingEmail != ""
+	default:
+		other, err := s.repo.GetUserByEmail(ctx, info.Email)
+		if err != nil {
+			return nil, fmt.Errorf("can't get user by email: %w", err)
+		}
+		if other != nil {
+			return nil, ErrEmailTaken
+		}
+		if update.Verification, token, err = s.newVerification(user, info.Email); err != nil {
+			return nil, err
+		}
+	}
+
+	applied, err := s.repo.ApplyUserUpdate(ctx, userId, update, s.cfg.Interval)
+	if err != nil {
+		return nil, fmt.Errorf("can't update user: %w", err)
+	}
+	if !applied {
+		return nil, ErrVerificationThrottled
+	}
+	if update.Verification != nil {
+		if err := s.mail(ctx, user, info.Email, token); err != nil {
+			return nil, err
+		}
+	}
+
+	user, err = s.repo.GetUser(ctx, userId)
+	if err != nil {
+		return nil, fmt.Errorf("can't get user: %w", err)
+	}
+	return user, nil
+}
+
+// send creates a verification of the email for the user and emails its token there.
+func (s *emailService) send(ctx context.Context, user *domain.User, email string) error {
+	v, token, err := s.newVerification(user, email)
+	if err != nil {
+		return err
+	}
+	created, err := s.repo.CreateEmailVerification(ctx, v, s.cfg.Interval)
+	if err != nil {
+		return fmt.Errorf("can't create email verification: %w", err)
+	}
+	if !created {
+		return ErrVerificationThrottled
+	}
+	return s.mail(ctx, user, email, token)
+}
+
+// newVerification returns a verification of the email for the user, to be stored, and its token.
+func (s *emailService) newVerification(user *domain.User, email string) (*domain.EmailVerification, string, error) {
+	token, err := newToken()
+	if err != nil {
+		return nil, "", fmt.Errorf("can't generate verification token: %w", err)
+	}
+	now := time.Now()
+	return &domain.EmailVerification{
+		UserId:    user.Id,
+		Email:     email,
+		Hash:      hashToken(token),
+		ExpiresAt: now.Add(s.cfg.TTL),
+		CreatedAt: now,
+	}, token, nil
+}
+
+// mail emails the token of a verification of the email to the user.
+func (s *emailService) mail(ctx context.Context, user *domain.User, email string, token string) error {
+	body := fmt.Sprintf("Hello %s,\n\n", user.Name)
+	if s.cfg.URL != "" {
+		body += fmt.Sprintf("To confirm that this email is yours, follow this link:\n\n%s%s\n\n", s.cfg.URL, token)
+	} else {
+		body += fmt.Sprintf("To confirm that this email is yours, use this token:\n\n%s\n\n", token)
+	}
+	body += fmt.Sprintf("It expires in %s. If you didn't sign up or change your email, ignore this email.\n",
+		expiresIn(s.cfg.TTL))
+
+	err := s.mailer.Send(ctx, &mail.Message{To: email, Subject: "Confirm your email", Body: body})
+	if err != nil {
+		return fmt.Errorf("can't send verification email: %w", err)
+	}
+	return nil
+}
+
+func (s *emailService) VerifyEmail(ctx context.Context, token string) (*domain.User, error) {
+	userId, err := s.repo.VerifyEmail(ctx, hashToken(token), time.Now())
+	if errors.Is(err, domain.ErrEmailTaken) {
+		return nil, ErrEmailTaken
+	}
+	if err != nil {
+		return nil, fmt.Errorf("can't verify email: %w", err)
+	}
+	if userId == 0 {
+		return nil, ErrInvalidVerificationToken
+	}
+
+	user, err := s.repo.GetUser(ctx, userId)
+	if err != nil {
+		return nil, fmt.Errorf("can't get user: %w", err)
+	}
+	return user, nil
+}
+
+func (s *emailService) EmailVerified(ctx context.Context, userId int) (bool, error) {
+	user, err := s.repo.GetUser(ctx, userId)
+	if err != nil {
+		return false, fmt.Errorf("can't get user: %w", err)
+	}
+	return user.EmailVerified(), nil
+}
+
+// expiresIn tells how long a token of the TTL is valid for, in whole hours or else in minutes.
+func expiresIn(ttl time.Duration) string {
+	switch {
+	case ttl == time.Hour:
+		return "1 hour"
+	case ttl > time.Hour && ttl%time.Hour == 0:
+		return fmt.Sprintf("%d hours", int(ttl.Hours()))
+	default:
+		return fmt.Sprintf("%d minutes", int(ttl.Minutes()))
+	}
+}
@@ -0,0 +1,144 @@
+package service
+
+import (
+	"bytes"
+	"context"
+	"fmt"
+	"regexp"
+	"testing"
+	"time"
+
+	"bank-api/internal/domain"
+	"bank-api/mocks"
+	"bank-api/pkg/mail"
+	"bank-api/pkg/validate"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"go.uber.org/mock/gomock"
+)
+
+func TestSendVerification(t *testing.T) {
+	mockRepo := mocks.NewMockUserRepository(gomock.NewController(t))
+	var sent bytes.Buffer
+	cfg := EmailVerificationConfig{TTL: 24 * time.Hour, Interval: time.Minute}
+	s := NewEmailService(mockRepo, mail.NewWriter(&sent, "no-reply@bank.example"), cfg)
+	ctx := context.Background()
+
+	mockRepo.EXPECT().GetUser(gomock.Any(), 1).Return(&domain.User{Id: 1, Email: "test@example.com"}, nil).Times(2)
+	var stored *domain.EmailVerification
+	mockRepo.EXPECT().CreateEmailVerification(gomock.Any(), gomock.Any(), time.Minute).DoAndReturn(func(_ context.Context, v *domain.EmailVerification, _ time.Duration) (bool, error) {
+		stored = v
+		return true, nil
+	})
+	require.NoError(t, s.SendVerification(ctx, 1))
+	assert.Equal(t, "test@example.com", stored.Email)
+	assert.WithinDuration(t, time.Now().Add(24*time.Hour), stored.ExpiresAt, time.Second)
+	assert.Contains(t, sent.String(), "To: test@example.com")
+	assert.Contains(t, sent.String(), "It expires in 24 hours.")
+	token := regexp.MustCompile(`use this token:\r\n\r\n(\S+)`).FindStringSubmatch(sent.String())
+	require.Len(t, token, 2)
+	assert.Equal(t, hashToken(token[1]), stored.Hash)
+
+	// Re-sending too soon is refused.
+	sent.Reset()
+	mockRepo.EXPECT().CreateEmailVerification(gomock.Any(), gomock.Any(), time.Minute).Return(false, nil)
+	assert.ErrorIs(t, s.SendVerification(ctx, 1), ErrVerificationThrottled)
+	assert.Empty(t, sent.String())
+
+	// A verified user has nothing to verify, unless they are changing their email.
+	verified := &domain.User{Id: 2, Email: "verified@example.com", EmailVerifiedAt: time.Now()}
+	mockRepo.EXPECT().GetUser(gomock.Any(), 2).Return(verified, nil)
+	assert.ErrorIs(t, s.SendVerification(ctx, 2), ErrEmailAlreadyVerified)
+
+	changing := &domain.User{Id: 3, Email: "old@example.com", PendingEmail: "new@example.com", EmailVerifiedAt: time.Now()}
+	mockRepo.EXPECT().GetUser(gomock.Any(), 3).Return(changing, nil)
+	mockRepo.EXPECT().CreateEmailVerification(gomock.Any(), gomock.Any(), time.Minute).DoAndReturn(func(_ context.Context, v *domain.EmailVerification, _ time.Duration) (bool, error) {
+		assert.Equal(t, "new@example.com", v.Email)
+		return true, nil
+	})
+	require.NoError(t, s.SendVerification(ctx, 3))
+	assert.Contains(t, sent.String(), "To: new@example.com")
+}
+
+func TestUpdateUser(t *testing.T) {
+	mockRepo := mocks.NewMockUserRepository(gomock.NewController(t))
+	var sent bytes.Buffer
+	cfg := EmailVerificationConfig{TTL: 24 * time.Hour, Interval: time.Minute}
+	s := NewEmailService(mockRepo, mail.NewWriter(&sent, "no-reply@bank.example"), cfg)
+	ctx := context.Background()
+
+	// Both fields are checked before anything is stored: no repository call is expected.
+	_, err := s.UpdateUser(ctx, 1, &domain.UserInfo{})
+	assert.ErrorIs(t, err, ErrEmptyUserInfo)
+	_, err = s.UpdateUser(ctx, 1, &domain.UserInfo{Name: "New Name", Email: "not an email"})
+	assert.ErrorIs(t, err, validate.ErrInvalidEmail)
+	_, err = s.UpdateUser(ctx, 1, &domain.UserInfo{Name: "x", Email: "new@example.com"})
+	assert.ErrorIs(t, err, validate.ErrInvalidName)
+
+	user := &domain.User{Id: 1, Name: "Old Name", Email: "old@example.com", EmailVerifiedAt: time.Now()}
+	mockRepo.EXPECT().GetUser(gomock.Any(), 1).Return(user, nil).AnyTimes()
+
+	mockRepo.EXPECT().GetUserByEmail(gomock.Any(), "taken@example.com").Return(&domain.User{Id: 2}, nil)
+	_, err = s.UpdateUser(ctx, 1, &domain.UserInfo{Name: "New Name", Email: "taken@example.com"})
+	assert.ErrorIs(t, err, ErrEmailTaken)
+
+	// The name and the verification are stored at once, and the verification goes to the new email only.
+	mockRepo.EXPECT().GetUserByEmail(gomock.Any(), "new@example.com").Return(nil, nil).Times(2)
+	mockRepo.EXPECT().ApplyUserUpdate(gomock.Any(), 1, gomock.Any(), time.Minute).DoAndReturn(func(_ context.Context, _ int, u *domain.UserUpdate, _ time.Duration) (bool, error) {
+		assert.Equal(t, "New Name", u.Name)
+		assert.Equal(t, 1, u.Verification.UserId)
+		assert.Equal(t, "new@example.com", u.Verification.Email)
+		assert.False(t, u.CancelEmailChange)
+		return true, nil
+	})
+	_, err = s.UpdateUser(ctx, 1, &domain.UserInfo{Name: "New Name", Email: "new@example.com"})
+	require.NoError(t, err)
+	assert.Contains(t, sent.String(), "To: new@example.com")
+	assert.NotContains(t, sent.String(), "old@example.com")
+
+	// Too soon after the last verification nothing changes, not even the name.
+	sent.Reset()
+	mockRepo.EXPECT().ApplyUserUpdate(gomock.Any(), 1, gomock.Any(), time.Minute).Return(false, nil)
+	_, err = s.UpdateUser(ctx, 1, &domain.UserInfo{Name: "New Name", Email: "new@example.com"})
+	assert.ErrorIs(t, err, ErrVerificationThrottled)
+	assert.Empty(t, sent.String())
+
+	// Changing back to the current email cancels the change, without a verification.
+	changing := &domain.User{Id: 2, Email: "old@example.com", PendingEmail: "new@example.com", EmailVerifiedAt: time.Now()}
+	mockRepo.EXPECT().GetUser(gomock.Any(), 2).Return(changing, nil).Times(2)
+	mockRepo.EXPECT().ApplyUserUpdate(gomock.Any(), 2, &domain.UserUpdate{CancelEmailChange: true}, time.Minute).Return(true, nil)
+	_, err = s.UpdateUser(ctx, 2, &domain.UserInfo{Email: "old@example.com"})
+	assert.NoError(t, err)
+	assert.Empty(t, sent.String())
+}
+
+func TestVerifyEmail(t *testing.T) {
+	mockRepo := mocks.NewMockUserRepository(gomock.NewController(t))
+	var sent bytes.Buffer
+	cfg := EmailVerificationConfig{TTL: 24 * time.Hour, Interval: time.Minute}
+	s := NewEmailService(mockRepo, mail.NewWriter(&sent, "no-reply@bank.example"), cfg)
+	ctx := context.Background()
+
+	mockRepo.EXPECT().VerifyEmail(gomock.Any(), hashToken("used"), gomock.Any()).Return(0, nil)
+	_, err := s.VerifyEmail(ctx, "used")
+	assert.ErrorIs(t, err, ErrInvalidVerificationToken)
+
+	mockRepo.EXPECT().VerifyEmail(gomock.Any(), hashToken("taken"), gomock.Any()).Return(0, fmt.Errorf("error verifying email: %w", domain.ErrEmailTaken))
+	_, err = s.VerifyEmail(ctx, "taken")
+	assert.ErrorIs(t, err, ErrEmailTaken)
+
+	user := &domain.User{Id: 1, Email: "new@example.com", EmailVerifiedAt: time.Now()}
+	mockRepo.EXPECT().VerifyEmail(gomock.Any(), hashToken("token"), gomock.Any()).Return(1, nil)
+	mockRepo.EXPECT().GetUser(gomock.Any(), 1).Return(user, nil)
+	verified, err := s.VerifyEmail(ctx, "token")
+	assert.NoError(t, err)
+	assert.Equal(t, user, verified)
+}
+
+func TestExpiresIn(t *testing.T) {
+	assert.Equal(t, "30 minutes", expiresIn(30*time.Minute))
+	assert.Equal(t, "1 hour", expiresIn(time.Hour))
+	assert.Equal(t, "90 minutes", expiresIn(90*time.Minute))
+	assert.Equal(t, "24 hours", expiresIn(24*time.Hour))
+}
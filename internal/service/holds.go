@@ -0,0 +1,189 @@
+package service
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"time"
+
+	"bank-api/internal/domain"
+)
+
+const maxHoldTTL = 30 * 24 * time.Hour
+
+var (
+	ErrNoSuchHold         = errors.New("no such hold")
+	ErrHoldNotActive      = errors.New("hold is not active")
+	ErrCaptureExceedsHold = errors.New("capture exceeds the held amount")
+	ErrInvalidHoldTTL     = errors.New("invalid hold ttl")
+	ErrCurrencyMismatch   = errors.New("accounts have different currencies")
+)
+
+func (s *transactionService) PlaceHold(ctx context.Context, userId int, hold *domain.Hold, ttl time.Duration) (*domain.Hold, error) {
+	if ttl == 0 {
+		ttl = s.holdTTL
+	}
+	if ttl < 0 || ttl > maxHoldTTL {
+		return nil, ErrInvalidHoldTTL
+	}
+	if hold.Amount.Sign() <= 0 {
+		return nil, ErrInvalidAmount
+	}
+
+	ok, err := s.repo.AccountExists(ctx, hold.AccountId)
+	if err != nil {
+		return nil, fmt.Errorf("can't check if such an account exists: %w", err)
+	}
+	if !ok {
+		return nil, ErrNoSuchAccount
+	}
+
+	account, err := s.repo.GetAccount(ctx, hold.AccountId)
+	if err != nil {
+		return nil, fmt.Errorf("can't get account: %w", err)
+	}
+	if account.UserId != userId {
+		return nil, ErrInvalidAccount
+	}
+
+	amount, err := amountIn(hold.Amount, account.Cur)
+	if err != nil {
+		return nil, err
+	}
+
+	if hold.ToAccountId != 0 {
+		if hold.ToAccountId == hold.AccountId {
+			return nil, ErrInvalidAccount
+		}
+		ok, err := s.repo.AccountExists(ctx, hold.ToAccountId)
+		if err != nil {
+			return nil, fmt.Errorf("can't check if such an account exists: %w", err)
+		}
+		if !ok {
+			return nil, ErrNoSuchAccount
+		}
+		accTo, err := s.repo.GetAccount(ctx, hold.ToAccountId)
+		if err != nil {
+			return nil, fmt.Errorf("can't get account: %w", err)
+		}
+		if accTo.Cur.Id != account.Cur.Id {
+			return nil, ErrCurrencyMismatch
+		}
+	}
+
+	placed := &domain.Hold{
+		AccountId:   hold.AccountId,
+		ToAccountId: hold.ToAccountId,
+		Cur:         account.Cur,
+		Amount:      amount,
+	}
+	err = s.repo.CreateHold(ctx, placed, ttl)
+	switch {
+	case errors.Is(err, domain.ErrInsufficientFunds):
+		return nil, ErrNotEnoughMoney
+	case errors.Is(err, domain.ErrAccountFrozen):
+		return nil, ErrAccountFrozen
+	case err != nil:
+		return nil, fmt.Errorf("can't create hold: %w", err)
+	}
+
+	return placed, nil
+}
+
+// getOwnHold returns the hold if it is on an account of the user.
+func (s *transactionService) getOwnHold(ctx context.Context, userId int, holdId int) (*domain.Hold, error) {
+	hold, err := s.repo.GetHold(ctx, holdId)
+	if err != nil {
+		return nil, fmt.Errorf("can't get hold: %w", err)
+	}
+	if hold == nil {
+		return nil, ErrNoSuchHold
+	}
+
+	account, err := s.repo.GetAccount(ctx, hold.AccountId)
+	if err != nil {
+		return nil, fmt.Errorf("can't get account: %w", err)
+	}
+	if account.UserId != userId {
+		return nil, ErrInvalidAccount
+	}
+
+	return hold, nil
+}
+
+func (s *transactionService) CaptureHold(ctx context.Context, userId int, holdId int, amount domain.Money) (*domain.Transaction, error) {
+	hold, err := s.getOwnHold(ctx, userId, holdId)
+	if err != nil {
+		return nil, err
+	}
+	if hold.Status != domain.HoldActive {
+		return nil, ErrHoldNotActive
+	}
+
+	if amount.IsZero() {
+		amount = hold.Amount
+	} else if amount, err = amountIn(amount, hold.Cur); err != nil {
+		return nil, err
+	}
+	if amount.Sign() <= 0 {
+		return nil, ErrInvalidAmount
+	}
+	if amount.Cmp(hold.Amount) > 0 {
+		return nil, ErrCaptureExceedsHold
+	}
+
+	capture := &domain.Transaction{
+		UserId:        userId,
+		FromAccountId: hold.AccountId,
+		ToAccountId:   hold.ToAccountId,
+		Cur:           hold.Cur,
+		Amount:        amount,
+		ToCur:         hold.Cur,
+		ToAmount:      amount,
+		HoldId:        hold.Id,
+		Type:          domain.Transfer,
+	}
+	if hold.ToAccountId == 0 {
+		capture.Type = domain.Withdraw
+	}
+
+	err = s.repo.CaptureHold(ctx, capture)
+	switch {
+	case errors.Is(err, domain.ErrInsufficientFunds):
+		return nil, ErrNotEnoughMoney
+	case errors.Is(err, domain.ErrAccountFrozen):
+		return nil, ErrAccountFrozen
+	case errors.Is(err, domain.ErrHoldNotActive):
+		return nil, ErrHoldNotActive
+	case errors.Is(err, domain.ErrCaptureExceedsHold):
+		return nil, ErrCaptureExceedsHold
+	case err != nil:
+		return nil, fmt.Errorf("can't capture hold: %w", err)
+	}
+
+	return capture, nil
+}
+
+func (s *transactionService) ReleaseHold(ctx context.Context, userId int, holdId int) error {
+	if _, err := s.getOwnHold(ctx, userId, holdId); err != nil {
+		return err
+	}
+
+	err := s.repo.ReleaseHold(ctx, holdId)
+	if errors.Is(err, domain.ErrHoldNotActive) {
+		return ErrHoldNotActive
+	}
+	if err != nil {
+		return fmt.Errorf("can't release hold: %w", err)
+	}
+
+	return nil
+}
+
+func (s *transactionService) ExpireHolds(ctx context.Context) (int, error) {
+	n, err := s.repo.ExpireHolds(ctx)
+	if err != nil {
+		return 0, fmt.Errorf("can't expire holds: %w", err)
+	}
+	return n, nil
+}
@@ -0,0 +1,217 @@
+package service
+
+import (
+	"context"
+	"fmt"
+	"testing"
+	"time"
+
+	"bank-api/internal/domain"
+	"bank-api/mocks"
+
+	"github.com/stretchr/testify/assert"
+	"go.uber.org/mock/gomock"
+)
+
+func TestPlaceHold(t *testing.T) {
+	mockRepo := mocks.NewMockAccountRepository(gomock.NewController(t))
+
+	mockRepo.EXPECT().AccountExists(gomock.Any(), 1).Return(true, nil)
+	mockRepo.EXPECT().GetAccount(gomock.Any(), 1).Return(&domain.Account{Id: 1, UserId: 1, Cur: rub}, nil)
+	mockRepo.EXPECT().AccountExists(gomock.Any(), 2).Return(true, nil)
+	mockRepo.EXPECT().GetAccount(gomock.Any(), 2).Return(&domain.Account{Id: 2, UserId: 2, Cur: rub}, nil)
+	mockRepo.EXPECT().CreateHold(gomock.Any(), &domain.Hold{
+		AccountId:   1,
+		ToAccountId: 2,
+		Cur:         rub,
+		Amount:      domain.NewMoney(5000, rub),
+	}, 24*time.Hour).DoAndReturn(func(_ context.Context, h *domain.Hold, _ time.Duration) error {
+		h.Id = 3
+		h.Status = domain.HoldActive
+		return nil
+	})
+
+	s := NewTransactionService(mockRepo, nil, nil, 0, 24*time.Hour)
+
+	hold, err := s.PlaceHold(context.Background(), 1, &domain.Hold{AccountId: 1, ToAccountId: 2, Amount: money(t, "50")}, 0)
+	assert.NoError(t, err)
+	assert.Equal(t, 3, hold.Id)
+	assert.Equal(t, domain.HoldActive, hold.Status)
+}
+
+func TestPlaceHold_Errors(t *testing.T) {
+	tests := []struct {
+		name   string
+		amount string
+		ttl    time.Duration
+		owner  int
+		toCur  domain.Currency
+		create error
+		err    error
+	}{
+		{name: "zero amount", amount: "0", err: ErrInvalidAmount},
+		{name: "ttl too long", amount: "50", ttl: maxHoldTTL + time.Second, err: ErrInvalidHoldTTL},
+		{name: "not the owner", amount: "50", owner: 2, err: ErrInvalidAccount},
+		{name: "too precise", amount: "0.001", owner: 1, err: ErrInvalidAmount},
+		{name: "other currency", amount: "50", owner: 1, toCur: domain.Currency{Id: 2, Symbol: "USD"}, err: ErrCurrencyMismatch},
+		{name: "not enough money", amount: "50", owner: 1, toCur: rub, create: domain.ErrInsufficientFunds, err: ErrNotEnoughMoney},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			mockRepo := mocks.NewMockAccountRepository(gomock.NewController(t))
+
+			if tt.owner != 0 {
+				mockRepo.EXPECT().AccountExists(gomock.Any(), 1).Return(true, nil)
+				mockRepo.EXPECT().GetAccount(gomock.Any(), 1).Return(&domain.Account{Id: 1, UserId: tt.owner, Cur: rub}, nil)
+			}
+			if tt.toCur.Symbol != "" {
+				mockRepo.EXPECT().AccountExists(gomock.Any(), 2).Return(true, nil)
+				mockRepo.EXPECT().GetAccount(gomock.Any(), 2).Return(&domain.Account{Id: 2, UserId: 2, Cur: tt.toCur}, nil)
+			}
+			if tt.create != nil {
+				mockRepo.EXPECT().CreateHold(gomock.Any(), gomock.Any(), gomock.Any()).Return(fmt.Errorf("wrapped: %w", tt.create))
+			}
+
+			s := NewTransactionService(mockRepo, nil, nil, 0, time.Hour)
+
+			_, err := s.PlaceHold(context.Background(), 1, &domain.Hold{AccountId: 1, ToAccountId: 2, Amount: money(t, tt.amount)}, tt.ttl)
+			assert.ErrorIs(t, err, tt.err)
+		})
+	}
+}
+
+func activeHold() *domain.Hold {
+	return &domain.Hold{
+		Id:          3,
+		AccountId:   1,
+		ToAccountId: 2,
+		Cur:         rub,
+		Amount:      domain.NewMoney(5000, rub),
+		Status:      domain.HoldActive,
+	}
+}
+
+func TestCaptureHold(t *testing.T) {
+	tests := []struct {
+		name   string
+		hold   func() *domain.Hold
+		amount string
+		want   *domain.Transaction
+	}{
+		{
+			name: "full",
+			hold: activeHold,
+			want: &domain.Transaction{
+				UserId: 1, FromAccountId: 1, ToAccountId: 2, Cur: rub, Amount: domain.NewMoney(5000, rub),
+				ToCur: rub, ToAmount: domain.NewMoney(5000, rub), HoldId: 3, Type: domain.Transfer,
+			},
+		},
+		{
+			name:   "partial",
+			hold:   activeHold,
+			amount: "20.5",
+			want: &domain.Transaction{
+				UserId: 1, FromAccountId: 1, ToAccountId: 2, Cur: rub, Amount: domain.NewMoney(2050, rub),
+				ToCur: rub, ToAmount: domain.NewMoney(2050, rub), HoldId: 3, Type: domain.Transfer,
+			},
+		},
+		{
+			name: "out of the bank",
+			hold: func() *domain.Hold { h := activeHold(); h.ToAccountId = 0; return h },
+			want: &domain.Transaction{
+				UserId: 1, FromAccountId: 1, Cur: rub, Amount: domain.NewMoney(5000, rub),
+				ToCur: rub, ToAmount: domain.NewMoney(5000, rub), HoldId: 3, Type: domain.Withdraw,
+			},
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			mockRepo := mocks.NewMockAccountRepository(gomock.NewController(t))
+
+			mockRepo.EXPECT().GetHold(gomock.Any(), 3).Return(tt.hold(), nil)
+			mockRepo.EXPECT().GetAccount(gomock.Any(), 1).Return(&domain.Account{Id: 1, UserId: 1, Cur: rub}, nil)
+			mockRepo.EXPECT().CaptureHold(gomock.Any(), tt.want).Return(nil)
+
+			s := NewTransactionService(mockRepo, nil, nil, 0, 0)
+
+			var amount domain.Money
+			if tt.amount != "" {
+				amount = money(t, tt.amount)
+			}
+			_, err := s.CaptureHold(context.Background(), 1, 3, amount)
+			assert.NoError(t, err)
+		})
+	}
+}
+
+func TestCaptureHold_Errors(t *testing.T) {
+	tests := []struct {
+		name    string
+		hold    func() *domain.Hold
+		owner   int
+		amount  string
+		capture error
+		err     error
+	}{
+		{name: "no such hold", hold: func() *domain.Hold { return nil }, err: ErrNoSuchHold},
+		{name: "not the owner", hold: activeHold, owner: 2, err: ErrInvalidAccount},
+		{name: "released", hold: func() *domain.Hold { h := activeHold(); h.Status = domain.HoldReleased; return h }, owner: 1, err: ErrHoldNotActive},
+		{name: "too much", hold: activeHold, owner: 1, amount: "50.01", err: ErrCaptureExceedsHold},
+		{name: "negative", hold: activeHold, owner: 1, amount: "-1", err: ErrInvalidAmount},
+		{name: "expired meanwhile", hold: activeHold, owner: 1, capture: domain.ErrHoldNotActive, err: ErrHoldNotActive},
+		{name: "balance lowered", hold: activeHold, owner: 1, capture: domain.ErrInsufficientFunds, err: ErrNotEnoughMoney},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			mockRepo := mocks.NewMockAccountRepository(gomock.NewController(t))
+
+			mockRepo.EXPECT().GetHold(gomock.Any(), 3).Return(tt.hold(), nil)
+			if tt.owner != 0 {
+				mockRepo.EXPECT().GetAccount(gomock.Any(), 1).Return(&domain.Account{Id: 1, UserId: tt.owner, Cur: rub}, nil)
+			}
+			if tt.capture != nil {
+				mockRepo.EXPECT().CaptureHold(gomock.Any(), gomock.Any()).Return(fmt.Errorf("wrapped: %w", tt.capture))
+			}
+
+			s := NewTransactionService(mockRepo, nil, nil, 0, 0)
+
+			var amount domain.Money
+			if tt.amount != "" {
+				amount = money(t, tt.amount)
+			}
+			_, err := s.CaptureHold(context.Background(), 1, 3, amount)
+			assert.ErrorIs(t, err, tt.err)
+		})
+	}
+}
+
+func TestReleaseHold(t *testing.T) {
+	mockRepo := mocks.NewMockAccountRepository(gomock.NewController(t))
+
+	mockRepo.EXPECT().GetHold(gomock.Any(), 3).Return(activeHold(), nil).Times(2)
+	mockRepo.EXPECT().GetAccount(gomock.Any(), 1).Return(&domain.Account{Id: 1, UserId: 1, Cur: rub}, nil).Times(2)
+	gomock.InOrder(
+		mockRepo.EXPECT().ReleaseHold(gomock.Any(), 3).Return(nil),
+		mockRepo.EXPECT().ReleaseHold(gomock.Any(), 3).Return(domain.ErrHoldNotActive),
+	)
+
+	s := NewTransactionService(mockRepo, nil, nil, 0, 0)
+
+	assert.NoError(t, s.ReleaseHold(context.Background(), 1, 3))
+	assert.ErrorIs(t, s.ReleaseHold(context.Background(), 1, 3), ErrHoldNotActive)
+}
+
+func TestExpireHolds(t *testing.T) {
+	mockRepo := mocks.NewMockAccountRepository(gomock.NewController(t))
+
+	mockRepo.EXPECT().ExpireHolds(gomock.Any()).Return(2, nil)
+
+	s := NewTransactionService(mockRepo, nil, nil, 0, 0)
+
+	n, err := s.ExpireHolds(context.Background())
+	assert.NoError(t, err)
+	assert.Equal(t, 2, n)
+}
@@ -0,0 +1,101 @@
+package service
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"time"
+
+	"bank-api/internal/domain"
+	"bank-api/internal/repository"
+)
+
+const (
+	maxIdempotencyKeyLen = 255
+
+	idempotencyPollInterval = 100 * time.Millisecond
+	idempotencyWaitTimeout  = 10 * time.Second
+)
+
+var (
+	ErrInvalidIdempotencyKey    = errors.New("invalid idempotency key")
+	ErrIdempotencyKeyReused     = errors.New("idempotency key is already used for another request")
+	ErrIdempotencyKeyInProgress = errors.New("request with the same idempotency key is still in progress")
+)
+
+type IdempotencyService interface {
+	// Begin either claims the key for a new request and returns nil, or returns the stored response of the
+	// request that claimed it first. Concurrent requests with the same key wait until the first one completes.
+	Begin(ctx context.Context, userId int, key string, requestHash string) (*domain.IdempotentResponse, error)
+	Complete(ctx context.Context, userId int, key string, resp *domain.IdempotentResponse) error
+	// Abandon releases the key so that the request can be retried, e.g. after an internal error.
+	Abandon(ctx context.Context, userId int, key string) error
+}
+
+type idempotencyService struct {
+	repo repository.IdempotencyRepository
+	ttl  time.Duration
+}
+
+func NewIdempotencyService(repo repository.IdempotencyRepository, ttl time.Duration) IdempotencyService {
+	return &idempotencyService{repo: repo, ttl: ttl}
+}
+
+func (s *idempotencyService) Begin(ctx context.Context, userId int, key string, requestHash string) (*domain.IdempotentResponse, error) {
+	if key == "" || len(key) > maxIdempotencyKeyLen {
+		return nil, ErrInvalidIdempotencyKey
+	}
+
+	ctx, cancel := context.WithTimeout(ctx, idempotencyWaitTimeout)
+	defer cancel()
+
+	for {
+		ok, err := s.repo.CreateIdempotencyKey(ctx, &domain.IdempotencyRecord{
+			UserId:      userId,
+			Key:         key,
+			RequestHash: requestHash,
+			ExpiresAt:   time.Now().Add(s.ttl),
+		})
+		if err != nil {
+			return nil, fmt.Errorf("can't create idempotency key: %w", err)
+		}
+		if ok {
+			return nil, nil
+		}
+
+		record, err := s.repo.GetIdempotencyKey(ctx, userId, key)
+		if err != nil {
+			return nil, fmt.Errorf("can't get idempotency key: %w", err)
+		}
+
+		// The key is gone if the first request has just been abandoned, so try to claim it again.
+		if record != nil {
+			if record.RequestHash != requestHash {
+				return nil, ErrIdempotencyKeyReused
+			}
+			if record.Response != nil {
+				return record.Response, nil
+			}
+		}
+
+		select {
+		case <-ctx.Done():
+			return nil, ErrIdempotencyKeyInProgress
+		case <-time.After(idempotencyPollInterval):
+		}
+	}
+}
+
+func (s *idempotencyService) Complete(ctx context.Context, userId int, key string, resp *domain.IdempotentResponse) error {
+	if err := s.repo.SaveIdempotentResponse(ctx, userId, key, resp); err != nil {
+		return fmt.Errorf("can't save idempotent response: %w", err)
+	}
+	return nil
+}
+
+func (s *idempotencyService) Abandon(ctx context.Context, userId int, key string) error {
+	if err := s.repo.DeleteIdempotencyKey(ctx, userId, key); err != nil {
+		return fmt.Errorf("can't delete idempotency key: %w", err)
+	}
+	return nil
+}
@@ -0,0 +1,109 @@
+package service
+
+import (
+	"context"
+	"testing"
+	"time"
+
+	"bank-api/internal/domain"
+	"bank-api/mocks"
+
+	"github.com/stretchr/testify/assert"
+	"go.uber.org/mock/gomock"
+)
+
+func TestIdempotency_Begin(t *testing.T) {
+	mockRepo := mocks.NewMockIdempotencyRepository(gomock.NewController(t))
+
+	mockRepo.EXPECT().CreateIdempotencyKey(gomock.Any(), gomock.Any()).Return(true, nil)
+
+	s := NewIdempotencyService(mockRepo, time.Hour)
+
+	resp, err := s.Begin(context.Background(), 1, "key", "hash")
+	assert.NoError(t, err)
+	assert.Nil(t, resp)
+}
+
+func TestIdempotency_Begin_Replay(t *testing.T) {
+	mockRepo := mocks.NewMockIdempotencyRepository(gomock.NewController(t))
+
+	stored := &domain.IdempotentResponse{StatusCode: 204}
+	mockRepo.EXPECT().CreateIdempotencyKey(gomock.Any(), gomock.Any()).Return(false, nil)
+	mockRepo.EXPECT().GetIdempotencyKey(gomock.Any(), 1, "key").Return(&domain.IdempotencyRecord{
+		UserId:      1,
+		Key:         "key",
+		RequestHash: "hash",
+		Response:    stored,
+	}, nil)
+
+	s := NewIdempotencyService(mockRepo, time.Hour)
+
+	resp, err := s.Begin(context.Background(), 1, "key", "hash")
+	assert.NoError(t, err)
+	assert.Equal(t, stored, resp)
+}
+
+func TestIdempotency_Begin_DifferentRequest(t *testing.T) {
+	mockRepo := mocks.NewMockIdempotencyRepository(gomock.NewController(t))
+
+	mockRepo.EXPECT().CreateIdempotencyKey(gomock.Any(), gomock.Any()).Return(false, nil)
+	mockRepo.EXPECT().GetIdempotencyKey(gomock.Any(), 1, "key").Return(&domain.IdempotencyRecord{
+		UserId:      1,
+		Key:         "key",
+		RequestHash: "other hash",
+	}, nil)
+
+	s := NewIdempotencyService(mockRepo, time.Hour)
+
+	resp, err := s.Begin(context.Background(), 1, "key", "hash")
+	assert.Nil(t, resp)
+	assert.ErrorIs(t, err, ErrIdempotencyKeyReused)
+}
+
+func TestIdempotency_Begin_WaitsForFirstRequest(t *testing.T) {
+	mockRepo := mocks.NewMockIdempotencyRepository(gomock.NewController(t))
+
+	inProgress := &domain.IdempotencyRecord{UserId: 1, Key: "key", RequestHash: "hash"}
+	completed := &domain.IdempotencyRecord{UserId: 1, Key: "key", RequestHash: "hash", Response: &domain.IdempotentResponse{StatusCode: 204}}
+
+	mockRepo.EXPECT().CreateIdempotencyKey(gomock.Any(), gomock.Any()).Return(false, nil).Times(2)
+	gomock.InOrder(
+		mockRepo.EXPECT().GetIdempotencyKey(gomock.Any(), 1, "key").Return(inProgress, nil),
+		mockRepo.EXPECT().GetIdempotencyKey(gomock.Any(), 1, "key").Return(completed, nil),
+	)
+
+	s := NewIdempotencyService(mockRepo, time.Hour)
+
+	resp, err := s.Begin(context.Background(), 1, "key", "hash")
+	assert.NoError(t, err)
+	assert.Equal(t, completed.Response, resp)
+}
+
+func TestIdempotency_Begin_StillInProgress(t *testing.T) {
+	mockRepo := mocks.NewMockIdempotencyRepository(gomock.NewController(t))
+
+	mockRepo.EXPECT().CreateIdempotencyKey(gomock.Any(), gomock.Any()).Return(false, nil).AnyTimes()
+	mockRepo.EXPECT().GetIdempotencyKey(gomock.Any(), 1, "key").Return(&domain.IdempotencyRecord{
+		UserId:      1,
+		Key:         "key",
+		RequestHash: "hash",
+	}, nil).AnyTimes()
+
+	s := NewIdempotencyService(mockRepo, time.Hour)
+
+	ctx, cancel := context.WithTimeout(context.Background(), 50*time.Millisecond)
+	defer cancel()
+
+	resp, err := s.Begin(ctx, 1, "key", "hash")
+	assert.Nil(t, resp)
+	assert.ErrorIs(t, err, ErrIdempotencyKeyInProgress)
+}
+
+func TestIdempotency_Begin_InvalidKey(t *testing.T) {
+	mockRepo := mocks.NewMockIdempotencyRepository(gomock.NewController(t))
+
+	s := NewIdempotencyService(mockRepo, time.Hour)
+
+	_, err := s.Begin(context.Background(), 1, "", "hash")
+	assert.ErrorIs(t, err, ErrInvalidIdempotencyKey)
+}
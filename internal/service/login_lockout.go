@@ -0,0 +1,163 @@
+package service
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"strings"
+	"sync"
+	"time"
+
+	"bank-api/internal/repository"
+
+	"golang.org/x/crypto/bcrypt"
+)
+
+var ErrLoginLocked = errors.New("too many failed logins")
+
+// LoginLockedError tells until when logins are refused. It is ErrLoginLocked.
+type LoginLockedError struct {
+	Until time.Time
+}
+
+func (e *LoginLockedError) Error() string {
+	return ErrLoginLocked.Error()
+}
+
+func (e *LoginLockedError) Unwrap() error {
+	return ErrLoginLocked
+}
+
+// LockoutPolicy slows down and then locks out the logins with an email after failures in a row. The failures from
+// an IP address only lock it out at MaxIPFailures, so users behind a shared address aren't slowed down by one
+// another. A zero policy doesn't limit logins at all.
+type LockoutPolicy struct {
+	// FreeAttempts is how many failures in a row of an email go without a delay.
+	FreeAttempts int
+	// BaseDelay is how long logins are refused after the first failure past FreeAttempts. It doubles with
+	// each further failure, up to MaxDelay.
+	BaseDelay time.Duration
+	MaxDelay  time.Duration
+	// After MaxFailures of an email, or MaxIPFailures from an IP address, its logins are locked out for Lockout.
+	MaxFailures   int
+	MaxIPFailures int
+	Lockout       time.Duration
+	// Window is how long after the last failure the failures are forgotten.
+	Window time.Duration
+}
+
+func (p LockoutPolicy) enabled() bool {
+	return p.MaxFailures > 0
+}
+
+// block returns how long logins with an email are refused after the given number of failures in a row.
+func (p LockoutPolicy) block(failures int) time.Duration {
+	if failures >= p.MaxFailures {
+		return p.Lockout
+	}
+	if failures <= p.FreeAttempts {
+		return 0
+	}
+	delay := p.BaseDelay
+	for i := p.FreeAttempts + 1; i < failures && delay < p.MaxDelay; i++ {
+		delay *= 2
+	}
+	return min(delay, p.MaxDelay)
+}
+
+// loginLimiter counts the failed logins and refuses logins as its policy says. Every check of a password goes
+// through one, so that guessing it anywhere runs into the same lockout.
+type loginLimiter struct {
+	repo   repository.UserRepository
+	policy LockoutPolicy
+}
+
+func emailLoginKey(email string) string {
+	return "email:" + strings.ToLower(strings.TrimSpace(email))
+}
+
+func ipLoginKey(ip string) string {
+	return "ip:" + ip
+}
+
+// check returns a LoginLockedError if logins with the email or from the IP address are blocked.
+// Emails of no user are counted and blocked like any other, so a lockout doesn't tell which emails are registered.
+func (l loginLimiter) check(ctx context.Context, email string, ip string, now time.Time) error {
+	if !l.policy.enabled() {
+		return nil
+	}
+
+	keys := []string{emailLoginKey(email)}
+	if ip != "" {
+		keys = append(keys, ipLoginKey(ip))
+	}
+	until, err := l.repo.GetLoginBlock(ctx, keys, now)
+	if err != nil {
+		return fmt.Errorf("can't check login block: %w", err)
+	}
+	if until.After(now) {
+		return &LoginLockedError{Until: until}
+	}
+	return nil
+}
+
+// recordFailure counts the failure for the email and the IP address, and blocks them if it's one too many.
+func (l loginLimiter) recordFailure(ctx context.Context, email string, ip string, now time.Time) error {
+	if !l.policy.enabled() {
+		return nil
+	}
+
+	failures, err := l.repo.RecordLoginFailure(ctx, emailLoginKey(email), now, l.policy.Window)
+	if err != nil {
+		return fmt.Errorf("can't record login failure: %w", err)
+	}
+	if err := l.block(ctx, emailLoginKey(email), now, l.policy.block(failures)); err != nil {
+		return err
+	}
+	if ip == "" {
+		return nil
+	}
+
+	failures, err = l.repo.RecordLoginFailure(ctx, ipLoginKey(ip), now, l.policy.Window)
+	if err != nil {
+		return fmt.Errorf("can't record login failure: %w", err)
+	}
+	if l.policy.MaxIPFailures > 0 && failures >= l.policy.MaxIPFailures {
+		return l.block(ctx, ipLoginKey(ip), now, l.policy.Lockout)
+	}
+	return nil
+}
+
+func (l loginLimiter) block(ctx context.Context, key string, now time.Time, block time.Duration) error {
+	if block <= 0 {
+		return nil
+	}
+	if err := l.repo.BlockLogin(ctx, key, now.Add(block)); err != nil {
+		return fmt.Errorf("can't block login: %w", err)
+	}
+	return nil
+}
+
+// clear unlocks the logins with the email. The failures from IP addresses still count.
+func (l loginLimiter) clear(ctx context.Context, email string) error {
+	if !l.policy.enabled() {
+		return nil
+	}
+	if err := l.repo.ClearLoginFailures(ctx, emailLoginKey(email)); err != nil {
+		return fmt.Errorf("can't clear login failures: %w", err)
+	}
+	return nil
+}
+
+var (
+	dummyHashOnce sync.Once
+	dummyHash     []byte
+)
+
+// compareDummyPassword takes as long as checking a real password, for logins with emails of no user.
+func compareDummyPassword(password string) {
+	dummyHashOnce.Do(func() {
+		dummyHash, _ = bcrypt.GenerateFromPassword([]byte("dummy password"), bcrypt.DefaultCost)
+	})
+	_ = bcrypt.CompareHashAndPassword(dummyHash, []byte(password))
+}
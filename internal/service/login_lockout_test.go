@@ -0,0 +1,176 @@
+package service
+
+import (
+	"context"
+	"testing"
+	"time"
+
+	"bank-api/internal/domain"
+	"bank-api/mocks"
+	"bank-api/pkg/totp"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"go.uber.org/mock/gomock"
+	"golang.org/x/crypto/bcrypt"
+)
+
+var testLockoutPolicy = LockoutPolicy{
+	FreeAttempts:  3,
+	BaseDelay:     time.Second,
+	MaxDelay:      10 * time.Second,
+	MaxFailures:   10,
+	MaxIPFailures: 100,
+	Lockout:       15 * time.Minute,
+	Window:        time.Hour,
+}
+
+func TestLockoutPolicy_Block(t *testing.T) {
+	tests := []struct {
+		failures int
+		want     time.Duration
+	}{
+		{failures: 1, want: 0},
+		{failures: 3, want: 0},
+		{failures: 4, want: time.Second},
+		{failures: 5, want: 2 * time.Second},
+		{failures: 7, want: 8 * time.Second},
+		{failures: 8, want: 10 * time.Second},
+		{failures: 9, want: 10 * time.Second},
+		{failures: 10, want: 15 * time.Minute},
+		{failures: 20, want: 15 * time.Minute},
+	}
+	for _, tt := range tests {
+		assert.Equal(t, tt.want, testLockoutPolicy.block(tt.failures), "failures: %d", tt.failures)
+	}
+}
+
+func TestAuthenticateUser_Locked(t *testing.T) {
+	mockRepo := mocks.NewMockUserRepository(gomock.NewController(t))
+	s := NewUserService(mockRepo, TwoFactorConfig{}, testLockoutPolicy)
+
+	until := time.Now().Add(time.Minute)
+	mockRepo.EXPECT().GetLoginBlock(gomock.Any(), []string{"email:test@example.com", "ip:10.0.0.1"}, gomock.Any()).Return(until, nil)
+
+	_, _, err := s.AuthenticateUser(context.Background(), &domain.UserInfo{Email: " Test@Example.com", Password: "password"}, "10.0.0.1")
+	assert.ErrorIs(t, err, ErrLoginLocked)
+	var locked *LoginLockedError
+	require.ErrorAs(t, err, &locked)
+	assert.Equal(t, until, locked.Until)
+}
+
+func TestAuthenticateUser_Failures(t *testing.T) {
+	mockRepo := mocks.NewMockUserRepository(gomock.NewController(t))
+	s := NewUserService(mockRepo, TwoFactorConfig{}, testLockoutPolicy)
+
+	hash, err := bcrypt.GenerateFromPassword([]byte("password"), bcrypt.MinCost)
+	require.NoError(t, err)
+	user := &domain.User{Id: 1, Email: "test@example.com", HashedPassword: string(hash)}
+	mockRepo.EXPECT().GetLoginBlock(gomock.Any(), gomock.Any(), gomock.Any()).Return(time.Time{}, nil).AnyTimes()
+	mockRepo.EXPECT().GetUserByEmail(gomock.Any(), user.Email).Return(user, nil).AnyTimes()
+
+	// The fourth failure in a row of the email blocks it for BaseDelay, but not the IP address.
+	mockRepo.EXPECT().RecordLoginFailure(gomock.Any(), "email:test@example.com", gomock.Any(), time.Hour).Return(4, nil)
+	mockRepo.EXPECT().RecordLoginFailure(gomock.Any(), "ip:10.0.0.1", gomock.Any(), time.Hour).Return(4, nil)
+	mockRepo.EXPECT().BlockLogin(gomock.Any(), "email:test@example.com", gomock.Any()).DoAndReturn(func(_ context.Context, _ string, until time.Time) error {
+		assert.WithinDuration(t, time.Now().Add(time.Second), until, time.Second)
+		return nil
+	})
+	_, _, err = s.AuthenticateUser(context.Background(), &domain.UserInfo{Email: user.Email, Password: "wrong"}, "10.0.0.1")
+	assert.ErrorIs(t, err, ErrWrongPassword)
+
+	// An email of no user fails just like a wrong password, and the IP address is locked out at MaxIPFailures.
+	mockRepo.EXPECT().GetUserByEmail(gomock.Any(), "nobody@example.com").Return(nil, nil)
+	mockRepo.EXPECT().RecordLoginFailure(gomock.Any(), "email:nobody@example.com", gomock.Any(), time.Hour).Return(1, nil)
+	mockRepo.EXPECT().RecordLoginFailure(gomock.Any(), "ip:10.0.0.1", gomock.Any(), time.Hour).Return(100, nil)
+	mockRepo.EXPECT().BlockLogin(gomock.Any(), "ip:10.0.0.1", gomock.Any()).DoAndReturn(func(_ context.Context, _ string, until time.Time) error {
+		assert.WithinDuration(t, time.Now().Add(15*time.Minute), until, time.Second)
+		return nil
+	})
+	_, _, err = s.AuthenticateUser(context.Background(), &domain.UserInfo{Email: "nobody@example.com", Password: "password"}, "10.0.0.1")
+	assert.ErrorIs(t, err, ErrWrongPassword)
+
+	// The right password clears the failures of the email.
+	mockRepo.EXPECT().ClearLoginFailures(gomock.Any(), "email:test@example.com").Return(nil)
+	mockRepo.EXPECT().GetTwoFactor(gomock.Any(), 1).Return(nil, nil)
+	u, _, err := s.AuthenticateUser(context.Background(), &domain.UserInfo{Email: user.Email, Password: "password"}, "10.0.0.1")
+	assert.NoError(t, err)
+	assert.Equal(t, user, u)
+}
+
+func TestCompleteTwoFactorLogin_Failures(t *testing.T) {
+	mockRepo := mocks.NewMockUserRepository(gomock.NewController(t))
+	cfg := testTwoFactorConfig(t)
+	s := NewUserService(mockRepo, cfg, testLockoutPolicy)
+	ctx := context.Background()
+
+	hash, err := bcrypt.GenerateFromPassword([]byte("password"), bcrypt.MinCost)
+	require.NoError(t, err)
+	user := &domain.User{Id: 1, Email: "test@example.com", HashedPassword: string(hash)}
+	tf, secret := enabledTwoFactor(t, cfg)
+	mockRepo.EXPECT().GetUserByEmail(gomock.Any(), user.Email).Return(user, nil).AnyTimes()
+	mockRepo.EXPECT().GetUser(gomock.Any(), 1).Return(user, nil).AnyTimes()
+
+	// The right password of a user with 2FA doesn't clear the failures, as no ClearLoginFailures is expected.
+	mockRepo.EXPECT().GetLoginBlock(gomock.Any(), gomock.Any(), gomock.Any()).Return(time.Time{}, nil).Times(2)
+	mockRepo.EXPECT().GetTwoFactor(gomock.Any(), 1).Return(tf, nil).Times(3)
+	mockRepo.EXPECT().CreateTwoFactorChallenge(gomock.Any(), gomock.Any()).Return(nil)
+	_, challenge, err := s.AuthenticateUser(ctx, &domain.UserInfo{Email: user.Email, Password: "password"}, "10.0.0.1")
+	require.NoError(t, err)
+	require.NotNil(t, challenge)
+
+	// A wrong code counts as a failure of the email and the IP address.
+	step := totp.Step(time.Now())
+	mockRepo.EXPECT().AttemptTwoFactorChallenge(gomock.Any(), hashToken(challenge.Token), gomock.Any(), maxChallengeAttempts).
+		Return(&domain.TwoFactorChallenge{Id: 7, UserId: 1}, nil).Times(3)
+	mockRepo.EXPECT().RecordLoginFailure(gomock.Any(), "email:test@example.com", gomock.Any(), time.Hour).Return(10, nil)
+	mockRepo.EXPECT().RecordLoginFailure(gomock.Any(), "ip:10.0.0.1", gomock.Any(), time.Hour).Return(10, nil)
+	mockRepo.EXPECT().BlockLogin(gomock.Any(), "email:test@example.com", gomock.Any()).Return(nil)
+	_, err = s.CompleteTwoFactorLogin(ctx, challenge.Token, totp.Code(secret, step+5), "10.0.0.1")
+	assert.ErrorIs(t, err, ErrWrongTwoFactorCode)
+
+	// Once the email is locked out, not even the right code is checked.
+	mockRepo.EXPECT().GetLoginBlock(gomock.Any(), []string{"email:test@example.com", "ip:10.0.0.1"}, gomock.Any()).
+		Return(time.Now().Add(15*time.Minute), nil)
+	_, err = s.CompleteTwoFactorLogin(ctx, challenge.Token, totp.Code(secret, step), "10.0.0.1")
+	assert.ErrorIs(t, err, ErrLoginLocked)
+
+	// The right code clears the failures of the email.
+	mockRepo.EXPECT().GetLoginBlock(gomock.Any(), gomock.Any(), gomock.Any()).Return(time.Time{}, nil)
+	mockRepo.EXPECT().UseTwoFactorStep(gomock.Any(), 1, step).Return(true, nil)
+	mockRepo.EXPECT().UseTwoFactorChallenge(gomock.Any(), 7, gomock.Any()).Return(true, nil)
+	mockRepo.EXPECT().ClearLoginFailures(gomock.Any(), "email:test@example.com").Return(nil)
+	u, err := s.CompleteTwoFactorLogin(ctx, challenge.Token, totp.Code(secret, step), "10.0.0.1")
+	require.NoError(t, err)
+	assert.Equal(t, user, u)
+}
+
+func TestChangePassword_Failures(t *testing.T) {
+	ctrl := gomock.NewController(t)
+	mockUsers := mocks.NewMockUserRepository(ctrl)
+	mockSessions := mocks.NewMockSessionRepository(ctrl)
+	tokens := NewTokenService(mockSessions, mockUsers, testJWT, 15*time.Minute, time.Hour, time.Minute)
+	s := NewPasswordService(mockUsers, tokens, nil, PasswordResetConfig{}, testLockoutPolicy)
+	ctx := context.Background()
+
+	hash, err := bcrypt.GenerateFromPassword([]byte("old"), bcrypt.MinCost)
+	require.NoError(t, err)
+	mockUsers.EXPECT().GetUser(gomock.Any(), 1).Return(&domain.User{Id: 1, Email: "test@example.com", HashedPassword: string(hash)}, nil).AnyTimes()
+
+	// A wrong current password counts as a failure of the email and the IP address.
+	mockUsers.EXPECT().GetLoginBlock(gomock.Any(), []string{"email:test@example.com", "ip:10.0.0.1"}, gomock.Any()).Return(time.Time{}, nil).Times(2)
+	mockUsers.EXPECT().RecordLoginFailure(gomock.Any(), "email:test@example.com", gomock.Any(), time.Hour).Return(10, nil)
+	mockUsers.EXPECT().RecordLoginFailure(gomock.Any(), "ip:10.0.0.1", gomock.Any(), time.Hour).Return(10, nil)
+	mockUsers.EXPECT().BlockLogin(gomock.Any(), "email:test@example.com", gomock.Any()).Return(nil)
+	assert.ErrorIs(t, s.ChangePassword(ctx, 1, 5, "wrong", "new", "10.0.0.1"), ErrWrongCurrentPassword)
+
+	// The right one clears the failures of the email.
+	mockUsers.EXPECT().ClearLoginFailures(gomock.Any(), "email:test@example.com").Return(nil)
+	mockUsers.EXPECT().UpdatePassword(gomock.Any(), 1, gomock.Any(), gomock.Any()).Return(nil)
+	mockSessions.EXPECT().RevokeUserSessions(gomock.Any(), 1, 5).Return(nil, nil)
+	assert.NoError(t, s.ChangePassword(ctx, 1, 5, "old", "new", "10.0.0.1"))
+
+	// Once the email is locked out, not even the right password is checked.
+	mockUsers.EXPECT().GetLoginBlock(gomock.Any(), gomock.Any(), gomock.Any()).Return(time.Now().Add(15*time.Minute), nil)
+	assert.ErrorIs(t, s.ChangePassword(ctx, 1, 5, "old", "newer", "10.0.0.1"), ErrLoginLocked)
+}
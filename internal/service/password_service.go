@@ -0,0 +1,214 @@
+package service
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"time"
+
+	"bank-api/internal/domain"
+	"bank-api/internal/repository"
+	"bank-api/pkg/mail"
+	"bank-api/pkg/validate"
+
+	"golang.org/x/crypto/bcrypt"
+)
+
+var (
+	ErrWrongCurrentPassword = errors.New("current password doesn't match")
+	ErrInvalidResetToken    = errors.New("invalid password reset token")
+)
+
+type PasswordResetConfig struct {
+	// TTL is how long a reset token can be used.
+	TTL time.Duration
+	// Interval is how long after a reset email another one can be sent to the same user.
+	Interval time.Duration
+	// URL, if set, is where the email links to, with the token appended.
+	URL string
+}
+
+// resetBatch is how many stored reset requests SendResets handles at a time.
+const resetBatch = 100
+
+type PasswordService interface {
+	// ChangePassword sets a new password given the current one, and logs the user out everywhere but in the session.
+	// A wrong current password counts as a failed login of the user's email, from the IP address.
+	ChangePassword(ctx context.Context, userId int, sessionId int, current string, new string, ip string) error
+	// RequestReset stores a request to email a reset token to the user with the email. It doesn't tell whether
+	// there is such a user, nor whether the email was not sent because the last one is too recent, and it does
+	// the same work either way.
+	RequestReset(ctx context.Context, email string) error
+	// SendResets emails reset tokens for the stored requests, and returns how many requests it handled. A request
+	// that fails is kept to be tried again.
+	SendResets(ctx context.Context) (int, error)
+	// ResetPassword sets a new password given a reset token, logs the user out everywhere and lifts a lockout of
+	// logins with their email.
+	ResetPassword(ctx context.Context, token string, new string) error
+}
+
+type passwordService struct {
+	repo   repository.UserRepository
+	tokens TokenService
+	mailer mail.Mailer
+	reset  PasswordResetConfig
+	logins loginLimiter
+}
+
+func NewPasswordService(repo repository.UserRepository, tokens TokenService, mailer mail.Mailer, reset PasswordResetConfig, lockout LockoutPolicy) PasswordService {
+	return &passwordService{
+		repo:   repo,
+		tokens: tokens,
+		mailer: mailer,
+		reset:  reset,
+		logins: loginLimiter{repo: repo, policy: lockout},
+	}
+}
+
+func (s *passwordService) ChangePassword(ctx context.Context, userId int, sessionId int, current string, new string, ip string) error {
+	user, err := s.repo.GetUser(ctx, userId)
+	if err != nil {
+		return fmt.Errorf("can't get user: %w", err)
+	}
+	now := time.Now()
+	if err := s.logins.check(ctx, user.Email, ip, now); err != nil {
+		return err
+	}
+	if err := bcrypt.CompareHashAndPassword([]byte(user.HashedPassword), []byte(current)); err != nil {
+		if err := s.logins.recordFailure(ctx, user.Email, ip, now); err != nil {
+			return err
+		}
+		return ErrWrongCurrentPassword
+	}
+	if err := s.logins.clear(ctx, user.Email); err != nil {
+		return err
+	}
+
+	hash, err := newPasswordHash(new)
+	if err != nil {
+		return err
+	}
+	if err := s.repo.UpdatePassword(ctx, userId, hash, now); err != nil {
+		return fmt.Errorf("can't update password: %w", err)
+	}
+
+	return s.tokens.RevokeOtherSessions(ctx, userId, sessionId)
+}
+
+// RequestReset leaves looking up the user, the token and the email to SendResets, so that the answer takes as
+// long whether or not the email is of a user.
+func (s *passwordService) RequestReset(ctx context.Context, email string) error {
+	if err := s.repo.RequestPasswordReset(ctx, email, time.Now()); err != nil {
+		return fmt.Errorf("can't request password reset: %w", err)
+	}
+	return nil
+}
+
+// SendResets can run on several instances at once: the one that creates the token sends the email, and for the
+// others the token is too recent.
+func (s *passwordService) SendResets(ctx context.Context) (int, error) {
+	emails, err := s.repo.ListPasswordResetRequests(ctx, resetBatch)
+	if err != nil {
+		return 0, fmt.Errorf("can't list password reset requests: %w", err)
+	}
+
+	var n int
+	var errs []error
+	for _, email := range emails {
+		if err := s.sendReset(ctx, email); err != nil {
+			errs = append(errs, err)
+			continue
+		}
+		if err := s.repo.DeletePasswordResetRequest(ctx, email); err != nil {
+			errs = append(errs, fmt.Errorf("can't delete password reset request: %w", err))
+			continue
+		}
+		n++
+	}
+	return n, errors.Join(errs...)
+}
+
+// sendReset emails a new reset token to the user with the email, unless there is no such user or the last token
+// is too recent.
+func (s *passwordService) sendReset(ctx context.Context, email string) error {
+	user, err := s.repo.GetUserByEmail(ctx, email)
+	if err != nil {
+		return fmt.Errorf("can't get user: %w", err)
+	}
+	if user == nil {
+		return nil
+	}
+
+	token, err := newToken()
+	if err != nil {
+		return fmt.Errorf("can't generate reset token: %w", err)
+	}
+	now := time.Now()
+	reset := &domain.PasswordResetToken{
+		UserId:    user.Id,
+		Hash:      hashToken(token),
+		ExpiresAt: now.Add(s.reset.TTL),
+		CreatedAt: now,
+	}
+	created, err := s.repo.CreatePasswordResetToken(ctx, reset, s.reset.Interval)
+	if err != nil {
+		return fmt.Errorf("can't create reset token: %w", err)
+	}
+	if !created {
+		return nil
+	}
+
+	body := fmt.Sprintf("Hello %s,\n\nSomeone asked to reset the password of your account. ", user.Name)
+	if s.reset.URL != "" {
+		body += fmt.Sprintf("To choose a new password, follow this link:\n\n%s%s\n\n", s.reset.URL, token)
+	} else {
+		body += fmt.Sprintf("To choose a new password, use this token:\n\n%s\n\n", token)
+	}
+	body += fmt.Sprintf("It expires in %s and can be used once. If it wasn't you, ignore this email: "+
+		"your password stays the same.\n", expiresIn(s.reset.TTL))
+
+	err = s.mailer.Send(ctx, &mail.Message{To: user.Email, Subject: "Reset your password", Body: body})
+	if err != nil {
+		return fmt.Errorf("can't send reset email: %w", err)
+	}
+	return nil
+}
+
+func (s *passwordService) ResetPassword(ctx context.Context, token string, new string) error {
+	hash, err := newPasswordHash(new)
+	if err != nil {
+		return err
+	}
+
+	userId, err := s.repo.ResetPassword(ctx, hashToken(token), hash, time.Now())
+	if err != nil {
+		return fmt.Errorf("can't reset password: %w", err)
+	}
+	if userId == 0 {
+		return ErrInvalidResetToken
+	}
+
+	if err := s.tokens.RevokeUserSessions(ctx, userId); err != nil {
+		return err
+	}
+
+	user, err := s.repo.GetUser(ctx, userId)
+	if err != nil {
+		return fmt.Errorf("can't get user: %w", err)
+	}
+	if err := s.repo.ClearLoginFailures(ctx, emailLoginKey(user.Email)); err != nil {
+		return fmt.Errorf("can't clear login failures: %w", err)
+	}
+	return nil
+}
+
+func newPasswordHash(password string) (string, error) {
+	if err := validate.Password(password); err != nil {
+		return "", err
+	}
+	hash, err := bcrypt.GenerateFromPassword([]byte(password), bcrypt.DefaultCost)
+	if err != nil {
+		return "", fmt.Errorf("can't hash password: %w", err)
+	}
+	return string(hash), nil
+}
@@ -0,0 +1,141 @@
+package service
+
+import (
+	"bytes"
+	"context"
+	"errors"
+	"regexp"
+	"testing"
+	"time"
+
+	"bank-api/internal/domain"
+	"bank-api/mocks"
+	"bank-api/pkg/mail"
+	"bank-api/pkg/validate"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"go.uber.org/mock/gomock"
+	"golang.org/x/crypto/bcrypt"
+)
+
+func TestChangePassword(t *testing.T) {
+	ctrl := gomock.NewController(t)
+	mockUsers := mocks.NewMockUserRepository(ctrl)
+	mockSessions := mocks.NewMockSessionRepository(ctrl)
+	var outbox bytes.Buffer
+	tokens := NewTokenService(mockSessions, mockUsers, testJWT, 15*time.Minute, time.Hour, time.Minute)
+	reset := PasswordResetConfig{TTL: time.Hour, Interval: time.Minute, URL: "https://bank.example/reset?token="}
+	s := NewPasswordService(mockUsers, tokens, mail.NewWriter(&outbox, "no-reply@bank.example"), reset, LockoutPolicy{})
+	ctx := context.Background()
+
+	hash, err := bcrypt.GenerateFromPassword([]byte("old"), bcrypt.MinCost)
+	require.NoError(t, err)
+	mockUsers.EXPECT().GetUser(gomock.Any(), 1).Return(&domain.User{Id: 1, HashedPassword: string(hash)}, nil).AnyTimes()
+
+	assert.ErrorIs(t, s.ChangePassword(ctx, 1, 5, "wrong", "new", ""), ErrWrongCurrentPassword)
+	assert.ErrorIs(t, s.ChangePassword(ctx, 1, 5, "old", "", ""), validate.ErrInvalidPassword)
+
+	mockUsers.EXPECT().UpdatePassword(gomock.Any(), 1, gomock.Any(), gomock.Any()).DoAndReturn(func(_ context.Context, _ int, hashed string, _ time.Time) error {
+		assert.NoError(t, bcrypt.CompareHashAndPassword([]byte(hashed), []byte("new")))
+		return nil
+	})
+	// The session the password is changed in stays.
+	mockSessions.EXPECT().RevokeUserSessions(gomock.Any(), 1, 5).Return([]int{6, 7}, nil)
+	assert.NoError(t, s.ChangePassword(ctx, 1, 5, "old", "new", ""))
+}
+
+func TestRequestReset(t *testing.T) {
+	ctrl := gomock.NewController(t)
+	mockUsers := mocks.NewMockUserRepository(ctrl)
+	mockSessions := mocks.NewMockSessionRepository(ctrl)
+	var outbox bytes.Buffer
+	tokens := NewTokenService(mockSessions, mockUsers, testJWT, 15*time.Minute, time.Hour, time.Minute)
+	reset := PasswordResetConfig{TTL: time.Hour, Interval: time.Minute, URL: "https://bank.example/reset?token="}
+	s := NewPasswordService(mockUsers, tokens, mail.NewWriter(&outbox, "no-reply@bank.example"), reset, LockoutPolicy{})
+	ctx := context.Background()
+
+	// Only the request is stored, whether or not there is such a user: no lookup is expected.
+	mockUsers.EXPECT().RequestPasswordReset(gomock.Any(), "nobody@example.com", gomock.Any()).Return(nil)
+	assert.NoError(t, s.RequestReset(ctx, "nobody@example.com"))
+	mockUsers.EXPECT().RequestPasswordReset(gomock.Any(), "test@example.com", gomock.Any()).Return(nil)
+	assert.NoError(t, s.RequestReset(ctx, "test@example.com"))
+	assert.Empty(t, outbox.String())
+}
+
+func TestSendResets(t *testing.T) {
+	ctrl := gomock.NewController(t)
+	mockUsers := mocks.NewMockUserRepository(ctrl)
+	mockSessions := mocks.NewMockSessionRepository(ctrl)
+	var outbox bytes.Buffer
+	tokens := NewTokenService(mockSessions, mockUsers, testJWT, 15*time.Minute, time.Hour, time.Minute)
+	reset := PasswordResetConfig{TTL: time.Hour, Interval: time.Minute, URL: "https://bank.example/reset?token="}
+	s := NewPasswordService(mockUsers, tokens, mail.NewWriter(&outbox, "no-reply@bank.example"), reset, LockoutPolicy{})
+	ctx := context.Background()
+
+	user := &domain.User{Id: 1, Name: "Test User", Email: "test@example.com"}
+	mockUsers.EXPECT().ListPasswordResetRequests(gomock.Any(), resetBatch).Return([]string{"nobody@example.com", user.Email}, nil)
+
+	// No email for no user, and the request is done with.
+	mockUsers.EXPECT().GetUserByEmail(gomock.Any(), "nobody@example.com").Return(nil, nil)
+	mockUsers.EXPECT().DeletePasswordResetRequest(gomock.Any(), "nobody@example.com").Return(nil)
+
+	mockUsers.EXPECT().GetUserByEmail(gomock.Any(), user.Email).Return(user, nil)
+	var stored *domain.PasswordResetToken
+	mockUsers.EXPECT().CreatePasswordResetToken(gomock.Any(), gomock.Any(), time.Minute).DoAndReturn(func(_ context.Context, token *domain.PasswordResetToken, _ time.Duration) (bool, error) {
+		stored = token
+		return true, nil
+	})
+	mockUsers.EXPECT().DeletePasswordResetRequest(gomock.Any(), user.Email).Return(nil)
+
+	n, err := s.SendResets(ctx)
+	require.NoError(t, err)
+	assert.Equal(t, 2, n)
+	assert.Equal(t, 1, stored.UserId)
+	assert.WithinDuration(t, time.Now().Add(time.Hour), stored.ExpiresAt, time.Second)
+
+	sent := outbox.String()
+	assert.Contains(t, sent, "To: test@example.com")
+	assert.NotContains(t, sent, "nobody@example.com")
+	token := regexp.MustCompile(`https://bank\.example/reset\?token=(\S+)`).FindStringSubmatch(sent)
+	require.Len(t, token, 2)
+	assert.Equal(t, hashToken(token[1]), stored.Hash)
+
+	// Too soon after the last one, no email is sent. A request that fails is kept for the next run.
+	outbox.Reset()
+	mockUsers.EXPECT().ListPasswordResetRequests(gomock.Any(), resetBatch).Return([]string{user.Email, "other@example.com"}, nil)
+	mockUsers.EXPECT().GetUserByEmail(gomock.Any(), user.Email).Return(user, nil)
+	mockUsers.EXPECT().CreatePasswordResetToken(gomock.Any(), gomock.Any(), time.Minute).Return(false, nil)
+	mockUsers.EXPECT().DeletePasswordResetRequest(gomock.Any(), user.Email).Return(nil)
+	mockUsers.EXPECT().GetUserByEmail(gomock.Any(), "other@example.com").Return(nil, errors.New("db is down"))
+
+	n, err = s.SendResets(ctx)
+	assert.Error(t, err)
+	assert.Equal(t, 1, n)
+	assert.Empty(t, outbox.String())
+}
+
+func TestResetPassword(t *testing.T) {
+	ctrl := gomock.NewController(t)
+	mockUsers := mocks.NewMockUserRepository(ctrl)
+	mockSessions := mocks.NewMockSessionRepository(ctrl)
+	var outbox bytes.Buffer
+	tokens := NewTokenService(mockSessions, mockUsers, testJWT, 15*time.Minute, time.Hour, time.Minute)
+	reset := PasswordResetConfig{TTL: time.Hour, Interval: time.Minute, URL: "https://bank.example/reset?token="}
+	s := NewPasswordService(mockUsers, tokens, mail.NewWriter(&outbox, "no-reply@bank.example"), reset, LockoutPolicy{})
+	ctx := context.Background()
+
+	assert.ErrorIs(t, s.ResetPassword(ctx, "token", ""), validate.ErrInvalidPassword)
+
+	mockUsers.EXPECT().ResetPassword(gomock.Any(), hashToken("used"), gomock.Any(), gomock.Any()).Return(0, nil)
+	assert.ErrorIs(t, s.ResetPassword(ctx, "used", "new"), ErrInvalidResetToken)
+
+	mockUsers.EXPECT().ResetPassword(gomock.Any(), hashToken("token"), gomock.Any(), gomock.Any()).DoAndReturn(func(_ context.Context, _ []byte, hashed string, _ time.Time) (int, error) {
+		assert.NoError(t, bcrypt.CompareHashAndPassword([]byte(hashed), []byte("new")))
+		return 1, nil
+	})
+	mockSessions.EXPECT().RevokeUserSessions(gomock.Any(), 1, 0).Return([]int{5}, nil)
+	mockUsers.EXPECT().GetUser(gomock.Any(), 1).Return(&domain.User{Id: 1, Email: "Test@example.com"}, nil)
+	mockUsers.EXPECT().ClearLoginFailures(gomock.Any(), "email:test@example.com").Return(nil)
+	assert.NoError(t, s.ResetPassword(ctx, "token", "new"))
+}
@@ -0,0 +1,76 @@
+package service
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"sort"
+
+	"bank-api/internal/domain"
+	"bank-api/internal/repository"
+	"bank-api/pkg/fxrates"
+)
+
+var ErrNoRatesToImport = errors.New("no rates for known currencies to import")
+
+type RateImportService interface {
+	// Import stores the rates between currencies of the currency table and reports how many were stored.
+	// Rates of other currencies are skipped, their symbols are returned sorted.
+	Import(ctx context.Context, rates []fxrates.Rate) (int, []string, error)
+}
+
+type rateImportService struct {
+	repo repository.ExchangeRepository
+}
+
+func NewRateImportService(repo repository.ExchangeRepository) RateImportService {
+	return &rateImportService{repo: repo}
+}
+
+func (s *rateImportService) Import(ctx context.Context, rates []fxrates.Rate) (int, []string, error) {
+	currencies, err := s.repo.ListCurrencies(ctx)
+	if err != nil {
+		return 0, nil, fmt.Errorf("can't list currencies: %w", err)
+	}
+	known := make(map[string]domain.Currency, len(currencies))
+	for _, cur := range currencies {
+		known[cur.Symbol] = cur
+	}
+
+	unknown := make(map[string]struct{})
+	var valid []*domain.ExchangeRate
+	for _, rate := range rates {
+		from, fromOk := known[rate.Base]
+		to, toOk := known[rate.Quote]
+		if !fromOk {
+			unknown[rate.Base] = struct{}{}
+		}
+		if !toOk {
+			unknown[rate.Quote] = struct{}{}
+		}
+		if !fromOk || !toOk {
+			continue
+		}
+
+		value, ok := domain.ParseRate(rate.Value)
+		if !ok {
+			return 0, nil, fmt.Errorf("invalid %s/%s rate %q", rate.Base, rate.Quote, rate.Value)
+		}
+		valid = append(valid, &domain.ExchangeRate{From: from, To: to, Rate: value, EffectiveAt: rate.EffectiveAt})
+	}
+
+	skipped := make([]string, 0, len(unknown))
+	for symbol := range unknown {
+		skipped = append(skipped, symbol)
+	}
+	sort.Strings(skipped)
+
+	if len(valid) == 0 {
+		return 0, skipped, ErrNoRatesToImport
+	}
+	if err := s.repo.UpsertRates(ctx, valid); err != nil {
+		return 0, skipped, fmt.Errorf("can't save rates: %w", err)
+	}
+
+	return len(valid), skipped, nil
+}
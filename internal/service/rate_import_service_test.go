@@ -0,0 +1,56 @@
+package service
+
+import (
+	"context"
+	"testing"
+	"time"
+
+	"bank-api/internal/domain"
+	"bank-api/mocks"
+	"bank-api/pkg/fxrates"
+
+	"github.com/stretchr/testify/assert"
+	"go.uber.org/mock/gomock"
+)
+
+func TestRateImport(t *testing.T) {
+	mockRepo := mocks.NewMockExchangeRepository(gomock.NewController(t))
+	day := time.Date(2024, 6, 3, 0, 0, 0, 0, time.UTC)
+
+	mockRepo.EXPECT().ListCurrencies(gomock.Any()).Return([]domain.Currency{
+		{Id: 1, Symbol: "RUB"}, {Id: 2, Symbol: "EUR"}, {Id: 3, Symbol: "USD"},
+	}, nil)
+	mockRepo.EXPECT().UpsertRates(gomock.Any(), gomock.Any()).DoAndReturn(func(_ context.Context, rates []*domain.ExchangeRate) error {
+		assert.Len(t, rates, 1)
+		assert.Equal(t, domain.Currency{Id: 2, Symbol: "EUR"}, rates[0].From)
+		assert.Equal(t, domain.Currency{Id: 3, Symbol: "USD"}, rates[0].To)
+		assert.Equal(t, "1.0842", rates[0].String())
+		assert.Equal(t, day, rates[0].EffectiveAt)
+		return nil
+	})
+
+	s := NewRateImportService(mockRepo)
+
+	imported, skipped, err := s.Import(context.Background(), []fxrates.Rate{
+		{Base: "EUR", Quote: "USD", Value: "1.0842", EffectiveAt: day},
+		{Base: "EUR", Quote: "CHF", Value: "0.9775", EffectiveAt: day},
+		{Base: "EUR", Quote: "BGN", Value: "1.9558", EffectiveAt: day},
+	})
+	assert.NoError(t, err)
+	assert.Equal(t, 1, imported)
+	assert.Equal(t, []string{"BGN", "CHF"}, skipped)
+}
+
+func TestRateImport_NothingToImport(t *testing.T) {
+	mockRepo := mocks.NewMockExchangeRepository(gomock.NewController(t))
+
+	mockRepo.EXPECT().ListCurrencies(gomock.Any()).Return([]domain.Currency{{Id: 1, Symbol: "RUB"}}, nil)
+
+	s := NewRateImportService(mockRepo)
+
+	_, skipped, err := s.Import(context.Background(), []fxrates.Rate{
+		{Base: "EUR", Quote: "CHF", Value: "0.9775", EffectiveAt: time.Now()},
+	})
+	assert.ErrorIs(t, err, ErrNoRatesToImport)
+	assert.Equal(t, []string{"CHF", "EUR"}, skipped)
+}
@@ -0,0 +1,85 @@
+package service
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"time"
+
+	"bank-api/internal/domain"
+	"bank-api/internal/repository"
+)
+
+// pivotCurrency is the currency rates are usually published against, used to derive cross rates.
+const pivotCurrency = "EUR"
+
+var ErrNoExchangeRate = errors.New("no exchange rate for these currencies")
+
+type RateProvider interface {
+	GetRate(ctx context.Context, from, to domain.Currency, at time.Time) (*domain.ExchangeRate, error)
+}
+
+type rateProvider struct {
+	repo repository.ExchangeRepository
+}
+
+func NewRateProvider(repo repository.ExchangeRepository) RateProvider {
+	return &rateProvider{repo: repo}
+}
+
+// GetRate looks for a direct rate first, then for an inverse one, then for a cross rate through the pivot currency.
+func (p *rateProvider) GetRate(ctx context.Context, from, to domain.Currency, at time.Time) (*domain.ExchangeRate, error) {
+	rate, err := p.findRate(ctx, from, to, at)
+	if err != nil {
+		return nil, err
+	}
+	if rate != nil {
+		return rate, nil
+	}
+
+	if from.Symbol == pivotCurrency || to.Symbol == pivotCurrency {
+		return nil, ErrNoExchangeRate
+	}
+	pivot := domain.Currency{Symbol: pivotCurrency}
+
+	toPivot, err := p.findRate(ctx, from, pivot, at)
+	if err != nil {
+		return nil, err
+	}
+	if toPivot == nil {
+		return nil, ErrNoExchangeRate
+	}
+	fromPivot, err := p.findRate(ctx, pivot, to, at)
+	if err != nil {
+		return nil, err
+	}
+	if fromPivot == nil {
+		return nil, ErrNoExchangeRate
+	}
+
+	cross := toPivot.Cross(fromPivot)
+	cross.From, cross.To = from, to
+	return cross, nil
+}
+
+func (p *rateProvider) findRate(ctx context.Context, from, to domain.Currency, at time.Time) (*domain.ExchangeRate, error) {
+	rate, err := p.repo.GetRate(ctx, from, to, at)
+	if err != nil {
+		return nil, fmt.Errorf("can't get exchange rate: %w", err)
+	}
+	if rate != nil {
+		rate.From, rate.To = from, to
+		return rate, nil
+	}
+
+	rate, err = p.repo.GetRate(ctx, to, from, at)
+	if err != nil {
+		return nil, fmt.Errorf("can't get exchange rate: %w", err)
+	}
+	if rate != nil {
+		rate.From, rate.To = to, from
+		return rate.Inverse(), nil
+	}
+
+	return nil, nil
+}
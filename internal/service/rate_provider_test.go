@@ -0,0 +1,80 @@
+package service
+
+import (
+	"context"
+	"testing"
+	"time"
+
+	"bank-api/internal/domain"
+	"bank-api/mocks"
+
+	"github.com/stretchr/testify/assert"
+	"go.uber.org/mock/gomock"
+)
+
+var (
+	eur = domain.Currency{Symbol: "EUR"}
+	usd = domain.Currency{Symbol: "USD"}
+)
+
+func rate(t *testing.T, id int, from, to domain.Currency, s string) *domain.ExchangeRate {
+	r, ok := domain.ParseRate(s)
+	assert.True(t, ok)
+	return &domain.ExchangeRate{Id: id, From: from, To: to, Rate: r}
+}
+
+func TestRateProvider_Direct(t *testing.T) {
+	mockRepo := mocks.NewMockExchangeRepository(gomock.NewController(t))
+
+	mockRepo.EXPECT().GetRate(gomock.Any(), usd, rub, gomock.Any()).Return(rate(t, 1, usd, rub, "91.5"), nil)
+
+	r, err := NewRateProvider(mockRepo).GetRate(context.Background(), usd, rub, time.Now())
+	assert.NoError(t, err)
+	assert.Equal(t, 1, r.Id)
+	assert.Equal(t, "91.5", r.String())
+}
+
+func TestRateProvider_Inverse(t *testing.T) {
+	mockRepo := mocks.NewMockExchangeRepository(gomock.NewController(t))
+
+	mockRepo.EXPECT().GetRate(gomock.Any(), rub, usd, gomock.Any()).Return(nil, nil)
+	mockRepo.EXPECT().GetRate(gomock.Any(), usd, rub, gomock.Any()).Return(rate(t, 1, usd, rub, "80"), nil)
+
+	r, err := NewRateProvider(mockRepo).GetRate(context.Background(), rub, usd, time.Now())
+	assert.NoError(t, err)
+	assert.Equal(t, 0, r.Id)
+	assert.Equal(t, []domain.RateSource{{Id: 1, Inverted: true}}, r.RateSources())
+	assert.Equal(t, rub, r.From)
+	assert.Equal(t, usd, r.To)
+	assert.Equal(t, "0.0125", r.String())
+}
+
+func TestRateProvider_Cross(t *testing.T) {
+	mockRepo := mocks.NewMockExchangeRepository(gomock.NewController(t))
+
+	mockRepo.EXPECT().GetRate(gomock.Any(), usd, rub, gomock.Any()).Return(nil, nil)
+	mockRepo.EXPECT().GetRate(gomock.Any(), rub, usd, gomock.Any()).Return(nil, nil)
+	mockRepo.EXPECT().GetRate(gomock.Any(), usd, eur, gomock.Any()).Return(nil, nil)
+	mockRepo.EXPECT().GetRate(gomock.Any(), eur, usd, gomock.Any()).Return(rate(t, 1, eur, usd, "1.25"), nil)
+	mockRepo.EXPECT().GetRate(gomock.Any(), eur, rub, gomock.Any()).Return(rate(t, 2, eur, rub, "100"), nil)
+
+	r, err := NewRateProvider(mockRepo).GetRate(context.Background(), usd, rub, time.Now())
+	assert.NoError(t, err)
+	assert.Equal(t, 0, r.Id)
+	assert.Equal(t, []domain.RateSource{{Id: 1, Inverted: true}, {Id: 2}}, r.RateSources())
+	assert.Equal(t, usd, r.From)
+	assert.Equal(t, rub, r.To)
+	assert.Equal(t, "80", r.String())
+
+	// Reversing a transfer at the cross rate applies both rates inverted, in the opposite order.
+	assert.Equal(t, []domain.RateSource{{Id: 2, Inverted: true}, {Id: 1}}, r.Inverse().RateSources())
+}
+
+func TestRateProvider_NoRate(t *testing.T) {
+	mockRepo := mocks.NewMockExchangeRepository(gomock.NewController(t))
+
+	mockRepo.EXPECT().GetRate(gomock.Any(), gomock.Any(), gomock.Any(), gomock.Any()).Return(nil, nil).AnyTimes()
+
+	_, err := NewRateProvider(mockRepo).GetRate(context.Background(), usd, rub, time.Now())
+	assert.ErrorIs(t, err, ErrNoExchangeRate)
+}
@@ -0,0 +1,346 @@
+package service
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"time"
+
+	"bank-api/internal/domain"
+	"bank-api/internal/repository"
+)
+
+const (
+	// standingOrderBatch is how many due orders a scheduler claims at a time.
+	standingOrderBatch = 100
+	// standingOrderLease is how long a claimed order is kept from other schedulers while it is executed.
+	standingOrderLease = 5 * time.Minute
+)
+
+var (
+	ErrNoSuchStandingOrder    = errors.New("no such standing order")
+	ErrStandingOrderNotActive = errors.New("standing order is not active")
+	ErrInvalidSchedule        = errors.New("invalid schedule")
+)
+
+// executionFailures are the errors an execution log shows as they are. Any other failure is logged as an internal error.
+var executionFailures = []error{ErrNotEnoughMoney, ErrAccountFrozen, ErrInvalidAmount, ErrNoSuchAccount, ErrInvalidAccount, ErrNoExchangeRate}
+
+// RetryPolicy says how a failed occurrence of a standing order is retried: up to MaxAttempts attempts in total,
+// waiting Delay after the first failure and twice as long after each next one. An occurrence that still fails
+// is skipped and the order goes on with the next one.
+type RetryPolicy struct {
+	MaxAttempts int
+	Delay       time.Duration
+}
+
+func (p RetryPolicy) delay(failures int) time.Duration {
+	return p.Delay << (failures - 1)
+}
+
+// StandingOrderUpdate holds the fields of a standing order to change, nil ones are left as they are.
+// A zero EndAt or MaxOccurrences removes the limit.
+type StandingOrderUpdate struct {
+	Amount         *domain.Money
+	Frequency      *domain.Frequency
+	StartAt        *time.Time
+	EndAt          *time.Time
+	MaxOccurrences *int
+}
+
+type StandingOrderService interface {
+	// CreateStandingOrder schedules transfers from one of the user's accounts. The first one is due at StartAt,
+	// or right away if it is zero. Amount is in the currency of the source account.
+	CreateStandingOrder(ctx context.Context, userId int, o *domain.StandingOrder) (*domain.StandingOrder, error)
+	ListStandingOrders(ctx context.Context, userId int) ([]*domain.StandingOrder, error)
+	// UpdateStandingOrder changes an active order. Changing its frequency or start restarts the schedule from the
+	// new start, or from the next due occurrence, and the occurrences done so far no longer count towards the limit.
+	UpdateStandingOrder(ctx context.Context, userId int, id int, update *StandingOrderUpdate) (*domain.StandingOrder, error)
+	CancelStandingOrder(ctx context.Context, userId int, id int) error
+	ListExecutions(ctx context.Context, userId int, id int) ([]*domain.StandingOrderExecution, error)
+
+	// RunDue executes the orders that are due and returns how many executions it logged.
+	RunDue(ctx context.Context) (int, error)
+}
+
+type standingOrderService struct {
+	repo     repository.StandingOrderRepository
+	accounts repository.AccountRepository
+	tr       TransactionService
+	retry    RetryPolicy
+}
+
+func NewStandingOrderService(repo repository.StandingOrderRepository, accounts repository.AccountRepository, tr TransactionService, retry RetryPolicy) StandingOrderService {
+	return &standingOrderService{repo: repo, accounts: accounts, tr: tr, retry: retry}
+}
+
+func (s *standingOrderService) CreateStandingOrder(ctx context.Context, userId int, o *domain.StandingOrder) (*domain.StandingOrder, error) {
+	now := time.Now()
+	startAt := o.StartAt
+	if startAt.IsZero() {
+		startAt = now
+	}
+	if startAt.Before(now) {
+		return nil, ErrInvalidSchedule
+	}
+
+	ok, err := s.accounts.AccountExists(ctx, o.FromAccountId)
+	if err != nil {
+		return nil, fmt.Errorf("can't check if such an account exists: %w", err)
+	}
+	if !ok {
+		return nil, ErrNoSuchAccount
+	}
+	accFrom, err := s.accounts.GetAccount(ctx, o.FromAccountId)
+	if err != nil {
+		return nil, fmt.Errorf("can't get account: %w", err)
+	}
+	if accFrom.UserId != userId || o.ToAccountId == o.FromAccountId {
+		return nil, ErrInvalidAccount
+	}
+
+	ok, err = s.accounts.AccountExists(ctx, o.ToAccountId)
+	if err != nil {
+		return nil, fmt.Errorf("can't check if such an account exists: %w", err)
+	}
+	if !ok {
+		return nil, ErrNoSuchAccount
+	}
+
+	order := &domain.StandingOrder{
+		UserId:         userId,
+		FromAccountId:  o.FromAccountId,
+		ToAccountId:    o.ToAccountId,
+		Cur:            accFrom.Cur,
+		Amount:         o.Amount,
+		Frequency:      o.Frequency,
+		StartAt:        startAt.UTC(),
+		EndAt:          o.EndAt,
+		MaxOccurrences: o.MaxOccurrences,
+		NextRunAt:      startAt.UTC(),
+	}
+	if err := validateStandingOrder(order); err != nil {
+		return nil, err
+	}
+
+	if err := s.repo.CreateStandingOrder(ctx, order); err != nil {
+		return nil, fmt.Errorf("can't create standing order: %w", err)
+	}
+	return order, nil
+}
+
+// validateStandingOrder checks the amount and the schedule of an order and puts the amount in its currency.
+func validateStandingOrder(o *domain.StandingOrder) error {
+	if o.Amount.Sign() <= 0 {
+		return ErrInvalidAmount
+	}
+	amount, err := amountIn(o.Amount, o.Cur)
+	if err != nil {
+		return err
+	}
+	o.Amount = amount
+
+	if !o.Frequency.Valid() || o.MaxOccurrences < 0 {
+		return ErrInvalidSchedule
+	}
+	if o.MaxOccurrences > 0 && o.Occurrences >= o.MaxOccurrences {
+		return ErrInvalidSchedule
+	}
+	if !o.EndAt.IsZero() && o.EndAt.Before(o.NextRunAt) {
+		return ErrInvalidSchedule
+	}
+	return nil
+}
+
+func (s *standingOrderService) ListStandingOrders(ctx context.Context, userId int) ([]*domain.StandingOrder, error) {
+	orders, err := s.repo.ListStandingOrders(ctx, userId)
+	if err != nil {
+		return nil, fmt.Errorf("can't list standing orders: %w", err)
+	}
+	return orders, nil
+}
+
+// getOwnStandingOrder returns the order if it belongs to the user. The orders of other users don't exist for them.
+func (s *standingOrderService) getOwnStandingOrder(ctx context.Context, userId int, id int) (*domain.StandingOrder, error) {
+	o, err := s.repo.GetStandingOrder(ctx, id)
+	if err != nil {
+		return nil, fmt.Errorf("can't get standing order: %w", err)
+	}
+	if o == nil || o.UserId != userId {
+		return nil, ErrNoSuchStandingOrder
+	}
+	return o, nil
+}
+
+func (s *standingOrderService) UpdateStandingOrder(ctx context.Context, userId int, id int, update *StandingOrderUpdate) (*domain.StandingOrder, error) {
+	o, err := s.getOwnStandingOrder(ctx, userId, id)
+	if err != nil {
+		return nil, err
+	}
+	if o.Status != domain.StandingOrderActive {
+		return nil, ErrStandingOrderNotActive
+	}
+
+	if update.Amount != nil {
+		o.Amount = *update.Amount
+	}
+	if update.EndAt != nil {
+		o.EndAt = update.EndAt.UTC()
+	}
+	if update.MaxOccurrences != nil {
+		o.MaxOccurrences = *update.MaxOccurrences
+	}
+	if update.Frequency != nil || update.StartAt != nil {
+		startAt := o.NextRunAt
+		if update.StartAt != nil {
+			startAt = *update.StartAt
+			if startAt.Before(time.Now()) {
+				return nil, ErrInvalidSchedule
+			}
+		}
+		if update.Frequency != nil {
+			o.Frequency = *update.Frequency
+		}
+		o.StartAt, o.NextRunAt = startAt.UTC(), startAt.UTC()
+		o.Occurrences, o.Attempts = 0, 0
+	}
+	if err := validateStandingOrder(o); err != nil {
+		return nil, err
+	}
+
+	if err := s.save(ctx, o); err != nil {
+		return nil, err
+	}
+	return o, nil
+}
+
+func (s *standingOrderService) CancelStandingOrder(ctx context.Context, userId int, id int) error {
+	o, err := s.getOwnStandingOrder(ctx, userId, id)
+	if err != nil {
+		return err
+	}
+	if o.Status != domain.StandingOrderActive {
+		return ErrStandingOrderNotActive
+	}
+
+	o.Status = domain.StandingOrderCancelled
+	return s.save(ctx, o)
+}
+
+func (s *standingOrderService) save(ctx context.Context, o *domain.StandingOrder) error {
+	err := s.repo.UpdateStandingOrder(ctx, o)
+	if errors.Is(err, domain.ErrStandingOrderNotActive) {
+		return ErrStandingOrderNotActive
+	}
+	if err != nil {
+		return fmt.Errorf("can't update standing order: %w", err)
+	}
+	return nil
+}
+
+func (s *standingOrderService) ListExecutions(ctx context.Context, userId int, id int) ([]*domain.StandingOrderExecution, error) {
+	if _, err := s.getOwnStandingOrder(ctx, userId, id); err != nil {
+		return nil, err
+	}
+
+	executions, err := s.repo.ListStandingOrderExecutions(ctx, id)
+	if err != nil {
+		return nil, fmt.Errorf("can't list standing order executions: %w", err)
+	}
+	return executions, nil
+}
+
+// RunDue claims due orders in batches until there are none left, so orders that missed several occurrences
+// while no scheduler was running catch up one occurrence per claim.
+func (s *standingOrderService) RunDue(ctx context.Context) (int, error) {
+	var n int
+	var errs []error
+	for {
+		orders, err := s.repo.ClaimDueStandingOrders(ctx, time.Now(), standingOrderLease, standingOrderBatch)
+		if err != nil {
+			return n, errors.Join(append(errs, fmt.Errorf("can't claim standing orders: %w", err))...)
+		}
+
+		for _, o := range orders {
+			logged, err := s.execute(ctx, o)
+			if logged {
+				n++
+			}
+			if err != nil {
+				errs = append(errs, fmt.Errorf("standing order %d: %w", o.Id, err))
+			}
+			if ctx.Err() != nil {
+				return n, errors.Join(errs...)
+			}
+		}
+
+		if len(orders) < standingOrderBatch {
+			return n, errors.Join(errs...)
+		}
+	}
+}
+
+// execute transfers the money of the current occurrence of the order, logs the execution and schedules
+// what comes next: the next occurrence, or a retry of this one if it failed and the retry policy allows it.
+// The transfer logs its execution itself, so an attempt that was paid but not scheduled further, e.g. because
+// the instance stopped in between, isn't paid again when the order is claimed next.
+// The error is only returned for failures that are not the user's business, such as database errors.
+func (s *standingOrderService) execute(ctx context.Context, o *domain.StandingOrder) (bool, error) {
+	e := &domain.StandingOrderExecution{
+		StandingOrderId: o.Id,
+		ScheduledAt:     o.NextRunAt,
+		Attempt:         o.Attempts + 1,
+		Status:          domain.ExecutionSucceeded,
+	}
+	t := &domain.Transaction{
+		UserId:        o.UserId,
+		FromAccountId: o.FromAccountId,
+		ToAccountId:   o.ToAccountId,
+		Amount:        o.Amount,
+		Type:          domain.Transfer,
+		Execution:     e,
+	}
+	transferErr := s.tr.ProcessTransaction(ctx, t)
+	if ctx.Err() != nil {
+		// Interrupted by a shutdown: the order is picked up again when its lease ends.
+		return false, nil
+	}
+
+	switch {
+	case transferErr == nil:
+		e.TransactionId = t.Id
+		o.Advance()
+	case errors.Is(transferErr, domain.ErrAlreadyExecuted):
+		// Paid by an earlier claim that stopped before scheduling what comes next.
+		o.Advance()
+	default:
+		e.Status = domain.ExecutionFailed
+		e.FailureReason = failureReason(transferErr)
+
+		o.Attempts++
+		if o.Attempts < s.retry.MaxAttempts {
+			o.NextRunAt = time.Now().Add(s.retry.delay(o.Attempts)).UTC()
+		} else {
+			o.Advance()
+		}
+	}
+
+	if err := s.repo.RecordStandingOrderExecution(ctx, o, e); err != nil {
+		return false, fmt.Errorf("can't record standing order execution: %w", err)
+	}
+	if e.FailureReason == internalFailure {
+		return true, transferErr
+	}
+	return true, nil
+}
+
+const internalFailure = "internal error"
+
+func failureReason(err error) string {
+	for _, failure := range executionFailures {
+		if errors.Is(err, failure) {
+			return failure.Error()
+		}
+	}
+	return internalFailure
+}
@@ -0,0 +1,285 @@
+package service
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"testing"
+	"time"
+
+	"bank-api/internal/domain"
+	"bank-api/mocks"
+
+	"github.com/stretchr/testify/assert"
+	"go.uber.org/mock/gomock"
+)
+
+// fakeTransfers completes every transfer with the given id, or fails it with err.
+type fakeTransfers struct {
+	TransactionService
+	id  int
+	err error
+}
+
+func (f *fakeTransfers) ProcessTransaction(_ context.Context, t *domain.Transaction) error {
+	if t.Execution == nil {
+		return errors.New("standing order transfer without its execution")
+	}
+	if f.err != nil {
+		return f.err
+	}
+	t.Id, t.Status = f.id, domain.StatusPosted
+	return nil
+}
+
+func TestCreateStandingOrder(t *testing.T) {
+	ctrl := gomock.NewController(t)
+	mockRepo := mocks.NewMockStandingOrderRepository(ctrl)
+	mockAccounts := mocks.NewMockAccountRepository(ctrl)
+
+	start := time.Now().Add(time.Hour).UTC()
+
+	mockAccounts.EXPECT().AccountExists(gomock.Any(), 1).Return(true, nil)
+	mockAccounts.EXPECT().GetAccount(gomock.Any(), 1).Return(&domain.Account{Id: 1, UserId: 1, Cur: rub}, nil)
+	mockAccounts.EXPECT().AccountExists(gomock.Any(), 2).Return(true, nil)
+	mockRepo.EXPECT().CreateStandingOrder(gomock.Any(), &domain.StandingOrder{
+		UserId:        1,
+		FromAccountId: 1,
+		ToAccountId:   2,
+		Cur:           rub,
+		Amount:        domain.NewMoney(100000, rub),
+		Frequency:     domain.FrequencyMonthly,
+		StartAt:       start,
+		NextRunAt:     start,
+	}).DoAndReturn(func(_ context.Context, o *domain.StandingOrder) error {
+		o.Id = 3
+		o.Status = domain.StandingOrderActive
+		return nil
+	})
+
+	s := NewStandingOrderService(mockRepo, mockAccounts, nil, RetryPolicy{})
+
+	order, err := s.CreateStandingOrder(context.Background(), 1, &domain.StandingOrder{
+		FromAccountId: 1,
+		ToAccountId:   2,
+		Amount:        money(t, "1000"),
+		Frequency:     domain.FrequencyMonthly,
+		StartAt:       start,
+	})
+	assert.NoError(t, err)
+	assert.Equal(t, 3, order.Id)
+	assert.Equal(t, start, order.NextRunAt)
+}
+
+func TestCreateStandingOrder_Errors(t *testing.T) {
+	future := time.Now().Add(time.Hour)
+
+	tests := []struct {
+		name  string
+		order domain.StandingOrder
+		owner int
+		err   error
+	}{
+		{name: "start in the past", order: domain.StandingOrder{StartAt: time.Now().Add(-time.Hour)}, err: ErrInvalidSchedule},
+		{name: "not the owner", order: domain.StandingOrder{ToAccountId: 2}, owner: 2, err: ErrInvalidAccount},
+		{name: "same account", order: domain.StandingOrder{ToAccountId: 1}, owner: 1, err: ErrInvalidAccount},
+		{name: "zero amount", order: domain.StandingOrder{ToAccountId: 2, Frequency: domain.FrequencyDaily}, owner: 1, err: ErrInvalidAmount},
+		{name: "unknown frequency", order: domain.StandingOrder{ToAccountId: 2, Amount: money(t, "10"), Frequency: "yearly"}, owner: 1, err: ErrInvalidSchedule},
+		{
+			name:  "ends before start",
+			order: domain.StandingOrder{ToAccountId: 2, Amount: money(t, "10"), Frequency: domain.FrequencyDaily, StartAt: future, EndAt: future.Add(-time.Minute)},
+			owner: 1,
+			err:   ErrInvalidSchedule,
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			ctrl := gomock.NewController(t)
+			mockAccounts := mocks.NewMockAccountRepository(ctrl)
+
+			if tt.owner != 0 {
+				mockAccounts.EXPECT().AccountExists(gomock.Any(), 1).Return(true, nil)
+				mockAccounts.EXPECT().GetAccount(gomock.Any(), 1).Return(&domain.Account{Id: 1, UserId: tt.owner, Cur: rub}, nil)
+			}
+			if tt.owner == 1 && tt.order.ToAccountId != 1 {
+				mockAccounts.EXPECT().AccountExists(gomock.Any(), 2).Return(true, nil)
+			}
+
+			s := NewStandingOrderService(mocks.NewMockStandingOrderRepository(ctrl), mockAccounts, nil, RetryPolicy{})
+
+			tt.order.FromAccountId = 1
+			_, err := s.CreateStandingOrder(context.Background(), 1, &tt.order)
+			assert.ErrorIs(t, err, tt.err)
+		})
+	}
+}
+
+func TestUpdateStandingOrder_RestartsSchedule(t *testing.T) {
+	mockRepo := mocks.NewMockStandingOrderRepository(gomock.NewController(t))
+
+	next := time.Now().Add(24 * time.Hour).UTC()
+	mockRepo.EXPECT().GetStandingOrder(gomock.Any(), 3).Return(&domain.StandingOrder{
+		Id:             3,
+		UserId:         1,
+		Cur:            rub,
+		Amount:         domain.NewMoney(1000, rub),
+		Frequency:      domain.FrequencyDaily,
+		StartAt:        next.AddDate(0, 0, -5),
+		MaxOccurrences: 10,
+		Occurrences:    5,
+		NextRunAt:      next,
+		Status:         domain.StandingOrderActive,
+	}, nil)
+	mockRepo.EXPECT().UpdateStandingOrder(gomock.Any(), gomock.Any()).Return(nil)
+
+	s := NewStandingOrderService(mockRepo, nil, nil, RetryPolicy{})
+
+	weekly := domain.FrequencyWeekly
+	order, err := s.UpdateStandingOrder(context.Background(), 1, 3, &StandingOrderUpdate{Frequency: &weekly})
+	assert.NoError(t, err)
+	assert.Equal(t, domain.FrequencyWeekly, order.Frequency)
+	assert.Equal(t, next, order.StartAt)
+	assert.Equal(t, next, order.NextRunAt)
+	assert.Equal(t, 0, order.Occurrences)
+}
+
+func TestCancelStandingOrder(t *testing.T) {
+	tests := []struct {
+		name   string
+		order  *domain.StandingOrder
+		update error
+		err    error
+	}{
+		{name: "ok", order: &domain.StandingOrder{Id: 3, UserId: 1, Status: domain.StandingOrderActive}},
+		{name: "no such order", err: ErrNoSuchStandingOrder},
+		{name: "other user's order", order: &domain.StandingOrder{Id: 3, UserId: 2, Status: domain.StandingOrderActive}, err: ErrNoSuchStandingOrder},
+		{name: "finished", order: &domain.StandingOrder{Id: 3, UserId: 1, Status: domain.StandingOrderFinished}, err: ErrStandingOrderNotActive},
+		{
+			name:   "finished meanwhile",
+			order:  &domain.StandingOrder{Id: 3, UserId: 1, Status: domain.StandingOrderActive},
+			update: domain.ErrStandingOrderNotActive,
+			err:    ErrStandingOrderNotActive,
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			mockRepo := mocks.NewMockStandingOrderRepository(gomock.NewController(t))
+
+			mockRepo.EXPECT().GetStandingOrder(gomock.Any(), 3).Return(tt.order, nil)
+			if tt.order != nil && tt.order.UserId == 1 && tt.order.Status == domain.StandingOrderActive {
+				mockRepo.EXPECT().UpdateStandingOrder(gomock.Any(), gomock.Any()).DoAndReturn(func(_ context.Context, o *domain.StandingOrder) error {
+					assert.Equal(t, domain.StandingOrderCancelled, o.Status)
+					return tt.update
+				})
+			}
+
+			s := NewStandingOrderService(mockRepo, nil, nil, RetryPolicy{})
+
+			err := s.CancelStandingOrder(context.Background(), 1, 3)
+			assert.ErrorIs(t, err, tt.err)
+		})
+	}
+}
+
+func TestRunDue(t *testing.T) {
+	due := time.Date(2024, time.January, 31, 9, 0, 0, 0, time.UTC)
+	retry := RetryPolicy{MaxAttempts: 3, Delay: time.Hour}
+
+	tests := []struct {
+		name      string
+		attempts  int
+		transfer  error
+		execution domain.StandingOrderExecution
+		next      time.Time
+		retried   bool
+		err       bool
+	}{
+		{
+			name:      "succeeded",
+			execution: domain.StandingOrderExecution{ScheduledAt: due, Attempt: 1, TransactionId: 7, Status: domain.ExecutionSucceeded},
+			next:      time.Date(2024, time.February, 29, 9, 0, 0, 0, time.UTC),
+		},
+		{
+			name:      "paid before",
+			transfer:  fmt.Errorf("can't process transaction: %w", domain.ErrAlreadyExecuted),
+			execution: domain.StandingOrderExecution{ScheduledAt: due, Attempt: 1, Status: domain.ExecutionSucceeded},
+			next:      time.Date(2024, time.February, 29, 9, 0, 0, 0, time.UTC),
+		},
+		{
+			name:      "retried",
+			attempts:  1,
+			transfer:  ErrNotEnoughMoney,
+			execution: domain.StandingOrderExecution{ScheduledAt: due, Attempt: 2, Status: domain.ExecutionFailed, FailureReason: "not enough money"},
+			retried:   true,
+		},
+		{
+			name:      "out of attempts",
+			attempts:  2,
+			transfer:  ErrNotEnoughMoney,
+			execution: domain.StandingOrderExecution{ScheduledAt: due, Attempt: 3, Status: domain.ExecutionFailed, FailureReason: "not enough money"},
+			next:      time.Date(2024, time.February, 29, 9, 0, 0, 0, time.UTC),
+		},
+		{
+			name:      "internal error",
+			transfer:  errors.New("connection reset"),
+			execution: domain.StandingOrderExecution{ScheduledAt: due, Attempt: 1, Status: domain.ExecutionFailed, FailureReason: "internal error"},
+			retried:   true,
+			err:       true,
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			mockRepo := mocks.NewMockStandingOrderRepository(gomock.NewController(t))
+
+			order := &domain.StandingOrder{
+				Id:            3,
+				UserId:        1,
+				FromAccountId: 1,
+				ToAccountId:   2,
+				Cur:           rub,
+				Amount:        domain.NewMoney(1000, rub),
+				Frequency:     domain.FrequencyMonthly,
+				StartAt:       due,
+				Attempts:      tt.attempts,
+				NextRunAt:     due,
+				Status:        domain.StandingOrderActive,
+			}
+			mockRepo.EXPECT().ClaimDueStandingOrders(gomock.Any(), gomock.Any(), standingOrderLease, standingOrderBatch).
+				Return([]*domain.StandingOrder{order}, nil)
+			mockRepo.EXPECT().RecordStandingOrderExecution(gomock.Any(), order, gomock.Any()).
+				DoAndReturn(func(_ context.Context, o *domain.StandingOrder, e *domain.StandingOrderExecution) error {
+					tt.execution.StandingOrderId = 3
+					assert.Equal(t, &tt.execution, e)
+					if tt.retried {
+						assert.Equal(t, tt.attempts+1, o.Attempts)
+						assert.WithinDuration(t, time.Now().Add(retry.delay(o.Attempts)), o.NextRunAt, time.Minute)
+					} else {
+						assert.Equal(t, 0, o.Attempts)
+						assert.Equal(t, 1, o.Occurrences)
+						assert.Equal(t, tt.next, o.NextRunAt)
+					}
+					return nil
+				})
+
+			s := NewStandingOrderService(mockRepo, nil, &fakeTransfers{id: 7, err: tt.transfer}, retry)
+
+			n, err := s.RunDue(context.Background())
+			assert.Equal(t, 1, n)
+			if tt.err {
+				assert.Error(t, err)
+			} else {
+				assert.NoError(t, err)
+			}
+		})
+	}
+}
+
+func TestRetryPolicy_Delay(t *testing.T) {
+	p := RetryPolicy{MaxAttempts: 4, Delay: time.Hour}
+	assert.Equal(t, time.Hour, p.delay(1))
+	assert.Equal(t, 2*time.Hour, p.delay(2))
+	assert.Equal(t, 4*time.Hour, p.delay(3))
+}
@@ -0,0 +1,218 @@
+package service
+
+import (
+	"bytes"
+	"context"
+	"errors"
+	"fmt"
+	"slices"
+	"time"
+
+	"bank-api/internal/domain"
+	"bank-api/internal/repository"
+	"bank-api/internal/statement"
+)
+
+// statementPageSize is how many transactions a statement reads from the history at a time.
+const statementPageSize = 500
+
+var (
+	ErrInvalidPeriod   = errors.New("invalid statement period")
+	ErrNoSuchStatement = errors.New("no such statement")
+)
+
+type StatementService interface {
+	// Statement lists the posted transactions, and the adjustments such as opening balances, of one of
+	// the user's accounts between from (inclusive) and to (exclusive) together with the balances at both ends.
+	Statement(ctx context.Context, userId int, accountId int, from, to time.Time) (*domain.Statement, error)
+
+	// GenerateMonthly renders and stores the PDF statements missing for the months before the one until is in,
+	// back to the first month of every account, and returns how many it stored. Accounts without any money
+	// or entries in a month get no statement for it.
+	GenerateMonthly(ctx context.Context, until time.Time) (int, error)
+	ListMonthly(ctx context.Context, userId int, accountId int) ([]*domain.StatementFile, error)
+	GetMonthly(ctx context.Context, userId int, accountId int, statementId int) (*domain.StatementFile, error)
+}
+
+type statementService struct {
+	repo  repository.AccountRepository
+	users repository.UserRepository
+	files repository.StatementRepository
+}
+
+func NewStatementService(repo repository.AccountRepository, users repository.UserRepository, files repository.StatementRepository) StatementService {
+	return &statementService{repo: repo, users: users, files: files}
+}
+
+func (s *statementService) Statement(ctx context.Context, userId int, accountId int, from, to time.Time) (*domain.Statement, error) {
+	if from.IsZero() || to.IsZero() || !from.Before(to) {
+		return nil, ErrInvalidPeriod
+	}
+
+	account, err := s.ownAccount(ctx, userId, accountId)
+	if err != nil {
+		return nil, err
+	}
+
+	return s.build(ctx, account, from, to)
+}
+
+// ownAccount returns the account if it belongs to the user.
+func (s *statementService) ownAccount(ctx context.Context, userId int, accountId int) (*domain.Account, error) {
+	ok, err := s.repo.AccountExists(ctx, accountId)
+	if err != nil {
+		return nil, fmt.Errorf("can't check if such an account exists: %w", err)
+	}
+	if !ok {
+		return nil, ErrNoSuchAccount
+	}
+	account, err := s.repo.GetAccount(ctx, accountId)
+	if err != nil {
+		return nil, fmt.Errorf("can't get account: %w", err)
+	}
+	if account.UserId != userId {
+		return nil, ErrInvalidAccount
+	}
+	return account, nil
+}
+
+func (s *statementService) build(ctx context.Context, account *domain.Account, from, to time.Time) (*domain.Statement, error) {
+	accountId := account.Id
+	holder, err := s.users.GetUser(ctx, account.UserId)
+	if err != nil {
+		return nil, fmt.Errorf("can't get account holder: %w", err)
+	}
+
+	opening, err := s.repo.GetBalanceAt(ctx, accountId, from)
+	if err != nil {
+		return nil, fmt.Errorf("can't get opening balance: %w", err)
+	}
+	closing, err := s.repo.GetBalanceAt(ctx, accountId, to)
+	if err != nil {
+		return nil, fmt.Errorf("can't get closing balance: %w", err)
+	}
+
+	transactions, err := s.postedTransactions(ctx, account.UserId, accountId, from, to)
+	if err != nil {
+		return nil, err
+	}
+
+	adjustments, err := s.repo.ListAdjustments(ctx, accountId, from, to)
+	if err != nil {
+		return nil, fmt.Errorf("can't list adjustments: %w", err)
+	}
+
+	st, err := domain.NewStatement(account, from, to, opening, closing, transactions, adjustments)
+	if err != nil {
+		return nil, fmt.Errorf("can't build statement: %w", err)
+	}
+	st.Holder = holder
+	st.CreatedAt = time.Now().UTC()
+
+	return st, nil
+}
+
+// postedTransactions reads the whole history of the period, oldest first, leaving out
+// the transactions that never moved money.
+func (s *statementService) postedTransactions(ctx context.Context, userId int, accountId int, from, to time.Time) ([]*domain.Transaction, error) {
+	filter := domain.TransactionFilter{UserId: userId, AccountId: accountId, From: from, To: to, Limit: statementPageSize}
+
+	var transactions []*domain.Transaction
+	for {
+		page, err := s.repo.ListTransactions(ctx, &filter)
+		if err != nil {
+			return nil, fmt.Errorf("can't list transactions: %w", err)
+		}
+		for _, t := range page {
+			if t.Status == domain.StatusPosted || t.Status == domain.StatusReversed {
+				transactions = append(transactions, t)
+			}
+		}
+		if len(page) < statementPageSize {
+			break
+		}
+		filter.After = domain.CursorOf(page[len(page)-1])
+	}
+
+	slices.Reverse(transactions)
+	return transactions, nil
+}
+
+func (s *statementService) GenerateMonthly(ctx context.Context, until time.Time) (int, error) {
+	until = until.UTC()
+	month := time.Date(until.Year(), until.Month(), 1, 0, 0, 0, 0, time.UTC)
+
+	missing, err := s.files.MissingStatements(ctx, month)
+	if err != nil {
+		return 0, fmt.Errorf("can't get missing statements: %w", err)
+	}
+
+	// A statement that fails to generate shouldn't keep the others from being stored.
+	var errs []error
+	stored := 0
+	for _, m := range missing {
+		ok, err := s.generate(ctx, m.AccountId, m.From, m.To)
+		if err != nil {
+			errs = append(errs, fmt.Errorf("account %d, %s: %w", m.AccountId, m.From.Format("2006-01"), err))
+			continue
+		}
+		if ok {
+			stored++
+		}
+	}
+
+	return stored, errors.Join(errs...)
+}
+
+func (s *statementService) generate(ctx context.Context, accountId int, from, to time.Time) (bool, error) {
+	account, err := s.repo.GetAccount(ctx, accountId)
+	if err != nil {
+		return false, fmt.Errorf("can't get account: %w", err)
+	}
+
+	st, err := s.build(ctx, account, from, to)
+	if err != nil {
+		return false, err
+	}
+	if len(st.Entries) == 0 && st.Opening.IsZero() && st.Closing.IsZero() {
+		return false, nil
+	}
+
+	var buf bytes.Buffer
+	if err := statement.WritePDF(&buf, st); err != nil {
+		return false, fmt.Errorf("can't render statement: %w", err)
+	}
+
+	file := &domain.StatementFile{AccountId: accountId, From: from, To: to, Content: buf.Bytes()}
+	if err := s.files.SaveStatementFile(ctx, file); err != nil {
+		return false, fmt.Errorf("can't save statement: %w", err)
+	}
+	return file.Id != 0, nil
+}
+
+func (s *statementService) ListMonthly(ctx context.Context, userId int, accountId int) ([]*domain.StatementFile, error) {
+	if _, err := s.ownAccount(ctx, userId, accountId); err != nil {
+		return nil, err
+	}
+
+	files, err := s.files.ListStatementFiles(ctx, accountId)
+	if err != nil {
+		return nil, fmt.Errorf("can't list statements: %w", err)
+	}
+	return files, nil
+}
+
+func (s *statementService) GetMonthly(ctx context.Context, userId int, accountId int, statementId int) (*domain.StatementFile, error) {
+	if _, err := s.ownAccount(ctx, userId, accountId); err != nil {
+		return nil, err
+	}
+
+	file, err := s.files.GetStatementFile(ctx, statementId)
+	if err != nil {
+		return nil, fmt.Errorf("can't get statement: %w", err)
+	}
+	if file == nil || file.AccountId != accountId {
+		return nil, ErrNoSuchStatement
+	}
+	return file, nil
+}
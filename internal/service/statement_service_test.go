@@ -0,0 +1,174 @@
+package service
+
+import (
+	"context"
+	"testing"
+	"time"
+
+	"bank-api/internal/domain"
+	"bank-api/mocks"
+
+	"github.com/stretchr/testify/assert"
+	"go.uber.org/mock/gomock"
+)
+
+func TestStatement(t *testing.T) {
+	ctrl := gomock.NewController(t)
+	mockRepo := mocks.NewMockAccountRepository(ctrl)
+	mockUsers := mocks.NewMockUserRepository(ctrl)
+
+	from := time.Date(2024, 6, 1, 0, 0, 0, 0, time.UTC)
+	to := time.Date(2024, 7, 1, 0, 0, 0, 0, time.UTC)
+
+	// The history comes newest first, a full page and then the rest.
+	firstPage := make([]*domain.Transaction, statementPageSize)
+	for i := range firstPage {
+		firstPage[i] = &domain.Transaction{
+			Id:          statementPageSize + 2 - i,
+			ToAccountId: 1,
+			Amount:      domain.NewMoney(100, rub),
+			ToAmount:    domain.NewMoney(100, rub),
+			Type:        domain.Deposit,
+			Status:      domain.StatusPosted,
+			Time:        to.Add(-time.Duration(i+1) * time.Minute),
+		}
+	}
+	firstPage[0].Status = domain.StatusFailed
+	lastPage := []*domain.Transaction{
+		{Id: 2, ToAccountId: 1, Amount: domain.NewMoney(100, rub), ToAmount: domain.NewMoney(100, rub), Type: domain.Deposit, Status: domain.StatusPending, Time: from.Add(time.Minute)},
+		{Id: 1, FromAccountId: 1, ToAccountId: 2, Amount: domain.NewMoney(500, rub), ToAmount: domain.NewMoney(500, rub), Type: domain.Transfer, Status: domain.StatusReversed, Time: from},
+	}
+
+	mockRepo.EXPECT().AccountExists(gomock.Any(), 1).Return(true, nil)
+	mockRepo.EXPECT().GetAccount(gomock.Any(), 1).Return(&domain.Account{Id: 1, UserId: 1, Cur: rub}, nil)
+	mockUsers.EXPECT().GetUser(gomock.Any(), 1).Return(&domain.User{Id: 1, Name: "Jane Doe"}, nil)
+	mockRepo.EXPECT().GetBalanceAt(gomock.Any(), 1, from).Return(domain.NewMoney(1000, rub), nil)
+	mockRepo.EXPECT().GetBalanceAt(gomock.Any(), 1, to).Return(domain.NewMoney(50600, rub), nil)
+	opening := &domain.Adjustment{EntryId: 3, Description: "opening balance", Amount: domain.NewMoney(200, rub), Time: from.Add(time.Second)}
+	mockRepo.EXPECT().ListAdjustments(gomock.Any(), 1, from, to).Return([]*domain.Adjustment{opening}, nil)
+	gomock.InOrder(
+		mockRepo.EXPECT().ListTransactions(gomock.Any(), &domain.TransactionFilter{
+			UserId: 1, AccountId: 1, From: from, To: to, Limit: statementPageSize,
+		}).Return(firstPage, nil),
+		mockRepo.EXPECT().ListTransactions(gomock.Any(), &domain.TransactionFilter{
+			UserId: 1, AccountId: 1, From: from, To: to, Limit: statementPageSize, After: domain.CursorOf(firstPage[statementPageSize-1]),
+		}).Return(lastPage, nil),
+	)
+
+	s := NewStatementService(mockRepo, mockUsers, nil)
+
+	statement, err := s.Statement(context.Background(), 1, 1, from, to)
+	assert.NoError(t, err)
+	assert.Equal(t, "Jane Doe", statement.Holder.Name)
+	assert.Equal(t, statementPageSize+1, len(statement.Entries))
+	assert.Equal(t, 1, statement.Entries[0].Transaction.Id)
+	assert.Equal(t, domain.NewMoney(-500, rub), statement.Entries[0].Amount)
+	assert.Equal(t, domain.NewMoney(500, rub), statement.Entries[0].Balance)
+	// Journal entries without a transaction are listed too, so that the balances add up.
+	assert.Equal(t, opening, statement.Entries[1].Adjustment)
+	assert.Equal(t, domain.NewMoney(700, rub), statement.Entries[1].Balance)
+	last := statement.Entries[len(statement.Entries)-1]
+	assert.Equal(t, 0, statement.Closing.Cmp(last.Balance))
+}
+
+func TestStatement_Errors(t *testing.T) {
+	from := time.Date(2024, 6, 1, 0, 0, 0, 0, time.UTC)
+
+	t.Run("empty period", func(t *testing.T) {
+		s := NewStatementService(mocks.NewMockAccountRepository(gomock.NewController(t)), nil, nil)
+
+		_, err := s.Statement(context.Background(), 1, 1, from, from)
+		assert.ErrorIs(t, err, ErrInvalidPeriod)
+	})
+
+	t.Run("account of another user", func(t *testing.T) {
+		mockRepo := mocks.NewMockAccountRepository(gomock.NewController(t))
+		mockRepo.EXPECT().AccountExists(gomock.Any(), 1).Return(true, nil)
+		mockRepo.EXPECT().GetAccount(gomock.Any(), 1).Return(&domain.Account{Id: 1, UserId: 2, Cur: rub}, nil)
+
+		s := NewStatementService(mockRepo, nil, nil)
+
+		_, err := s.Statement(context.Background(), 1, 1, from, from.AddDate(0, 1, 0))
+		assert.ErrorIs(t, err, ErrInvalidAccount)
+	})
+}
+
+func TestGenerateMonthly(t *testing.T) {
+	ctrl := gomock.NewController(t)
+	mockRepo := mocks.NewMockAccountRepository(ctrl)
+	mockUsers := mocks.NewMockUserRepository(ctrl)
+	mockFiles := mocks.NewMockStatementRepository(ctrl)
+
+	may := time.Date(2024, 5, 1, 0, 0, 0, 0, time.UTC)
+	june := time.Date(2024, 6, 1, 0, 0, 0, 0, time.UTC)
+	july := time.Date(2024, 7, 1, 0, 0, 0, 0, time.UTC)
+
+	// Account 1 misses the statements of two months, e.g. because the service was down over the 1st of June.
+	missing := []*domain.StatementFile{
+		{AccountId: 1, From: may, To: june},
+		{AccountId: 1, From: june, To: july},
+		{AccountId: 2, From: june, To: july},
+		{AccountId: 3, From: june, To: july},
+	}
+	mockFiles.EXPECT().MissingStatements(gomock.Any(), july).Return(missing, nil)
+	for _, m := range missing {
+		balance := map[int]int64{1: 1000, 2: 0, 3: 500}[m.AccountId]
+		mockRepo.EXPECT().GetAccount(gomock.Any(), m.AccountId).Return(&domain.Account{Id: m.AccountId, UserId: 1, Cur: rub}, nil)
+		mockRepo.EXPECT().GetBalanceAt(gomock.Any(), m.AccountId, m.From).Return(domain.NewMoney(balance, rub), nil)
+		mockRepo.EXPECT().GetBalanceAt(gomock.Any(), m.AccountId, m.To).Return(domain.NewMoney(balance, rub), nil)
+		mockRepo.EXPECT().ListTransactions(gomock.Any(), gomock.Any()).Return(nil, nil)
+		mockRepo.EXPECT().ListAdjustments(gomock.Any(), m.AccountId, m.From, m.To).Return(nil, nil)
+	}
+	mockUsers.EXPECT().GetUser(gomock.Any(), 1).Return(&domain.User{Id: 1, Name: "Jane Doe"}, nil).Times(4)
+
+	// Account 2 turns out empty and gets no statement, account 3 got one from another instance meanwhile.
+	var saved []time.Time
+	mockFiles.EXPECT().SaveStatementFile(gomock.Any(), gomock.Any()).DoAndReturn(func(_ context.Context, f *domain.StatementFile) error {
+		assert.Equal(t, f.From.AddDate(0, 1, 0), f.To)
+		assert.Contains(t, string(f.Content), "%PDF-")
+		if f.AccountId == 1 {
+			saved = append(saved, f.From)
+			f.Id = len(saved)
+		}
+		return nil
+	}).Times(3)
+
+	s := NewStatementService(mockRepo, mockUsers, mockFiles)
+
+	n, err := s.GenerateMonthly(context.Background(), time.Date(2024, 7, 15, 10, 0, 0, 0, time.UTC))
+	assert.NoError(t, err)
+	assert.Equal(t, 2, n)
+	assert.Equal(t, []time.Time{may, june}, saved)
+}
+
+func TestGetMonthly(t *testing.T) {
+	tests := []struct {
+		name string
+		file *domain.StatementFile
+		err  error
+	}{
+		{name: "found", file: &domain.StatementFile{Id: 10, AccountId: 1}},
+		{name: "missing", err: ErrNoSuchStatement},
+		{name: "of another account", file: &domain.StatementFile{Id: 10, AccountId: 2}, err: ErrNoSuchStatement},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			ctrl := gomock.NewController(t)
+			mockRepo := mocks.NewMockAccountRepository(ctrl)
+			mockFiles := mocks.NewMockStatementRepository(ctrl)
+
+			mockRepo.EXPECT().AccountExists(gomock.Any(), 1).Return(true, nil)
+			mockRepo.EXPECT().GetAccount(gomock.Any(), 1).Return(&domain.Account{Id: 1, UserId: 1, Cur: rub}, nil)
+			mockFiles.EXPECT().GetStatementFile(gomock.Any(), 10).Return(tt.file, nil)
+
+			s := NewStatementService(mockRepo, nil, mockFiles)
+
+			file, err := s.GetMonthly(context.Background(), 1, 1, 10)
+			assert.ErrorIs(t, err, tt.err)
+			if tt.err == nil {
+				assert.Equal(t, tt.file, file)
+			}
+		})
+	}
+}
@@ -0,0 +1,228 @@
+package service
+
+import (
+	"context"
+	"crypto/rand"
+	"crypto/sha256"
+	"encoding/base64"
+	"errors"
+	"fmt"
+	"sync"
+	"time"
+
+	"bank-api/internal/auth"
+	"bank-api/internal/domain"
+	"bank-api/internal/repository"
+)
+
+// maxCachedSessions is how many sessions the revocation cache holds before it drops the stale ones.
+const maxCachedSessions = 10000
+
+var (
+	ErrInvalidRefreshToken = errors.New("invalid refresh token")
+	ErrRefreshTokenReused  = errors.New("refresh token is already used")
+)
+
+type TokenService interface {
+	// IssueTokens starts a new session for the user and returns its first access and refresh tokens.
+	IssueTokens(ctx context.Context, userId int) (*domain.Tokens, error)
+	// RefreshTokens exchanges a refresh token for a new pair of tokens of the same session. Using a refresh token
+	// a second time revokes the whole session, since one of the two users of the token is not its owner.
+	RefreshTokens(ctx context.Context, refreshToken string) (*domain.Tokens, error)
+	RevokeSession(ctx context.Context, sessionId int) error
+	// RevokeUserSessions logs the user out everywhere, so that the next tokens the user gets carry the current role.
+	RevokeUserSessions(ctx context.Context, userId int) error
+	// RevokeOtherSessions logs the user out everywhere but in the session.
+	RevokeOtherSessions(ctx context.Context, userId int, sessionId int) error
+	// SessionRevoked tells whether the access tokens of the session must be rejected. The answer can be
+	// up to the cache TTL old for sessions revoked by another instance.
+	SessionRevoked(ctx context.Context, sessionId int) (bool, error)
+}
+
+type tokenService struct {
+	repo       repository.SessionRepository
+	users      repository.UserRepository
+	jwt        *auth.JWT
+	accessTTL  time.Duration
+	refreshTTL time.Duration
+	revoked    *revocationCache
+}
+
+func NewTokenService(repo repository.SessionRepository, users repository.UserRepository, jwt *auth.JWT, accessTTL, refreshTTL, cacheTTL time.Duration) TokenService {
+	return &tokenService{
+		repo:       repo,
+		users:      users,
+		jwt:        jwt,
+		accessTTL:  accessTTL,
+		refreshTTL: refreshTTL,
+		revoked:    newRevocationCache(cacheTTL),
+	}
+}
+
+func (s *tokenService) IssueTokens(ctx context.Context, userId int) (*domain.Tokens, error) {
+	session := &domain.Session{UserId: userId}
+	if err := s.repo.CreateSession(ctx, session); err != nil {
+		return nil, fmt.Errorf("can't create session: %w", err)
+	}
+	return s.issue(ctx, session)
+}
+
+func (s *tokenService) RefreshTokens(ctx context.Context, refreshToken string) (*domain.Tokens, error) {
+	now := time.Now()
+	token, err := s.repo.UseRefreshToken(ctx, hashToken(refreshToken), now)
+	if err != nil {
+		return nil, fmt.Errorf("can't use refresh token: %w", err)
+	}
+	if token == nil {
+		return nil, ErrInvalidRefreshToken
+	}
+	if !token.UsedAt.IsZero() {
+		if err := s.RevokeSession(ctx, token.SessionId); err != nil {
+			return nil, err
+		}
+		return nil, ErrRefreshTokenReused
+	}
+	if !now.Before(token.ExpiresAt) {
+		return nil, ErrInvalidRefreshToken
+	}
+
+	session, err := s.repo.GetSession(ctx, token.SessionId)
+	if err != nil {
+		return nil, fmt.Errorf("can't get session: %w", err)
+	}
+	if session == nil || session.Revoked() {
+		return nil, ErrInvalidRefreshToken
+	}
+
+	return s.issue(ctx, session)
+}
+
+// issue creates a new refresh token in the session and signs an access token for it, with the current role of the user.
+func (s *tokenService) issue(ctx context.Context, session *domain.Session) (*domain.Tokens, error) {
+	now := time.Now()
+
+	user, err := s.users.GetUser(ctx, session.UserId)
+	if err != nil {
+		return nil, fmt.Errorf("can't get user: %w", err)
+	}
+
+	refreshToken, err := newToken()
+	if err != nil {
+		return nil, fmt.Errorf("can't generate refresh token: %w", err)
+	}
+
+	token := &domain.RefreshToken{
+		SessionId: session.Id,
+		Hash:      hashToken(refreshToken),
+		ExpiresAt: now.Add(s.refreshTTL).UTC(),
+	}
+	if err := s.repo.CreateRefreshToken(ctx, token); err != nil {
+		return nil, fmt.Errorf("can't create refresh token: %w", err)
+	}
+
+	accessToken, err := s.jwt.Issue(domain.Principal{UserId: session.UserId, SessionId: session.Id, Role: user.Role}, now, s.accessTTL)
+	if err != nil {
+		return nil, fmt.Errorf("can't sign access token: %w", err)
+	}
+
+	return &domain.Tokens{
+		SessionId:        session.Id,
+		AccessToken:      accessToken,
+		AccessExpiresAt:  now.Add(s.accessTTL),
+		RefreshToken:     refreshToken,
+		RefreshExpiresAt: token.ExpiresAt,
+	}, nil
+}
+
+// newToken returns a random token for the client to keep and hand back, of which only the hash is stored.
+func newToken() (string, error) {
+	raw := make([]byte, 32)
+	if _, err := rand.Read(raw); err != nil {
+		return "", err
+	}
+	return base64.RawURLEncoding.EncodeToString(raw), nil
+}
+
+func hashToken(token string) []byte {
+	hash := sha256.Sum256([]byte(token))
+	return hash[:]
+}
+
+func (s *tokenService) RevokeSession(ctx context.Context, sessionId int) error {
+	if err := s.repo.RevokeSession(ctx, sessionId); err != nil {
+		return fmt.Errorf("can't revoke session: %w", err)
+	}
+	s.revoked.set(sessionId, true)
+	return nil
+}
+
+func (s *tokenService) RevokeUserSessions(ctx context.Context, userId int) error {
+	return s.RevokeOtherSessions(ctx, userId, 0)
+}
+
+func (s *tokenService) RevokeOtherSessions(ctx context.Context, userId int, sessionId int) error {
+	ids, err := s.repo.RevokeUserSessions(ctx, userId, sessionId)
+	if err != nil {
+		return fmt.Errorf("can't revoke sessions: %w", err)
+	}
+	for _, id := range ids {
+		s.revoked.set(id, true)
+	}
+	return nil
+}
+
+func (s *tokenService) SessionRevoked(ctx context.Context, sessionId int) (bool, error) {
+	if revoked, ok := s.revoked.get(sessionId); ok {
+		return revoked, nil
+	}
+
+	session, err := s.repo.GetSession(ctx, sessionId)
+	if err != nil {
+		return false, fmt.Errorf("can't get session: %w", err)
+	}
+	revoked := session == nil || session.Revoked()
+	s.revoked.set(sessionId, revoked)
+	return revoked, nil
+}
+
+// revocationCache remembers for ttl whether sessions are revoked.
+type revocationCache struct {
+	mu      sync.Mutex
+	ttl     time.Duration
+	entries map[int]revocationEntry
+}
+
+type revocationEntry struct {
+	revoked   bool
+	checkedAt time.Time
+}
+
+func newRevocationCache(ttl time.Duration) *revocationCache {
+	return &revocationCache{ttl: ttl, entries: make(map[int]revocationEntry)}
+}
+
+func (c *revocationCache) get(sessionId int) (revoked bool, ok bool) {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+
+	entry, ok := c.entries[sessionId]
+	if !ok || time.Since(entry.checkedAt) >= c.ttl {
+		return false, false
+	}
+	return entry.revoked, true
+}
+
+func (c *revocationCache) set(sessionId int, revoked bool) {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+
+	now := time.Now()
+	if len(c.entries) >= maxCachedSessions {
+		for id, entry := range c.entries {
+			if now.Sub(entry.checkedAt) >= c.ttl {
+				delete(c.entries, id)
+			}
+		}
+	}
+	c.entries[sessionId] = revocationEntry{revoked: revoked, checkedAt: now}
+}
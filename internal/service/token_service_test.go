@@ -0,0 +1,171 @@
+package service
+
+import (
+	"context"
+	"testing"
+	"time"
+
+	"bank-api/internal/auth"
+	"bank-api/internal/domain"
+	"bank-api/mocks"
+
+	"github.com/stretchr/testify/assert"
+	"go.uber.org/mock/gomock"
+)
+
+var testJWT = auth.NewJWT("secret", "bank-api", "bank-api", 0)
+
+func TestIssueTokens(t *testing.T) {
+	ctrl := gomock.NewController(t)
+	mockRepo := mocks.NewMockSessionRepository(ctrl)
+	mockUsers := mocks.NewMockUserRepository(ctrl)
+
+	mockUsers.EXPECT().GetUser(gomock.Any(), 1).Return(&domain.User{Id: 1, Role: domain.RoleSupport}, nil)
+
+	mockRepo.EXPECT().CreateSession(gomock.Any(), &domain.Session{UserId: 1}).DoAndReturn(func(_ context.Context, s *domain.Session) error {
+		s.Id = 5
+		return nil
+	})
+	var stored *domain.RefreshToken
+	mockRepo.EXPECT().CreateRefreshToken(gomock.Any(), gomock.Any()).DoAndReturn(func(_ context.Context, rt *domain.RefreshToken) error {
+		stored = rt
+		return nil
+	})
+
+	s := NewTokenService(mockRepo, mockUsers, testJWT, 15*time.Minute, time.Hour, time.Minute)
+
+	tokens, err := s.IssueTokens(context.Background(), 1)
+	assert.NoError(t, err)
+	assert.Equal(t, 5, tokens.SessionId)
+	assert.Equal(t, 5, stored.SessionId)
+	assert.Equal(t, hashToken(tokens.RefreshToken), stored.Hash)
+
+	principal, err := testJWT.Verify(tokens.AccessToken)
+	assert.NoError(t, err)
+	assert.Equal(t, &domain.Principal{UserId: 1, SessionId: 5, Role: domain.RoleSupport}, principal)
+	assert.WithinDuration(t, time.Now().Add(15*time.Minute), tokens.AccessExpiresAt, time.Second)
+}
+
+func TestRefreshTokens_Rotates(t *testing.T) {
+	ctrl := gomock.NewController(t)
+	mockRepo := mocks.NewMockSessionRepository(ctrl)
+	mockUsers := mocks.NewMockUserRepository(ctrl)
+
+	mockRepo.EXPECT().UseRefreshToken(gomock.Any(), hashToken("old"), gomock.Any()).
+		Return(&domain.RefreshToken{Id: 1, SessionId: 5, ExpiresAt: time.Now().Add(time.Hour)}, nil)
+	mockRepo.EXPECT().GetSession(gomock.Any(), 5).Return(&domain.Session{Id: 5, UserId: 1}, nil)
+	mockRepo.EXPECT().CreateRefreshToken(gomock.Any(), gomock.Any()).Return(nil)
+	// The role is looked up again, so a role changed since the login is in the new token.
+	mockUsers.EXPECT().GetUser(gomock.Any(), 1).Return(&domain.User{Id: 1, Role: domain.RoleAdmin}, nil)
+
+	s := NewTokenService(mockRepo, mockUsers, testJWT, 15*time.Minute, time.Hour, time.Minute)
+
+	tokens, err := s.RefreshTokens(context.Background(), "old")
+	assert.NoError(t, err)
+	assert.Equal(t, 5, tokens.SessionId)
+	assert.NotEqual(t, "old", tokens.RefreshToken)
+
+	principal, err := testJWT.Verify(tokens.AccessToken)
+	assert.NoError(t, err)
+	assert.Equal(t, domain.RoleAdmin, principal.Role)
+}
+
+func TestRefreshTokens_ReuseRevokesSession(t *testing.T) {
+	mockRepo := mocks.NewMockSessionRepository(gomock.NewController(t))
+
+	mockRepo.EXPECT().UseRefreshToken(gomock.Any(), hashToken("stolen"), gomock.Any()).
+		Return(&domain.RefreshToken{Id: 1, SessionId: 5, ExpiresAt: time.Now().Add(time.Hour), UsedAt: time.Now().Add(-time.Minute)}, nil)
+	mockRepo.EXPECT().RevokeSession(gomock.Any(), 5).Return(nil)
+
+	s := NewTokenService(mockRepo, nil, testJWT, 15*time.Minute, time.Hour, time.Minute)
+
+	_, err := s.RefreshTokens(context.Background(), "stolen")
+	assert.ErrorIs(t, err, ErrRefreshTokenReused)
+
+	// The revocation is known without asking the database.
+	revoked, err := s.SessionRevoked(context.Background(), 5)
+	assert.NoError(t, err)
+	assert.True(t, revoked)
+}
+
+func TestRefreshTokens_Invalid(t *testing.T) {
+	tests := []struct {
+		name    string
+		token   *domain.RefreshToken
+		session *domain.Session
+	}{
+		{name: "no such token"},
+		{name: "expired", token: &domain.RefreshToken{SessionId: 5, ExpiresAt: time.Now().Add(-time.Second)}},
+		{name: "revoked session", token: &domain.RefreshToken{SessionId: 5, ExpiresAt: time.Now().Add(time.Hour)}, session: &domain.Session{Id: 5, RevokedAt: time.Now()}},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			mockRepo := mocks.NewMockSessionRepository(gomock.NewController(t))
+
+			mockRepo.EXPECT().UseRefreshToken(gomock.Any(), gomock.Any(), gomock.Any()).Return(tt.token, nil)
+			if tt.session != nil {
+				mockRepo.EXPECT().GetSession(gomock.Any(), 5).Return(tt.session, nil)
+			}
+
+			s := NewTokenService(mockRepo, nil, testJWT, 15*time.Minute, time.Hour, time.Minute)
+
+			_, err := s.RefreshTokens(context.Background(), "token")
+			assert.ErrorIs(t, err, ErrInvalidRefreshToken)
+		})
+	}
+}
+
+func TestSessionRevoked_Cached(t *testing.T) {
+	mockRepo := mocks.NewMockSessionRepository(gomock.NewController(t))
+
+	mockRepo.EXPECT().GetSession(gomock.Any(), 5).Return(&domain.Session{Id: 5, UserId: 1}, nil).Times(1)
+	mockRepo.EXPECT().GetSession(gomock.Any(), 6).Return(nil, nil).Times(1)
+
+	s := NewTokenService(mockRepo, nil, testJWT, 15*time.Minute, time.Hour, time.Minute)
+
+	for i := 0; i < 3; i++ {
+		revoked, err := s.SessionRevoked(context.Background(), 5)
+		assert.NoError(t, err)
+		assert.False(t, revoked)
+
+		revoked, err = s.SessionRevoked(context.Background(), 6)
+		assert.NoError(t, err)
+		assert.True(t, revoked)
+	}
+}
+
+func TestSessionRevoked_CacheExpires(t *testing.T) {
+	mockRepo := mocks.NewMockSessionRepository(gomock.NewController(t))
+
+	gomock.InOrder(
+		mockRepo.EXPECT().GetSession(gomock.Any(), 5).Return(&domain.Session{Id: 5, UserId: 1}, nil),
+		mockRepo.EXPECT().GetSession(gomock.Any(), 5).Return(&domain.Session{Id: 5, UserId: 1, RevokedAt: time.Now()}, nil),
+	)
+
+	s := NewTokenService(mockRepo, nil, testJWT, 15*time.Minute, time.Hour, 0)
+
+	revoked, err := s.SessionRevoked(context.Background(), 5)
+	assert.NoError(t, err)
+	assert.False(t, revoked)
+
+	revoked, err = s.SessionRevoked(context.Background(), 5)
+	assert.NoError(t, err)
+	assert.True(t, revoked)
+}
+
+func TestRevokeUserSessions(t *testing.T) {
+	mockRepo := mocks.NewMockSessionRepository(gomock.NewController(t))
+
+	mockRepo.EXPECT().RevokeUserSessions(gomock.Any(), 1, 0).Return([]int{5, 6}, nil)
+
+	s := NewTokenService(mockRepo, nil, testJWT, 15*time.Minute, time.Hour, time.Minute)
+	assert.NoError(t, s.RevokeUserSessions(context.Background(), 1))
+
+	// The revocations are known without asking the database.
+	for _, id := range []int{5, 6} {
+		revoked, err := s.SessionRevoked(context.Background(), id)
+		assert.NoError(t, err)
+		assert.True(t, revoked)
+	}
+}
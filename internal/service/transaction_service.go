@@ -4,6 +4,7 @@ import (
 	"context"
 	"errors"
 	"fmt"
+	"time"
 
 	"bank-api/internal/domain"
 	"bank-api/internal/repository"
@@ -12,23 +13,66 @@ import (
 var (
 	ErrNotEnoughMoney = errors.New("not enough money")
 	ErrInvalidAmount  = errors.New("invalid amount")
+
+	ErrLedgerInconsistent = errors.New("ledger is inconsistent")
+
+	ErrNoSuchQuote      = errors.New("no such quote")
+	ErrInvalidQuote     = errors.New("quote doesn't match the transfer")
+	ErrQuoteExpired     = errors.New("quote has expired")
+	ErrQuoteAlreadyUsed = errors.New("quote is already used")
+	ErrSameCurrency     = errors.New("accounts have the same currency")
+
+	ErrNoSuchTransaction     = errors.New("no such transaction")
+	ErrNotReversible         = errors.New("transaction can't be reversed")
+	ErrReversalExceedsAmount = errors.New("reversal exceeds the amount left to reverse")
+
+	ErrInvalidFilter = errors.New("invalid transaction filter")
+)
+
+const (
+	defaultHistoryLimit = 50
+	maxHistoryLimit     = 100
 )
 
 type TransactionService interface {
+	// ProcessTransaction moves the money of a deposit, withdrawal or transfer. Posted transfers get their id and status set.
 	ProcessTransaction(ctx context.Context, transaction *domain.Transaction) error
-	ListTransactions(ctx context.Context, accountId int) ([]*domain.Transaction, error)
+	// ListTransactions returns a page of the transactions matching filter, newest first, and the cursor
+	// of the next page, which is nil if this page is the last one.
+	ListTransactions(ctx context.Context, filter *domain.TransactionFilter) ([]*domain.Transaction, *domain.Cursor, error)
+	VerifyLedger(ctx context.Context) error
+	// CreateQuote locks the exchange rate of a cross-currency transfer for the quote TTL.
+	CreateQuote(ctx context.Context, transaction *domain.Transaction) (*domain.Quote, error)
+	// ReverseTransaction returns amount of a transfer from the receiving account back to the sender, all that is left
+	// to reverse if amount is zero. Only the owner of the receiving account can reverse a transfer.
+	ReverseTransaction(ctx context.Context, userId int, transactionId int, amount domain.Money) (*domain.Transaction, error)
+
+	// PlaceHold reserves hold.Amount on one of the user's accounts for ttl, or for the default hold TTL if ttl is zero.
+	PlaceHold(ctx context.Context, userId int, hold *domain.Hold, ttl time.Duration) (*domain.Hold, error)
+	// CaptureHold moves amount of the hold, all of it if amount is zero, to its destination and releases the rest.
+	CaptureHold(ctx context.Context, userId int, holdId int, amount domain.Money) (*domain.Transaction, error)
+	ReleaseHold(ctx context.Context, userId int, holdId int) error
+	// ExpireHolds marks the holds past their expiry as expired and returns how many there were.
+	ExpireHolds(ctx context.Context) (int, error)
+	// FailStalePending marks the transactions that have been pending for longer than timeout failed
+	// and returns how many there were.
+	FailStalePending(ctx context.Context, timeout time.Duration) (int, error)
 }
 
 type transactionService struct {
-	repo repository.AccountRepository
+	repo     repository.AccountRepository
+	quotes   repository.ExchangeRepository
+	rates    RateProvider
+	quoteTTL time.Duration
+	holdTTL  time.Duration
 }
 
-func NewTransactionService(repo repository.AccountRepository) TransactionService {
-	return &transactionService{repo: repo}
+func NewTransactionService(repo repository.AccountRepository, quotes repository.ExchangeRepository, rates RateProvider, quoteTTL time.Duration, holdTTL time.Duration) TransactionService {
+	return &transactionService{repo: repo, quotes: quotes, rates: rates, quoteTTL: quoteTTL, holdTTL: holdTTL}
 }
 
 func (s *transactionService) ProcessTransaction(ctx context.Context, transaction *domain.Transaction) error {
-	if transaction.Amount <= 0 {
+	if transaction.Amount.Sign() <= 0 {
 		return ErrInvalidAmount
 	}
 
@@ -61,7 +105,16 @@ func (s *transactionService) processDeposit(ctx context.Context, transaction *do
 		return ErrInvalidAccount
 	}
 
-	if err := s.repo.Transaction(ctx, transaction.ToAccountId, transaction.Amount, transaction.Type); err != nil {
+	amount, err := amountIn(transaction.Amount, accTo.Cur)
+	if err != nil {
+		return err
+	}
+
+	err = s.repo.Transaction(ctx, transaction.ToAccountId, amount, transaction.Type)
+	switch {
+	case errors.Is(err, domain.ErrAccountFrozen):
+		return ErrAccountFrozen
+	case err != nil:
 		return fmt.Errorf("can't perform transaction: %w", err)
 	}
 
@@ -85,11 +138,18 @@ func (s *transactionService) processWithdraw(ctx context.Context, transaction *d
 		return ErrInvalidAccount
 	}
 
-	if accFrom.Amount < transaction.Amount {
-		return ErrNotEnoughMoney
+	amount, err := amountIn(transaction.Amount, accFrom.Cur)
+	if err != nil {
+		return err
 	}
 
-	if err := s.repo.Transaction(ctx, transaction.FromAccountId, transaction.Amount, transaction.Type); err != nil {
+	err = s.repo.Transaction(ctx, transaction.FromAccountId, amount, transaction.Type)
+	switch {
+	case errors.Is(err, domain.ErrInsufficientFunds):
+		return ErrNotEnoughMoney
+	case errors.Is(err, domain.ErrAccountFrozen):
+		return ErrAccountFrozen
+	case err != nil:
 		return fmt.Errorf("can't process transaction: %w", err)
 	}
 
@@ -97,54 +157,342 @@ func (s *transactionService) processWithdraw(ctx context.Context, transaction *d
 }
 
 func (s *transactionService) processTransfer(ctx context.Context, transaction *domain.Transaction) error {
+	t, err := s.prepareTransfer(ctx, transaction)
+	if err != nil {
+		return err
+	}
+
+	if transaction.QuoteId != 0 {
+		if err := s.applyQuote(ctx, t, transaction.QuoteId); err != nil {
+			return err
+		}
+	} else if t.ToCur.Id != t.Cur.Id {
+		t.Rate, err = s.rates.GetRate(ctx, t.Cur, t.ToCur, time.Now())
+		if err != nil {
+			return err
+		}
+		if t.ToAmount, err = convert(t.Amount, t.Rate); err != nil {
+			return err
+		}
+	}
+
+	// The balance is checked by the repository under a row lock, so concurrent transfers can't overdraw the account.
+	err = s.repo.Transfer(ctx, t)
+	switch {
+	case errors.Is(err, domain.ErrInsufficientFunds):
+		return ErrNotEnoughMoney
+	case errors.Is(err, domain.ErrAccountFrozen):
+		return ErrAccountFrozen
+	case errors.Is(err, domain.ErrQuoteExpired):
+		return ErrQuoteExpired
+	case errors.Is(err, domain.ErrQuoteUsed):
+		return ErrQuoteAlreadyUsed
+	case err != nil:
+		return fmt.Errorf("can't process transaction: %w", err)
+	}
+
+	transaction.Id, transaction.Status = t.Id, t.Status
+	return nil
+}
+
+// prepareTransfer checks both accounts of a transfer and returns it with the amount in the source account currency.
+// The destination amount is the same amount until a rate is applied to it.
+func (s *transactionService) prepareTransfer(ctx context.Context, transaction *domain.Transaction) (*domain.Transaction, error) {
 	ok, err := s.repo.AccountExists(ctx, transaction.FromAccountId)
 	if err != nil {
-		return fmt.Errorf("can't check if such an account exists: %w", err)
+		return nil, fmt.Errorf("can't check if such an account exists: %w", err)
 	}
 	if !ok {
-		return ErrNoSuchAccount
+		return nil, ErrNoSuchAccount
 	}
 
-	accFrom, err := s.repo.GetAccount(ctx, transaction.ToAccountId)
+	accFrom, err := s.repo.GetAccount(ctx, transaction.FromAccountId)
 	if err != nil {
-		return fmt.Errorf("can't get account: %w", err)
+		return nil, fmt.Errorf("can't get account: %w", err)
 	}
 	if accFrom.UserId != transaction.UserId {
-		return ErrInvalidAccount
+		return nil, ErrInvalidAccount
 	}
 
-	if accFrom.Amount < transaction.Amount {
-		return ErrNotEnoughMoney
+	amount, err := amountIn(transaction.Amount, accFrom.Cur)
+	if err != nil {
+		return nil, err
 	}
 
 	ok, err = s.repo.AccountExists(ctx, transaction.ToAccountId)
 	if err != nil {
-		return fmt.Errorf("can't check if such an account exists: %w", err)
+		return nil, fmt.Errorf("can't check if such an account exists: %w", err)
 	}
 	if !ok {
-		return ErrNoSuchAccount
+		return nil, ErrNoSuchAccount
 	}
 
-	if err := s.repo.Transfer(ctx, transaction.FromAccountId, transaction.ToAccountId, transaction.Amount); err != nil {
-		return fmt.Errorf("can't process transaction: %w", err)
+	accTo, err := s.repo.GetAccount(ctx, transaction.ToAccountId)
+	if err != nil {
+		return nil, fmt.Errorf("can't get account: %w", err)
 	}
 
+	return &domain.Transaction{
+		UserId:        transaction.UserId,
+		FromAccountId: transaction.FromAccountId,
+		ToAccountId:   transaction.ToAccountId,
+		Cur:           accFrom.Cur,
+		Amount:        amount,
+		ToCur:         accTo.Cur,
+		ToAmount:      amount,
+		Type:          domain.Transfer,
+		Execution:     transaction.Execution,
+	}, nil
+}
+
+// applyQuote makes the transfer use the quoted rate. The quote is consumed by the repository together with the transfer.
+func (s *transactionService) applyQuote(ctx context.Context, t *domain.Transaction, quoteId int) error {
+	quote, err := s.quotes.GetQuote(ctx, quoteId)
+	if err != nil {
+		return fmt.Errorf("can't get quote: %w", err)
+	}
+	if quote == nil || quote.UserId != t.UserId {
+		return ErrNoSuchQuote
+	}
+	if quote.FromAccountId != t.FromAccountId || quote.ToAccountId != t.ToAccountId || quote.Amount.Cmp(t.Amount) != 0 {
+		return ErrInvalidQuote
+	}
+	if quote.Used {
+		return ErrQuoteAlreadyUsed
+	}
+	if quote.Expired(time.Now()) {
+		return ErrQuoteExpired
+	}
+
+	t.ToAmount = quote.ToAmount
+	t.Rate = quote.Rate
+	t.QuoteId = quote.Id
 	return nil
 }
 
-func (s *transactionService) ListTransactions(ctx context.Context, userId int) ([]*domain.Transaction, error) {
-	ok, err := s.repo.UserExistsById(ctx, userId)
+func (s *transactionService) CreateQuote(ctx context.Context, transaction *domain.Transaction) (*domain.Quote, error) {
+	if transaction.Amount.Sign() <= 0 {
+		return nil, ErrInvalidAmount
+	}
+
+	t, err := s.prepareTransfer(ctx, transaction)
+	if err != nil {
+		return nil, err
+	}
+	if t.ToCur.Id == t.Cur.Id {
+		return nil, ErrSameCurrency
+	}
+
+	now := time.Now()
+	rate, err := s.rates.GetRate(ctx, t.Cur, t.ToCur, now)
+	if err != nil {
+		return nil, err
+	}
+	toAmount, err := convert(t.Amount, rate)
 	if err != nil {
-		return nil, fmt.Errorf("can't check if such a user exists: %w", err)
+		return nil, err
+	}
+
+	quote := &domain.Quote{
+		UserId:        t.UserId,
+		FromAccountId: t.FromAccountId,
+		ToAccountId:   t.ToAccountId,
+		Cur:           t.Cur,
+		Amount:        t.Amount,
+		ToCur:         t.ToCur,
+		ToAmount:      toAmount,
+		Rate:          rate,
+		ExpiresAt:     now.Add(s.quoteTTL).UTC(),
+	}
+	if err := s.quotes.CreateQuote(ctx, quote); err != nil {
+		return nil, fmt.Errorf("can't create quote: %w", err)
+	}
+
+	return quote, nil
+}
+
+// convert applies rate to amount, rejecting amounts that would turn into nothing.
+func convert(amount domain.Money, rate *domain.ExchangeRate) (domain.Money, error) {
+	converted, err := rate.Convert(amount)
+	if err != nil || converted.Sign() <= 0 {
+		return domain.Money{}, ErrInvalidAmount
+	}
+	return converted, nil
+}
+
+// amountIn converts a requested amount to the minor units of cur, rejecting amounts that are too precise for it.
+func amountIn(amount domain.Money, cur domain.Currency) (domain.Money, error) {
+	converted, err := amount.In(cur)
+	if err != nil {
+		return domain.Money{}, ErrInvalidAmount
+	}
+	return converted, nil
+}
+
+func (s *transactionService) ReverseTransaction(ctx context.Context, userId int, transactionId int, amount domain.Money) (*domain.Transaction, error) {
+	original, err := s.repo.GetTransaction(ctx, transactionId)
+	if err != nil {
+		return nil, fmt.Errorf("can't get transaction: %w", err)
+	}
+	if original == nil {
+		return nil, ErrNoSuchTransaction
+	}
+	if original.Type != domain.Transfer || original.ReversesId != 0 || original.Status != domain.StatusPosted {
+		return nil, ErrNotReversible
+	}
+
+	for _, id := range []int{original.FromAccountId, original.ToAccountId} {
+		ok, err := s.repo.AccountExists(ctx, id)
+		if err != nil {
+			return nil, fmt.Errorf("can't check if such an account exists: %w", err)
+		}
+		if !ok {
+			return nil, ErrNotReversible
+		}
+	}
+	accTo, err := s.repo.GetAccount(ctx, original.ToAccountId)
+	if err != nil {
+		return nil, fmt.Errorf("can't get account: %w", err)
+	}
+	if accTo.UserId != userId {
+		return nil, ErrInvalidAccount
+	}
+
+	if amount.IsZero() {
+		amount = original.Remaining()
+	} else if amount, err = amountIn(amount, original.ToCur); err != nil {
+		return nil, err
+	}
+	if amount.Sign() <= 0 {
+		return nil, ErrInvalidAmount
+	}
+
+	refund, err := original.Refund(amount)
+	if errors.Is(err, domain.ErrReversalExceedsAmount) {
+		return nil, ErrReversalExceedsAmount
+	}
+	if err != nil {
+		return nil, ErrInvalidAmount
+	}
+
+	reversal := &domain.Transaction{
+		UserId:        userId,
+		FromAccountId: original.ToAccountId,
+		ToAccountId:   original.FromAccountId,
+		Cur:           original.ToCur,
+		Amount:        amount,
+		ToCur:         original.Cur,
+		ToAmount:      refund,
+		Type:          domain.Transfer,
+		ReversesId:    original.Id,
+	}
+	if original.Rate != nil {
+		reversal.Rate = original.Rate.Inverse()
+	}
+
+	err = s.repo.Reverse(ctx, original, reversal)
+	switch {
+	case errors.Is(err, domain.ErrInsufficientFunds):
+		return nil, ErrNotEnoughMoney
+	case errors.Is(err, domain.ErrAccountFrozen):
+		return nil, ErrAccountFrozen
+	case errors.Is(err, domain.ErrReversalExceedsAmount):
+		return nil, ErrReversalExceedsAmount
+	case errors.Is(err, domain.ErrNotReversible):
+		return nil, ErrNotReversible
+	case err != nil:
+		return nil, fmt.Errorf("can't reverse transaction: %w", err)
+	}
+
+	return reversal, nil
+}
+
+func (s *transactionService) ListTransactions(ctx context.Context, filter *domain.TransactionFilter) ([]*domain.Transaction, *domain.Cursor, error) {
+	page := *filter
+	if page.Limit == 0 {
+		page.Limit = defaultHistoryLimit
+	}
+	if page.Limit < 0 || page.Limit > maxHistoryLimit {
+		return nil, nil, ErrInvalidFilter
+	}
+	if !page.From.IsZero() && !page.To.IsZero() && !page.From.Before(page.To) {
+		return nil, nil, ErrInvalidFilter
+	}
+	if page.MinAmount != nil && page.MaxAmount != nil && page.MinAmount.Cmp(*page.MaxAmount) > 0 {
+		return nil, nil, ErrInvalidFilter
+	}
+
+	ok, err := s.repo.UserExistsById(ctx, page.UserId)
+	if err != nil {
+		return nil, nil, fmt.Errorf("can't check if such a user exists: %w", err)
 	}
 	if !ok {
-		return nil, ErrNoSuchUser
+		return nil, nil, ErrNoSuchUser
+	}
+
+	if page.AccountId != 0 {
+		ok, err := s.repo.AccountExists(ctx, page.AccountId)
+		if err != nil {
+			return nil, nil, fmt.Errorf("can't check if such an account exists: %w", err)
+		}
+		if !ok {
+			return nil, nil, ErrNoSuchAccount
+		}
+		account, err := s.repo.GetAccount(ctx, page.AccountId)
+		if err != nil {
+			return nil, nil, fmt.Errorf("can't get account: %w", err)
+		}
+		if account.UserId != page.UserId {
+			return nil, nil, ErrInvalidAccount
+		}
+	}
+
+	if page.Cur.Symbol != "" {
+		ok, err := s.repo.CurrencyExists(ctx, page.Cur)
+		if err != nil {
+			return nil, nil, fmt.Errorf("can't check if such currency exists: %w", err)
+		}
+		if !ok {
+			return nil, nil, ErrNoSuchCurrency
+		}
+		if page.Cur.Id, err = s.repo.GetCurrencyId(ctx, page.Cur); err != nil {
+			return nil, nil, fmt.Errorf("can't get currency id: %w", err)
+		}
+	}
+
+	// One more transaction than asked for tells whether there is a next page.
+	limit := page.Limit
+	page.Limit++
+	trs, err := s.repo.ListTransactions(ctx, &page)
+	if err != nil {
+		return nil, nil, fmt.Errorf("can't list transactions: %w", err)
 	}
 
-	trs, err := s.repo.ListTransactions(ctx, userId)
+	if len(trs) <= limit {
+		return trs, nil, nil
+	}
+	trs = trs[:limit]
+	return trs, domain.CursorOf(trs[limit-1]), nil
+}
+
+func (s *transactionService) VerifyLedger(ctx context.Context) error {
+	report, err := s.repo.CheckLedger(ctx)
 	if err != nil {
-		return nil, fmt.Errorf("can't list transactions: %w", err)
+		return fmt.Errorf("can't check ledger: %w", err)
 	}
 
-	return trs, nil
+	if !report.Consistent() {
+		return fmt.Errorf("%w: %d unbalanced currencies, %d accounts differ from their postings",
+			ErrLedgerInconsistent, len(report.Imbalances), len(report.Drifts))
+	}
+
+	return nil
+}
+
+func (s *transactionService) FailStalePending(ctx context.Context, timeout time.Duration) (int, error) {
+	n, err := s.repo.FailStalePendingTransactions(ctx, time.Now().Add(-timeout))
+	if err != nil {
+		return 0, fmt.Errorf("can't fail pending transactions: %w", err)
+	}
+	return n, nil
 }
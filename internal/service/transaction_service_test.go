@@ -2,10 +2,15 @@ package service
 
 import (
 	"context"
+	"errors"
+	"fmt"
+	"math/rand"
+	"sync"
 	"testing"
 	"time"
 
 	"bank-api/internal/domain"
+	"bank-api/internal/repository"
 	"bank-api/mocks"
 
 	"github.com/stretchr/testify/assert"
@@ -19,15 +24,15 @@ func TestProcessTransaction_Deposit(t *testing.T) {
 	mockRepo.EXPECT().GetAccount(gomock.Any(), 1).Return(&domain.Account{Id: 1, UserId: 1, Cur: domain.Currency{
 		Id:     1,
 		Symbol: "RUB",
-	}, Amount: 100}, nil)
-	mockRepo.EXPECT().Transaction(gomock.Any(), 1, 100, domain.Deposit).Return(nil)
+	}, Amount: domain.NewMoney(10000, rub)}, nil)
+	mockRepo.EXPECT().Transaction(gomock.Any(), 1, domain.NewMoney(10000, rub), domain.Deposit).Return(nil)
 
-	s := NewTransactionService(mockRepo)
+	s := NewTransactionService(mockRepo, nil, nil, 0, 0)
 
 	transaction := &domain.Transaction{
 		ToAccountId: 1,
 		UserId:      1,
-		Amount:      100,
+		Amount:      money(t, "100"),
 		Type:        domain.Deposit,
 	}
 
@@ -38,19 +43,19 @@ func TestProcessTransaction_Deposit(t *testing.T) {
 func TestProcessTransaction_Deposit_InvalidAmount(t *testing.T) {
 	mockRepo := mocks.NewMockAccountRepository(gomock.NewController(t))
 
-	s := NewTransactionService(mockRepo)
+	s := NewTransactionService(mockRepo, nil, nil, 0, 0)
 
 	transaction := &domain.Transaction{
 		ToAccountId: 1,
 		UserId:      1,
-		Amount:      -100,
+		Amount:      money(t, "-100"),
 		Type:        domain.Deposit,
 	}
 
 	err := s.ProcessTransaction(context.Background(), transaction)
 	assert.Error(t, err)
 
-	transaction.Amount = 0
+	transaction.Amount = domain.Money{}
 	err = s.ProcessTransaction(context.Background(), transaction)
 	assert.Error(t, err)
 }
@@ -60,12 +65,12 @@ func TestProcessTransaction_Deposit_InvalidAccount(t *testing.T) {
 
 	mockRepo.EXPECT().AccountExists(gomock.Any(), 1).Return(false, nil)
 
-	s := NewTransactionService(mockRepo)
+	s := NewTransactionService(mockRepo, nil, nil, 0, 0)
 
 	transaction := &domain.Transaction{
 		ToAccountId: 1,
 		UserId:      1,
-		Amount:      100,
+		Amount:      money(t, "100"),
 		Type:        domain.Deposit,
 	}
 
@@ -80,15 +85,15 @@ func TestProcessTransaction_Withdraw(t *testing.T) {
 	mockRepo.EXPECT().GetAccount(gomock.Any(), 1).Return(&domain.Account{Id: 1, UserId: 1, Cur: domain.Currency{
 		Id:     1,
 		Symbol: "RUB",
-	}, Amount: 200}, nil)
-	mockRepo.EXPECT().Transaction(gomock.Any(), 1, 200, domain.Withdraw).Return(nil)
+	}, Amount: domain.NewMoney(20000, rub)}, nil)
+	mockRepo.EXPECT().Transaction(gomock.Any(), 1, domain.NewMoney(20000, rub), domain.Withdraw).Return(nil)
 
-	s := NewTransactionService(mockRepo)
+	s := NewTransactionService(mockRepo, nil, nil, 0, 0)
 
 	transaction := &domain.Transaction{
 		FromAccountId: 1,
 		UserId:        1,
-		Amount:        200,
+		Amount:        money(t, "200"),
 		Type:          domain.Withdraw,
 	}
 
@@ -99,48 +104,565 @@ func TestProcessTransaction_Withdraw(t *testing.T) {
 func TestProcessTransaction_Withdraw_InvalidAmount(t *testing.T) {
 	mockRepo := mocks.NewMockAccountRepository(gomock.NewController(t))
 
-	s := NewTransactionService(mockRepo)
+	s := NewTransactionService(mockRepo, nil, nil, 0, 0)
 
 	transaction := &domain.Transaction{
 		FromAccountId: 1,
 		UserId:        1,
-		Amount:        -100,
+		Amount:        money(t, "-100"),
 		Type:          domain.Withdraw,
 	}
 
 	err := s.ProcessTransaction(context.Background(), transaction)
 	assert.ErrorIs(t, ErrInvalidAmount, err)
 
-	transaction.Amount = 0
+	transaction.Amount = domain.Money{}
 	err = s.ProcessTransaction(context.Background(), transaction)
 	assert.ErrorIs(t, ErrInvalidAmount, err)
 }
 
+func TestProcessTransaction_Withdraw_TooPrecise(t *testing.T) {
+	mockRepo := mocks.NewMockAccountRepository(gomock.NewController(t))
+
+	mockRepo.EXPECT().AccountExists(gomock.Any(), 1).Return(true, nil)
+	mockRepo.EXPECT().GetAccount(gomock.Any(), 1).Return(&domain.Account{Id: 1, UserId: 1, Cur: domain.Currency{
+		Id:     1,
+		Symbol: "RUB",
+	}, Amount: domain.NewMoney(20000, rub)}, nil)
+
+	s := NewTransactionService(mockRepo, nil, nil, 0, 0)
+
+	transaction := &domain.Transaction{
+		FromAccountId: 1,
+		UserId:        1,
+		Amount:        money(t, "10.005"),
+		Type:          domain.Withdraw,
+	}
+
+	err := s.ProcessTransaction(context.Background(), transaction)
+	assert.ErrorIs(t, err, ErrInvalidAmount)
+}
+
+func TestProcessTransaction_FrozenAccount(t *testing.T) {
+	tests := []struct {
+		name        string
+		transaction *domain.Transaction
+		setup       func(repo *mocks.MockAccountRepository)
+	}{
+		{
+			name:        "deposit",
+			transaction: &domain.Transaction{ToAccountId: 1, UserId: 1, Amount: money(t, "100"), Type: domain.Deposit},
+			setup: func(repo *mocks.MockAccountRepository) {
+				repo.EXPECT().Transaction(gomock.Any(), 1, gomock.Any(), domain.Deposit).Return(fmt.Errorf("wrapped: %w", domain.ErrAccountFrozen))
+			},
+		},
+		{
+			name:        "withdraw",
+			transaction: &domain.Transaction{FromAccountId: 1, UserId: 1, Amount: money(t, "100"), Type: domain.Withdraw},
+			setup: func(repo *mocks.MockAccountRepository) {
+				repo.EXPECT().Transaction(gomock.Any(), 1, gomock.Any(), domain.Withdraw).Return(fmt.Errorf("wrapped: %w", domain.ErrAccountFrozen))
+			},
+		},
+		{
+			name:        "transfer",
+			transaction: &domain.Transaction{FromAccountId: 1, ToAccountId: 2, UserId: 1, Amount: money(t, "100"), Type: domain.Transfer},
+			setup: func(repo *mocks.MockAccountRepository) {
+				repo.EXPECT().AccountExists(gomock.Any(), 2).Return(true, nil)
+				repo.EXPECT().GetAccount(gomock.Any(), 2).Return(&domain.Account{Id: 2, UserId: 2, Cur: rub}, nil)
+				repo.EXPECT().Transfer(gomock.Any(), gomock.Any()).Return(fmt.Errorf("wrapped: %w", domain.ErrAccountFrozen))
+			},
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			mockRepo := mocks.NewMockAccountRepository(gomock.NewController(t))
+			mockRepo.EXPECT().AccountExists(gomock.Any(), 1).Return(true, nil)
+			mockRepo.EXPECT().GetAccount(gomock.Any(), 1).Return(&domain.Account{Id: 1, UserId: 1, Cur: rub, Amount: domain.NewMoney(20000, rub)}, nil)
+			tt.setup(mockRepo)
+
+			err := NewTransactionService(mockRepo, nil, nil, 0, 0).ProcessTransaction(context.Background(), tt.transaction)
+			assert.ErrorIs(t, err, ErrAccountFrozen)
+		})
+	}
+}
+
 func TestProcessTransfer(t *testing.T) {
 	mockRepo := mocks.NewMockAccountRepository(gomock.NewController(t))
 
 	mockRepo.EXPECT().AccountExists(gomock.Any(), 1).Return(true, nil)
-	mockRepo.EXPECT().GetAccount(gomock.Any(), 2).Return(&domain.Account{Id: 1, UserId: 1, Cur: domain.Currency{
+	mockRepo.EXPECT().GetAccount(gomock.Any(), 1).Return(&domain.Account{Id: 1, UserId: 1, Cur: domain.Currency{
 		Id:     1,
 		Symbol: "RUB",
-	}, Amount: 100}, nil)
+	}, Amount: domain.NewMoney(10000, rub)}, nil)
 	mockRepo.EXPECT().AccountExists(gomock.Any(), 2).Return(true, nil)
-	mockRepo.EXPECT().Transfer(gomock.Any(), 1, 2, 50).Return(nil)
+	mockRepo.EXPECT().GetAccount(gomock.Any(), 2).Return(&domain.Account{Id: 2, UserId: 2, Cur: rub}, nil)
+	mockRepo.EXPECT().Transfer(gomock.Any(), &domain.Transaction{
+		UserId:        1,
+		FromAccountId: 1,
+		ToAccountId:   2,
+		Cur:           rub,
+		Amount:        domain.NewMoney(5000, rub),
+		ToCur:         rub,
+		ToAmount:      domain.NewMoney(5000, rub),
+		Type:          domain.Transfer,
+	}).Return(nil)
+
+	s := NewTransactionService(mockRepo, nil, nil, 0, 0)
+
+	transaction := &domain.Transaction{
+		FromAccountId: 1,
+		ToAccountId:   2,
+		UserId:        1,
+		Amount:        money(t, "50"),
+		Type:          domain.Transfer,
+	}
+
+	err := s.ProcessTransaction(context.Background(), transaction)
+	assert.NoError(t, err)
+}
+
+func TestProcessTransfer_NotEnoughMoney(t *testing.T) {
+	mockRepo := mocks.NewMockAccountRepository(gomock.NewController(t))
+
+	mockRepo.EXPECT().AccountExists(gomock.Any(), 1).Return(true, nil)
+	mockRepo.EXPECT().GetAccount(gomock.Any(), 1).Return(&domain.Account{Id: 1, UserId: 1, Cur: domain.Currency{
+		Id:     1,
+		Symbol: "RUB",
+	}, Amount: domain.NewMoney(1000, rub)}, nil)
+	mockRepo.EXPECT().AccountExists(gomock.Any(), 2).Return(true, nil)
+	mockRepo.EXPECT().GetAccount(gomock.Any(), 2).Return(&domain.Account{Id: 2, UserId: 2, Cur: rub}, nil)
+	mockRepo.EXPECT().Transfer(gomock.Any(), gomock.Any()).Return(fmt.Errorf("wrapped: %w", domain.ErrInsufficientFunds))
+
+	s := NewTransactionService(mockRepo, nil, nil, 0, 0)
 
-	s := NewTransactionService(mockRepo)
+	transaction := &domain.Transaction{
+		FromAccountId: 1,
+		ToAccountId:   2,
+		UserId:        1,
+		Amount:        money(t, "50"),
+		Type:          domain.Transfer,
+	}
+
+	err := s.ProcessTransaction(context.Background(), transaction)
+	assert.ErrorIs(t, err, ErrNotEnoughMoney)
+}
+
+func TestProcessTransfer_WrongUser(t *testing.T) {
+	mockRepo := mocks.NewMockAccountRepository(gomock.NewController(t))
+
+	mockRepo.EXPECT().AccountExists(gomock.Any(), 1).Return(true, nil)
+	mockRepo.EXPECT().GetAccount(gomock.Any(), 1).Return(&domain.Account{Id: 1, UserId: 2, Cur: domain.Currency{
+		Id:     1,
+		Symbol: "RUB",
+	}, Amount: domain.NewMoney(10000, rub)}, nil)
+
+	s := NewTransactionService(mockRepo, nil, nil, 0, 0)
 
 	transaction := &domain.Transaction{
 		FromAccountId: 1,
 		ToAccountId:   2,
 		UserId:        1,
-		Amount:        50,
+		Amount:        money(t, "50"),
 		Type:          domain.Transfer,
 	}
 
 	err := s.ProcessTransaction(context.Background(), transaction)
+	assert.ErrorIs(t, err, ErrInvalidAccount)
+}
+
+func TestProcessTransfer_CrossCurrency(t *testing.T) {
+	ctrl := gomock.NewController(t)
+	mockRepo := mocks.NewMockAccountRepository(ctrl)
+	mockExchange := mocks.NewMockExchangeRepository(ctrl)
+	usd := domain.Currency{Id: 2, Symbol: "USD"}
+	rate, _ := domain.ParseRate("0.0109")
+
+	mockRepo.EXPECT().AccountExists(gomock.Any(), 1).Return(true, nil)
+	mockRepo.EXPECT().GetAccount(gomock.Any(), 1).Return(&domain.Account{Id: 1, UserId: 1, Cur: rub, Amount: domain.NewMoney(100000, rub)}, nil)
+	mockRepo.EXPECT().AccountExists(gomock.Any(), 2).Return(true, nil)
+	mockRepo.EXPECT().GetAccount(gomock.Any(), 2).Return(&domain.Account{Id: 2, UserId: 2, Cur: usd}, nil)
+	mockExchange.EXPECT().GetRate(gomock.Any(), rub, usd, gomock.Any()).Return(&domain.ExchangeRate{Id: 7, From: rub, To: usd, Rate: rate}, nil)
+	mockRepo.EXPECT().Transfer(gomock.Any(), gomock.Any()).DoAndReturn(func(_ context.Context, tr *domain.Transaction) error {
+		assert.Equal(t, rub, tr.Cur)
+		assert.Equal(t, domain.NewMoney(50000, rub), tr.Amount)
+		assert.Equal(t, usd, tr.ToCur)
+		assert.Equal(t, domain.NewMoney(545, usd), tr.ToAmount)
+		assert.Equal(t, 7, tr.Rate.Id)
+		return nil
+	})
+
+	s := NewTransactionService(mockRepo, mockExchange, NewRateProvider(mockExchange), time.Minute, 0)
+
+	err := s.ProcessTransaction(context.Background(), &domain.Transaction{
+		FromAccountId: 1,
+		ToAccountId:   2,
+		UserId:        1,
+		Amount:        money(t, "500"),
+		Type:          domain.Transfer,
+	})
 	assert.NoError(t, err)
 }
 
+func TestProcessTransfer_NoExchangeRate(t *testing.T) {
+	ctrl := gomock.NewController(t)
+	mockRepo := mocks.NewMockAccountRepository(ctrl)
+	mockExchange := mocks.NewMockExchangeRepository(ctrl)
+	usd := domain.Currency{Id: 2, Symbol: "USD"}
+
+	mockRepo.EXPECT().AccountExists(gomock.Any(), 1).Return(true, nil)
+	mockRepo.EXPECT().GetAccount(gomock.Any(), 1).Return(&domain.Account{Id: 1, UserId: 1, Cur: rub, Amount: domain.NewMoney(100000, rub)}, nil)
+	mockRepo.EXPECT().AccountExists(gomock.Any(), 2).Return(true, nil)
+	mockRepo.EXPECT().GetAccount(gomock.Any(), 2).Return(&domain.Account{Id: 2, UserId: 2, Cur: usd}, nil)
+	mockExchange.EXPECT().GetRate(gomock.Any(), gomock.Any(), gomock.Any(), gomock.Any()).Return(nil, nil).AnyTimes()
+
+	s := NewTransactionService(mockRepo, mockExchange, NewRateProvider(mockExchange), time.Minute, 0)
+
+	err := s.ProcessTransaction(context.Background(), &domain.Transaction{
+		FromAccountId: 1,
+		ToAccountId:   2,
+		UserId:        1,
+		Amount:        money(t, "500"),
+		Type:          domain.Transfer,
+	})
+	assert.ErrorIs(t, err, ErrNoExchangeRate)
+}
+
+func expectRubToUsdAccounts(mockRepo *mocks.MockAccountRepository, usd domain.Currency) {
+	mockRepo.EXPECT().AccountExists(gomock.Any(), 1).Return(true, nil)
+	mockRepo.EXPECT().GetAccount(gomock.Any(), 1).Return(&domain.Account{Id: 1, UserId: 1, Cur: rub, Amount: domain.NewMoney(100000, rub)}, nil)
+	mockRepo.EXPECT().AccountExists(gomock.Any(), 2).Return(true, nil)
+	mockRepo.EXPECT().GetAccount(gomock.Any(), 2).Return(&domain.Account{Id: 2, UserId: 1, Cur: usd}, nil)
+}
+
+func TestCreateQuote(t *testing.T) {
+	ctrl := gomock.NewController(t)
+	mockRepo := mocks.NewMockAccountRepository(ctrl)
+	mockExchange := mocks.NewMockExchangeRepository(ctrl)
+	usd := domain.Currency{Id: 2, Symbol: "USD"}
+	rate, _ := domain.ParseRate("0.0109")
+
+	expectRubToUsdAccounts(mockRepo, usd)
+	mockExchange.EXPECT().GetRate(gomock.Any(), rub, usd, gomock.Any()).Return(&domain.ExchangeRate{Id: 7, From: rub, To: usd, Rate: rate}, nil)
+	mockExchange.EXPECT().CreateQuote(gomock.Any(), gomock.Any()).DoAndReturn(func(_ context.Context, q *domain.Quote) error {
+		q.Id = 3
+		return nil
+	})
+
+	s := NewTransactionService(mockRepo, mockExchange, NewRateProvider(mockExchange), 30*time.Second, 0)
+
+	quote, err := s.CreateQuote(context.Background(), &domain.Transaction{
+		FromAccountId: 1,
+		ToAccountId:   2,
+		UserId:        1,
+		Amount:        money(t, "500"),
+		Type:          domain.Transfer,
+	})
+	assert.NoError(t, err)
+	assert.Equal(t, 3, quote.Id)
+	assert.Equal(t, domain.NewMoney(545, usd), quote.ToAmount)
+	assert.Equal(t, 7, quote.Rate.Id)
+	assert.WithinDuration(t, time.Now().Add(30*time.Second), quote.ExpiresAt, time.Second)
+}
+
+func TestCreateQuote_SameCurrency(t *testing.T) {
+	ctrl := gomock.NewController(t)
+	mockRepo := mocks.NewMockAccountRepository(ctrl)
+	mockExchange := mocks.NewMockExchangeRepository(ctrl)
+
+	expectRubToUsdAccounts(mockRepo, rub)
+
+	s := NewTransactionService(mockRepo, mockExchange, NewRateProvider(mockExchange), 30*time.Second, 0)
+
+	_, err := s.CreateQuote(context.Background(), &domain.Transaction{
+		FromAccountId: 1,
+		ToAccountId:   2,
+		UserId:        1,
+		Amount:        money(t, "500"),
+		Type:          domain.Transfer,
+	})
+	assert.ErrorIs(t, err, ErrSameCurrency)
+}
+
+func TestProcessTransfer_Quote(t *testing.T) {
+	usd := domain.Currency{Id: 2, Symbol: "USD"}
+	rate, _ := domain.ParseRate("0.0109")
+	quoted := func() *domain.Quote {
+		return &domain.Quote{
+			Id:            3,
+			UserId:        1,
+			FromAccountId: 1,
+			ToAccountId:   2,
+			Cur:           rub,
+			Amount:        domain.NewMoney(50000, rub),
+			ToCur:         usd,
+			ToAmount:      domain.NewMoney(545, usd),
+			Rate:          &domain.ExchangeRate{Id: 7, From: rub, To: usd, Rate: rate},
+			ExpiresAt:     time.Now().Add(time.Minute),
+		}
+	}
+
+	tests := []struct {
+		name     string
+		quote    func() *domain.Quote
+		amount   string
+		transfer error
+		err      error
+	}{
+		{name: "ok", quote: quoted, amount: "500"},
+		{name: "no such quote", quote: func() *domain.Quote { return nil }, amount: "500", err: ErrNoSuchQuote},
+		{name: "another user", quote: func() *domain.Quote { q := quoted(); q.UserId = 2; return q }, amount: "500", err: ErrNoSuchQuote},
+		{name: "another amount", quote: quoted, amount: "600", err: ErrInvalidQuote},
+		{name: "expired", quote: func() *domain.Quote { q := quoted(); q.ExpiresAt = time.Now().Add(-time.Second); return q }, amount: "500", err: ErrQuoteExpired},
+		{name: "used", quote: func() *domain.Quote { q := quoted(); q.Used = true; return q }, amount: "500", err: ErrQuoteAlreadyUsed},
+		{name: "used concurrently", quote: quoted, amount: "500", transfer: domain.ErrQuoteUsed, err: ErrQuoteAlreadyUsed},
+		{name: "expired concurrently", quote: quoted, amount: "500", transfer: domain.ErrQuoteExpired, err: ErrQuoteExpired},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			ctrl := gomock.NewController(t)
+			mockRepo := mocks.NewMockAccountRepository(ctrl)
+			mockExchange := mocks.NewMockExchangeRepository(ctrl)
+
+			expectRubToUsdAccounts(mockRepo, usd)
+			mockExchange.EXPECT().GetQuote(gomock.Any(), 3).Return(tt.quote(), nil)
+			if tt.err == nil || tt.transfer != nil {
+				mockRepo.EXPECT().Transfer(gomock.Any(), gomock.Any()).DoAndReturn(func(_ context.Context, tr *domain.Transaction) error {
+					assert.Equal(t, 3, tr.QuoteId)
+					assert.Equal(t, domain.NewMoney(545, usd), tr.ToAmount)
+					assert.Equal(t, 7, tr.Rate.Id)
+					return tt.transfer
+				})
+			}
+
+			s := NewTransactionService(mockRepo, mockExchange, NewRateProvider(mockExchange), time.Minute, 0)
+
+			err := s.ProcessTransaction(context.Background(), &domain.Transaction{
+				FromAccountId: 1,
+				ToAccountId:   2,
+				UserId:        1,
+				Amount:        money(t, tt.amount),
+				QuoteId:       3,
+				Type:          domain.Transfer,
+			})
+			if tt.err != nil {
+				assert.ErrorIs(t, err, tt.err)
+			} else {
+				assert.NoError(t, err)
+			}
+		})
+	}
+}
+
+func postedTransfer() *domain.Transaction {
+	return &domain.Transaction{
+		Id:            10,
+		FromAccountId: 1,
+		ToAccountId:   2,
+		Cur:           rub,
+		Amount:        domain.NewMoney(10000, rub),
+		ToCur:         rub,
+		ToAmount:      domain.NewMoney(10000, rub),
+		Type:          domain.Transfer,
+		Status:        domain.StatusPosted,
+	}
+}
+
+func expectReversibleAccounts(mockRepo *mocks.MockAccountRepository, owner int) {
+	mockRepo.EXPECT().AccountExists(gomock.Any(), 1).Return(true, nil)
+	mockRepo.EXPECT().AccountExists(gomock.Any(), 2).Return(true, nil)
+	mockRepo.EXPECT().GetAccount(gomock.Any(), 2).Return(&domain.Account{Id: 2, UserId: owner, Cur: rub}, nil)
+}
+
+func TestReverseTransaction(t *testing.T) {
+	mockRepo := mocks.NewMockAccountRepository(gomock.NewController(t))
+
+	original := postedTransfer()
+	original.ReversedAmount = domain.NewMoney(4000, rub)
+	original.RefundedAmount = domain.NewMoney(4000, rub)
+
+	mockRepo.EXPECT().GetTransaction(gomock.Any(), 10).Return(original, nil)
+	expectReversibleAccounts(mockRepo, 2)
+	mockRepo.EXPECT().Reverse(gomock.Any(), original, &domain.Transaction{
+		UserId:        2,
+		FromAccountId: 2,
+		ToAccountId:   1,
+		Cur:           rub,
+		Amount:        domain.NewMoney(6000, rub),
+		ToCur:         rub,
+		ToAmount:      domain.NewMoney(6000, rub),
+		Type:          domain.Transfer,
+		ReversesId:    10,
+	}).Return(nil)
+
+	s := NewTransactionService(mockRepo, nil, nil, 0, 0)
+
+	reversal, err := s.ReverseTransaction(context.Background(), 2, 10, domain.Money{})
+	assert.NoError(t, err)
+	assert.Equal(t, 10, reversal.ReversesId)
+}
+
+func TestReverseTransaction_Partial(t *testing.T) {
+	mockRepo := mocks.NewMockAccountRepository(gomock.NewController(t))
+
+	original := postedTransfer()
+
+	mockRepo.EXPECT().GetTransaction(gomock.Any(), 10).Return(original, nil)
+	expectReversibleAccounts(mockRepo, 2)
+	mockRepo.EXPECT().Reverse(gomock.Any(), original, gomock.Any()).DoAndReturn(func(_ context.Context, _ *domain.Transaction, r *domain.Transaction) error {
+		assert.Equal(t, domain.NewMoney(2550, rub), r.Amount)
+		assert.Equal(t, domain.NewMoney(2550, rub), r.ToAmount)
+		return nil
+	})
+
+	s := NewTransactionService(mockRepo, nil, nil, 0, 0)
+
+	_, err := s.ReverseTransaction(context.Background(), 2, 10, money(t, "25.5"))
+	assert.NoError(t, err)
+}
+
+func TestReverseTransaction_Errors(t *testing.T) {
+	tests := []struct {
+		name     string
+		original func() *domain.Transaction
+		owner    int
+		amount   string
+		reverse  error
+		err      error
+	}{
+		{name: "no such transaction", original: func() *domain.Transaction { return nil }, err: ErrNoSuchTransaction},
+		{name: "deposit", original: func() *domain.Transaction { tr := postedTransfer(); tr.Type = domain.Deposit; return tr }, err: ErrNotReversible},
+		{name: "reversal", original: func() *domain.Transaction { tr := postedTransfer(); tr.ReversesId = 9; return tr }, err: ErrNotReversible},
+		{name: "failed", original: func() *domain.Transaction { tr := postedTransfer(); tr.Status = domain.StatusFailed; return tr }, err: ErrNotReversible},
+		{name: "reversed", original: func() *domain.Transaction { tr := postedTransfer(); tr.Status = domain.StatusReversed; return tr }, err: ErrNotReversible},
+		{name: "not the receiver", original: postedTransfer, owner: 1, err: ErrInvalidAccount},
+		{name: "too much", original: postedTransfer, owner: 2, amount: "100.01", err: ErrReversalExceedsAmount},
+		{name: "too precise", original: postedTransfer, owner: 2, amount: "0.001", err: ErrInvalidAmount},
+		{name: "negative", original: postedTransfer, owner: 2, amount: "-1", err: ErrInvalidAmount},
+		{name: "receiver spent it", original: postedTransfer, owner: 2, reverse: domain.ErrInsufficientFunds, err: ErrNotEnoughMoney},
+		{name: "reversed concurrently", original: postedTransfer, owner: 2, reverse: domain.ErrReversalExceedsAmount, err: ErrReversalExceedsAmount},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			mockRepo := mocks.NewMockAccountRepository(gomock.NewController(t))
+
+			mockRepo.EXPECT().GetTransaction(gomock.Any(), 10).Return(tt.original(), nil)
+			if tt.owner != 0 {
+				expectReversibleAccounts(mockRepo, tt.owner)
+			}
+			if tt.reverse != nil {
+				mockRepo.EXPECT().Reverse(gomock.Any(), gomock.Any(), gomock.Any()).Return(fmt.Errorf("wrapped: %w", tt.reverse))
+			}
+
+			s := NewTransactionService(mockRepo, nil, nil, 0, 0)
+
+			var amount domain.Money
+			if tt.amount != "" {
+				amount = money(t, tt.amount)
+			}
+			_, err := s.ReverseTransaction(context.Background(), 2, 10, amount)
+			assert.ErrorIs(t, err, tt.err)
+		})
+	}
+}
+
+// memAccountRepo keeps balances in memory and checks and updates them under a mutex. It stands in for
+// the locking of the database implementation, which is tested against Postgres in the queries package.
+type memAccountRepo struct {
+	repository.AccountRepository
+
+	mu       sync.Mutex
+	accounts map[int]*domain.Account
+}
+
+func newMemAccountRepo(n int, balance domain.Money) *memAccountRepo {
+	r := &memAccountRepo{accounts: make(map[int]*domain.Account, n)}
+	for id := 1; id <= n; id++ {
+		r.accounts[id] = &domain.Account{Id: id, UserId: 1, Cur: rub, Amount: balance}
+	}
+	return r
+}
+
+func (r *memAccountRepo) AccountExists(_ context.Context, id int) (bool, error) {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	_, ok := r.accounts[id]
+	return ok, nil
+}
+
+func (r *memAccountRepo) GetAccount(_ context.Context, id int) (*domain.Account, error) {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	account := *r.accounts[id]
+	return &account, nil
+}
+
+func (r *memAccountRepo) Transfer(_ context.Context, t *domain.Transaction) error {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+
+	from, to := r.accounts[t.FromAccountId], r.accounts[t.ToAccountId]
+	if from.Amount.Cmp(t.Amount) < 0 {
+		return domain.ErrInsufficientFunds
+	}
+	from.Amount, _ = from.Amount.Sub(t.Amount)
+	to.Amount, _ = to.Amount.Add(t.ToAmount)
+	return nil
+}
+
+// TestProcessTransfer_Concurrent checks that the service itself keeps no state that concurrent transfers race on.
+func TestProcessTransfer_Concurrent(t *testing.T) {
+	const (
+		accounts  = 5
+		workers   = 20
+		transfers = 200
+	)
+
+	initial := domain.NewMoney(10000, rub)
+	repo := newMemAccountRepo(accounts, initial)
+	s := NewTransactionService(repo, nil, nil, 0, 0)
+
+	var wg sync.WaitGroup
+	errs := make(chan error, workers*transfers)
+	for w := 0; w < workers; w++ {
+		wg.Add(1)
+		go func(seed int64) {
+			defer wg.Done()
+			rnd := rand.New(rand.NewSource(seed))
+			for i := 0; i < transfers; i++ {
+				from := rnd.Intn(accounts) + 1
+				to := (from+rnd.Intn(accounts-1))%accounts + 1
+				err := s.ProcessTransaction(context.Background(), &domain.Transaction{
+					UserId:        1,
+					FromAccountId: from,
+					ToAccountId:   to,
+					Amount:        domain.NewMoney(int64(rnd.Intn(5000)+1), rub),
+					Type:          domain.Transfer,
+				})
+				if err != nil && !errors.Is(err, ErrNotEnoughMoney) {
+					errs <- err
+				}
+			}
+		}(int64(w))
+	}
+	wg.Wait()
+	close(errs)
+
+	for err := range errs {
+		assert.NoError(t, err)
+	}
+
+	total := domain.Money{}
+	for _, account := range repo.accounts {
+		assert.GreaterOrEqual(t, account.Amount.Sign(), 0, "account %d is overdrawn", account.Id)
+		total, _ = total.Add(account.Amount)
+	}
+	expected := domain.NewMoney(initial.Units()*accounts, rub)
+	assert.Equal(t, 0, expected.Cmp(total), "money was created or lost: expected %s, got %s", expected, total)
+}
+
 func TestListTransactions(t *testing.T) {
 	mockRepo := mocks.NewMockAccountRepository(gomock.NewController(t))
 
@@ -156,7 +678,7 @@ func TestListTransactions(t *testing.T) {
 			FromAccountId: 1,
 			ToAccountId:   2,
 			UserId:        1,
-			Amount:        50,
+			Amount:        money(t, "50"),
 			Type:          domain.Transfer,
 			Time:          trTimes[0],
 		},
@@ -164,7 +686,7 @@ func TestListTransactions(t *testing.T) {
 			FromAccountId: 3,
 			ToAccountId:   1,
 			UserId:        2,
-			Amount:        100,
+			Amount:        money(t, "100"),
 			Type:          domain.Transfer,
 			Time:          trTimes[1],
 		},
@@ -172,7 +694,7 @@ func TestListTransactions(t *testing.T) {
 			FromAccountId: 1,
 			ToAccountId:   0,
 			UserId:        1,
-			Amount:        100,
+			Amount:        money(t, "100"),
 			Type:          domain.Withdraw,
 			Time:          trTimes[2],
 		},
@@ -180,17 +702,18 @@ func TestListTransactions(t *testing.T) {
 			FromAccountId: 0,
 			ToAccountId:   1,
 			UserId:        1,
-			Amount:        100,
+			Amount:        money(t, "100"),
 			Type:          domain.Deposit,
 			Time:          trTimes[3],
 		},
 	}
-	mockRepo.EXPECT().ListTransactions(gomock.Any(), 1).Return(trs, nil)
+	mockRepo.EXPECT().ListTransactions(gomock.Any(), &domain.TransactionFilter{UserId: 1, Limit: defaultHistoryLimit + 1}).Return(trs, nil)
 
-	s := NewTransactionService(mockRepo)
+	s := NewTransactionService(mockRepo, nil, nil, 0, 0)
 
-	transactions, err := s.ListTransactions(context.Background(), 1)
+	transactions, next, err := s.ListTransactions(context.Background(), &domain.TransactionFilter{UserId: 1})
 	assert.NoError(t, err)
+	assert.Nil(t, next)
 	assert.NotNil(t, transactions)
 	assert.Equal(t, 4, len(transactions))
 
@@ -199,6 +722,103 @@ func TestListTransactions(t *testing.T) {
 	}
 }
 
+func TestListTransactions_Pages(t *testing.T) {
+	mockRepo := mocks.NewMockAccountRepository(gomock.NewController(t))
+
+	now := time.Now().UTC()
+	trs := []*domain.Transaction{
+		{Id: 3, ToAccountId: 1, Amount: money(t, "10"), Type: domain.Deposit, Time: now},
+		{Id: 2, ToAccountId: 1, Amount: money(t, "20"), Type: domain.Deposit, Time: now.Add(-time.Minute)},
+		{Id: 1, ToAccountId: 1, Amount: money(t, "30"), Type: domain.Deposit, Time: now.Add(-time.Minute)},
+	}
+	after := &domain.Cursor{Time: now.Add(time.Hour), Id: 7}
+
+	mockRepo.EXPECT().UserExistsById(gomock.Any(), 1).Return(true, nil)
+	mockRepo.EXPECT().AccountExists(gomock.Any(), 1).Return(true, nil)
+	mockRepo.EXPECT().GetAccount(gomock.Any(), 1).Return(&domain.Account{Id: 1, UserId: 1, Cur: rub}, nil)
+	mockRepo.EXPECT().CurrencyExists(gomock.Any(), domain.Currency{Symbol: "RUB"}).Return(true, nil)
+	mockRepo.EXPECT().GetCurrencyId(gomock.Any(), domain.Currency{Symbol: "RUB"}).Return(1, nil)
+	mockRepo.EXPECT().ListTransactions(gomock.Any(), &domain.TransactionFilter{
+		UserId:    1,
+		AccountId: 1,
+		Cur:       rub,
+		After:     after,
+		Limit:     3,
+	}).Return(trs, nil)
+
+	s := NewTransactionService(mockRepo, nil, nil, 0, 0)
+
+	transactions, next, err := s.ListTransactions(context.Background(), &domain.TransactionFilter{
+		UserId:    1,
+		AccountId: 1,
+		Cur:       domain.Currency{Symbol: "RUB"},
+		After:     after,
+		Limit:     2,
+	})
+	assert.NoError(t, err)
+	assert.Equal(t, trs[:2], transactions)
+	assert.Equal(t, &domain.Cursor{Time: trs[1].Time, Id: 2}, next)
+}
+
+func TestListTransactions_Errors(t *testing.T) {
+	minAmount, maxAmount := money(t, "100"), money(t, "10")
+	now := time.Now()
+
+	tests := []struct {
+		name   string
+		filter domain.TransactionFilter
+		expect func(mockRepo *mocks.MockAccountRepository)
+		err    error
+	}{
+		{name: "limit too big", filter: domain.TransactionFilter{Limit: maxHistoryLimit + 1}, err: ErrInvalidFilter},
+		{name: "negative limit", filter: domain.TransactionFilter{Limit: -1}, err: ErrInvalidFilter},
+		{name: "empty date range", filter: domain.TransactionFilter{From: now, To: now}, err: ErrInvalidFilter},
+		{name: "empty amount range", filter: domain.TransactionFilter{MinAmount: &minAmount, MaxAmount: &maxAmount}, err: ErrInvalidFilter},
+		{
+			name:   "no such user",
+			filter: domain.TransactionFilter{},
+			expect: func(mockRepo *mocks.MockAccountRepository) {
+				mockRepo.EXPECT().UserExistsById(gomock.Any(), 1).Return(false, nil)
+			},
+			err: ErrNoSuchUser,
+		},
+		{
+			name:   "account of another user",
+			filter: domain.TransactionFilter{AccountId: 2},
+			expect: func(mockRepo *mocks.MockAccountRepository) {
+				mockRepo.EXPECT().UserExistsById(gomock.Any(), 1).Return(true, nil)
+				mockRepo.EXPECT().AccountExists(gomock.Any(), 2).Return(true, nil)
+				mockRepo.EXPECT().GetAccount(gomock.Any(), 2).Return(&domain.Account{Id: 2, UserId: 2, Cur: rub}, nil)
+			},
+			err: ErrInvalidAccount,
+		},
+		{
+			name:   "no such currency",
+			filter: domain.TransactionFilter{Cur: domain.Currency{Symbol: "XXX"}},
+			expect: func(mockRepo *mocks.MockAccountRepository) {
+				mockRepo.EXPECT().UserExistsById(gomock.Any(), 1).Return(true, nil)
+				mockRepo.EXPECT().CurrencyExists(gomock.Any(), domain.Currency{Symbol: "XXX"}).Return(false, nil)
+			},
+			err: ErrNoSuchCurrency,
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			mockRepo := mocks.NewMockAccountRepository(gomock.NewController(t))
+			if tt.expect != nil {
+				tt.expect(mockRepo)
+			}
+
+			s := NewTransactionService(mockRepo, nil, nil, 0, 0)
+
+			tt.filter.UserId = 1
+			_, _, err := s.ListTransactions(context.Background(), &tt.filter)
+			assert.ErrorIs(t, err, tt.err)
+		})
+	}
+}
+
 func assertTransaction(t *testing.T, expected *domain.Transaction, got *domain.Transaction) {
 	assert.Equal(t, expected.FromAccountId, got.FromAccountId)
 	assert.Equal(t, expected.ToAccountId, got.ToAccountId)
@@ -207,3 +827,43 @@ func assertTransaction(t *testing.T, expected *domain.Transaction, got *domain.T
 	assert.Equal(t, expected.Type, got.Type)
 	assert.Equal(t, expected.Time, got.Time)
 }
+
+func TestVerifyLedger(t *testing.T) {
+	mockRepo := mocks.NewMockAccountRepository(gomock.NewController(t))
+
+	mockRepo.EXPECT().CheckLedger(gomock.Any()).Return(&domain.LedgerReport{}, nil)
+
+	s := NewTransactionService(mockRepo, nil, nil, 0, 0)
+
+	err := s.VerifyLedger(context.Background())
+	assert.NoError(t, err)
+}
+
+func TestVerifyLedger_Inconsistent(t *testing.T) {
+	mockRepo := mocks.NewMockAccountRepository(gomock.NewController(t))
+
+	mockRepo.EXPECT().CheckLedger(gomock.Any()).Return(&domain.LedgerReport{
+		Imbalances: []domain.CurrencyImbalance{{Cur: domain.Currency{Id: 1, Symbol: "RUB"}, Sum: domain.NewMoney(10000, rub)}},
+		Drifts:     []domain.AccountDrift{{AccountId: 1, Balance: domain.NewMoney(20000, rub), Posted: domain.NewMoney(10000, rub)}},
+	}, nil)
+
+	s := NewTransactionService(mockRepo, nil, nil, 0, 0)
+
+	err := s.VerifyLedger(context.Background())
+	assert.ErrorIs(t, err, ErrLedgerInconsistent)
+}
+
+func TestFailStalePending(t *testing.T) {
+	mockRepo := mocks.NewMockAccountRepository(gomock.NewController(t))
+
+	mockRepo.EXPECT().FailStalePendingTransactions(gomock.Any(), gomock.Any()).DoAndReturn(func(_ context.Context, before time.Time) (int, error) {
+		assert.WithinDuration(t, time.Now().Add(-5*time.Minute), before, time.Second)
+		return 1, nil
+	})
+
+	s := NewTransactionService(mockRepo, nil, nil, 0, 0)
+
+	n, err := s.FailStalePending(context.Background(), 5*time.Minute)
+	assert.NoError(t, err)
+	assert.Equal(t, 1, n)
+}
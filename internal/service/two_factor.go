@@ -0,0 +1,256 @@
+package service
+
+import (
+	"context"
+	"crypto/rand"
+	"encoding/base32"
+	"errors"
+	"fmt"
+	"strconv"
+	"strings"
+	"time"
+
+	"bank-api/internal/domain"
+	"bank-api/pkg/secretbox"
+	"bank-api/pkg/totp"
+)
+
+const (
+	// totpSkew accepts the codes of the periods right before and after the current one.
+	totpSkew          = 1
+	recoveryCodeCount = 10
+	// maxChallengeAttempts is how many codes can be tried on one challenge before the password must be entered again.
+	maxChallengeAttempts = 5
+)
+
+var (
+	ErrTwoFactorEnabled     = errors.New("two-factor authentication is already enabled")
+	ErrTwoFactorNotEnrolled = errors.New("two-factor authentication isn't enrolled")
+	ErrTwoFactorNotEnabled  = errors.New("two-factor authentication isn't enabled")
+	ErrWrongTwoFactorCode   = errors.New("wrong two-factor code")
+	ErrInvalidChallenge     = errors.New("invalid two-factor challenge")
+	ErrTwoFactorUnavailable = errors.New("two-factor authentication is unavailable")
+)
+
+type TwoFactorConfig struct {
+	// Secrets encrypts the TOTP secrets before they are stored. Without it 2FA is disabled.
+	Secrets *secretbox.Box
+	// Issuer names the bank in authenticator apps.
+	Issuer       string
+	ChallengeTTL time.Duration
+}
+
+func (s *userService) EnrollTwoFactor(ctx context.Context, userId int) (*domain.TwoFactorEnrollment, error) {
+	user, err := s.GetUserById(ctx, userId)
+	if err != nil {
+		return nil, err
+	}
+
+	secret, err := totp.GenerateSecret()
+	if err != nil {
+		return nil, fmt.Errorf("can't generate TOTP secret: %w", err)
+	}
+	sealed, err := s.twoFactor.Secrets.Seal(secret, secretData(userId))
+	if err != nil {
+		return nil, fmt.Errorf("can't encrypt TOTP secret: %w", err)
+	}
+
+	err = s.repo.EnrollTwoFactor(ctx, userId, sealed)
+	if errors.Is(err, domain.ErrTwoFactorEnabled) {
+		return nil, ErrTwoFactorEnabled
+	}
+	if err != nil {
+		return nil, fmt.Errorf("can't enroll two-factor authentication: %w", err)
+	}
+
+	return &domain.TwoFactorEnrollment{
+		Secret: totp.EncodeSecret(secret),
+		URI:    totp.URI(s.twoFactor.Issuer, user.Email, secret),
+	}, nil
+}
+
+func (s *userService) ConfirmTwoFactor(ctx context.Context, userId int, code string) ([]string, error) {
+	tf, err := s.repo.GetTwoFactor(ctx, userId)
+	if err != nil {
+		return nil, fmt.Errorf("can't get two-factor enrollment: %w", err)
+	}
+	if tf == nil {
+		return nil, ErrTwoFactorNotEnrolled
+	}
+	if tf.Enabled() {
+		return nil, ErrTwoFactorEnabled
+	}
+
+	secret, err := s.twoFactor.Secrets.Open(tf.Secret, secretData(userId))
+	if err != nil {
+		return nil, fmt.Errorf("can't decrypt TOTP secret: %w", err)
+	}
+	now := time.Now()
+	step, ok := totp.Verify(secret, normalizeCode(code), now, totpSkew)
+	if !ok {
+		return nil, ErrWrongTwoFactorCode
+	}
+
+	codes := make([]string, recoveryCodeCount)
+	hashes := make([][]byte, recoveryCodeCount)
+	for i := range codes {
+		if codes[i], err = newRecoveryCode(); err != nil {
+			return nil, fmt.Errorf("can't generate recovery code: %w", err)
+		}
+		hashes[i] = hashToken(normalizeCode(codes[i]))
+	}
+
+	err = s.repo.ConfirmTwoFactor(ctx, userId, step, hashes, now)
+	if errors.Is(err, domain.ErrTwoFactorEnabled) {
+		return nil, ErrTwoFactorEnabled
+	}
+	if err != nil {
+		return nil, fmt.Errorf("can't confirm two-factor authentication: %w", err)
+	}
+	return codes, nil
+}
+
+// DisableTwoFactor turns 2FA off after checking a code or a recovery code, so a stolen session alone can't do it.
+func (s *userService) DisableTwoFactor(ctx context.Context, userId int, code string) error {
+	tf, err := s.repo.GetTwoFactor(ctx, userId)
+	if err != nil {
+		return fmt.Errorf("can't get two-factor enrollment: %w", err)
+	}
+	if tf == nil || !tf.Enabled() {
+		return ErrTwoFactorNotEnabled
+	}
+
+	ok, err := s.checkCode(ctx, tf, code)
+	if err != nil {
+		return err
+	}
+	if !ok {
+		return ErrWrongTwoFactorCode
+	}
+
+	if err := s.repo.DeleteTwoFactor(ctx, userId); err != nil {
+		return fmt.Errorf("can't disable two-factor authentication: %w", err)
+	}
+	return nil
+}
+
+// CompleteTwoFactorLogin counts wrong codes as login failures of the email of the user, so that guessing codes
+// with one challenge after another runs into the lockout like guessing passwords does.
+func (s *userService) CompleteTwoFactorLogin(ctx context.Context, challengeToken string, code string, ip string) (*domain.User, error) {
+	now := time.Now()
+	challenge, err := s.repo.AttemptTwoFactorChallenge(ctx, hashToken(challengeToken), now, maxChallengeAttempts)
+	if err != nil {
+		return nil, fmt.Errorf("can't attempt two-factor challenge: %w", err)
+	}
+	if challenge == nil {
+		return nil, ErrInvalidChallenge
+	}
+
+	user, err := s.repo.GetUser(ctx, challenge.UserId)
+	if err != nil {
+		return nil, fmt.Errorf("can't get user: %w", err)
+	}
+	if err := s.logins.check(ctx, user.Email, ip, now); err != nil {
+		return nil, err
+	}
+
+	tf, err := s.repo.GetTwoFactor(ctx, challenge.UserId)
+	if err != nil {
+		return nil, fmt.Errorf("can't get two-factor enrollment: %w", err)
+	}
+	if tf == nil || !tf.Enabled() {
+		return nil, ErrInvalidChallenge
+	}
+
+	ok, err := s.checkCode(ctx, tf, code)
+	if err != nil {
+		return nil, err
+	}
+	if !ok {
+		if err := s.logins.recordFailure(ctx, user.Email, ip, now); err != nil {
+			return nil, err
+		}
+		return nil, ErrWrongTwoFactorCode
+	}
+
+	ok, err = s.repo.UseTwoFactorChallenge(ctx, challenge.Id, now)
+	if err != nil {
+		return nil, fmt.Errorf("can't use two-factor challenge: %w", err)
+	}
+	if !ok {
+		return nil, ErrInvalidChallenge
+	}
+
+	if err := s.logins.clear(ctx, user.Email); err != nil {
+		return nil, err
+	}
+	return user, nil
+}
+
+// newChallenge returns a challenge to log in with, which is only stored by the hash of its token.
+func (s *userService) newChallenge(ctx context.Context, userId int) (*domain.TwoFactorChallenge, error) {
+	token, err := newToken()
+	if err != nil {
+		return nil, fmt.Errorf("can't generate two-factor challenge: %w", err)
+	}
+
+	challenge := &domain.TwoFactorChallenge{
+		UserId:    userId,
+		Token:     token,
+		Hash:      hashToken(token),
+		ExpiresAt: time.Now().Add(s.twoFactor.ChallengeTTL),
+	}
+	if err := s.repo.CreateTwoFactorChallenge(ctx, challenge); err != nil {
+		return nil, fmt.Errorf("can't create two-factor challenge: %w", err)
+	}
+	return challenge, nil
+}
+
+// checkCode accepts either a TOTP code that wasn't used yet or an unused recovery code, and uses it up.
+func (s *userService) checkCode(ctx context.Context, tf *domain.TwoFactor, code string) (bool, error) {
+	code = normalizeCode(code)
+
+	if len(code) == totp.Digits {
+		secret, err := s.twoFactor.Secrets.Open(tf.Secret, secretData(tf.UserId))
+		if err != nil {
+			return false, fmt.Errorf("can't decrypt TOTP secret: %w", err)
+		}
+		step, ok := totp.Verify(secret, code, time.Now(), totpSkew)
+		if !ok {
+			return false, nil
+		}
+		ok, err = s.repo.UseTwoFactorStep(ctx, tf.UserId, step)
+		if err != nil {
+			return false, fmt.Errorf("can't use two-factor code: %w", err)
+		}
+		return ok, nil
+	}
+
+	ok, err := s.repo.UseRecoveryCode(ctx, tf.UserId, hashToken(code), time.Now())
+	if err != nil {
+		return false, fmt.Errorf("can't use recovery code: %w", err)
+	}
+	return ok, nil
+}
+
+// secretData binds an encrypted TOTP secret to its user, so it can't be moved to another one.
+func secretData(userId int) []byte {
+	return []byte(strconv.Itoa(userId))
+}
+
+var recoveryCodeEncoding = base32.StdEncoding.WithPadding(base32.NoPadding)
+
+// newRecoveryCode returns a code of 10 base32 characters (50 bits), shown as xxxxx-xxxxx.
+func newRecoveryCode() (string, error) {
+	raw := make([]byte, 7)
+	if _, err := rand.Read(raw); err != nil {
+		return "", err
+	}
+	code := strings.ToLower(recoveryCodeEncoding.EncodeToString(raw))[:10]
+	return code[:5] + "-" + code[5:], nil
+}
+
+// normalizeCode drops what users tend to type around codes: spaces, dashes and capitals.
+func normalizeCode(code string) string {
+	return strings.ToLower(strings.NewReplacer(" ", "", "-", "").Replace(code))
+}
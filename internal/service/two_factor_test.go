@@ -0,0 +1,246 @@
+package service
+
+import (
+	"bytes"
+	"context"
+	"testing"
+	"time"
+
+	"bank-api/internal/domain"
+	"bank-api/mocks"
+	"bank-api/pkg/secretbox"
+	"bank-api/pkg/totp"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"go.uber.org/mock/gomock"
+	"golang.org/x/crypto/bcrypt"
+)
+
+func testTwoFactorConfig(t *testing.T) TwoFactorConfig {
+	box, err := secretbox.New(bytes.Repeat([]byte{1}, secretbox.KeySize))
+	require.NoError(t, err)
+	return TwoFactorConfig{Secrets: box, Issuer: "Bank API", ChallengeTTL: 5 * time.Minute}
+}
+
+// enabledTwoFactor returns the enrollment of user 1 with its plain secret.
+func enabledTwoFactor(t *testing.T, cfg TwoFactorConfig) (*domain.TwoFactor, []byte) {
+	secret, err := totp.GenerateSecret()
+	require.NoError(t, err)
+	sealed, err := cfg.Secrets.Seal(secret, secretData(1))
+	require.NoError(t, err)
+	return &domain.TwoFactor{UserId: 1, Secret: sealed, ConfirmedAt: time.Now()}, secret
+}
+
+func TestEnrollAndConfirmTwoFactor(t *testing.T) {
+	mockRepo := mocks.NewMockUserRepository(gomock.NewController(t))
+	cfg := testTwoFactorConfig(t)
+	s := NewUserService(mockRepo, cfg, LockoutPolicy{})
+
+	mockRepo.EXPECT().UserExistsById(gomock.Any(), 1).Return(true, nil)
+	mockRepo.EXPECT().GetUser(gomock.Any(), 1).Return(&domain.User{Id: 1, Email: "test@example.com"}, nil)
+	var sealed []byte
+	mockRepo.EXPECT().EnrollTwoFactor(gomock.Any(), 1, gomock.Any()).DoAndReturn(func(_ context.Context, _ int, secret []byte) error {
+		sealed = secret
+		return nil
+	})
+
+	enrollment, err := s.EnrollTwoFactor(context.Background(), 1)
+	require.NoError(t, err)
+	assert.Contains(t, enrollment.URI, "otpauth://totp/Bank%20API:test@example.com?")
+	assert.Contains(t, enrollment.URI, "secret="+enrollment.Secret)
+
+	// The secret is stored encrypted.
+	secret, err := cfg.Secrets.Open(sealed, secretData(1))
+	require.NoError(t, err)
+	assert.Equal(t, enrollment.Secret, totp.EncodeSecret(secret))
+	assert.NotContains(t, string(sealed), string(secret))
+
+	pending := &domain.TwoFactor{UserId: 1, Secret: sealed}
+	mockRepo.EXPECT().GetTwoFactor(gomock.Any(), 1).Return(pending, nil).Times(2)
+
+	_, err = s.ConfirmTwoFactor(context.Background(), 1, "000000")
+	assert.ErrorIs(t, err, ErrWrongTwoFactorCode)
+
+	step := totp.Step(time.Now())
+	var hashes [][]byte
+	mockRepo.EXPECT().ConfirmTwoFactor(gomock.Any(), 1, step, gomock.Any(), gomock.Any()).
+		DoAndReturn(func(_ context.Context, _ int, _ int64, codes [][]byte, _ time.Time) error {
+			hashes = codes
+			return nil
+		})
+
+	codes, err := s.ConfirmTwoFactor(context.Background(), 1, totp.Code(secret, step))
+	require.NoError(t, err)
+	require.Len(t, codes, recoveryCodeCount)
+	assert.Regexp(t, `^[a-z2-7]{5}-[a-z2-7]{5}$`, codes[0])
+	for i, code := range codes {
+		assert.Equal(t, hashToken(normalizeCode(code)), hashes[i])
+	}
+}
+
+func TestEnrollTwoFactor_AlreadyEnabled(t *testing.T) {
+	mockRepo := mocks.NewMockUserRepository(gomock.NewController(t))
+	s := NewUserService(mockRepo, testTwoFactorConfig(t), LockoutPolicy{})
+
+	mockRepo.EXPECT().UserExistsById(gomock.Any(), 1).Return(true, nil)
+	mockRepo.EXPECT().GetUser(gomock.Any(), 1).Return(&domain.User{Id: 1, Email: "test@example.com"}, nil)
+	mockRepo.EXPECT().EnrollTwoFactor(gomock.Any(), 1, gomock.Any()).Return(domain.ErrTwoFactorEnabled)
+
+	_, err := s.EnrollTwoFactor(context.Background(), 1)
+	assert.ErrorIs(t, err, ErrTwoFactorEnabled)
+}
+
+func TestAuthenticateUser_TwoFactor(t *testing.T) {
+	mockRepo := mocks.NewMockUserRepository(gomock.NewController(t))
+	cfg := testTwoFactorConfig(t)
+	s := NewUserService(mockRepo, cfg, LockoutPolicy{})
+
+	hash, err := bcrypt.GenerateFromPassword([]byte("password"), bcrypt.MinCost)
+	require.NoError(t, err)
+	user := &domain.User{Id: 1, Email: "test@example.com", HashedPassword: string(hash)}
+	mockRepo.EXPECT().GetUserByEmail(gomock.Any(), user.Email).Return(user, nil).AnyTimes()
+	login := &domain.UserInfo{Email: user.Email, Password: "password"}
+
+	// Without 2FA the password is enough.
+	mockRepo.EXPECT().GetTwoFactor(gomock.Any(), 1).Return(nil, nil)
+	u, challenge, err := s.AuthenticateUser(context.Background(), login, "")
+	assert.NoError(t, err)
+	assert.Equal(t, user, u)
+	assert.Nil(t, challenge)
+
+	_, _, err = s.AuthenticateUser(context.Background(), &domain.UserInfo{Email: user.Email, Password: "wrong"}, "")
+	assert.ErrorIs(t, err, ErrWrongPassword)
+
+	tf, _ := enabledTwoFactor(t, cfg)
+	mockRepo.EXPECT().GetTwoFactor(gomock.Any(), 1).Return(tf, nil)
+	var stored *domain.TwoFactorChallenge
+	mockRepo.EXPECT().CreateTwoFactorChallenge(gomock.Any(), gomock.Any()).DoAndReturn(func(_ context.Context, c *domain.TwoFactorChallenge) error {
+		stored = c
+		return nil
+	})
+
+	u, challenge, err = s.AuthenticateUser(context.Background(), login, "")
+	assert.NoError(t, err)
+	assert.Nil(t, u)
+	require.NotNil(t, challenge)
+	assert.NotEmpty(t, challenge.Token)
+	assert.Equal(t, hashToken(challenge.Token), stored.Hash)
+	assert.Equal(t, 1, stored.UserId)
+	assert.WithinDuration(t, time.Now().Add(5*time.Minute), stored.ExpiresAt, time.Second)
+
+	// Without the key the codes can't be checked, so the user can't log in.
+	mockRepo.EXPECT().GetTwoFactor(gomock.Any(), 1).Return(tf, nil)
+	_, _, err = NewUserService(mockRepo, TwoFactorConfig{}, LockoutPolicy{}).AuthenticateUser(context.Background(), login, "")
+	assert.ErrorIs(t, err, ErrTwoFactorUnavailable)
+}
+
+func TestCompleteTwoFactorLogin(t *testing.T) {
+	cfg := testTwoFactorConfig(t)
+	tf, secret := enabledTwoFactor(t, cfg)
+	step := totp.Step(time.Now())
+	challenge := &domain.TwoFactorChallenge{Id: 7, UserId: 1, Attempts: 1}
+
+	tests := []struct {
+		name  string
+		code  string
+		setup func(repo *mocks.MockUserRepository)
+		err   error
+	}{
+		{
+			name: "TOTP code",
+			code: totp.Code(secret, step),
+			setup: func(repo *mocks.MockUserRepository) {
+				repo.EXPECT().UseTwoFactorStep(gomock.Any(), 1, step).Return(true, nil)
+				repo.EXPECT().UseTwoFactorChallenge(gomock.Any(), 7, gomock.Any()).Return(true, nil)
+			},
+		},
+		{
+			name: "TOTP code used before",
+			code: totp.Code(secret, step),
+			setup: func(repo *mocks.MockUserRepository) {
+				repo.EXPECT().UseTwoFactorStep(gomock.Any(), 1, step).Return(false, nil)
+			},
+			err: ErrWrongTwoFactorCode,
+		},
+		{
+			name: "wrong TOTP code",
+			code: totp.Code(secret, step+5),
+			err:  ErrWrongTwoFactorCode,
+		},
+		{
+			name: "recovery code",
+			code: "ABCDE-FGHIJ",
+			setup: func(repo *mocks.MockUserRepository) {
+				repo.EXPECT().UseRecoveryCode(gomock.Any(), 1, hashToken("abcdefghij"), gomock.Any()).Return(true, nil)
+				repo.EXPECT().UseTwoFactorChallenge(gomock.Any(), 7, gomock.Any()).Return(true, nil)
+			},
+		},
+		{
+			name: "unknown recovery code",
+			code: "abcde-fghij",
+			setup: func(repo *mocks.MockUserRepository) {
+				repo.EXPECT().UseRecoveryCode(gomock.Any(), 1, hashToken("abcdefghij"), gomock.Any()).Return(false, nil)
+			},
+			err: ErrWrongTwoFactorCode,
+		},
+		{
+			name: "challenge answered concurrently",
+			code: totp.Code(secret, step),
+			setup: func(repo *mocks.MockUserRepository) {
+				repo.EXPECT().UseTwoFactorStep(gomock.Any(), 1, step).Return(true, nil)
+				repo.EXPECT().UseTwoFactorChallenge(gomock.Any(), 7, gomock.Any()).Return(false, nil)
+			},
+			err: ErrInvalidChallenge,
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			mockRepo := mocks.NewMockUserRepository(gomock.NewController(t))
+			mockRepo.EXPECT().AttemptTwoFactorChallenge(gomock.Any(), hashToken("challenge"), gomock.Any(), maxChallengeAttempts).Return(challenge, nil)
+			mockRepo.EXPECT().GetUser(gomock.Any(), 1).Return(&domain.User{Id: 1}, nil)
+			mockRepo.EXPECT().GetTwoFactor(gomock.Any(), 1).Return(tf, nil)
+			if tt.setup != nil {
+				tt.setup(mockRepo)
+			}
+
+			user, err := NewUserService(mockRepo, cfg, LockoutPolicy{}).CompleteTwoFactorLogin(context.Background(), "challenge", tt.code, "")
+			if tt.err != nil {
+				assert.ErrorIs(t, err, tt.err)
+				assert.Nil(t, user)
+				return
+			}
+			assert.NoError(t, err)
+			assert.Equal(t, 1, user.Id)
+		})
+	}
+}
+
+func TestCompleteTwoFactorLogin_InvalidChallenge(t *testing.T) {
+	mockRepo := mocks.NewMockUserRepository(gomock.NewController(t))
+	mockRepo.EXPECT().AttemptTwoFactorChallenge(gomock.Any(), hashToken("expired"), gomock.Any(), maxChallengeAttempts).Return(nil, nil)
+
+	_, err := NewUserService(mockRepo, testTwoFactorConfig(t), LockoutPolicy{}).CompleteTwoFactorLogin(context.Background(), "expired", "123456", "")
+	assert.ErrorIs(t, err, ErrInvalidChallenge)
+}
+
+func TestDisableTwoFactor(t *testing.T) {
+	mockRepo := mocks.NewMockUserRepository(gomock.NewController(t))
+	cfg := testTwoFactorConfig(t)
+	s := NewUserService(mockRepo, cfg, LockoutPolicy{})
+	tf, secret := enabledTwoFactor(t, cfg)
+	step := totp.Step(time.Now())
+
+	mockRepo.EXPECT().GetTwoFactor(gomock.Any(), 1).Return(tf, nil).Times(2)
+
+	err := s.DisableTwoFactor(context.Background(), 1, totp.Code(secret, step+5))
+	assert.ErrorIs(t, err, ErrWrongTwoFactorCode)
+
+	mockRepo.EXPECT().UseTwoFactorStep(gomock.Any(), 1, step).Return(true, nil)
+	mockRepo.EXPECT().DeleteTwoFactor(gomock.Any(), 1).Return(nil)
+	assert.NoError(t, s.DisableTwoFactor(context.Background(), 1, totp.Code(secret, step)))
+
+	mockRepo.EXPECT().GetTwoFactor(gomock.Any(), 1).Return(nil, nil)
+	assert.ErrorIs(t, s.DisableTwoFactor(context.Background(), 1, "123456"), ErrTwoFactorNotEnabled)
+}
@@ -4,6 +4,7 @@ import (
 	"context"
 	"errors"
 	"fmt"
+	"time"
 
 	"bank-api/internal/domain"
 	"bank-api/internal/repository"
@@ -23,17 +24,31 @@ type UserService interface {
 	CreateUser(ctx context.Context, info *domain.UserInfo) (*domain.User, error)
 	GetUserById(ctx context.Context, id int) (*domain.User, error)
 	GetUserByEmail(ctx context.Context, email string) (*domain.User, error)
+	// UpdateUserInfo changes the name of the user. The email is changed by EmailService.UpdateUser, once verified.
 	UpdateUserInfo(ctx context.Context, id int, info *domain.UserInfo) (*domain.User, error)
 	DeleteUserById(ctx context.Context, id int) error
-	AuthenticateUser(ctx context.Context, u *domain.UserInfo) (*domain.User, error)
+	// AuthenticateUser checks the password of a login from the IP address. Users with 2FA get a challenge instead
+	// of the user, which CompleteTwoFactorLogin exchanges for the user given a valid code. An unknown email fails
+	// like a wrong password does, and after too many failures logins are refused with a LoginLockedError.
+	// Wrong codes count as failures too, and the failures are only cleared once the login is complete.
+	AuthenticateUser(ctx context.Context, u *domain.UserInfo, ip string) (*domain.User, *domain.TwoFactorChallenge, error)
+	CompleteTwoFactorLogin(ctx context.Context, challengeToken string, code string, ip string) (*domain.User, error)
+
+	// EnrollTwoFactor starts enrolling the user in 2FA with a new secret. Until it's confirmed
+	// by ConfirmTwoFactor, which returns the recovery codes, logins don't ask for codes.
+	EnrollTwoFactor(ctx context.Context, userId int) (*domain.TwoFactorEnrollment, error)
+	ConfirmTwoFactor(ctx context.Context, userId int, code string) ([]string, error)
+	DisableTwoFactor(ctx context.Context, userId int, code string) error
 }
 
 type userService struct {
-	repo repository.UserRepository
+	repo      repository.UserRepository
+	twoFactor TwoFactorConfig
+	logins    loginLimiter
 }
 
-func NewUserService(repo repository.UserRepository) UserService {
-	return &userService{repo: repo}
+func NewUserService(repo repository.UserRepository, twoFactor TwoFactorConfig, lockout LockoutPolicy) UserService {
+	return &userService{repo: repo, twoFactor: twoFactor, logins: loginLimiter{repo: repo, policy: lockout}}
 }
 
 func (s *userService) CreateUser(ctx context.Context, new *domain.UserInfo) (*domain.User, error) {
@@ -120,7 +135,7 @@ func (s *userService) GetUserByEmail(ctx context.Context, email string) (*domain
 }
 
 func (s *userService) UpdateUserInfo(ctx context.Context, id int, newInfo *domain.UserInfo) (*domain.User, error) {
-	if newInfo.Name == "" && newInfo.Email == "" {
+	if newInfo.Name == "" {
 		return nil, ErrEmptyUserInfo
 	}
 
@@ -132,21 +147,11 @@ func (s *userService) UpdateUserInfo(ctx context.Context, id int, newInfo *domai
 		return nil, ErrNoSuchUser
 	}
 
-	var info domain.UserInfo
-	if newInfo.Name != "" {
-		if err := validate.Name(newInfo.Name); err != nil {
-			return nil, err
-		}
-		info.Name = newInfo.Name
-	}
-	if newInfo.Email != "" {
-		if err := validate.Email(newInfo.Email); err != nil {
-			return nil, err
-		}
-		info.Email = newInfo.Email
+	if err := validate.Name(newInfo.Name); err != nil {
+		return nil, err
 	}
 
-	user, err := s.repo.UpdateUser(ctx, id, &info)
+	user, err := s.repo.UpdateUser(ctx, id, &domain.UserInfo{Name: newInfo.Name})
 	if err != nil {
 		return nil, fmt.Errorf("can't update user: %w", err)
 	}
@@ -171,15 +176,44 @@ func (s *userService) DeleteUserById(ctx context.Context, id int) error {
 	return nil
 }
 
-func (s *userService) AuthenticateUser(ctx context.Context, login *domain.UserInfo) (*domain.User, error) {
-	user, err := s.GetUserByEmail(ctx, login.Email)
+func (s *userService) AuthenticateUser(ctx context.Context, login *domain.UserInfo, ip string) (*domain.User, *domain.TwoFactorChallenge, error) {
+	now := time.Now()
+	if err := s.logins.check(ctx, login.Email, ip, now); err != nil {
+		return nil, nil, err
+	}
+
+	user, err := s.repo.GetUserByEmail(ctx, login.Email)
 	if err != nil {
-		return nil, err
+		return nil, nil, fmt.Errorf("can't get user: %w", err)
+	}
+	if user == nil {
+		compareDummyPassword(login.Password)
+	}
+	if user == nil || bcrypt.CompareHashAndPassword([]byte(user.HashedPassword), []byte(login.Password)) != nil {
+		if err := s.logins.recordFailure(ctx, login.Email, ip, now); err != nil {
+			return nil, nil, err
+		}
+		return nil, nil, ErrWrongPassword
 	}
 
-	if err := bcrypt.CompareHashAndPassword([]byte(user.HashedPassword), []byte(login.Password)); err != nil {
-		return nil, ErrWrongPassword
+	tf, err := s.repo.GetTwoFactor(ctx, user.Id)
+	if err != nil {
+		return nil, nil, fmt.Errorf("can't get two-factor enrollment: %w", err)
+	}
+	if tf == nil || !tf.Enabled() {
+		if err := s.logins.clear(ctx, login.Email); err != nil {
+			return nil, nil, err
+		}
+		return user, nil, nil
+	}
+	// The codes of users who enabled 2FA can't be checked without the key, and they can't log in without them.
+	if s.twoFactor.Secrets == nil {
+		return nil, nil, ErrTwoFactorUnavailable
 	}
 
-	return user, nil
+	challenge, err := s.newChallenge(ctx, user.Id)
+	if err != nil {
+		return nil, nil, err
+	}
+	return nil, challenge, nil
 }
@@ -26,7 +26,7 @@ func TestCreateUser(t *testing.T) {
 
 	repoMock.EXPECT().CreateUser(gomock.Any(), userInfo).Return(&domain.User{Id: 1, Name: "Test User", Email: "test@example.com", HashedPassword: "hash", CreatedAt: time.Now()}, nil)
 
-	s := NewUserService(repoMock)
+	s := NewUserService(repoMock, TwoFactorConfig{}, LockoutPolicy{})
 
 	user, err := s.CreateUser(context.Background(), userInfo)
 	assert.NoError(t, err)
@@ -47,7 +47,7 @@ func TestCreateUser_UserAlreadyExists(t *testing.T) {
 
 	mockRepo.EXPECT().UserExistsByEmail(gomock.Any(), userInfo.Email).Return(true, nil)
 
-	s := NewUserService(mockRepo)
+	s := NewUserService(mockRepo, TwoFactorConfig{}, LockoutPolicy{})
 
 	user, err := s.CreateUser(context.Background(), userInfo)
 	assert.Nil(t, user)
@@ -62,7 +62,7 @@ func TestCreateUser_BadEmail(t *testing.T) {
 		Email: "",
 	}
 
-	s := NewUserService(mockRepo)
+	s := NewUserService(mockRepo, TwoFactorConfig{}, LockoutPolicy{})
 
 	user, err := s.CreateUser(context.Background(), userInfo)
 	assert.Nil(t, user)
@@ -86,7 +86,7 @@ func TestUserService_GetUserById(t *testing.T) {
 	mockRepo.EXPECT().UserExistsById(gomock.Any(), user.Id).Return(true, nil)
 	mockRepo.EXPECT().GetUser(gomock.Any(), user.Id).Return(user, nil)
 
-	s := NewUserService(mockRepo)
+	s := NewUserService(mockRepo, TwoFactorConfig{}, LockoutPolicy{})
 
 	user, err := s.GetUserById(context.Background(), user.Id)
 	assert.Nil(t, err)
@@ -117,7 +117,7 @@ func TestUserService_GetUserByEmail(t *testing.T) {
 	mockRepo.EXPECT().GetUserIdByEmail(gomock.Any(), user.Email).Return(user.Id, nil)
 	mockRepo.EXPECT().GetUser(gomock.Any(), user.Id).Return(user, nil)
 
-	s := NewUserService(mockRepo)
+	s := NewUserService(mockRepo, TwoFactorConfig{}, LockoutPolicy{})
 
 	user, err := s.GetUserByEmail(context.Background(), user.Email)
 	assert.NoError(t, err)
@@ -149,14 +149,18 @@ func TestUserService_UpdateUserInfo(t *testing.T) {
 		Email: "test@example.com",
 	}
 
+	// The email isn't changed here: it only changes once the new one is verified.
 	mockRepo.EXPECT().UserExistsById(gomock.Any(), user.Id).Return(true, nil)
-	mockRepo.EXPECT().UpdateUser(gomock.Any(), user.Id, userInfo).Return(user, nil)
+	mockRepo.EXPECT().UpdateUser(gomock.Any(), user.Id, &domain.UserInfo{Name: "Kylie Chalamet"}).Return(user, nil)
 
-	s := NewUserService(mockRepo)
+	s := NewUserService(mockRepo, TwoFactorConfig{}, LockoutPolicy{})
 
 	user, err := s.UpdateUserInfo(context.Background(), user.Id, userInfo)
 	assert.NoError(t, err)
 	assert.NotNil(t, user)
+
+	_, err = s.UpdateUserInfo(context.Background(), user.Id, &domain.UserInfo{Email: "new@example.com"})
+	assert.ErrorIs(t, err, ErrEmptyUserInfo)
 }
 
 func TestUserService_DeleteUserById(t *testing.T) {
@@ -173,7 +177,7 @@ func TestUserService_DeleteUserById(t *testing.T) {
 	mockRepo.EXPECT().UserExistsById(gomock.Any(), user.Id).Return(true, nil)
 	mockRepo.EXPECT().DeleteUser(gomock.Any(), user.Id).Return(nil)
 
-	s := NewUserService(mockRepo)
+	s := NewUserService(mockRepo, TwoFactorConfig{}, LockoutPolicy{})
 
 	err := s.DeleteUserById(context.Background(), user.Id)
 	assert.NoError(t, err)
@@ -0,0 +1,140 @@
+package statement
+
+import (
+	"encoding/xml"
+	"fmt"
+	"io"
+	"strconv"
+	"time"
+
+	"bank-api/internal/domain"
+)
+
+const camt053Namespace = "urn:iso:std:iso:20022:tech:xsd:camt.053.001.02"
+
+type camtDocument struct {
+	XMLName   xml.Name `xml:"Document"`
+	Namespace string   `xml:"xmlns,attr"`
+	Statement struct {
+		Header struct {
+			MessageId string `xml:"MsgId"`
+			CreatedAt string `xml:"CreDtTm"`
+		} `xml:"GrpHdr"`
+		Statement camtStatement `xml:"Stmt"`
+	} `xml:"BkToCstmrStmt"`
+}
+
+type camtStatement struct {
+	Id        string `xml:"Id"`
+	CreatedAt string `xml:"CreDtTm"`
+	Period    struct {
+		From string `xml:"FrDtTm"`
+		To   string `xml:"ToDtTm"`
+	} `xml:"FrToDt"`
+	Account struct {
+		Id       string `xml:"Id>Othr>Id"`
+		Currency string `xml:"Ccy"`
+	} `xml:"Acct"`
+	Balances []camtBalance `xml:"Bal"`
+	Entries  []camtEntry   `xml:"Ntry"`
+}
+
+type camtBalance struct {
+	Type        string     `xml:"Tp>CdOrPrtry>Cd"`
+	Amount      camtAmount `xml:"Amt"`
+	CreditDebit string     `xml:"CdtDbtInd"`
+	Date        string     `xml:"Dt>DtTm"`
+}
+
+type camtEntry struct {
+	Reference   string     `xml:"NtryRef"`
+	Amount      camtAmount `xml:"Amt"`
+	CreditDebit string     `xml:"CdtDbtInd"`
+	Reversal    bool       `xml:"RvslInd,omitempty"`
+	Status      string     `xml:"Sts"`
+	BookedAt    string     `xml:"BookgDt>DtTm"`
+	Code        struct {
+		Code   string `xml:"Cd"`
+		Issuer string `xml:"Issr"`
+	} `xml:"BkTxCd>Prtry"`
+	TransactionId string `xml:"NtryDtls>TxDtls>Refs>TxId"`
+	Info          string `xml:"AddtlNtryInf"`
+}
+
+type camtAmount struct {
+	Currency string `xml:"Ccy,attr"`
+	Value    string `xml:",chardata"`
+}
+
+// WriteCamt053 writes an ISO 20022 camt.053.001.02 bank to customer statement.
+func WriteCamt053(w io.Writer, s *domain.Statement) error {
+	var doc camtDocument
+	doc.Namespace = camt053Namespace
+	id := fmt.Sprintf("%d-%s-%s", s.Account.Id, s.From.UTC().Format("20060102"), s.To.UTC().Format("20060102"))
+	doc.Statement.Header.MessageId = "STMT-" + id
+	doc.Statement.Header.CreatedAt = camtTime(s.CreatedAt)
+
+	cur := s.Account.Cur.Symbol
+	st := &doc.Statement.Statement
+	st.Id = id
+	st.CreatedAt = camtTime(s.CreatedAt)
+	st.Period.From = camtTime(s.From)
+	st.Period.To = camtTime(s.To)
+	st.Account.Id = strconv.Itoa(s.Account.Id)
+	st.Account.Currency = cur
+	st.Balances = []camtBalance{
+		newCamtBalance("OPBD", s.Opening, cur, s.From),
+		newCamtBalance("CLBD", s.Closing, cur, s.To),
+	}
+	for i := range s.Entries {
+		e := &s.Entries[i]
+		entry := camtEntry{
+			Reference:     e.Reference(),
+			Amount:        camtAmount{Currency: cur, Value: abs(e.Amount).String()},
+			CreditDebit:   creditDebit(e.Amount),
+			Status:        "BOOK",
+			BookedAt:      camtTime(e.Time()),
+			TransactionId: e.Reference(),
+			Info:          description(e),
+		}
+		if e.Adjustment != nil {
+			entry.Code.Code = "adjustment"
+		} else {
+			entry.Reversal = e.Transaction.ReversesId != 0
+			entry.Code.Code = e.Transaction.Type.String()
+		}
+		entry.Code.Issuer = bankId
+		st.Entries = append(st.Entries, entry)
+	}
+
+	if _, err := io.WriteString(w, xml.Header); err != nil {
+		return err
+	}
+	enc := xml.NewEncoder(w)
+	enc.Indent("", "  ")
+	if err := enc.Encode(doc); err != nil {
+		return err
+	}
+	_, err := io.WriteString(w, "\n")
+	return err
+}
+
+func newCamtBalance(code string, balance domain.Money, cur string, at time.Time) camtBalance {
+	return camtBalance{
+		Type:        code,
+		Amount:      camtAmount{Currency: cur, Value: abs(balance).String()},
+		CreditDebit: creditDebit(balance),
+		Date:        camtTime(at),
+	}
+}
+
+func creditDebit(m domain.Money) string {
+	if m.Sign() < 0 {
+		return "DBIT"
+	}
+	return "CRDT"
+}
+
+func camtTime(t time.Time) string {
+	return t.UTC().Format("2006-01-02T15:04:05")
+}
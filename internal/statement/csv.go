@@ -0,0 +1,46 @@
+package statement
+
+import (
+	"encoding/csv"
+	"io"
+	"strconv"
+	"time"
+
+	"bank-api/internal/domain"
+)
+
+var csvHeader = []string{"date", "transaction_id", "description", "counterparty_account_id", "amount", "balance", "currency"}
+
+// WriteCSV writes a line per entry between an opening and a closing balance line.
+func WriteCSV(w io.Writer, s *domain.Statement) error {
+	cw := csv.NewWriter(w)
+	cur := s.Account.Cur.Symbol
+
+	records := [][]string{
+		csvHeader,
+		{s.From.UTC().Format(time.RFC3339), "", "Opening balance", "", "", s.Opening.String(), cur},
+	}
+	for i := range s.Entries {
+		e := &s.Entries[i]
+		counterparty := ""
+		if e.CounterpartyId != 0 {
+			counterparty = strconv.Itoa(e.CounterpartyId)
+		}
+		transactionId := ""
+		if e.Transaction != nil {
+			transactionId = strconv.Itoa(e.Transaction.Id)
+		}
+		records = append(records, []string{
+			e.Time().UTC().Format(time.RFC3339),
+			transactionId,
+			description(e),
+			counterparty,
+			e.Amount.String(),
+			e.Balance.String(),
+			cur,
+		})
+	}
+	records = append(records, []string{s.To.UTC().Format(time.RFC3339), "", "Closing balance", "", "", s.Closing.String(), cur})
+
+	return cw.WriteAll(records)
+}
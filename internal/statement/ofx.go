@@ -0,0 +1,146 @@
+package statement
+
+import (
+	"encoding/xml"
+	"io"
+	"strconv"
+	"time"
+
+	"bank-api/internal/domain"
+)
+
+const (
+	ofxHeader = `<?xml version="1.0" encoding="UTF-8" standalone="no"?>` + "\n" +
+		`<?OFX OFXHEADER="200" VERSION="220" SECURITY="NONE" OLDFILEUID="NONE" NEWFILEUID="NONE"?>` + "\n"
+	ofxTimeLayout = "20060102150405.000[0:GMT]"
+)
+
+type ofx struct {
+	XMLName xml.Name `xml:"OFX"`
+	SignOn  struct {
+		Response struct {
+			Status   ofxStatus `xml:"STATUS"`
+			Server   string    `xml:"DTSERVER"`
+			Language string    `xml:"LANGUAGE"`
+		} `xml:"SONRS"`
+	} `xml:"SIGNONMSGSRSV1"`
+	Bank struct {
+		Transaction struct {
+			Id        string       `xml:"TRNUID"`
+			Status    ofxStatus    `xml:"STATUS"`
+			Statement ofxStatement `xml:"STMTRS"`
+		} `xml:"STMTTRNRS"`
+	} `xml:"BANKMSGSRSV1"`
+}
+
+type ofxStatus struct {
+	Code     int    `xml:"CODE"`
+	Severity string `xml:"SEVERITY"`
+}
+
+type ofxStatement struct {
+	Currency string `xml:"CURDEF"`
+	Account  struct {
+		BankId string `xml:"BANKID"`
+		Id     string `xml:"ACCTID"`
+		Type   string `xml:"ACCTTYPE"`
+	} `xml:"BANKACCTFROM"`
+	Transactions struct {
+		Start   string           `xml:"DTSTART"`
+		End     string           `xml:"DTEND"`
+		Entries []ofxTransaction `xml:"STMTTRN"`
+	} `xml:"BANKTRANLIST"`
+	Ledger   ofxBalance `xml:"LEDGERBAL"`
+	Balances []ofxBal   `xml:"BALLIST>BAL"`
+}
+
+type ofxTransaction struct {
+	Type   string `xml:"TRNTYPE"`
+	Posted string `xml:"DTPOSTED"`
+	Amount string `xml:"TRNAMT"`
+	Id     string `xml:"FITID"`
+	Name   string `xml:"NAME"`
+}
+
+type ofxBalance struct {
+	Amount string `xml:"BALAMT"`
+	AsOf   string `xml:"DTASOF"`
+}
+
+type ofxBal struct {
+	Name  string `xml:"NAME"`
+	Desc  string `xml:"DESC"`
+	Type  string `xml:"BALTYPE"`
+	Value string `xml:"VALUE"`
+	AsOf  string `xml:"DTASOF"`
+}
+
+// WriteOFX writes an OFX 2.2 bank statement response. OFX only has a closing (ledger) balance,
+// the opening balance goes to the balance list.
+func WriteOFX(w io.Writer, s *domain.Statement) error {
+	var doc ofx
+	ok := ofxStatus{Code: 0, Severity: "INFO"}
+	doc.SignOn.Response.Status = ok
+	doc.SignOn.Response.Server = ofxTime(s.CreatedAt)
+	doc.SignOn.Response.Language = "ENG"
+	doc.Bank.Transaction.Id = "0"
+	doc.Bank.Transaction.Status = ok
+
+	st := &doc.Bank.Transaction.Statement
+	st.Currency = s.Account.Cur.Symbol
+	st.Account.BankId = bankId
+	st.Account.Id = strconv.Itoa(s.Account.Id)
+	st.Account.Type = "CHECKING"
+	st.Transactions.Start = ofxTime(s.From)
+	st.Transactions.End = ofxTime(s.To)
+	for i := range s.Entries {
+		e := &s.Entries[i]
+		st.Transactions.Entries = append(st.Transactions.Entries, ofxTransaction{
+			Type:   ofxTransactionType(e),
+			Posted: ofxTime(e.Time()),
+			Amount: e.Amount.String(),
+			Id:     e.Reference(),
+			Name:   description(e),
+		})
+	}
+	st.Ledger = ofxBalance{Amount: s.Closing.String(), AsOf: ofxTime(s.To)}
+	st.Balances = []ofxBal{{
+		Name:  "Opening balance",
+		Desc:  "Balance at the start of the statement",
+		Type:  "DOLLAR",
+		Value: s.Opening.String(),
+		AsOf:  ofxTime(s.From),
+	}}
+
+	if _, err := io.WriteString(w, ofxHeader); err != nil {
+		return err
+	}
+	enc := xml.NewEncoder(w)
+	enc.Indent("", "  ")
+	if err := enc.Encode(doc); err != nil {
+		return err
+	}
+	_, err := io.WriteString(w, "\n")
+	return err
+}
+
+func ofxTransactionType(e *domain.StatementEntry) string {
+	if e.Adjustment != nil {
+		if e.Amount.Sign() < 0 {
+			return "DEBIT"
+		}
+		return "CREDIT"
+	}
+	switch e.Transaction.Type {
+	case domain.Deposit:
+		return "DEP"
+	case domain.Withdraw:
+		return "DEBIT"
+	default:
+		return "XFER"
+	}
+}
+
+func ofxTime(t time.Time) string {
+	return t.UTC().Format(ofxTimeLayout)
+}
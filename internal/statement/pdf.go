@@ -0,0 +1,90 @@
+package statement
+
+import (
+	"fmt"
+	"io"
+
+	"bank-api/internal/domain"
+	"bank-api/pkg/pdf"
+)
+
+const (
+	pdfMargin     = 50.0
+	pdfLineHeight = 14.0
+	pdfFontSize   = 9.0
+	pdfTimeLayout = "2006-01-02 15:04"
+
+	// Columns of the entries table: text columns start at their x, amounts end at it.
+	pdfDateX        = pdfMargin
+	pdfIdX          = 140.0
+	pdfDescriptionX = 185.0
+	pdfAmountX      = 455.0
+	pdfBalanceX     = pdf.PageWidth - pdfMargin
+)
+
+// WritePDF writes the statement as a PDF with a header about the holder, the account and the balances
+// followed by the entries, on as many pages as they take.
+func WritePDF(w io.Writer, s *domain.Statement) error {
+	doc := pdf.New(fmt.Sprintf("Statement of account %d", s.Account.Id))
+	cur := s.Account.Cur.Symbol
+
+	page := doc.AddPage()
+	y := pdf.PageHeight - pdfMargin
+	page.Text(pdfMargin, y, pdf.Bold, 18, "Account statement")
+	y -= 2 * pdfLineHeight
+	if s.Holder != nil {
+		page.Text(pdfMargin, y, pdf.Regular, 11, s.Holder.Name)
+		y -= pdfLineHeight
+	}
+	page.Text(pdfMargin, y, pdf.Regular, 11, fmt.Sprintf("Account %d, %s", s.Account.Id, cur))
+	y -= pdfLineHeight
+	page.Text(pdfMargin, y, pdf.Regular, 11, fmt.Sprintf("Period: %s to %s UTC", s.From.UTC().Format(pdfTimeLayout), s.To.UTC().Format(pdfTimeLayout)))
+	y -= 2 * pdfLineHeight
+	page.Text(pdfMargin, y, pdf.Regular, 11, "Opening balance")
+	page.TextRight(pdfBalanceX, y, pdf.Regular, 11, s.Opening.String()+" "+cur)
+	y -= pdfLineHeight
+	page.Text(pdfMargin, y, pdf.Regular, 11, "Closing balance")
+	page.TextRight(pdfBalanceX, y, pdf.Regular, 11, s.Closing.String()+" "+cur)
+	y -= 2 * pdfLineHeight
+
+	pages := []*pdf.Page{page}
+	y = pdfTableHeader(page, y)
+	for i := range s.Entries {
+		if y < pdfMargin+pdfLineHeight {
+			page = doc.AddPage()
+			pages = append(pages, page)
+			y = pdfTableHeader(page, pdf.PageHeight-pdfMargin)
+		}
+
+		e := &s.Entries[i]
+		page.Text(pdfDateX, y, pdf.Regular, pdfFontSize, e.Time().UTC().Format(pdfTimeLayout))
+		page.Text(pdfIdX, y, pdf.Regular, pdfFontSize, e.Reference())
+		page.Text(pdfDescriptionX, y, pdf.Regular, pdfFontSize, description(e))
+		page.TextRight(pdfAmountX, y, pdf.Regular, pdfFontSize, e.Amount.String())
+		page.TextRight(pdfBalanceX, y, pdf.Regular, pdfFontSize, e.Balance.String())
+		y -= pdfLineHeight
+	}
+	if len(s.Entries) == 0 {
+		page.Text(pdfDescriptionX, y, pdf.Regular, pdfFontSize, "No entries in this period")
+	}
+
+	created := "Generated " + s.CreatedAt.UTC().Format(pdfTimeLayout) + " UTC"
+	for i, p := range pages {
+		p.Text(pdfMargin, pdfMargin/2, pdf.Regular, 8, created)
+		p.TextRight(pdfBalanceX, pdfMargin/2, pdf.Regular, 8, fmt.Sprintf("Page %d of %d", i+1, len(pages)))
+	}
+
+	_, err := doc.WriteTo(w)
+	return err
+}
+
+// pdfTableHeader draws the column titles of the entries table at y and returns where the first row goes.
+func pdfTableHeader(page *pdf.Page, y float64) float64 {
+	page.Text(pdfDateX, y, pdf.Bold, pdfFontSize, "Date")
+	page.Text(pdfIdX, y, pdf.Bold, pdfFontSize, "Id")
+	page.Text(pdfDescriptionX, y, pdf.Bold, pdfFontSize, "Description")
+	page.TextRight(pdfAmountX, y, pdf.Bold, pdfFontSize, "Amount")
+	page.TextRight(pdfBalanceX, y, pdf.Bold, pdfFontSize, "Balance")
+	page.Line(pdfMargin, y-4, pdfBalanceX, y-4, 0.5)
+	return y - pdfLineHeight - 2
+}
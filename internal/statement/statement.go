@@ -0,0 +1,87 @@
+// Package statement renders account statements as CSV, OFX 2.2, ISO 20022 camt.053 and PDF files.
+package statement
+
+import (
+	"errors"
+	"fmt"
+	"io"
+	"strings"
+
+	"bank-api/internal/domain"
+)
+
+const (
+	FormatCSV     = "csv"
+	FormatOFX     = "ofx"
+	FormatCamt053 = "camt053"
+	FormatPDF     = "pdf"
+)
+
+var ErrUnknownFormat = errors.New("unknown statement format")
+
+// bankId identifies the bank in the files, there is no routing number or BIC to use instead.
+const bankId = "bank-api"
+
+type format struct {
+	contentType string
+	extension   string
+	write       func(w io.Writer, s *domain.Statement) error
+}
+
+var formats = map[string]format{
+	FormatCSV:     {contentType: "text/csv", extension: "csv", write: WriteCSV},
+	FormatOFX:     {contentType: "application/x-ofx", extension: "ofx", write: WriteOFX},
+	FormatCamt053: {contentType: "application/xml", extension: "xml", write: WriteCamt053},
+	FormatPDF:     {contentType: "application/pdf", extension: "pdf", write: WritePDF},
+}
+
+func Write(w io.Writer, s *domain.Statement, format string) error {
+	f, ok := formats[format]
+	if !ok {
+		return ErrUnknownFormat
+	}
+	return f.write(w, s)
+}
+
+// ContentType returns the MIME type of the format, or an empty string if the format is unknown.
+func ContentType(format string) string {
+	return formats[format].contentType
+}
+
+// FileName returns the name a statement in the format is downloaded as.
+func FileName(s *domain.Statement, format string) string {
+	return fmt.Sprintf("statement-%d-%s-%s.%s", s.Account.Id, s.From.Format("20060102"), s.To.Format("20060102"), formats[format].extension)
+}
+
+// description tells what the entry is about in a line.
+func description(e *domain.StatementEntry) string {
+	if e.Adjustment != nil {
+		if e.Adjustment.Description == "" {
+			return "Adjustment"
+		}
+		return strings.ToUpper(e.Adjustment.Description[:1]) + e.Adjustment.Description[1:]
+	}
+
+	t := e.Transaction
+	switch {
+	case t.ReversesId != 0:
+		return fmt.Sprintf("Reversal of transaction %d", t.ReversesId)
+	case t.HoldId != 0:
+		return fmt.Sprintf("Capture of hold %d", t.HoldId)
+	case t.Type == domain.Deposit:
+		return "Deposit"
+	case t.Type == domain.Withdraw:
+		return "Withdrawal"
+	case e.Amount.Sign() < 0:
+		return fmt.Sprintf("Transfer to account %d", e.CounterpartyId)
+	default:
+		return fmt.Sprintf("Transfer from account %d", e.CounterpartyId)
+	}
+}
+
+func abs(m domain.Money) domain.Money {
+	if m.Sign() < 0 {
+		return m.Neg()
+	}
+	return m
+}
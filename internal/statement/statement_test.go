@@ -0,0 +1,94 @@
+package statement
+
+import (
+	"bytes"
+	"flag"
+	"os"
+	"path/filepath"
+	"testing"
+	"time"
+
+	"bank-api/internal/domain"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+var update = flag.Bool("update", false, "update the golden files")
+
+func testStatement(t *testing.T) *domain.Statement {
+	rub := domain.Currency{Id: 1, Symbol: "RUB"}
+	usd := domain.Currency{Id: 3, Symbol: "USD"}
+	at := func(day, hour int) time.Time { return time.Date(2024, 6, day, hour, 30, 0, 0, time.UTC) }
+	money := func(minor int64, cur domain.Currency) domain.Money { return domain.NewMoney(minor, cur) }
+
+	account := &domain.Account{Id: 1, UserId: 7, Cur: rub}
+	transactions := []*domain.Transaction{
+		{Id: 11, ToAccountId: 1, Cur: rub, Amount: money(5000, rub), ToCur: rub, ToAmount: money(5000, rub), Type: domain.Deposit, Time: at(3, 9)},
+		{Id: 12, FromAccountId: 1, ToAccountId: 2, Cur: rub, Amount: money(3000, rub), ToCur: rub, ToAmount: money(3000, rub), Type: domain.Transfer, Time: at(5, 12)},
+		{Id: 13, FromAccountId: 3, ToAccountId: 1, Cur: usd, Amount: money(20, usd), ToCur: rub, ToAmount: money(1550, rub), Type: domain.Transfer, Time: at(10, 18)},
+		{Id: 14, FromAccountId: 2, ToAccountId: 1, Cur: rub, Amount: money(1000, rub), ToCur: rub, ToAmount: money(1000, rub), Type: domain.Transfer, ReversesId: 12, Time: at(11, 8)},
+		{Id: 15, FromAccountId: 1, Cur: rub, Amount: money(500, rub), ToCur: rub, ToAmount: money(500, rub), Type: domain.Withdraw, HoldId: 3, Time: at(20, 23)},
+	}
+
+	// The account existed before the ledger, which opened its balance in the middle of the month.
+	adjustments := []*domain.Adjustment{
+		{EntryId: 4, Description: "opening balance", Amount: money(10000, rub), Time: at(2, 0)},
+	}
+
+	s, err := domain.NewStatement(account, time.Date(2024, 6, 1, 0, 0, 0, 0, time.UTC), time.Date(2024, 7, 1, 0, 0, 0, 0, time.UTC),
+		money(0, rub), money(14050, rub), transactions, adjustments)
+	require.NoError(t, err)
+	s.Holder = &domain.User{Id: 7, Name: "Jane Doe"}
+	s.CreatedAt = time.Date(2024, 7, 1, 6, 0, 0, 0, time.UTC)
+	return s
+}
+
+func TestWrite(t *testing.T) {
+	tests := []struct {
+		format string
+		golden string
+	}{
+		{format: FormatCSV, golden: "statement.csv"},
+		{format: FormatOFX, golden: "statement.ofx"},
+		{format: FormatCamt053, golden: "statement.camt053.xml"},
+		{format: FormatPDF, golden: "statement.pdf"},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.format, func(t *testing.T) {
+			var buf bytes.Buffer
+			require.NoError(t, Write(&buf, testStatement(t), tt.format))
+
+			path := filepath.Join("testdata", tt.golden)
+			if *update {
+				require.NoError(t, os.WriteFile(path, buf.Bytes(), 0o644))
+			}
+			golden, err := os.ReadFile(path)
+			require.NoError(t, err)
+			assert.Equal(t, string(golden), buf.String())
+		})
+	}
+}
+
+func TestWrite_UnknownFormat(t *testing.T) {
+	assert.ErrorIs(t, Write(&bytes.Buffer{}, testStatement(t), "xlsx"), ErrUnknownFormat)
+}
+
+func TestFileName(t *testing.T) {
+	assert.Equal(t, "statement-1-20240601-20240701.xml", FileName(testStatement(t), FormatCamt053))
+}
+
+func TestWritePDF_Pages(t *testing.T) {
+	s := testStatement(t)
+	for len(s.Entries) < 100 {
+		s.Entries = append(s.Entries, s.Entries...)
+	}
+	// 42 entries fit on the first page under the header and 51 on the next ones.
+	s.Entries = s.Entries[:100]
+
+	var buf bytes.Buffer
+	require.NoError(t, WritePDF(&buf, s))
+	assert.Contains(t, buf.String(), "/Count 3")
+	assert.Contains(t, buf.String(), "(Page 3 of 3) Tj")
+}
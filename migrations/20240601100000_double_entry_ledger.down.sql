@@ -0,0 +1,14 @@
+DROP TRIGGER IF EXISTS posting_balanced ON posting;
+DROP FUNCTION IF EXISTS check_journal_entry_balanced;
+
+DROP TABLE IF EXISTS posting;
+DROP TABLE IF EXISTS journal_entry;
+
+DELETE FROM account WHERE kind <> 'customer';
+
+DROP INDEX IF EXISTS account_system_kind_idx;
+
+ALTER TABLE account
+    DROP CONSTRAINT IF EXISTS account_owner_check,
+    DROP COLUMN IF EXISTS kind,
+    ALTER COLUMN user_id SET NOT NULL;
@@ -0,0 +1,80 @@
+ALTER TABLE account
+    ALTER COLUMN user_id DROP NOT NULL,
+    ADD COLUMN IF NOT EXISTS kind VARCHAR(16) NOT NULL DEFAULT 'customer';
+
+ALTER TABLE account
+    ADD CONSTRAINT account_owner_check CHECK (kind <> 'customer' OR user_id IS NOT NULL);
+
+CREATE UNIQUE INDEX IF NOT EXISTS account_system_kind_idx ON account (kind, currency_id) WHERE kind <> 'customer';
+
+INSERT INTO account (currency_id, kind)
+SELECT id, 'external'
+FROM currency
+ON CONFLICT DO NOTHING;
+
+CREATE TABLE IF NOT EXISTS journal_entry
+(
+    id             SERIAL PRIMARY KEY,
+    transaction_id INT,
+    description    VARCHAR(255) NOT NULL DEFAULT '',
+    created_at     TIMESTAMP    NOT NULL DEFAULT CURRENT_TIMESTAMP,
+    FOREIGN KEY (transaction_id) REFERENCES transaction (id)
+);
+
+CREATE TABLE IF NOT EXISTS posting
+(
+    id          SERIAL PRIMARY KEY,
+    entry_id    INT            NOT NULL,
+    account_id  INT            NOT NULL,
+    currency_id INT            NOT NULL,
+    amount      DECIMAL(10, 2) NOT NULL CHECK (amount <> 0),
+    FOREIGN KEY (entry_id) REFERENCES journal_entry (id),
+    FOREIGN KEY (account_id) REFERENCES account (id),
+    FOREIGN KEY (currency_id) REFERENCES currency (id)
+);
+
+CREATE INDEX IF NOT EXISTS posting_entry_id_idx ON posting (entry_id);
+CREATE INDEX IF NOT EXISTS posting_account_id_idx ON posting (account_id);
+
+CREATE OR REPLACE FUNCTION check_journal_entry_balanced() RETURNS TRIGGER AS
+$$
+BEGIN
+    IF EXISTS (SELECT 1
+               FROM posting
+               WHERE entry_id = NEW.entry_id
+               GROUP BY currency_id
+               HAVING SUM(amount) <> 0) THEN
+        RAISE EXCEPTION 'journal entry % is not balanced', NEW.entry_id;
+    END IF;
+    RETURN NULL;
+END
+$$ LANGUAGE plpgsql;
+
+CREATE CONSTRAINT TRIGGER posting_balanced
+    AFTER INSERT OR UPDATE
+    ON posting
+    DEFERRABLE INITIALLY DEFERRED
+    FOR EACH ROW
+EXECUTE FUNCTION check_journal_entry_balanced();
+
+-- Existing balances have no history behind them, so they are opened against the external account.
+DO
+$$
+    DECLARE
+        acc          RECORD;
+        new_entry_id INT;
+    BEGIN
+        FOR acc IN SELECT account.id, account.currency_id, account.amount, external.id AS external_id
+                   FROM account
+                   JOIN account external ON external.kind = 'external' AND external.currency_id = account.currency_id
+                   WHERE account.kind = 'customer'
+                     AND account.amount <> 0
+            LOOP
+                INSERT INTO journal_entry (description) VALUES ('opening balance') RETURNING id INTO new_entry_id;
+
+                INSERT INTO posting (entry_id, account_id, currency_id, amount)
+                VALUES (new_entry_id, acc.id, acc.currency_id, acc.amount),
+                       (new_entry_id, acc.external_id, acc.currency_id, -acc.amount);
+            END LOOP;
+    END
+$$;
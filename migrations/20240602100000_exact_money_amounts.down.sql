@@ -0,0 +1,8 @@
+ALTER TABLE posting
+    ALTER COLUMN amount TYPE DECIMAL(10, 2);
+
+ALTER TABLE transaction
+    ALTER COLUMN amount TYPE DECIMAL(10, 2);
+
+ALTER TABLE account
+    ALTER COLUMN amount TYPE DECIMAL(10, 2);
@@ -0,0 +1,8 @@
+ALTER TABLE account
+    ALTER COLUMN amount TYPE NUMERIC(19, 4);
+
+ALTER TABLE transaction
+    ALTER COLUMN amount TYPE NUMERIC(19, 4);
+
+ALTER TABLE posting
+    ALTER COLUMN amount TYPE NUMERIC(19, 4);
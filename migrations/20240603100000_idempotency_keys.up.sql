@@ -0,0 +1,13 @@
+CREATE TABLE IF NOT EXISTS idempotency_key
+(
+    user_id      INT          NOT NULL,
+    key          VARCHAR(255) NOT NULL,
+    request_hash VARCHAR(64)  NOT NULL,
+    status_code  INT,
+    content_type VARCHAR(255),
+    body         BYTEA,
+    created_at   TIMESTAMP    NOT NULL DEFAULT CURRENT_TIMESTAMP,
+    expires_at   TIMESTAMP    NOT NULL,
+    PRIMARY KEY (user_id, key),
+    FOREIGN KEY (user_id) REFERENCES "user" (id) ON DELETE CASCADE
+);
@@ -0,0 +1,10 @@
+ALTER TABLE transaction
+    DROP COLUMN IF EXISTS cross_rate_inverted,
+    DROP COLUMN IF EXISTS cross_rate_id,
+    DROP COLUMN IF EXISTS rate_inverted,
+    DROP COLUMN IF EXISTS rate_id,
+    DROP COLUMN IF EXISTS rate,
+    DROP COLUMN IF EXISTS to_amount,
+    DROP COLUMN IF EXISTS to_currency_id;
+
+DROP TABLE IF EXISTS exchange_rate;
@@ -0,0 +1,44 @@
+CREATE TABLE IF NOT EXISTS exchange_rate
+(
+    id                SERIAL PRIMARY KEY,
+    base_currency_id  INT             NOT NULL,
+    quote_currency_id INT             NOT NULL,
+    rate              NUMERIC(20, 10) NOT NULL CHECK (rate > 0),
+    effective_at      TIMESTAMP       NOT NULL,
+    created_at        TIMESTAMP       NOT NULL DEFAULT CURRENT_TIMESTAMP,
+    UNIQUE (base_currency_id, quote_currency_id, effective_at),
+    CHECK (base_currency_id <> quote_currency_id),
+    FOREIGN KEY (base_currency_id) REFERENCES currency (id),
+    FOREIGN KEY (quote_currency_id) REFERENCES currency (id)
+);
+
+ALTER TABLE transaction
+    ADD COLUMN IF NOT EXISTS to_currency_id INT,
+    ADD COLUMN IF NOT EXISTS to_amount      NUMERIC(19, 4),
+    ADD COLUMN IF NOT EXISTS rate           NUMERIC(20, 10),
+    ADD COLUMN IF NOT EXISTS rate_id        INT,
+    ADD FOREIGN KEY (to_currency_id) REFERENCES currency (id),
+    ADD FOREIGN KEY (rate_id) REFERENCES exchange_rate (id);
+
+-- A rate derived from stored ones records them: the rate it is the inverse of,
+-- or the two rates through the pivot currency a cross rate chains.
+ALTER TABLE transaction
+    ADD COLUMN IF NOT EXISTS rate_inverted       BOOLEAN NOT NULL DEFAULT FALSE,
+    ADD COLUMN IF NOT EXISTS cross_rate_id       INT,
+    ADD COLUMN IF NOT EXISTS cross_rate_inverted BOOLEAN NOT NULL DEFAULT FALSE,
+    ADD FOREIGN KEY (cross_rate_id) REFERENCES exchange_rate (id);
+
+UPDATE transaction
+SET to_currency_id = currency_id,
+    to_amount      = amount
+WHERE to_currency_id IS NULL;
+
+ALTER TABLE transaction
+    ALTER COLUMN to_currency_id SET NOT NULL,
+    ALTER COLUMN to_amount SET NOT NULL;
+
+-- Currency positions the bank takes when converting money between customer accounts.
+INSERT INTO account (currency_id, kind)
+SELECT id, 'fx'
+FROM currency
+ON CONFLICT DO NOTHING;
@@ -0,0 +1,30 @@
+CREATE TABLE IF NOT EXISTS fx_quote
+(
+    id                  SERIAL PRIMARY KEY,
+    user_id             INT             NOT NULL,
+    from_account_id     INT             NOT NULL,
+    to_account_id       INT             NOT NULL,
+    currency_id         INT             NOT NULL,
+    amount              NUMERIC(19, 4)  NOT NULL,
+    to_currency_id      INT             NOT NULL,
+    to_amount           NUMERIC(19, 4)  NOT NULL,
+    rate                NUMERIC(20, 10) NOT NULL,
+    rate_id             INT,
+    rate_inverted       BOOLEAN         NOT NULL DEFAULT FALSE,
+    cross_rate_id       INT,
+    cross_rate_inverted BOOLEAN         NOT NULL DEFAULT FALSE,
+    expires_at          TIMESTAMP       NOT NULL,
+    used_at             TIMESTAMP,
+    transaction_id      INT,
+    created_at          TIMESTAMP       NOT NULL DEFAULT CURRENT_TIMESTAMP,
+    FOREIGN KEY (user_id) REFERENCES "user" (id) ON DELETE CASCADE,
+    FOREIGN KEY (from_account_id) REFERENCES account (id) ON DELETE CASCADE,
+    FOREIGN KEY (to_account_id) REFERENCES account (id) ON DELETE CASCADE,
+    FOREIGN KEY (currency_id) REFERENCES currency (id),
+    FOREIGN KEY (to_currency_id) REFERENCES currency (id),
+    FOREIGN KEY (rate_id) REFERENCES exchange_rate (id),
+    FOREIGN KEY (cross_rate_id) REFERENCES exchange_rate (id),
+    FOREIGN KEY (transaction_id) REFERENCES transaction (id)
+);
+
+CREATE INDEX IF NOT EXISTS fx_quote_expires_at_idx ON fx_quote (expires_at) WHERE used_at IS NULL;
@@ -0,0 +1,11 @@
+DROP INDEX IF EXISTS transaction_pending_idx;
+
+-- Failed attempts have no journal entries and didn't happen as far as the old schema is concerned.
+DELETE FROM transaction
+WHERE status IN ('pending', 'failed');
+
+ALTER TABLE transaction
+    DROP CONSTRAINT IF EXISTS transaction_status_check,
+    DROP COLUMN IF EXISTS updated_at,
+    DROP COLUMN IF EXISTS failure_reason,
+    DROP COLUMN IF EXISTS status;
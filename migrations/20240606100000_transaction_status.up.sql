@@ -0,0 +1,11 @@
+ALTER TABLE transaction
+    ADD COLUMN IF NOT EXISTS status         VARCHAR(16)  NOT NULL DEFAULT 'posted',
+    ADD COLUMN IF NOT EXISTS failure_reason VARCHAR(255),
+    ADD COLUMN IF NOT EXISTS updated_at     TIMESTAMP    NOT NULL DEFAULT CURRENT_TIMESTAMP,
+    ADD CONSTRAINT transaction_status_check CHECK (status IN ('pending', 'posted', 'failed', 'reversed'));
+
+-- Transactions recorded before the status column were all posted, new ones start pending.
+ALTER TABLE transaction
+    ALTER COLUMN status SET DEFAULT 'pending';
+
+CREATE INDEX IF NOT EXISTS transaction_pending_idx ON transaction (created_at) WHERE status = 'pending';
@@ -0,0 +1,4 @@
+DROP INDEX IF EXISTS transaction_reverses_id_idx;
+
+ALTER TABLE transaction
+    DROP COLUMN IF EXISTS reverses_id;
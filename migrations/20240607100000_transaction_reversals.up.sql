@@ -0,0 +1,5 @@
+ALTER TABLE transaction
+    ADD COLUMN IF NOT EXISTS reverses_id INT,
+    ADD FOREIGN KEY (reverses_id) REFERENCES transaction (id);
+
+CREATE INDEX IF NOT EXISTS transaction_reverses_id_idx ON transaction (reverses_id) WHERE reverses_id IS NOT NULL;
@@ -0,0 +1,4 @@
+ALTER TABLE transaction
+    DROP COLUMN IF EXISTS hold_id;
+
+DROP TABLE IF EXISTS hold;
@@ -0,0 +1,23 @@
+CREATE TABLE IF NOT EXISTS hold
+(
+    id              SERIAL PRIMARY KEY,
+    account_id      INT            NOT NULL,
+    to_account_id   INT,
+    currency_id     INT            NOT NULL,
+    amount          NUMERIC(19, 4) NOT NULL CHECK (amount > 0),
+    captured_amount NUMERIC(19, 4) NOT NULL DEFAULT 0,
+    status          VARCHAR(16)    NOT NULL DEFAULT 'active' CHECK (status IN ('active', 'captured', 'released', 'expired')),
+    expires_at      TIMESTAMP      NOT NULL,
+    created_at      TIMESTAMP      NOT NULL DEFAULT CURRENT_TIMESTAMP,
+    updated_at      TIMESTAMP      NOT NULL DEFAULT CURRENT_TIMESTAMP,
+    FOREIGN KEY (account_id) REFERENCES account (id) ON DELETE CASCADE,
+    FOREIGN KEY (to_account_id) REFERENCES account (id) ON DELETE CASCADE,
+    FOREIGN KEY (currency_id) REFERENCES currency (id)
+);
+
+CREATE INDEX IF NOT EXISTS hold_active_idx ON hold (account_id) WHERE status = 'active';
+CREATE INDEX IF NOT EXISTS hold_expires_at_idx ON hold (expires_at) WHERE status = 'active';
+
+ALTER TABLE transaction
+    ADD COLUMN IF NOT EXISTS hold_id INT,
+    ADD FOREIGN KEY (hold_id) REFERENCES hold (id);
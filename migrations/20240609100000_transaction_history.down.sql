@@ -0,0 +1,2 @@
+DROP INDEX IF EXISTS transaction_to_account_id_created_at_idx;
+DROP INDEX IF EXISTS transaction_from_account_id_created_at_idx;
@@ -0,0 +1,2 @@
+CREATE INDEX IF NOT EXISTS transaction_from_account_id_created_at_idx ON transaction (from_account_id, created_at DESC, id DESC);
+CREATE INDEX IF NOT EXISTS transaction_to_account_id_created_at_idx ON transaction (to_account_id, created_at DESC, id DESC);
@@ -0,0 +1,11 @@
+CREATE TABLE IF NOT EXISTS statement
+(
+    id           SERIAL PRIMARY KEY,
+    account_id   INT       NOT NULL,
+    period_start TIMESTAMP NOT NULL,
+    period_end   TIMESTAMP NOT NULL,
+    content      BYTEA     NOT NULL,
+    created_at   TIMESTAMP NOT NULL DEFAULT CURRENT_TIMESTAMP,
+    FOREIGN KEY (account_id) REFERENCES account (id) ON DELETE CASCADE,
+    UNIQUE (account_id, period_start)
+);
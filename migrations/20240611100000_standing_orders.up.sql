@@ -0,0 +1,45 @@
+CREATE TABLE IF NOT EXISTS standing_order
+(
+    id              SERIAL PRIMARY KEY,
+    user_id         INT            NOT NULL,
+    from_account_id INT            NOT NULL,
+    to_account_id   INT            NOT NULL,
+    currency_id     INT            NOT NULL,
+    amount          NUMERIC(19, 4) NOT NULL CHECK (amount > 0),
+    frequency       VARCHAR(16)    NOT NULL CHECK (frequency IN ('once', 'daily', 'weekly', 'monthly')),
+    start_at        TIMESTAMP      NOT NULL,
+    end_at          TIMESTAMP,
+    max_occurrences INT CHECK (max_occurrences > 0),
+    occurrences     INT            NOT NULL DEFAULT 0,
+    attempts        INT            NOT NULL DEFAULT 0,
+    next_run_at     TIMESTAMP      NOT NULL,
+    status          VARCHAR(16)    NOT NULL DEFAULT 'active' CHECK (status IN ('active', 'finished', 'cancelled')),
+    locked_until    TIMESTAMP,
+    created_at      TIMESTAMP      NOT NULL DEFAULT CURRENT_TIMESTAMP,
+    updated_at      TIMESTAMP      NOT NULL DEFAULT CURRENT_TIMESTAMP,
+    FOREIGN KEY (user_id) REFERENCES "user" (id) ON DELETE CASCADE,
+    FOREIGN KEY (from_account_id) REFERENCES account (id) ON DELETE CASCADE,
+    FOREIGN KEY (to_account_id) REFERENCES account (id) ON DELETE CASCADE,
+    FOREIGN KEY (currency_id) REFERENCES currency (id)
+);
+
+CREATE INDEX IF NOT EXISTS standing_order_user_id_idx ON standing_order (user_id);
+CREATE INDEX IF NOT EXISTS standing_order_due_idx ON standing_order (next_run_at) WHERE status = 'active';
+
+CREATE TABLE IF NOT EXISTS standing_order_execution
+(
+    id                SERIAL PRIMARY KEY,
+    standing_order_id INT          NOT NULL,
+    scheduled_at      TIMESTAMP    NOT NULL,
+    attempt           INT          NOT NULL,
+    transaction_id    INT,
+    status            VARCHAR(16)  NOT NULL CHECK (status IN ('succeeded', 'failed')),
+    failure_reason    VARCHAR(255) NOT NULL DEFAULT '',
+    created_at        TIMESTAMP    NOT NULL DEFAULT CURRENT_TIMESTAMP,
+    FOREIGN KEY (standing_order_id) REFERENCES standing_order (id) ON DELETE CASCADE,
+    FOREIGN KEY (transaction_id) REFERENCES transaction (id)
+);
+
+CREATE INDEX IF NOT EXISTS standing_order_execution_order_idx ON standing_order_execution (standing_order_id, id DESC);
+-- An attempt at an occurrence is recorded once, by the transfer that pays it.
+CREATE UNIQUE INDEX IF NOT EXISTS standing_order_execution_attempt_idx ON standing_order_execution (standing_order_id, scheduled_at, attempt);
@@ -0,0 +1,23 @@
+CREATE TABLE IF NOT EXISTS session
+(
+    id         SERIAL PRIMARY KEY,
+    user_id    INT       NOT NULL,
+    revoked_at TIMESTAMP,
+    created_at TIMESTAMP NOT NULL DEFAULT CURRENT_TIMESTAMP,
+    FOREIGN KEY (user_id) REFERENCES "user" (id) ON DELETE CASCADE
+);
+
+CREATE INDEX IF NOT EXISTS session_user_id_idx ON session (user_id);
+
+CREATE TABLE IF NOT EXISTS refresh_token
+(
+    id         SERIAL PRIMARY KEY,
+    session_id INT       NOT NULL,
+    token_hash BYTEA     NOT NULL UNIQUE,
+    expires_at TIMESTAMP NOT NULL,
+    used_at    TIMESTAMP,
+    created_at TIMESTAMP NOT NULL DEFAULT CURRENT_TIMESTAMP,
+    FOREIGN KEY (session_id) REFERENCES session (id) ON DELETE CASCADE
+);
+
+CREATE INDEX IF NOT EXISTS refresh_token_session_id_idx ON refresh_token (session_id);
@@ -0,0 +1,31 @@
+CREATE TABLE IF NOT EXISTS two_factor
+(
+    user_id      INT PRIMARY KEY,
+    secret       BYTEA     NOT NULL,
+    last_step    BIGINT    NOT NULL DEFAULT 0,
+    confirmed_at TIMESTAMP,
+    created_at   TIMESTAMP NOT NULL DEFAULT CURRENT_TIMESTAMP,
+    FOREIGN KEY (user_id) REFERENCES "user" (id) ON DELETE CASCADE
+);
+
+CREATE TABLE IF NOT EXISTS two_factor_recovery_code
+(
+    id        SERIAL PRIMARY KEY,
+    user_id   INT   NOT NULL,
+    code_hash BYTEA NOT NULL,
+    used_at   TIMESTAMP,
+    UNIQUE (user_id, code_hash),
+    FOREIGN KEY (user_id) REFERENCES two_factor (user_id) ON DELETE CASCADE
+);
+
+CREATE TABLE IF NOT EXISTS two_factor_challenge
+(
+    id         SERIAL PRIMARY KEY,
+    user_id    INT       NOT NULL,
+    token_hash BYTEA     NOT NULL UNIQUE,
+    attempts   INT       NOT NULL DEFAULT 0,
+    expires_at TIMESTAMP NOT NULL,
+    used_at    TIMESTAMP,
+    created_at TIMESTAMP NOT NULL DEFAULT CURRENT_TIMESTAMP,
+    FOREIGN KEY (user_id) REFERENCES "user" (id) ON DELETE CASCADE
+);
@@ -0,0 +1,15 @@
+CREATE TABLE IF NOT EXISTS api_key
+(
+    id           SERIAL PRIMARY KEY,
+    user_id      INT,
+    name         VARCHAR(100) NOT NULL,
+    prefix       VARCHAR(32)  NOT NULL UNIQUE,
+    secret_hash  BYTEA        NOT NULL,
+    scopes       TEXT[]       NOT NULL,
+    last_used_at TIMESTAMP,
+    revoked_at   TIMESTAMP,
+    created_at   TIMESTAMP    NOT NULL DEFAULT CURRENT_TIMESTAMP,
+    FOREIGN KEY (user_id) REFERENCES "user" (id) ON DELETE CASCADE
+);
+
+CREATE INDEX IF NOT EXISTS api_key_user_id_idx ON api_key (user_id);
@@ -0,0 +1,8 @@
+DROP TABLE IF EXISTS admin_audit;
+
+ALTER TABLE account
+    DROP COLUMN IF EXISTS frozen_at,
+    DROP COLUMN IF EXISTS frozen_reason;
+
+ALTER TABLE "user"
+    DROP COLUMN IF EXISTS role;
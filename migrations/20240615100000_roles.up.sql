@@ -0,0 +1,25 @@
+ALTER TABLE "user"
+    ADD COLUMN IF NOT EXISTS role VARCHAR(20) NOT NULL DEFAULT 'customer'
+        CHECK (role IN ('customer', 'support', 'admin', 'auditor'));
+
+ALTER TABLE account
+    ADD COLUMN IF NOT EXISTS frozen_at     TIMESTAMP,
+    ADD COLUMN IF NOT EXISTS frozen_reason VARCHAR(255);
+
+-- The audit log has no foreign keys, so its entries outlive the users and accounts they are about.
+CREATE TABLE IF NOT EXISTS admin_audit
+(
+    id               SERIAL PRIMARY KEY,
+    actor_user_id    INT,
+    actor_session_id INT,
+    actor_api_key_id INT,
+    actor_role       VARCHAR(20),
+    action           VARCHAR(50) NOT NULL,
+    target_type      VARCHAR(20) NOT NULL,
+    target_id        INT,
+    details          JSONB       NOT NULL DEFAULT '{}',
+    created_at       TIMESTAMP   NOT NULL DEFAULT CURRENT_TIMESTAMP
+);
+
+CREATE INDEX IF NOT EXISTS admin_audit_actor_user_id_idx ON admin_audit (actor_user_id);
+CREATE INDEX IF NOT EXISTS admin_audit_target_idx ON admin_audit (target_type, target_id);
@@ -0,0 +1,8 @@
+-- Failed logins are counted per key, which is the email tried or the IP address the attempt came from.
+CREATE TABLE IF NOT EXISTS login_failure
+(
+    key             VARCHAR(300) PRIMARY KEY,
+    failures        INT          NOT NULL,
+    last_failure_at TIMESTAMP    NOT NULL,
+    blocked_until   TIMESTAMP
+);
@@ -0,0 +1,19 @@
+CREATE TABLE IF NOT EXISTS password_reset_token
+(
+    id         SERIAL PRIMARY KEY,
+    user_id    INT       NOT NULL,
+    token_hash BYTEA     NOT NULL UNIQUE,
+    expires_at TIMESTAMP NOT NULL,
+    used_at    TIMESTAMP,
+    created_at TIMESTAMP NOT NULL,
+    FOREIGN KEY (user_id) REFERENCES "user" (id) ON DELETE CASCADE
+);
+
+CREATE INDEX IF NOT EXISTS password_reset_token_user_id_idx ON password_reset_token (user_id);
+
+-- Reset emails are sent from here in the background, so that asking for one takes as long for any email.
+CREATE TABLE IF NOT EXISTS password_reset_request
+(
+    email        VARCHAR(255) PRIMARY KEY,
+    requested_at TIMESTAMP    NOT NULL
+);
@@ -0,0 +1,5 @@
+DROP TABLE IF EXISTS email_verification;
+
+ALTER TABLE "user"
+    DROP COLUMN IF EXISTS email_verified_at,
+    DROP COLUMN IF EXISTS pending_email;
@@ -0,0 +1,21 @@
+-- Users who signed up before emails were verified keep using the API as they did.
+ALTER TABLE "user"
+    ADD COLUMN IF NOT EXISTS email_verified_at TIMESTAMP,
+    ADD COLUMN IF NOT EXISTS pending_email     VARCHAR(255);
+
+UPDATE "user" SET email_verified_at = created_at WHERE email_verified_at IS NULL;
+
+-- A verification confirms email, which is either the email of the user or the one they are changing it to.
+CREATE TABLE IF NOT EXISTS email_verification
+(
+    id         SERIAL PRIMARY KEY,
+    user_id    INT          NOT NULL,
+    email      VARCHAR(255) NOT NULL,
+    token_hash BYTEA        NOT NULL UNIQUE,
+    expires_at TIMESTAMP    NOT NULL,
+    used_at    TIMESTAMP,
+    created_at TIMESTAMP    NOT NULL,
+    FOREIGN KEY (user_id) REFERENCES "user" (id) ON DELETE CASCADE
+);
+
+CREATE INDEX IF NOT EXISTS email_verification_user_id_idx ON email_verification (user_id);
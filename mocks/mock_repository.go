@@ -13,6 +13,7 @@ import (
 	domain "bank-api/internal/domain"
 	context "context"
 	reflect "reflect"
+	time "time"
 
 	gomock "go.uber.org/mock/gomock"
 )
@@ -40,6 +41,122 @@ func (m *MockUserRepository) EXPECT() *MockUserRepositoryMockRecorder {
 	return m.recorder
 }
 
+// ApplyUserUpdate mocks base method.
+func (m *MockUserRepository) ApplyUserUpdate(ctx context.Context, userId int, update *domain.UserUpdate, interval time.Duration) (bool, error) {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "ApplyUserUpdate", ctx, userId, update, interval)
+	ret0, _ := ret[0].(bool)
+	ret1, _ := ret[1].(error)
+	return ret0, ret1
+}
+
+// ApplyUserUpdate indicates an expected call of ApplyUserUpdate.
+func (mr *MockUserRepositoryMockRecorder) ApplyUserUpdate(ctx, userId, update, interval any) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "ApplyUserUpdate", reflect.TypeOf((*MockUserRepository)(nil).ApplyUserUpdate), ctx, userId, update, interval)
+}
+
+// AttemptTwoFactorChallenge mocks base method.
+func (m *MockUserRepository) AttemptTwoFactorChallenge(ctx context.Context, hash []byte, now time.Time, maxAttempts int) (*domain.TwoFactorChallenge, error) {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "AttemptTwoFactorChallenge", ctx, hash, now, maxAttempts)
+	ret0, _ := ret[0].(*domain.TwoFactorChallenge)
+	ret1, _ := ret[1].(error)
+	return ret0, ret1
+}
+
+// AttemptTwoFactorChallenge indicates an expected call of AttemptTwoFactorChallenge.
+func (mr *MockUserRepositoryMockRecorder) AttemptTwoFactorChallenge(ctx, hash, now, maxAttempts any) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "AttemptTwoFactorChallenge", reflect.TypeOf((*MockUserRepository)(nil).AttemptTwoFactorChallenge), ctx, hash, now, maxAttempts)
+}
+
+// BlockLogin mocks base method.
+func (m *MockUserRepository) BlockLogin(ctx context.Context, key string, until time.Time) error {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "BlockLogin", ctx, key, until)
+	ret0, _ := ret[0].(error)
+	return ret0
+}
+
+// BlockLogin indicates an expected call of BlockLogin.
+func (mr *MockUserRepositoryMockRecorder) BlockLogin(ctx, key, until any) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "BlockLogin", reflect.TypeOf((*MockUserRepository)(nil).BlockLogin), ctx, key, until)
+}
+
+// ClearLoginFailures mocks base method.
+func (m *MockUserRepository) ClearLoginFailures(ctx context.Context, key string) error {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "ClearLoginFailures", ctx, key)
+	ret0, _ := ret[0].(error)
+	return ret0
+}
+
+// ClearLoginFailures indicates an expected call of ClearLoginFailures.
+func (mr *MockUserRepositoryMockRecorder) ClearLoginFailures(ctx, key any) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "ClearLoginFailures", reflect.TypeOf((*MockUserRepository)(nil).ClearLoginFailures), ctx, key)
+}
+
+// ConfirmTwoFactor mocks base method.
+func (m *MockUserRepository) ConfirmTwoFactor(ctx context.Context, userId int, step int64, recoveryCodes [][]byte, now time.Time) error {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "ConfirmTwoFactor", ctx, userId, step, recoveryCodes, now)
+	ret0, _ := ret[0].(error)
+	return ret0
+}
+
+// ConfirmTwoFactor indicates an expected call of ConfirmTwoFactor.
+func (mr *MockUserRepositoryMockRecorder) ConfirmTwoFactor(ctx, userId, step, recoveryCodes, now any) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "ConfirmTwoFactor", reflect.TypeOf((*MockUserRepository)(nil).ConfirmTwoFactor), ctx, userId, step, recoveryCodes, now)
+}
+
+// CreateEmailVerification mocks base method.
+func (m *MockUserRepository) CreateEmailVerification(ctx context.Context, v *domain.EmailVerification, interval time.Duration) (bool, error) {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "CreateEmailVerification", ctx, v, interval)
+	ret0, _ := ret[0].(bool)
+	ret1, _ := ret[1].(error)
+	return ret0, ret1
+}
+
+// CreateEmailVerification indicates an expected call of CreateEmailVerification.
+func (mr *MockUserRepositoryMockRecorder) CreateEmailVerification(ctx, v, interval any) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "CreateEmailVerification", reflect.TypeOf((*MockUserRepository)(nil).CreateEmailVerification), ctx, v, interval)
+}
+
+// CreatePasswordResetToken mocks base method.
+func (m *MockUserRepository) CreatePasswordResetToken(ctx context.Context, token *domain.PasswordResetToken, interval time.Duration) (bool, error) {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "CreatePasswordResetToken", ctx, token, interval)
+	ret0, _ := ret[0].(bool)
+	ret1, _ := ret[1].(error)
+	return ret0, ret1
+}
+
+// CreatePasswordResetToken indicates an expected call of CreatePasswordResetToken.
+func (mr *MockUserRepositoryMockRecorder) CreatePasswordResetToken(ctx, token, interval any) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "CreatePasswordResetToken", reflect.TypeOf((*MockUserRepository)(nil).CreatePasswordResetToken), ctx, token, interval)
+}
+
+// CreateTwoFactorChallenge mocks base method.
+func (m *MockUserRepository) CreateTwoFactorChallenge(ctx context.Context, c *domain.TwoFactorChallenge) error {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "CreateTwoFactorChallenge", ctx, c)
+	ret0, _ := ret[0].(error)
+	return ret0
+}
+
+// CreateTwoFactorChallenge indicates an expected call of CreateTwoFactorChallenge.
+func (mr *MockUserRepositoryMockRecorder) CreateTwoFactorChallenge(ctx, c any) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "CreateTwoFactorChallenge", reflect.TypeOf((*MockUserRepository)(nil).CreateTwoFactorChallenge), ctx, c)
+}
+
 // CreateUser mocks base method.
 func (m *MockUserRepository) CreateUser(ctx context.Context, userInfo *domain.UserInfo) (*domain.User, error) {
 	m.ctrl.T.Helper()
@@ -55,6 +172,34 @@ func (mr *MockUserRepositoryMockRecorder) CreateUser(ctx, userInfo any) *gomock.
 	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "CreateUser", reflect.TypeOf((*MockUserRepository)(nil).CreateUser), ctx, userInfo)
 }
 
+// DeletePasswordResetRequest mocks base method.
+func (m *MockUserRepository) DeletePasswordResetRequest(ctx context.Context, email string) error {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "DeletePasswordResetRequest", ctx, email)
+	ret0, _ := ret[0].(error)
+	return ret0
+}
+
+// DeletePasswordResetRequest indicates an expected call of DeletePasswordResetRequest.
+func (mr *MockUserRepositoryMockRecorder) DeletePasswordResetRequest(ctx, email any) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "DeletePasswordResetRequest", reflect.TypeOf((*MockUserRepository)(nil).DeletePasswordResetRequest), ctx, email)
+}
+
+// DeleteTwoFactor mocks base method.
+func (m *MockUserRepository) DeleteTwoFactor(ctx context.Context, userId int) error {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "DeleteTwoFactor", ctx, userId)
+	ret0, _ := ret[0].(error)
+	return ret0
+}
+
+// DeleteTwoFactor indicates an expected call of DeleteTwoFactor.
+func (mr *MockUserRepositoryMockRecorder) DeleteTwoFactor(ctx, userId any) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "DeleteTwoFactor", reflect.TypeOf((*MockUserRepository)(nil).DeleteTwoFactor), ctx, userId)
+}
+
 // DeleteUser mocks base method.
 func (m *MockUserRepository) DeleteUser(ctx context.Context, id int) error {
 	m.ctrl.T.Helper()
@@ -69,6 +214,50 @@ func (mr *MockUserRepositoryMockRecorder) DeleteUser(ctx, id any) *gomock.Call {
 	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "DeleteUser", reflect.TypeOf((*MockUserRepository)(nil).DeleteUser), ctx, id)
 }
 
+// EnrollTwoFactor mocks base method.
+func (m *MockUserRepository) EnrollTwoFactor(ctx context.Context, userId int, secret []byte) error {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "EnrollTwoFactor", ctx, userId, secret)
+	ret0, _ := ret[0].(error)
+	return ret0
+}
+
+// EnrollTwoFactor indicates an expected call of EnrollTwoFactor.
+func (mr *MockUserRepositoryMockRecorder) EnrollTwoFactor(ctx, userId, secret any) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "EnrollTwoFactor", reflect.TypeOf((*MockUserRepository)(nil).EnrollTwoFactor), ctx, userId, secret)
+}
+
+// GetLoginBlock mocks base method.
+func (m *MockUserRepository) GetLoginBlock(ctx context.Context, keys []string, now time.Time) (time.Time, error) {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "GetLoginBlock", ctx, keys, now)
+	ret0, _ := ret[0].(time.Time)
+	ret1, _ := ret[1].(error)
+	return ret0, ret1
+}
+
+// GetLoginBlock indicates an expected call of GetLoginBlock.
+func (mr *MockUserRepositoryMockRecorder) GetLoginBlock(ctx, keys, now any) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "GetLoginBlock", reflect.TypeOf((*MockUserRepository)(nil).GetLoginBlock), ctx, keys, now)
+}
+
+// GetTwoFactor mocks base method.
+func (m *MockUserRepository) GetTwoFactor(ctx context.Context, userId int) (*domain.TwoFactor, error) {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "GetTwoFactor", ctx, userId)
+	ret0, _ := ret[0].(*domain.TwoFactor)
+	ret1, _ := ret[1].(error)
+	return ret0, ret1
+}
+
+// GetTwoFactor indicates an expected call of GetTwoFactor.
+func (mr *MockUserRepositoryMockRecorder) GetTwoFactor(ctx, userId any) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "GetTwoFactor", reflect.TypeOf((*MockUserRepository)(nil).GetTwoFactor), ctx, userId)
+}
+
 // GetUser mocks base method.
 func (m *MockUserRepository) GetUser(ctx context.Context, id int) (*domain.User, error) {
 	m.ctrl.T.Helper()
@@ -78,527 +267,1306 @@ func (m *MockUserRepository) GetUser(ctx context.Context, id int) (*domain.User,
 	return ret0, ret1
 }
 
-// GetUser indicates an expected call of GetUser.
-func (mr *MockUserRepositoryMockRecorder) GetUser(ctx, id any) *gomock.Call {
-	mr.mock.ctrl.T.Helper()
-	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "GetUser", reflect.TypeOf((*MockUserRepository)(nil).GetUser), ctx, id)
+// GetUser indicates an expected call of GetUser.
+func (mr *MockUserRepositoryMockRecorder) GetUser(ctx, id any) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "GetUser", reflect.TypeOf((*MockUserRepository)(nil).GetUser), ctx, id)
+}
+
+// GetUserByEmail mocks base method.
+func (m *MockUserRepository) GetUserByEmail(ctx context.Context, email string) (*domain.User, error) {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "GetUserByEmail", ctx, email)
+	ret0, _ := ret[0].(*domain.User)
+	ret1, _ := ret[1].(error)
+	return ret0, ret1
+}
+
+// GetUserByEmail indicates an expected call of GetUserByEmail.
+func (mr *MockUserRepositoryMockRecorder) GetUserByEmail(ctx, email any) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "GetUserByEmail", reflect.TypeOf((*MockUserRepository)(nil).GetUserByEmail), ctx, email)
+}
+
+// GetUserIdByEmail mocks base method.
+func (m *MockUserRepository) GetUserIdByEmail(ctx context.Context, email string) (int, error) {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "GetUserIdByEmail", ctx, email)
+	ret0, _ := ret[0].(int)
+	ret1, _ := ret[1].(error)
+	return ret0, ret1
+}
+
+// GetUserIdByEmail indicates an expected call of GetUserIdByEmail.
+func (mr *MockUserRepositoryMockRecorder) GetUserIdByEmail(ctx, email any) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "GetUserIdByEmail", reflect.TypeOf((*MockUserRepository)(nil).GetUserIdByEmail), ctx, email)
+}
+
+// ListPasswordResetRequests mocks base method.
+func (m *MockUserRepository) ListPasswordResetRequests(ctx context.Context, limit int) ([]string, error) {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "ListPasswordResetRequests", ctx, limit)
+	ret0, _ := ret[0].([]string)
+	ret1, _ := ret[1].(error)
+	return ret0, ret1
+}
+
+// ListPasswordResetRequests indicates an expected call of ListPasswordResetRequests.
+func (mr *MockUserRepositoryMockRecorder) ListPasswordResetRequests(ctx, limit any) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "ListPasswordResetRequests", reflect.TypeOf((*MockUserRepository)(nil).ListPasswordResetRequests), ctx, limit)
+}
+
+// RecordLoginFailure mocks base method.
+func (m *MockUserRepository) RecordLoginFailure(ctx context.Context, key string, now time.Time, window time.Duration) (int, error) {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "RecordLoginFailure", ctx, key, now, window)
+	ret0, _ := ret[0].(int)
+	ret1, _ := ret[1].(error)
+	return ret0, ret1
+}
+
+// RecordLoginFailure indicates an expected call of RecordLoginFailure.
+func (mr *MockUserRepositoryMockRecorder) RecordLoginFailure(ctx, key, now, window any) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "RecordLoginFailure", reflect.TypeOf((*MockUserRepository)(nil).RecordLoginFailure), ctx, key, now, window)
+}
+
+// RequestPasswordReset mocks base method.
+func (m *MockUserRepository) RequestPasswordReset(ctx context.Context, email string, now time.Time) error {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "RequestPasswordReset", ctx, email, now)
+	ret0, _ := ret[0].(error)
+	return ret0
+}
+
+// RequestPasswordReset indicates an expected call of RequestPasswordReset.
+func (mr *MockUserRepositoryMockRecorder) RequestPasswordReset(ctx, email, now any) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "RequestPasswordReset", reflect.TypeOf((*MockUserRepository)(nil).RequestPasswordReset), ctx, email, now)
+}
+
+// ResetPassword mocks base method.
+func (m *MockUserRepository) ResetPassword(ctx context.Context, hash []byte, hashedPassword string, now time.Time) (int, error) {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "ResetPassword", ctx, hash, hashedPassword, now)
+	ret0, _ := ret[0].(int)
+	ret1, _ := ret[1].(error)
+	return ret0, ret1
+}
+
+// ResetPassword indicates an expected call of ResetPassword.
+func (mr *MockUserRepositoryMockRecorder) ResetPassword(ctx, hash, hashedPassword, now any) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "ResetPassword", reflect.TypeOf((*MockUserRepository)(nil).ResetPassword), ctx, hash, hashedPassword, now)
+}
+
+// UpdatePassword mocks base method.
+func (m *MockUserRepository) UpdatePassword(ctx context.Context, userId int, hashedPassword string, now time.Time) error {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "UpdatePassword", ctx, userId, hashedPassword, now)
+	ret0, _ := ret[0].(error)
+	return ret0
+}
+
+// UpdatePassword indicates an expected call of UpdatePassword.
+func (mr *MockUserRepositoryMockRecorder) UpdatePassword(ctx, userId, hashedPassword, now any) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "UpdatePassword", reflect.TypeOf((*MockUserRepository)(nil).UpdatePassword), ctx, userId, hashedPassword, now)
+}
+
+// UpdateUser mocks base method.
+func (m *MockUserRepository) UpdateUser(ctx context.Context, id int, userInfo *domain.UserInfo) (*domain.User, error) {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "UpdateUser", ctx, id, userInfo)
+	ret0, _ := ret[0].(*domain.User)
+	ret1, _ := ret[1].(error)
+	return ret0, ret1
+}
+
+// UpdateUser indicates an expected call of UpdateUser.
+func (mr *MockUserRepositoryMockRecorder) UpdateUser(ctx, id, userInfo any) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "UpdateUser", reflect.TypeOf((*MockUserRepository)(nil).UpdateUser), ctx, id, userInfo)
+}
+
+// UseRecoveryCode mocks base method.
+func (m *MockUserRepository) UseRecoveryCode(ctx context.Context, userId int, hash []byte, now time.Time) (bool, error) {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "UseRecoveryCode", ctx, userId, hash, now)
+	ret0, _ := ret[0].(bool)
+	ret1, _ := ret[1].(error)
+	return ret0, ret1
+}
+
+// UseRecoveryCode indicates an expected call of UseRecoveryCode.
+func (mr *MockUserRepositoryMockRecorder) UseRecoveryCode(ctx, userId, hash, now any) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "UseRecoveryCode", reflect.TypeOf((*MockUserRepository)(nil).UseRecoveryCode), ctx, userId, hash, now)
+}
+
+// UseTwoFactorChallenge mocks base method.
+func (m *MockUserRepository) UseTwoFactorChallenge(ctx context.Context, id int, now time.Time) (bool, error) {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "UseTwoFactorChallenge", ctx, id, now)
+	ret0, _ := ret[0].(bool)
+	ret1, _ := ret[1].(error)
+	return ret0, ret1
+}
+
+// UseTwoFactorChallenge indicates an expected call of UseTwoFactorChallenge.
+func (mr *MockUserRepositoryMockRecorder) UseTwoFactorChallenge(ctx, id, now any) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "UseTwoFactorChallenge", reflect.TypeOf((*MockUserRepository)(nil).UseTwoFactorChallenge), ctx, id, now)
+}
+
+// UseTwoFactorStep mocks base method.
+func (m *MockUserRepository) UseTwoFactorStep(ctx context.Context, userId int, step int64) (bool, error) {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "UseTwoFactorStep", ctx, userId, step)
+	ret0, _ := ret[0].(bool)
+	ret1, _ := ret[1].(error)
+	return ret0, ret1
+}
+
+// UseTwoFactorStep indicates an expected call of UseTwoFactorStep.
+func (mr *MockUserRepositoryMockRecorder) UseTwoFactorStep(ctx, userId, step any) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "UseTwoFactorStep", reflect.TypeOf((*MockUserRepository)(nil).UseTwoFactorStep), ctx, userId, step)
+}
+
+// UserExistsByEmail mocks base method.
+func (m *MockUserRepository) UserExistsByEmail(ctx context.Context, email string) (bool, error) {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "UserExistsByEmail", ctx, email)
+	ret0, _ := ret[0].(bool)
+	ret1, _ := ret[1].(error)
+	return ret0, ret1
+}
+
+// UserExistsByEmail indicates an expected call of UserExistsByEmail.
+func (mr *MockUserRepositoryMockRecorder) UserExistsByEmail(ctx, email any) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "UserExistsByEmail", reflect.TypeOf((*MockUserRepository)(nil).UserExistsByEmail), ctx, email)
+}
+
+// UserExistsById mocks base method.
+func (m *MockUserRepository) UserExistsById(ctx context.Context, id int) (bool, error) {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "UserExistsById", ctx, id)
+	ret0, _ := ret[0].(bool)
+	ret1, _ := ret[1].(error)
+	return ret0, ret1
+}
+
+// UserExistsById indicates an expected call of UserExistsById.
+func (mr *MockUserRepositoryMockRecorder) UserExistsById(ctx, id any) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "UserExistsById", reflect.TypeOf((*MockUserRepository)(nil).UserExistsById), ctx, id)
+}
+
+// VerifyEmail mocks base method.
+func (m *MockUserRepository) VerifyEmail(ctx context.Context, hash []byte, now time.Time) (int, error) {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "VerifyEmail", ctx, hash, now)
+	ret0, _ := ret[0].(int)
+	ret1, _ := ret[1].(error)
+	return ret0, ret1
+}
+
+// VerifyEmail indicates an expected call of VerifyEmail.
+func (mr *MockUserRepositoryMockRecorder) VerifyEmail(ctx, hash, now any) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "VerifyEmail", reflect.TypeOf((*MockUserRepository)(nil).VerifyEmail), ctx, hash, now)
+}
+
+// MockAccountRepository is a mock of AccountRepository interface.
+type MockAccountRepository struct {
+	ctrl     *gomock.Controller
+	recorder *MockAccountRepositoryMockRecorder
+}
+
+// MockAccountRepositoryMockRecorder is the mock recorder for MockAccountRepository.
+type MockAccountRepositoryMockRecorder struct {
+	mock *MockAccountRepository
+}
+
+// NewMockAccountRepository creates a new mock instance.
+func NewMockAccountRepository(ctrl *gomock.Controller) *MockAccountRepository {
+	mock := &MockAccountRepository{ctrl: ctrl}
+	mock.recorder = &MockAccountRepositoryMockRecorder{mock}
+	return mock
+}
+
+// EXPECT returns an object that allows the caller to indicate expected use.
+func (m *MockAccountRepository) EXPECT() *MockAccountRepositoryMockRecorder {
+	return m.recorder
+}
+
+// AccountExists mocks base method.
+func (m *MockAccountRepository) AccountExists(ctx context.Context, id int) (bool, error) {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "AccountExists", ctx, id)
+	ret0, _ := ret[0].(bool)
+	ret1, _ := ret[1].(error)
+	return ret0, ret1
+}
+
+// AccountExists indicates an expected call of AccountExists.
+func (mr *MockAccountRepositoryMockRecorder) AccountExists(ctx, id any) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "AccountExists", reflect.TypeOf((*MockAccountRepository)(nil).AccountExists), ctx, id)
+}
+
+// CaptureHold mocks base method.
+func (m *MockAccountRepository) CaptureHold(ctx context.Context, capture *domain.Transaction) error {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "CaptureHold", ctx, capture)
+	ret0, _ := ret[0].(error)
+	return ret0
+}
+
+// CaptureHold indicates an expected call of CaptureHold.
+func (mr *MockAccountRepositoryMockRecorder) CaptureHold(ctx, capture any) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "CaptureHold", reflect.TypeOf((*MockAccountRepository)(nil).CaptureHold), ctx, capture)
+}
+
+// CheckLedger mocks base method.
+func (m *MockAccountRepository) CheckLedger(ctx context.Context) (*domain.LedgerReport, error) {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "CheckLedger", ctx)
+	ret0, _ := ret[0].(*domain.LedgerReport)
+	ret1, _ := ret[1].(error)
+	return ret0, ret1
+}
+
+// CheckLedger indicates an expected call of CheckLedger.
+func (mr *MockAccountRepositoryMockRecorder) CheckLedger(ctx any) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "CheckLedger", reflect.TypeOf((*MockAccountRepository)(nil).CheckLedger), ctx)
+}
+
+// CreateAccount mocks base method.
+func (m *MockAccountRepository) CreateAccount(ctx context.Context, userId int, cur domain.Currency) (*domain.Account, error) {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "CreateAccount", ctx, userId, cur)
+	ret0, _ := ret[0].(*domain.Account)
+	ret1, _ := ret[1].(error)
+	return ret0, ret1
+}
+
+// CreateAccount indicates an expected call of CreateAccount.
+func (mr *MockAccountRepositoryMockRecorder) CreateAccount(ctx, userId, cur any) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "CreateAccount", reflect.TypeOf((*MockAccountRepository)(nil).CreateAccount), ctx, userId, cur)
+}
+
+// CreateHold mocks base method.
+func (m *MockAccountRepository) CreateHold(ctx context.Context, hold *domain.Hold, ttl time.Duration) error {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "CreateHold", ctx, hold, ttl)
+	ret0, _ := ret[0].(error)
+	return ret0
+}
+
+// CreateHold indicates an expected call of CreateHold.
+func (mr *MockAccountRepositoryMockRecorder) CreateHold(ctx, hold, ttl any) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "CreateHold", reflect.TypeOf((*MockAccountRepository)(nil).CreateHold), ctx, hold, ttl)
+}
+
+// CurrencyExists mocks base method.
+func (m *MockAccountRepository) CurrencyExists(ctx context.Context, cur domain.Currency) (bool, error) {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "CurrencyExists", ctx, cur)
+	ret0, _ := ret[0].(bool)
+	ret1, _ := ret[1].(error)
+	return ret0, ret1
+}
+
+// CurrencyExists indicates an expected call of CurrencyExists.
+func (mr *MockAccountRepositoryMockRecorder) CurrencyExists(ctx, cur any) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "CurrencyExists", reflect.TypeOf((*MockAccountRepository)(nil).CurrencyExists), ctx, cur)
+}
+
+// DeleteAccount mocks base method.
+func (m *MockAccountRepository) DeleteAccount(ctx context.Context, id int) error {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "DeleteAccount", ctx, id)
+	ret0, _ := ret[0].(error)
+	return ret0
+}
+
+// DeleteAccount indicates an expected call of DeleteAccount.
+func (mr *MockAccountRepositoryMockRecorder) DeleteAccount(ctx, id any) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "DeleteAccount", reflect.TypeOf((*MockAccountRepository)(nil).DeleteAccount), ctx, id)
+}
+
+// ExpireHolds mocks base method.
+func (m *MockAccountRepository) ExpireHolds(ctx context.Context) (int, error) {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "ExpireHolds", ctx)
+	ret0, _ := ret[0].(int)
+	ret1, _ := ret[1].(error)
+	return ret0, ret1
+}
+
+// ExpireHolds indicates an expected call of ExpireHolds.
+func (mr *MockAccountRepositoryMockRecorder) ExpireHolds(ctx any) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "ExpireHolds", reflect.TypeOf((*MockAccountRepository)(nil).ExpireHolds), ctx)
+}
+
+// FailStalePendingTransactions mocks base method.
+func (m *MockAccountRepository) FailStalePendingTransactions(ctx context.Context, before time.Time) (int, error) {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "FailStalePendingTransactions", ctx, before)
+	ret0, _ := ret[0].(int)
+	ret1, _ := ret[1].(error)
+	return ret0, ret1
+}
+
+// FailStalePendingTransactions indicates an expected call of FailStalePendingTransactions.
+func (mr *MockAccountRepositoryMockRecorder) FailStalePendingTransactions(ctx, before any) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "FailStalePendingTransactions", reflect.TypeOf((*MockAccountRepository)(nil).FailStalePendingTransactions), ctx, before)
+}
+
+// GetAccount mocks base method.
+func (m *MockAccountRepository) GetAccount(ctx context.Context, id int) (*domain.Account, error) {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "GetAccount", ctx, id)
+	ret0, _ := ret[0].(*domain.Account)
+	ret1, _ := ret[1].(error)
+	return ret0, ret1
+}
+
+// GetAccount indicates an expected call of GetAccount.
+func (mr *MockAccountRepositoryMockRecorder) GetAccount(ctx, id any) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "GetAccount", reflect.TypeOf((*MockAccountRepository)(nil).GetAccount), ctx, id)
+}
+
+// GetBalanceAt mocks base method.
+func (m *MockAccountRepository) GetBalanceAt(ctx context.Context, accountId int, at time.Time) (domain.Money, error) {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "GetBalanceAt", ctx, accountId, at)
+	ret0, _ := ret[0].(domain.Money)
+	ret1, _ := ret[1].(error)
+	return ret0, ret1
+}
+
+// GetBalanceAt indicates an expected call of GetBalanceAt.
+func (mr *MockAccountRepositoryMockRecorder) GetBalanceAt(ctx, accountId, at any) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "GetBalanceAt", reflect.TypeOf((*MockAccountRepository)(nil).GetBalanceAt), ctx, accountId, at)
+}
+
+// GetCurrencyId mocks base method.
+func (m *MockAccountRepository) GetCurrencyId(ctx context.Context, cur domain.Currency) (int, error) {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "GetCurrencyId", ctx, cur)
+	ret0, _ := ret[0].(int)
+	ret1, _ := ret[1].(error)
+	return ret0, ret1
+}
+
+// GetCurrencyId indicates an expected call of GetCurrencyId.
+func (mr *MockAccountRepositoryMockRecorder) GetCurrencyId(ctx, cur any) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "GetCurrencyId", reflect.TypeOf((*MockAccountRepository)(nil).GetCurrencyId), ctx, cur)
+}
+
+// GetHold mocks base method.
+func (m *MockAccountRepository) GetHold(ctx context.Context, id int) (*domain.Hold, error) {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "GetHold", ctx, id)
+	ret0, _ := ret[0].(*domain.Hold)
+	ret1, _ := ret[1].(error)
+	return ret0, ret1
+}
+
+// GetHold indicates an expected call of GetHold.
+func (mr *MockAccountRepositoryMockRecorder) GetHold(ctx, id any) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "GetHold", reflect.TypeOf((*MockAccountRepository)(nil).GetHold), ctx, id)
+}
+
+// GetTransaction mocks base method.
+func (m *MockAccountRepository) GetTransaction(ctx context.Context, id int) (*domain.Transaction, error) {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "GetTransaction", ctx, id)
+	ret0, _ := ret[0].(*domain.Transaction)
+	ret1, _ := ret[1].(error)
+	return ret0, ret1
+}
+
+// GetTransaction indicates an expected call of GetTransaction.
+func (mr *MockAccountRepositoryMockRecorder) GetTransaction(ctx, id any) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "GetTransaction", reflect.TypeOf((*MockAccountRepository)(nil).GetTransaction), ctx, id)
+}
+
+// ListAdjustments mocks base method.
+func (m *MockAccountRepository) ListAdjustments(ctx context.Context, accountId int, from, to time.Time) ([]*domain.Adjustment, error) {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "ListAdjustments", ctx, accountId, from, to)
+	ret0, _ := ret[0].([]*domain.Adjustment)
+	ret1, _ := ret[1].(error)
+	return ret0, ret1
+}
+
+// ListAdjustments indicates an expected call of ListAdjustments.
+func (mr *MockAccountRepositoryMockRecorder) ListAdjustments(ctx, accountId, from, to any) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "ListAdjustments", reflect.TypeOf((*MockAccountRepository)(nil).ListAdjustments), ctx, accountId, from, to)
+}
+
+// ListTransactions mocks base method.
+func (m *MockAccountRepository) ListTransactions(ctx context.Context, filter *domain.TransactionFilter) ([]*domain.Transaction, error) {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "ListTransactions", ctx, filter)
+	ret0, _ := ret[0].([]*domain.Transaction)
+	ret1, _ := ret[1].(error)
+	return ret0, ret1
+}
+
+// ListTransactions indicates an expected call of ListTransactions.
+func (mr *MockAccountRepositoryMockRecorder) ListTransactions(ctx, filter any) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "ListTransactions", reflect.TypeOf((*MockAccountRepository)(nil).ListTransactions), ctx, filter)
+}
+
+// RefreshCurrencies mocks base method.
+func (m *MockAccountRepository) RefreshCurrencies(ctx context.Context) error {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "RefreshCurrencies", ctx)
+	ret0, _ := ret[0].(error)
+	return ret0
+}
+
+// RefreshCurrencies indicates an expected call of RefreshCurrencies.
+func (mr *MockAccountRepositoryMockRecorder) RefreshCurrencies(ctx any) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "RefreshCurrencies", reflect.TypeOf((*MockAccountRepository)(nil).RefreshCurrencies), ctx)
+}
+
+// ReleaseHold mocks base method.
+func (m *MockAccountRepository) ReleaseHold(ctx context.Context, id int) error {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "ReleaseHold", ctx, id)
+	ret0, _ := ret[0].(error)
+	return ret0
+}
+
+// ReleaseHold indicates an expected call of ReleaseHold.
+func (mr *MockAccountRepositoryMockRecorder) ReleaseHold(ctx, id any) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "ReleaseHold", reflect.TypeOf((*MockAccountRepository)(nil).ReleaseHold), ctx, id)
+}
+
+// Reverse mocks base method.
+func (m *MockAccountRepository) Reverse(ctx context.Context, original, reversal *domain.Transaction) error {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "Reverse", ctx, original, reversal)
+	ret0, _ := ret[0].(error)
+	return ret0
+}
+
+// Reverse indicates an expected call of Reverse.
+func (mr *MockAccountRepositoryMockRecorder) Reverse(ctx, original, reversal any) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "Reverse", reflect.TypeOf((*MockAccountRepository)(nil).Reverse), ctx, original, reversal)
+}
+
+// Transaction mocks base method.
+func (m *MockAccountRepository) Transaction(ctx context.Context, accountId int, amount domain.Money, t domain.TransactionType) error {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "Transaction", ctx, accountId, amount, t)
+	ret0, _ := ret[0].(error)
+	return ret0
+}
+
+// Transaction indicates an expected call of Transaction.
+func (mr *MockAccountRepositoryMockRecorder) Transaction(ctx, accountId, amount, t any) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "Transaction", reflect.TypeOf((*MockAccountRepository)(nil).Transaction), ctx, accountId, amount, t)
+}
+
+// Transfer mocks base method.
+func (m *MockAccountRepository) Transfer(ctx context.Context, t *domain.Transaction) error {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "Transfer", ctx, t)
+	ret0, _ := ret[0].(error)
+	return ret0
+}
+
+// Transfer indicates an expected call of Transfer.
+func (mr *MockAccountRepositoryMockRecorder) Transfer(ctx, t any) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "Transfer", reflect.TypeOf((*MockAccountRepository)(nil).Transfer), ctx, t)
+}
+
+// UpdateAccount mocks base method.
+func (m *MockAccountRepository) UpdateAccount(ctx context.Context, id int, amount domain.Money) (*domain.Account, error) {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "UpdateAccount", ctx, id, amount)
+	ret0, _ := ret[0].(*domain.Account)
+	ret1, _ := ret[1].(error)
+	return ret0, ret1
+}
+
+// UpdateAccount indicates an expected call of UpdateAccount.
+func (mr *MockAccountRepositoryMockRecorder) UpdateAccount(ctx, id, amount any) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "UpdateAccount", reflect.TypeOf((*MockAccountRepository)(nil).UpdateAccount), ctx, id, amount)
+}
+
+// UserExistsById mocks base method.
+func (m *MockAccountRepository) UserExistsById(ctx context.Context, id int) (bool, error) {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "UserExistsById", ctx, id)
+	ret0, _ := ret[0].(bool)
+	ret1, _ := ret[1].(error)
+	return ret0, ret1
+}
+
+// UserExistsById indicates an expected call of UserExistsById.
+func (mr *MockAccountRepositoryMockRecorder) UserExistsById(ctx, id any) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "UserExistsById", reflect.TypeOf((*MockAccountRepository)(nil).UserExistsById), ctx, id)
+}
+
+// MockIdempotencyRepository is a mock of IdempotencyRepository interface.
+type MockIdempotencyRepository struct {
+	ctrl     *gomock.Controller
+	recorder *MockIdempotencyRepositoryMockRecorder
+}
+
+// MockIdempotencyRepositoryMockRecorder is the mock recorder for MockIdempotencyRepository.
+type MockIdempotencyRepositoryMockRecorder struct {
+	mock *MockIdempotencyRepository
+}
+
+// NewMockIdempotencyRepository creates a new mock instance.
+func NewMockIdempotencyRepository(ctrl *gomock.Controller) *MockIdempotencyRepository {
+	mock := &MockIdempotencyRepository{ctrl: ctrl}
+	mock.recorder = &MockIdempotencyRepositoryMockRecorder{mock}
+	return mock
+}
+
+// EXPECT returns an object that allows the caller to indicate expected use.
+func (m *MockIdempotencyRepository) EXPECT() *MockIdempotencyRepositoryMockRecorder {
+	return m.recorder
+}
+
+// CreateIdempotencyKey mocks base method.
+func (m *MockIdempotencyRepository) CreateIdempotencyKey(ctx context.Context, record *domain.IdempotencyRecord) (bool, error) {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "CreateIdempotencyKey", ctx, record)
+	ret0, _ := ret[0].(bool)
+	ret1, _ := ret[1].(error)
+	return ret0, ret1
+}
+
+// CreateIdempotencyKey indicates an expected call of CreateIdempotencyKey.
+func (mr *MockIdempotencyRepositoryMockRecorder) CreateIdempotencyKey(ctx, record any) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "CreateIdempotencyKey", reflect.TypeOf((*MockIdempotencyRepository)(nil).CreateIdempotencyKey), ctx, record)
+}
+
+// DeleteIdempotencyKey mocks base method.
+func (m *MockIdempotencyRepository) DeleteIdempotencyKey(ctx context.Context, userId int, key string) error {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "DeleteIdempotencyKey", ctx, userId, key)
+	ret0, _ := ret[0].(error)
+	return ret0
+}
+
+// DeleteIdempotencyKey indicates an expected call of DeleteIdempotencyKey.
+func (mr *MockIdempotencyRepositoryMockRecorder) DeleteIdempotencyKey(ctx, userId, key any) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "DeleteIdempotencyKey", reflect.TypeOf((*MockIdempotencyRepository)(nil).DeleteIdempotencyKey), ctx, userId, key)
+}
+
+// GetIdempotencyKey mocks base method.
+func (m *MockIdempotencyRepository) GetIdempotencyKey(ctx context.Context, userId int, key string) (*domain.IdempotencyRecord, error) {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "GetIdempotencyKey", ctx, userId, key)
+	ret0, _ := ret[0].(*domain.IdempotencyRecord)
+	ret1, _ := ret[1].(error)
+	return ret0, ret1
+}
+
+// GetIdempotencyKey indicates an expected call of GetIdempotencyKey.
+func (mr *MockIdempotencyRepositoryMockRecorder) GetIdempotencyKey(ctx, userId, key any) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "GetIdempotencyKey", reflect.TypeOf((*MockIdempotencyRepository)(nil).GetIdempotencyKey), ctx, userId, key)
+}
+
+// SaveIdempotentResponse mocks base method.
+func (m *MockIdempotencyRepository) SaveIdempotentResponse(ctx context.Context, userId int, key string, resp *domain.IdempotentResponse) error {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "SaveIdempotentResponse", ctx, userId, key, resp)
+	ret0, _ := ret[0].(error)
+	return ret0
+}
+
+// SaveIdempotentResponse indicates an expected call of SaveIdempotentResponse.
+func (mr *MockIdempotencyRepositoryMockRecorder) SaveIdempotentResponse(ctx, userId, key, resp any) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "SaveIdempotentResponse", reflect.TypeOf((*MockIdempotencyRepository)(nil).SaveIdempotentResponse), ctx, userId, key, resp)
+}
+
+// MockExchangeRepository is a mock of ExchangeRepository interface.
+type MockExchangeRepository struct {
+	ctrl     *gomock.Controller
+	recorder *MockExchangeRepositoryMockRecorder
+}
+
+// MockExchangeRepositoryMockRecorder is the mock recorder for MockExchangeRepository.
+type MockExchangeRepositoryMockRecorder struct {
+	mock *MockExchangeRepository
+}
+
+// NewMockExchangeRepository creates a new mock instance.
+func NewMockExchangeRepository(ctrl *gomock.Controller) *MockExchangeRepository {
+	mock := &MockExchangeRepository{ctrl: ctrl}
+	mock.recorder = &MockExchangeRepositoryMockRecorder{mock}
+	return mock
+}
+
+// EXPECT returns an object that allows the caller to indicate expected use.
+func (m *MockExchangeRepository) EXPECT() *MockExchangeRepositoryMockRecorder {
+	return m.recorder
+}
+
+// CreateQuote mocks base method.
+func (m *MockExchangeRepository) CreateQuote(ctx context.Context, quote *domain.Quote) error {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "CreateQuote", ctx, quote)
+	ret0, _ := ret[0].(error)
+	return ret0
+}
+
+// CreateQuote indicates an expected call of CreateQuote.
+func (mr *MockExchangeRepositoryMockRecorder) CreateQuote(ctx, quote any) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "CreateQuote", reflect.TypeOf((*MockExchangeRepository)(nil).CreateQuote), ctx, quote)
+}
+
+// GetQuote mocks base method.
+func (m *MockExchangeRepository) GetQuote(ctx context.Context, id int) (*domain.Quote, error) {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "GetQuote", ctx, id)
+	ret0, _ := ret[0].(*domain.Quote)
+	ret1, _ := ret[1].(error)
+	return ret0, ret1
+}
+
+// GetQuote indicates an expected call of GetQuote.
+func (mr *MockExchangeRepositoryMockRecorder) GetQuote(ctx, id any) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "GetQuote", reflect.TypeOf((*MockExchangeRepository)(nil).GetQuote), ctx, id)
+}
+
+// GetRate mocks base method.
+func (m *MockExchangeRepository) GetRate(ctx context.Context, from, to domain.Currency, at time.Time) (*domain.ExchangeRate, error) {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "GetRate", ctx, from, to, at)
+	ret0, _ := ret[0].(*domain.ExchangeRate)
+	ret1, _ := ret[1].(error)
+	return ret0, ret1
+}
+
+// GetRate indicates an expected call of GetRate.
+func (mr *MockExchangeRepositoryMockRecorder) GetRate(ctx, from, to, at any) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "GetRate", reflect.TypeOf((*MockExchangeRepository)(nil).GetRate), ctx, from, to, at)
+}
+
+// ListCurrencies mocks base method.
+func (m *MockExchangeRepository) ListCurrencies(ctx context.Context) ([]domain.Currency, error) {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "ListCurrencies", ctx)
+	ret0, _ := ret[0].([]domain.Currency)
+	ret1, _ := ret[1].(error)
+	return ret0, ret1
+}
+
+// ListCurrencies indicates an expected call of ListCurrencies.
+func (mr *MockExchangeRepositoryMockRecorder) ListCurrencies(ctx any) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "ListCurrencies", reflect.TypeOf((*MockExchangeRepository)(nil).ListCurrencies), ctx)
+}
+
+// UpsertRates mocks base method.
+func (m *MockExchangeRepository) UpsertRates(ctx context.Context, rates []*domain.ExchangeRate) error {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "UpsertRates", ctx, rates)
+	ret0, _ := ret[0].(error)
+	return ret0
+}
+
+// UpsertRates indicates an expected call of UpsertRates.
+func (mr *MockExchangeRepositoryMockRecorder) UpsertRates(ctx, rates any) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "UpsertRates", reflect.TypeOf((*MockExchangeRepository)(nil).UpsertRates), ctx, rates)
+}
+
+// MockStatementRepository is a mock of StatementRepository interface.
+type MockStatementRepository struct {
+	ctrl     *gomock.Controller
+	recorder *MockStatementRepositoryMockRecorder
+}
+
+// MockStatementRepositoryMockRecorder is the mock recorder for MockStatementRepository.
+type MockStatementRepositoryMockRecorder struct {
+	mock *MockStatementRepository
+}
+
+// NewMockStatementRepository creates a new mock instance.
+func NewMockStatementRepository(ctrl *gomock.Controller) *MockStatementRepository {
+	mock := &MockStatementRepository{ctrl: ctrl}
+	mock.recorder = &MockStatementRepositoryMockRecorder{mock}
+	return mock
+}
+
+// EXPECT returns an object that allows the caller to indicate expected use.
+func (m *MockStatementRepository) EXPECT() *MockStatementRepositoryMockRecorder {
+	return m.recorder
 }
 
-// GetUserIdByEmail mocks base method.
-func (m *MockUserRepository) GetUserIdByEmail(ctx context.Context, email string) (int, error) {
+// GetStatementFile mocks base method.
+func (m *MockStatementRepository) GetStatementFile(ctx context.Context, id int) (*domain.StatementFile, error) {
 	m.ctrl.T.Helper()
-	ret := m.ctrl.Call(m, "GetUserIdByEmail", ctx, email)
-	ret0, _ := ret[0].(int)
+	ret := m.ctrl.Call(m, "GetStatementFile", ctx, id)
+	ret0, _ := ret[0].(*domain.StatementFile)
 	ret1, _ := ret[1].(error)
 	return ret0, ret1
 }
 
-// GetUserIdByEmail indicates an expected call of GetUserIdByEmail.
-func (mr *MockUserRepositoryMockRecorder) GetUserIdByEmail(ctx, email any) *gomock.Call {
+// GetStatementFile indicates an expected call of GetStatementFile.
+func (mr *MockStatementRepositoryMockRecorder) GetStatementFile(ctx, id any) *gomock.Call {
 	mr.mock.ctrl.T.Helper()
-	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "GetUserIdByEmail", reflect.TypeOf((*MockUserRepository)(nil).GetUserIdByEmail), ctx, email)
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "GetStatementFile", reflect.TypeOf((*MockStatementRepository)(nil).GetStatementFile), ctx, id)
 }
 
-// UpdateUser mocks base method.
-func (m *MockUserRepository) UpdateUser(ctx context.Context, id int, userInfo *domain.UserInfo) (*domain.User, error) {
+// ListStatementFiles mocks base method.
+func (m *MockStatementRepository) ListStatementFiles(ctx context.Context, accountId int) ([]*domain.StatementFile, error) {
 	m.ctrl.T.Helper()
-	ret := m.ctrl.Call(m, "UpdateUser", ctx, id, userInfo)
-	ret0, _ := ret[0].(*domain.User)
+	ret := m.ctrl.Call(m, "ListStatementFiles", ctx, accountId)
+	ret0, _ := ret[0].([]*domain.StatementFile)
 	ret1, _ := ret[1].(error)
 	return ret0, ret1
 }
 
-// UpdateUser indicates an expected call of UpdateUser.
-func (mr *MockUserRepositoryMockRecorder) UpdateUser(ctx, id, userInfo any) *gomock.Call {
+// ListStatementFiles indicates an expected call of ListStatementFiles.
+func (mr *MockStatementRepositoryMockRecorder) ListStatementFiles(ctx, accountId any) *gomock.Call {
 	mr.mock.ctrl.T.Helper()
-	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "UpdateUser", reflect.TypeOf((*MockUserRepository)(nil).UpdateUser), ctx, id, userInfo)
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "ListStatementFiles", reflect.TypeOf((*MockStatementRepository)(nil).ListStatementFiles), ctx, accountId)
 }
 
-// UserExistsByEmail mocks base method.
-func (m *MockUserRepository) UserExistsByEmail(ctx context.Context, email string) (bool, error) {
+// MissingStatements mocks base method.
+func (m *MockStatementRepository) MissingStatements(ctx context.Context, before time.Time) ([]*domain.StatementFile, error) {
 	m.ctrl.T.Helper()
-	ret := m.ctrl.Call(m, "UserExistsByEmail", ctx, email)
-	ret0, _ := ret[0].(bool)
+	ret := m.ctrl.Call(m, "MissingStatements", ctx, before)
+	ret0, _ := ret[0].([]*domain.StatementFile)
 	ret1, _ := ret[1].(error)
 	return ret0, ret1
 }
 
-// UserExistsByEmail indicates an expected call of UserExistsByEmail.
-func (mr *MockUserRepositoryMockRecorder) UserExistsByEmail(ctx, email any) *gomock.Call {
+// MissingStatements indicates an expected call of MissingStatements.
+func (mr *MockStatementRepositoryMockRecorder) MissingStatements(ctx, before any) *gomock.Call {
 	mr.mock.ctrl.T.Helper()
-	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "UserExistsByEmail", reflect.TypeOf((*MockUserRepository)(nil).UserExistsByEmail), ctx, email)
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "MissingStatements", reflect.TypeOf((*MockStatementRepository)(nil).MissingStatements), ctx, before)
 }
 
-// UserExistsById mocks base method.
-func (m *MockUserRepository) UserExistsById(ctx context.Context, id int) (bool, error) {
+// SaveStatementFile mocks base method.
+func (m *MockStatementRepository) SaveStatementFile(ctx context.Context, file *domain.StatementFile) error {
 	m.ctrl.T.Helper()
-	ret := m.ctrl.Call(m, "UserExistsById", ctx, id)
-	ret0, _ := ret[0].(bool)
-	ret1, _ := ret[1].(error)
-	return ret0, ret1
+	ret := m.ctrl.Call(m, "SaveStatementFile", ctx, file)
+	ret0, _ := ret[0].(error)
+	return ret0
 }
 
-// UserExistsById indicates an expected call of UserExistsById.
-func (mr *MockUserRepositoryMockRecorder) UserExistsById(ctx, id any) *gomock.Call {
+// SaveStatementFile indicates an expected call of SaveStatementFile.
+func (mr *MockStatementRepositoryMockRecorder) SaveStatementFile(ctx, file any) *gomock.Call {
 	mr.mock.ctrl.T.Helper()
-	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "UserExistsById", reflect.TypeOf((*MockUserRepository)(nil).UserExistsById), ctx, id)
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "SaveStatementFile", reflect.TypeOf((*MockStatementRepository)(nil).SaveStatementFile), ctx, file)
 }
 
-// MockAccountRepository is a mock of AccountRepository interface.
-type MockAccountRepository struct {
+// MockStandingOrderRepository is a mock of StandingOrderRepository interface.
+type MockStandingOrderRepository struct {
 	ctrl     *gomock.Controller
-	recorder *MockAccountRepositoryMockRecorder
+	recorder *MockStandingOrderRepositoryMockRecorder
 }
 
-// MockAccountRepositoryMockRecorder is the mock recorder for MockAccountRepository.
-type MockAccountRepositoryMockRecorder struct {
-	mock *MockAccountRepository
+// MockStandingOrderRepositoryMockRecorder is the mock recorder for MockStandingOrderRepository.
+type MockStandingOrderRepositoryMockRecorder struct {
+	mock *MockStandingOrderRepository
 }
 
-// NewMockAccountRepository creates a new mock instance.
-func NewMockAccountRepository(ctrl *gomock.Controller) *MockAccountRepository {
-	mock := &MockAccountRepository{ctrl: ctrl}
-	mock.recorder = &MockAccountRepositoryMockRecorder{mock}
+// NewMockStandingOrderRepository creates a new mock instance.
+func NewMockStandingOrderRepository(ctrl *gomock.Controller) *MockStandingOrderRepository {
+	mock := &MockStandingOrderRepository{ctrl: ctrl}
+	mock.recorder = &MockStandingOrderRepositoryMockRecorder{mock}
 	return mock
 }
 
 // EXPECT returns an object that allows the caller to indicate expected use.
-func (m *MockAccountRepository) EXPECT() *MockAccountRepositoryMockRecorder {
+func (m *MockStandingOrderRepository) EXPECT() *MockStandingOrderRepositoryMockRecorder {
 	return m.recorder
 }
 
-// AccountExists mocks base method.
-func (m *MockAccountRepository) AccountExists(ctx context.Context, id int) (bool, error) {
+// ClaimDueStandingOrders mocks base method.
+func (m *MockStandingOrderRepository) ClaimDueStandingOrders(ctx context.Context, now time.Time, lease time.Duration, limit int) ([]*domain.StandingOrder, error) {
 	m.ctrl.T.Helper()
-	ret := m.ctrl.Call(m, "AccountExists", ctx, id)
-	ret0, _ := ret[0].(bool)
+	ret := m.ctrl.Call(m, "ClaimDueStandingOrders", ctx, now, lease, limit)
+	ret0, _ := ret[0].([]*domain.StandingOrder)
 	ret1, _ := ret[1].(error)
 	return ret0, ret1
 }
 
-// AccountExists indicates an expected call of AccountExists.
-func (mr *MockAccountRepositoryMockRecorder) AccountExists(ctx, id any) *gomock.Call {
+// ClaimDueStandingOrders indicates an expected call of ClaimDueStandingOrders.
+func (mr *MockStandingOrderRepositoryMockRecorder) ClaimDueStandingOrders(ctx, now, lease, limit any) *gomock.Call {
 	mr.mock.ctrl.T.Helper()
-	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "AccountExists", reflect.TypeOf((*MockAccountRepository)(nil).AccountExists), ctx, id)
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "ClaimDueStandingOrders", reflect.TypeOf((*MockStandingOrderRepository)(nil).ClaimDueStandingOrders), ctx, now, lease, limit)
 }
 
-// CreateAccount mocks base method.
-func (m *MockAccountRepository) CreateAccount(ctx context.Context, userId int, cur domain.Currency) (*domain.Account, error) {
+// CreateStandingOrder mocks base method.
+func (m *MockStandingOrderRepository) CreateStandingOrder(ctx context.Context, o *domain.StandingOrder) error {
 	m.ctrl.T.Helper()
-	ret := m.ctrl.Call(m, "CreateAccount", ctx, userId, cur)
-	ret0, _ := ret[0].(*domain.Account)
-	ret1, _ := ret[1].(error)
-	return ret0, ret1
+	ret := m.ctrl.Call(m, "CreateStandingOrder", ctx, o)
+	ret0, _ := ret[0].(error)
+	return ret0
 }
 
-// CreateAccount indicates an expected call of CreateAccount.
-func (mr *MockAccountRepositoryMockRecorder) CreateAccount(ctx, userId, cur any) *gomock.Call {
+// CreateStandingOrder indicates an expected call of CreateStandingOrder.
+func (mr *MockStandingOrderRepositoryMockRecorder) CreateStandingOrder(ctx, o any) *gomock.Call {
 	mr.mock.ctrl.T.Helper()
-	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "CreateAccount", reflect.TypeOf((*MockAccountRepository)(nil).CreateAccount), ctx, userId, cur)
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "CreateStandingOrder", reflect.TypeOf((*MockStandingOrderRepository)(nil).CreateStandingOrder), ctx, o)
 }
 
-// CurrencyExists mocks base method.
-func (m *MockAccountRepository) CurrencyExists(ctx context.Context, cur domain.Currency) (bool, error) {
+// GetStandingOrder mocks base method.
+func (m *MockStandingOrderRepository) GetStandingOrder(ctx context.Context, id int) (*domain.StandingOrder, error) {
 	m.ctrl.T.Helper()
-	ret := m.ctrl.Call(m, "CurrencyExists", ctx, cur)
-	ret0, _ := ret[0].(bool)
+	ret := m.ctrl.Call(m, "GetStandingOrder", ctx, id)
+	ret0, _ := ret[0].(*domain.StandingOrder)
 	ret1, _ := ret[1].(error)
 	return ret0, ret1
 }
 
-// CurrencyExists indicates an expected call of CurrencyExists.
-func (mr *MockAccountRepositoryMockRecorder) CurrencyExists(ctx, cur any) *gomock.Call {
+// GetStandingOrder indicates an expected call of GetStandingOrder.
+func (mr *MockStandingOrderRepositoryMockRecorder) GetStandingOrder(ctx, id any) *gomock.Call {
 	mr.mock.ctrl.T.Helper()
-	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "CurrencyExists", reflect.TypeOf((*MockAccountRepository)(nil).CurrencyExists), ctx, cur)
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "GetStandingOrder", reflect.TypeOf((*MockStandingOrderRepository)(nil).GetStandingOrder), ctx, id)
 }
 
-// DeleteAccount mocks base method.
-func (m *MockAccountRepository) DeleteAccount(ctx context.Context, id int) error {
+// ListStandingOrderExecutions mocks base method.
+func (m *MockStandingOrderRepository) ListStandingOrderExecutions(ctx context.Context, orderId int) ([]*domain.StandingOrderExecution, error) {
 	m.ctrl.T.Helper()
-	ret := m.ctrl.Call(m, "DeleteAccount", ctx, id)
-	ret0, _ := ret[0].(error)
-	return ret0
+	ret := m.ctrl.Call(m, "ListStandingOrderExecutions", ctx, orderId)
+	ret0, _ := ret[0].([]*domain.StandingOrderExecution)
+	ret1, _ := ret[1].(error)
+	return ret0, ret1
 }
 
-// DeleteAccount indicates an expected call of DeleteAccount.
-func (mr *MockAccountRepositoryMockRecorder) DeleteAccount(ctx, id any) *gomock.Call {
+// ListStandingOrderExecutions indicates an expected call of ListStandingOrderExecutions.
+func (mr *MockStandingOrderRepositoryMockRecorder) ListStandingOrderExecutions(ctx, orderId any) *gomock.Call {
 	mr.mock.ctrl.T.Helper()
-	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "DeleteAccount", reflect.TypeOf((*MockAccountRepository)(nil).DeleteAccount), ctx, id)
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "ListStandingOrderExecutions", reflect.TypeOf((*MockStandingOrderRepository)(nil).ListStandingOrderExecutions), ctx, orderId)
 }
 
-// GetAccount mocks base method.
-func (m *MockAccountRepository) GetAccount(ctx context.Context, id int) (*domain.Account, error) {
+// ListStandingOrders mocks base method.
+func (m *MockStandingOrderRepository) ListStandingOrders(ctx context.Context, userId int) ([]*domain.StandingOrder, error) {
 	m.ctrl.T.Helper()
-	ret := m.ctrl.Call(m, "GetAccount", ctx, id)
-	ret0, _ := ret[0].(*domain.Account)
+	ret := m.ctrl.Call(m, "ListStandingOrders", ctx, userId)
+	ret0, _ := ret[0].([]*domain.StandingOrder)
 	ret1, _ := ret[1].(error)
 	return ret0, ret1
 }
 
-// GetAccount indicates an expected call of GetAccount.
-func (mr *MockAccountRepositoryMockRecorder) GetAccount(ctx, id any) *gomock.Call {
+// ListStandingOrders indicates an expected call of ListStandingOrders.
+func (mr *MockStandingOrderRepositoryMockRecorder) ListStandingOrders(ctx, userId any) *gomock.Call {
 	mr.mock.ctrl.T.Helper()
-	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "GetAccount", reflect.TypeOf((*MockAccountRepository)(nil).GetAccount), ctx, id)
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "ListStandingOrders", reflect.TypeOf((*MockStandingOrderRepository)(nil).ListStandingOrders), ctx, userId)
 }
 
-// GetCurrencyId mocks base method.
-func (m *MockAccountRepository) GetCurrencyId(ctx context.Context, cur domain.Currency) (int, error) {
+// RecordStandingOrderExecution mocks base method.
+func (m *MockStandingOrderRepository) RecordStandingOrderExecution(ctx context.Context, o *domain.StandingOrder, e *domain.StandingOrderExecution) error {
 	m.ctrl.T.Helper()
-	ret := m.ctrl.Call(m, "GetCurrencyId", ctx, cur)
-	ret0, _ := ret[0].(int)
-	ret1, _ := ret[1].(error)
-	return ret0, ret1
+	ret := m.ctrl.Call(m, "RecordStandingOrderExecution", ctx, o, e)
+	ret0, _ := ret[0].(error)
+	return ret0
 }
 
-// GetCurrencyId indicates an expected call of GetCurrencyId.
-func (mr *MockAccountRepositoryMockRecorder) GetCurrencyId(ctx, cur any) *gomock.Call {
+// RecordStandingOrderExecution indicates an expected call of RecordStandingOrderExecution.
+func (mr *MockStandingOrderRepositoryMockRecorder) RecordStandingOrderExecution(ctx, o, e any) *gomock.Call {
 	mr.mock.ctrl.T.Helper()
-	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "GetCurrencyId", reflect.TypeOf((*MockAccountRepository)(nil).GetCurrencyId), ctx, cur)
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "RecordStandingOrderExecution", reflect.TypeOf((*MockStandingOrderRepository)(nil).RecordStandingOrderExecution), ctx, o, e)
 }
 
-// ListTransactions mocks base method.
-func (m *MockAccountRepository) ListTransactions(ctx context.Context, accountId int) ([]*domain.Transaction, error) {
+// UpdateStandingOrder mocks base method.
+func (m *MockStandingOrderRepository) UpdateStandingOrder(ctx context.Context, o *domain.StandingOrder) error {
 	m.ctrl.T.Helper()
-	ret := m.ctrl.Call(m, "ListTransactions", ctx, accountId)
-	ret0, _ := ret[0].([]*domain.Transaction)
-	ret1, _ := ret[1].(error)
-	return ret0, ret1
+	ret := m.ctrl.Call(m, "UpdateStandingOrder", ctx, o)
+	ret0, _ := ret[0].(error)
+	return ret0
 }
 
-// ListTransactions indicates an expected call of ListTransactions.
-func (mr *MockAccountRepositoryMockRecorder) ListTransactions(ctx, accountId any) *gomock.Call {
+// UpdateStandingOrder indicates an expected call of UpdateStandingOrder.
+func (mr *MockStandingOrderRepositoryMockRecorder) UpdateStandingOrder(ctx, o any) *gomock.Call {
 	mr.mock.ctrl.T.Helper()
-	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "ListTransactions", reflect.TypeOf((*MockAccountRepository)(nil).ListTransactions), ctx, accountId)
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "UpdateStandingOrder", reflect.TypeOf((*MockStandingOrderRepository)(nil).UpdateStandingOrder), ctx, o)
 }
 
-// Transaction mocks base method.
-func (m *MockAccountRepository) Transaction(ctx context.Context, accountId, amount int, t domain.TransactionType) error {
+// MockSessionRepository is a mock of SessionRepository interface.
+type MockSessionRepository struct {
+	ctrl     *gomock.Controller
+	recorder *MockSessionRepositoryMockRecorder
+}
+
+// MockSessionRepositoryMockRecorder is the mock recorder for MockSessionRepository.
+type MockSessionRepositoryMockRecorder struct {
+	mock *MockSessionRepository
+}
+
+// NewMockSessionRepository creates a new mock instance.
+func NewMockSessionRepository(ctrl *gomock.Controller) *MockSessionRepository {
+	mock := &MockSessionRepository{ctrl: ctrl}
+	mock.recorder = &MockSessionRepositoryMockRecorder{mock}
+	return mock
+}
+
+// EXPECT returns an object that allows the caller to indicate expected use.
+func (m *MockSessionRepository) EXPECT() *MockSessionRepositoryMockRecorder {
+	return m.recorder
+}
+
+// CreateRefreshToken mocks base method.
+func (m *MockSessionRepository) CreateRefreshToken(ctx context.Context, token *domain.RefreshToken) error {
 	m.ctrl.T.Helper()
-	ret := m.ctrl.Call(m, "Transaction", ctx, accountId, amount, t)
+	ret := m.ctrl.Call(m, "CreateRefreshToken", ctx, token)
 	ret0, _ := ret[0].(error)
 	return ret0
 }
 
-// Transaction indicates an expected call of Transaction.
-func (mr *MockAccountRepositoryMockRecorder) Transaction(ctx, accountId, amount, t any) *gomock.Call {
+// CreateRefreshToken indicates an expected call of CreateRefreshToken.
+func (mr *MockSessionRepositoryMockRecorder) CreateRefreshToken(ctx, token any) *gomock.Call {
 	mr.mock.ctrl.T.Helper()
-	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "Transaction", reflect.TypeOf((*MockAccountRepository)(nil).Transaction), ctx, accountId, amount, t)
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "CreateRefreshToken", reflect.TypeOf((*MockSessionRepository)(nil).CreateRefreshToken), ctx, token)
 }
 
-// Transfer mocks base method.
-func (m *MockAccountRepository) Transfer(ctx context.Context, fromAccountId, toAccountId, amount int) error {
+// CreateSession mocks base method.
+func (m *MockSessionRepository) CreateSession(ctx context.Context, session *domain.Session) error {
 	m.ctrl.T.Helper()
-	ret := m.ctrl.Call(m, "Transfer", ctx, fromAccountId, toAccountId, amount)
+	ret := m.ctrl.Call(m, "CreateSession", ctx, session)
 	ret0, _ := ret[0].(error)
 	return ret0
 }
 
-// Transfer indicates an expected call of Transfer.
-func (mr *MockAccountRepositoryMockRecorder) Transfer(ctx, fromAccountId, toAccountId, amount any) *gomock.Call {
+// CreateSession indicates an expected call of CreateSession.
+func (mr *MockSessionRepositoryMockRecorder) CreateSession(ctx, session any) *gomock.Call {
 	mr.mock.ctrl.T.Helper()
-	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "Transfer", reflect.TypeOf((*MockAccountRepository)(nil).Transfer), ctx, fromAccountId, toAccountId, amount)
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "CreateSession", reflect.TypeOf((*MockSessionRepository)(nil).CreateSession), ctx, session)
 }
 
-// UpdateAccount mocks base method.
-func (m *MockAccountRepository) UpdateAccount(ctx context.Context, id, amount int) (*domain.Account, error) {
+// GetSession mocks base method.
+func (m *MockSessionRepository) GetSession(ctx context.Context, id int) (*domain.Session, error) {
 	m.ctrl.T.Helper()
-	ret := m.ctrl.Call(m, "UpdateAccount", ctx, id, amount)
-	ret0, _ := ret[0].(*domain.Account)
+	ret := m.ctrl.Call(m, "GetSession", ctx, id)
+	ret0, _ := ret[0].(*domain.Session)
 	ret1, _ := ret[1].(error)
 	return ret0, ret1
 }
 
-// UpdateAccount indicates an expected call of UpdateAccount.
-func (mr *MockAccountRepositoryMockRecorder) UpdateAccount(ctx, id, amount any) *gomock.Call {
+// GetSession indicates an expected call of GetSession.
+func (mr *MockSessionRepositoryMockRecorder) GetSession(ctx, id any) *gomock.Call {
 	mr.mock.ctrl.T.Helper()
-	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "UpdateAccount", reflect.TypeOf((*MockAccountRepository)(nil).UpdateAccount), ctx, id, amount)
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "GetSession", reflect.TypeOf((*MockSessionRepository)(nil).GetSession), ctx, id)
 }
 
-// UserExistsById mocks base method.
-func (m *MockAccountRepository) UserExistsById(ctx context.Context, id int) (bool, error) {
+// RevokeSession mocks base method.
+func (m *MockSessionRepository) RevokeSession(ctx context.Context, id int) error {
 	m.ctrl.T.Helper()
-	ret := m.ctrl.Call(m, "UserExistsById", ctx, id)
-	ret0, _ := ret[0].(bool)
+	ret := m.ctrl.Call(m, "RevokeSession", ctx, id)
+	ret0, _ := ret[0].(error)
+	return ret0
+}
+
+// RevokeSession indicates an expected call of RevokeSession.
+func (mr *MockSessionRepositoryMockRecorder) RevokeSession(ctx, id any) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "RevokeSession", reflect.TypeOf((*MockSessionRepository)(nil).RevokeSession), ctx, id)
+}
+
+// RevokeUserSessions mocks base method.
+func (m *MockSessionRepository) RevokeUserSessions(ctx context.Context, userId, exceptId int) ([]int, error) {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "RevokeUserSessions", ctx, userId, exceptId)
+	ret0, _ := ret[0].([]int)
 	ret1, _ := ret[1].(error)
 	return ret0, ret1
 }
 
-// UserExistsById indicates an expected call of UserExistsById.
-func (mr *MockAccountRepositoryMockRecorder) UserExistsById(ctx, id any) *gomock.Call {
+// RevokeUserSessions indicates an expected call of RevokeUserSessions.
+func (mr *MockSessionRepositoryMockRecorder) RevokeUserSessions(ctx, userId, exceptId any) *gomock.Call {
 	mr.mock.ctrl.T.Helper()
-	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "UserExistsById", reflect.TypeOf((*MockAccountRepository)(nil).UserExistsById), ctx, id)
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "RevokeUserSessions", reflect.TypeOf((*MockSessionRepository)(nil).RevokeUserSessions), ctx, userId, exceptId)
+}
+
+// UseRefreshToken mocks base method.
+func (m *MockSessionRepository) UseRefreshToken(ctx context.Context, hash []byte, now time.Time) (*domain.RefreshToken, error) {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "UseRefreshToken", ctx, hash, now)
+	ret0, _ := ret[0].(*domain.RefreshToken)
+	ret1, _ := ret[1].(error)
+	return ret0, ret1
+}
+
+// UseRefreshToken indicates an expected call of UseRefreshToken.
+func (mr *MockSessionRepositoryMockRecorder) UseRefreshToken(ctx, hash, now any) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "UseRefreshToken", reflect.TypeOf((*MockSessionRepository)(nil).UseRefreshToken), ctx, hash, now)
 }
 
-// MockRepository is a mock of Repository interface.
-type MockRepository struct {
+// MockAPIKeyRepository is a mock of APIKeyRepository interface.
+type MockAPIKeyRepository struct {
 	ctrl     *gomock.Controller
-	recorder *MockRepositoryMockRecorder
+	recorder *MockAPIKeyRepositoryMockRecorder
 }
 
-// MockRepositoryMockRecorder is the mock recorder for MockRepository.
-type MockRepositoryMockRecorder struct {
-	mock *MockRepository
+// MockAPIKeyRepositoryMockRecorder is the mock recorder for MockAPIKeyRepository.
+type MockAPIKeyRepositoryMockRecorder struct {
+	mock *MockAPIKeyRepository
 }
 
-// NewMockRepository creates a new mock instance.
-func NewMockRepository(ctrl *gomock.Controller) *MockRepository {
-	mock := &MockRepository{ctrl: ctrl}
-	mock.recorder = &MockRepositoryMockRecorder{mock}
+// NewMockAPIKeyRepository creates a new mock instance.
+func NewMockAPIKeyRepository(ctrl *gomock.Controller) *MockAPIKeyRepository {
+	mock := &MockAPIKeyRepository{ctrl: ctrl}
+	mock.recorder = &MockAPIKeyRepositoryMockRecorder{mock}
 	return mock
 }
 
 // EXPECT returns an object that allows the caller to indicate expected use.
-func (m *MockRepository) EXPECT() *MockRepositoryMockRecorder {
+func (m *MockAPIKeyRepository) EXPECT() *MockAPIKeyRepositoryMockRecorder {
 	return m.recorder
 }
 
-// AccountExists mocks base method.
-func (m *MockRepository) AccountExists(ctx context.Context, id int) (bool, error) {
+// CreateAPIKey mocks base method.
+func (m *MockAPIKeyRepository) CreateAPIKey(ctx context.Context, k *domain.APIKey) error {
 	m.ctrl.T.Helper()
-	ret := m.ctrl.Call(m, "AccountExists", ctx, id)
-	ret0, _ := ret[0].(bool)
-	ret1, _ := ret[1].(error)
-	return ret0, ret1
+	ret := m.ctrl.Call(m, "CreateAPIKey", ctx, k)
+	ret0, _ := ret[0].(error)
+	return ret0
 }
 
-// AccountExists indicates an expected call of AccountExists.
-func (mr *MockRepositoryMockRecorder) AccountExists(ctx, id any) *gomock.Call {
+// CreateAPIKey indicates an expected call of CreateAPIKey.
+func (mr *MockAPIKeyRepositoryMockRecorder) CreateAPIKey(ctx, k any) *gomock.Call {
 	mr.mock.ctrl.T.Helper()
-	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "AccountExists", reflect.TypeOf((*MockRepository)(nil).AccountExists), ctx, id)
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "CreateAPIKey", reflect.TypeOf((*MockAPIKeyRepository)(nil).CreateAPIKey), ctx, k)
 }
 
-// CreateAccount mocks base method.
-func (m *MockRepository) CreateAccount(ctx context.Context, userId int, cur domain.Currency) (*domain.Account, error) {
+// GetAPIKey mocks base method.
+func (m *MockAPIKeyRepository) GetAPIKey(ctx context.Context, id int) (*domain.APIKey, error) {
 	m.ctrl.T.Helper()
-	ret := m.ctrl.Call(m, "CreateAccount", ctx, userId, cur)
-	ret0, _ := ret[0].(*domain.Account)
+	ret := m.ctrl.Call(m, "GetAPIKey", ctx, id)
+	ret0, _ := ret[0].(*domain.APIKey)
 	ret1, _ := ret[1].(error)
 	return ret0, ret1
 }
 
-// CreateAccount indicates an expected call of CreateAccount.
-func (mr *MockRepositoryMockRecorder) CreateAccount(ctx, userId, cur any) *gomock.Call {
+// GetAPIKey indicates an expected call of GetAPIKey.
+func (mr *MockAPIKeyRepositoryMockRecorder) GetAPIKey(ctx, id any) *gomock.Call {
 	mr.mock.ctrl.T.Helper()
-	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "CreateAccount", reflect.TypeOf((*MockRepository)(nil).CreateAccount), ctx, userId, cur)
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "GetAPIKey", reflect.TypeOf((*MockAPIKeyRepository)(nil).GetAPIKey), ctx, id)
 }
 
-// CreateUser mocks base method.
-func (m *MockRepository) CreateUser(ctx context.Context, userInfo *domain.UserInfo) (*domain.User, error) {
+// GetAPIKeyByPrefix mocks base method.
+func (m *MockAPIKeyRepository) GetAPIKeyByPrefix(ctx context.Context, prefix string) (*domain.APIKey, error) {
 	m.ctrl.T.Helper()
-	ret := m.ctrl.Call(m, "CreateUser", ctx, userInfo)
-	ret0, _ := ret[0].(*domain.User)
+	ret := m.ctrl.Call(m, "GetAPIKeyByPrefix", ctx, prefix)
+	ret0, _ := ret[0].(*domain.APIKey)
 	ret1, _ := ret[1].(error)
 	return ret0, ret1
 }
 
-// CreateUser indicates an expected call of CreateUser.
-func (mr *MockRepositoryMockRecorder) CreateUser(ctx, userInfo any) *gomock.Call {
+// GetAPIKeyByPrefix indicates an expected call of GetAPIKeyByPrefix.
+func (mr *MockAPIKeyRepositoryMockRecorder) GetAPIKeyByPrefix(ctx, prefix any) *gomock.Call {
 	mr.mock.ctrl.T.Helper()
-	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "CreateUser", reflect.TypeOf((*MockRepository)(nil).CreateUser), ctx, userInfo)
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "GetAPIKeyByPrefix", reflect.TypeOf((*MockAPIKeyRepository)(nil).GetAPIKeyByPrefix), ctx, prefix)
 }
 
-// CurrencyExists mocks base method.
-func (m *MockRepository) CurrencyExists(ctx context.Context, cur domain.Currency) (bool, error) {
+// ListAPIKeys mocks base method.
+func (m *MockAPIKeyRepository) ListAPIKeys(ctx context.Context, userId int) ([]*domain.APIKey, error) {
 	m.ctrl.T.Helper()
-	ret := m.ctrl.Call(m, "CurrencyExists", ctx, cur)
-	ret0, _ := ret[0].(bool)
+	ret := m.ctrl.Call(m, "ListAPIKeys", ctx, userId)
+	ret0, _ := ret[0].([]*domain.APIKey)
 	ret1, _ := ret[1].(error)
 	return ret0, ret1
 }
 
-// CurrencyExists indicates an expected call of CurrencyExists.
-func (mr *MockRepositoryMockRecorder) CurrencyExists(ctx, cur any) *gomock.Call {
+// ListAPIKeys indicates an expected call of ListAPIKeys.
+func (mr *MockAPIKeyRepositoryMockRecorder) ListAPIKeys(ctx, userId any) *gomock.Call {
 	mr.mock.ctrl.T.Helper()
-	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "CurrencyExists", reflect.TypeOf((*MockRepository)(nil).CurrencyExists), ctx, cur)
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "ListAPIKeys", reflect.TypeOf((*MockAPIKeyRepository)(nil).ListAPIKeys), ctx, userId)
 }
 
-// DeleteAccount mocks base method.
-func (m *MockRepository) DeleteAccount(ctx context.Context, id int) error {
+// RevokeAPIKey mocks base method.
+func (m *MockAPIKeyRepository) RevokeAPIKey(ctx context.Context, id int, now time.Time) error {
 	m.ctrl.T.Helper()
-	ret := m.ctrl.Call(m, "DeleteAccount", ctx, id)
+	ret := m.ctrl.Call(m, "RevokeAPIKey", ctx, id, now)
 	ret0, _ := ret[0].(error)
 	return ret0
 }
 
-// DeleteAccount indicates an expected call of DeleteAccount.
-func (mr *MockRepositoryMockRecorder) DeleteAccount(ctx, id any) *gomock.Call {
+// RevokeAPIKey indicates an expected call of RevokeAPIKey.
+func (mr *MockAPIKeyRepositoryMockRecorder) RevokeAPIKey(ctx, id, now any) *gomock.Call {
 	mr.mock.ctrl.T.Helper()
-	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "DeleteAccount", reflect.TypeOf((*MockRepository)(nil).DeleteAccount), ctx, id)
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "RevokeAPIKey", reflect.TypeOf((*MockAPIKeyRepository)(nil).RevokeAPIKey), ctx, id, now)
 }
 
-// DeleteUser mocks base method.
-func (m *MockRepository) DeleteUser(ctx context.Context, id int) error {
+// TouchAPIKey mocks base method.
+func (m *MockAPIKeyRepository) TouchAPIKey(ctx context.Context, id int, now time.Time) error {
 	m.ctrl.T.Helper()
-	ret := m.ctrl.Call(m, "DeleteUser", ctx, id)
+	ret := m.ctrl.Call(m, "TouchAPIKey", ctx, id, now)
 	ret0, _ := ret[0].(error)
 	return ret0
 }
 
-// DeleteUser indicates an expected call of DeleteUser.
-func (mr *MockRepositoryMockRecorder) DeleteUser(ctx, id any) *gomock.Call {
+// TouchAPIKey indicates an expected call of TouchAPIKey.
+func (mr *MockAPIKeyRepositoryMockRecorder) TouchAPIKey(ctx, id, now any) *gomock.Call {
 	mr.mock.ctrl.T.Helper()
-	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "DeleteUser", reflect.TypeOf((*MockRepository)(nil).DeleteUser), ctx, id)
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "TouchAPIKey", reflect.TypeOf((*MockAPIKeyRepository)(nil).TouchAPIKey), ctx, id, now)
 }
 
-// GetAccount mocks base method.
-func (m *MockRepository) GetAccount(ctx context.Context, id int) (*domain.Account, error) {
-	m.ctrl.T.Helper()
-	ret := m.ctrl.Call(m, "GetAccount", ctx, id)
-	ret0, _ := ret[0].(*domain.Account)
-	ret1, _ := ret[1].(error)
-	return ret0, ret1
+// MockAdminRepository is a mock of AdminRepository interface.
+type MockAdminRepository struct {
+	ctrl     *gomock.Controller
+	recorder *MockAdminRepositoryMockRecorder
 }
 
-// GetAccount indicates an expected call of GetAccount.
-func (mr *MockRepositoryMockRecorder) GetAccount(ctx, id any) *gomock.Call {
-	mr.mock.ctrl.T.Helper()
-	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "GetAccount", reflect.TypeOf((*MockRepository)(nil).GetAccount), ctx, id)
+// MockAdminRepositoryMockRecorder is the mock recorder for MockAdminRepository.
+type MockAdminRepositoryMockRecorder struct {
+	mock *MockAdminRepository
 }
 
-// GetCurrencyId mocks base method.
-func (m *MockRepository) GetCurrencyId(ctx context.Context, cur domain.Currency) (int, error) {
-	m.ctrl.T.Helper()
-	ret := m.ctrl.Call(m, "GetCurrencyId", ctx, cur)
-	ret0, _ := ret[0].(int)
-	ret1, _ := ret[1].(error)
-	return ret0, ret1
+// NewMockAdminRepository creates a new mock instance.
+func NewMockAdminRepository(ctrl *gomock.Controller) *MockAdminRepository {
+	mock := &MockAdminRepository{ctrl: ctrl}
+	mock.recorder = &MockAdminRepositoryMockRecorder{mock}
+	return mock
 }
 
-// GetCurrencyId indicates an expected call of GetCurrencyId.
-func (mr *MockRepositoryMockRecorder) GetCurrencyId(ctx, cur any) *gomock.Call {
-	mr.mock.ctrl.T.Helper()
-	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "GetCurrencyId", reflect.TypeOf((*MockRepository)(nil).GetCurrencyId), ctx, cur)
+// EXPECT returns an object that allows the caller to indicate expected use.
+func (m *MockAdminRepository) EXPECT() *MockAdminRepositoryMockRecorder {
+	return m.recorder
 }
 
-// GetUser mocks base method.
-func (m *MockRepository) GetUser(ctx context.Context, id int) (*domain.User, error) {
+// FreezeAccount mocks base method.
+func (m *MockAdminRepository) FreezeAccount(ctx context.Context, accountId int, reason string, now time.Time, entry *domain.AuditEntry) (bool, error) {
 	m.ctrl.T.Helper()
-	ret := m.ctrl.Call(m, "GetUser", ctx, id)
-	ret0, _ := ret[0].(*domain.User)
+	ret := m.ctrl.Call(m, "FreezeAccount", ctx, accountId, reason, now, entry)
+	ret0, _ := ret[0].(bool)
 	ret1, _ := ret[1].(error)
 	return ret0, ret1
 }
 
-// GetUser indicates an expected call of GetUser.
-func (mr *MockRepositoryMockRecorder) GetUser(ctx, id any) *gomock.Call {
+// FreezeAccount indicates an expected call of FreezeAccount.
+func (mr *MockAdminRepositoryMockRecorder) FreezeAccount(ctx, accountId, reason, now, entry any) *gomock.Call {
 	mr.mock.ctrl.T.Helper()
-	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "GetUser", reflect.TypeOf((*MockRepository)(nil).GetUser), ctx, id)
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "FreezeAccount", reflect.TypeOf((*MockAdminRepository)(nil).FreezeAccount), ctx, accountId, reason, now, entry)
 }
 
-// GetUserIdByEmail mocks base method.
-func (m *MockRepository) GetUserIdByEmail(ctx context.Context, email string) (int, error) {
+// ListAuditEntries mocks base method.
+func (m *MockAdminRepository) ListAuditEntries(ctx context.Context, filter *domain.AuditFilter) ([]*domain.AuditEntry, error) {
 	m.ctrl.T.Helper()
-	ret := m.ctrl.Call(m, "GetUserIdByEmail", ctx, email)
-	ret0, _ := ret[0].(int)
+	ret := m.ctrl.Call(m, "ListAuditEntries", ctx, filter)
+	ret0, _ := ret[0].([]*domain.AuditEntry)
 	ret1, _ := ret[1].(error)
 	return ret0, ret1
 }
 
-// GetUserIdByEmail indicates an expected call of GetUserIdByEmail.
-func (mr *MockRepositoryMockRecorder) GetUserIdByEmail(ctx, email any) *gomock.Call {
+// ListAuditEntries indicates an expected call of ListAuditEntries.
+func (mr *MockAdminRepositoryMockRecorder) ListAuditEntries(ctx, filter any) *gomock.Call {
 	mr.mock.ctrl.T.Helper()
-	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "GetUserIdByEmail", reflect.TypeOf((*MockRepository)(nil).GetUserIdByEmail), ctx, email)
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "ListAuditEntries", reflect.TypeOf((*MockAdminRepository)(nil).ListAuditEntries), ctx, filter)
 }
 
-// ListTransactions mocks base method.
-func (m *MockRepository) ListTransactions(ctx context.Context, accountId int) ([]*domain.Transaction, error) {
+// ListUserAccounts mocks base method.
+func (m *MockAdminRepository) ListUserAccounts(ctx context.Context, userId int) ([]*domain.Account, error) {
 	m.ctrl.T.Helper()
-	ret := m.ctrl.Call(m, "ListTransactions", ctx, accountId)
-	ret0, _ := ret[0].([]*domain.Transaction)
+	ret := m.ctrl.Call(m, "ListUserAccounts", ctx, userId)
+	ret0, _ := ret[0].([]*domain.Account)
 	ret1, _ := ret[1].(error)
 	return ret0, ret1
 }
 
-// ListTransactions indicates an expected call of ListTransactions.
-func (mr *MockRepositoryMockRecorder) ListTransactions(ctx, accountId any) *gomock.Call {
-	mr.mock.ctrl.T.Helper()
-	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "ListTransactions", reflect.TypeOf((*MockRepository)(nil).ListTransactions), ctx, accountId)
-}
-
-// Transaction mocks base method.
-func (m *MockRepository) Transaction(ctx context.Context, accountId, amount int, t domain.TransactionType) error {
-	m.ctrl.T.Helper()
-	ret := m.ctrl.Call(m, "Transaction", ctx, accountId, amount, t)
-	ret0, _ := ret[0].(error)
-	return ret0
-}
-
-// Transaction indicates an expected call of Transaction.
-func (mr *MockRepositoryMockRecorder) Transaction(ctx, accountId, amount, t any) *gomock.Call {
+// ListUserAccounts indicates an expected call of ListUserAccounts.
+func (mr *MockAdminRepositoryMockRecorder) ListUserAccounts(ctx, userId any) *gomock.Call {
 	mr.mock.ctrl.T.Helper()
-	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "Transaction", reflect.TypeOf((*MockRepository)(nil).Transaction), ctx, accountId, amount, t)
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "ListUserAccounts", reflect.TypeOf((*MockAdminRepository)(nil).ListUserAccounts), ctx, userId)
 }
 
-// Transfer mocks base method.
-func (m *MockRepository) Transfer(ctx context.Context, fromAccountId, toAccountId, amount int) error {
+// RecordAudit mocks base method.
+func (m *MockAdminRepository) RecordAudit(ctx context.Context, entry *domain.AuditEntry) error {
 	m.ctrl.T.Helper()
-	ret := m.ctrl.Call(m, "Transfer", ctx, fromAccountId, toAccountId, amount)
+	ret := m.ctrl.Call(m, "RecordAudit", ctx, entry)
 	ret0, _ := ret[0].(error)
 	return ret0
 }
 
-// Transfer indicates an expected call of Transfer.
-func (mr *MockRepositoryMockRecorder) Transfer(ctx, fromAccountId, toAccountId, amount any) *gomock.Call {
-	mr.mock.ctrl.T.Helper()
-	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "Transfer", reflect.TypeOf((*MockRepository)(nil).Transfer), ctx, fromAccountId, toAccountId, amount)
-}
-
-// UpdateAccount mocks base method.
-func (m *MockRepository) UpdateAccount(ctx context.Context, id, amount int) (*domain.Account, error) {
-	m.ctrl.T.Helper()
-	ret := m.ctrl.Call(m, "UpdateAccount", ctx, id, amount)
-	ret0, _ := ret[0].(*domain.Account)
-	ret1, _ := ret[1].(error)
-	return ret0, ret1
-}
-
-// UpdateAccount indicates an expected call of UpdateAccount.
-func (mr *MockRepositoryMockRecorder) UpdateAccount(ctx, id, amount any) *gomock.Call {
+// RecordAudit indicates an expected call of RecordAudit.
+func (mr *MockAdminRepositoryMockRecorder) RecordAudit(ctx, entry any) *gomock.Call {
 	mr.mock.ctrl.T.Helper()
-	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "UpdateAccount", reflect.TypeOf((*MockRepository)(nil).UpdateAccount), ctx, id, amount)
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "RecordAudit", reflect.TypeOf((*MockAdminRepository)(nil).RecordAudit), ctx, entry)
 }
 
-// UpdateUser mocks base method.
-func (m *MockRepository) UpdateUser(ctx context.Context, id int, userInfo *domain.UserInfo) (*domain.User, error) {
+// SearchUsers mocks base method.
+func (m *MockAdminRepository) SearchUsers(ctx context.Context, filter *domain.UserFilter) ([]*domain.User, error) {
 	m.ctrl.T.Helper()
-	ret := m.ctrl.Call(m, "UpdateUser", ctx, id, userInfo)
-	ret0, _ := ret[0].(*domain.User)
+	ret := m.ctrl.Call(m, "SearchUsers", ctx, filter)
+	ret0, _ := ret[0].([]*domain.User)
 	ret1, _ := ret[1].(error)
 	return ret0, ret1
 }
 
-// UpdateUser indicates an expected call of UpdateUser.
-func (mr *MockRepositoryMockRecorder) UpdateUser(ctx, id, userInfo any) *gomock.Call {
+// SearchUsers indicates an expected call of SearchUsers.
+func (mr *MockAdminRepositoryMockRecorder) SearchUsers(ctx, filter any) *gomock.Call {
 	mr.mock.ctrl.T.Helper()
-	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "UpdateUser", reflect.TypeOf((*MockRepository)(nil).UpdateUser), ctx, id, userInfo)
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "SearchUsers", reflect.TypeOf((*MockAdminRepository)(nil).SearchUsers), ctx, filter)
 }
 
-// UserExistsByEmail mocks base method.
-func (m *MockRepository) UserExistsByEmail(ctx context.Context, email string) (bool, error) {
+// SetUserRole mocks base method.
+func (m *MockAdminRepository) SetUserRole(ctx context.Context, userId int, role domain.Role, entry *domain.AuditEntry) error {
 	m.ctrl.T.Helper()
-	ret := m.ctrl.Call(m, "UserExistsByEmail", ctx, email)
-	ret0, _ := ret[0].(bool)
-	ret1, _ := ret[1].(error)
-	return ret0, ret1
+	ret := m.ctrl.Call(m, "SetUserRole", ctx, userId, role, entry)
+	ret0, _ := ret[0].(error)
+	return ret0
 }
 
-// UserExistsByEmail indicates an expected call of UserExistsByEmail.
-func (mr *MockRepositoryMockRecorder) UserExistsByEmail(ctx, email any) *gomock.Call {
+// SetUserRole indicates an expected call of SetUserRole.
+func (mr *MockAdminRepositoryMockRecorder) SetUserRole(ctx, userId, role, entry any) *gomock.Call {
 	mr.mock.ctrl.T.Helper()
-	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "UserExistsByEmail", reflect.TypeOf((*MockRepository)(nil).UserExistsByEmail), ctx, email)
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "SetUserRole", reflect.TypeOf((*MockAdminRepository)(nil).SetUserRole), ctx, userId, role, entry)
 }
 
-// UserExistsById mocks base method.
-func (m *MockRepository) UserExistsById(ctx context.Context, id int) (bool, error) {
+// UnfreezeAccount mocks base method.
+func (m *MockAdminRepository) UnfreezeAccount(ctx context.Context, accountId int, entry *domain.AuditEntry) (bool, error) {
 	m.ctrl.T.Helper()
-	ret := m.ctrl.Call(m, "UserExistsById", ctx, id)
+	ret := m.ctrl.Call(m, "UnfreezeAccount", ctx, accountId, entry)
 	ret0, _ := ret[0].(bool)
 	ret1, _ := ret[1].(error)
 	return ret0, ret1
 }
 
-// UserExistsById indicates an expected call of UserExistsById.
-func (mr *MockRepositoryMockRecorder) UserExistsById(ctx, id any) *gomock.Call {
+// UnfreezeAccount indicates an expected call of UnfreezeAccount.
+func (mr *MockAdminRepositoryMockRecorder) UnfreezeAccount(ctx, accountId, entry any) *gomock.Call {
 	mr.mock.ctrl.T.Helper()
-	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "UserExistsById", reflect.TypeOf((*MockRepository)(nil).UserExistsById), ctx, id)
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "UnfreezeAccount", reflect.TypeOf((*MockAdminRepository)(nil).UnfreezeAccount), ctx, accountId, entry)
 }
@@ -1,6 +1,8 @@
 package config
 
 import (
+	"time"
+
 	"github.com/joho/godotenv"
 	"github.com/kelseyhightower/envconfig"
 	"go.uber.org/zap"
@@ -10,7 +12,74 @@ type Config struct {
 	HttpPort      string `envconfig:"HTTP_PORT" default:"8080"`
 	DbUrl         string `envconfig:"DB_URL" required:"true"`
 	MigrationPath string `envconfig:"MIGRATION_PATH" required:"true"`
-	JwtSecret     string `envconfig:"JWT_SECRET" required:"true"`
+	JwtSecret     string `envconfig:"JWT_SECRET"`
+
+	JwtIssuer   string        `envconfig:"JWT_ISSUER" default:"bank-api"`
+	JwtAudience string        `envconfig:"JWT_AUDIENCE" default:"bank-api"`
+	JwtLeeway   time.Duration `envconfig:"JWT_LEEWAY" default:"30s"`
+	// JwtSigningKey and JwtRetiredKeys are paths to PEM files. Without a signing key tokens are signed with JwtSecret.
+	JwtSigningKey  string   `envconfig:"JWT_SIGNING_KEY"`
+	JwtRetiredKeys []string `envconfig:"JWT_RETIRED_KEYS"`
+
+	AccessTokenTTL  time.Duration `envconfig:"ACCESS_TOKEN_TTL" default:"15m"`
+	RefreshTokenTTL time.Duration `envconfig:"REFRESH_TOKEN_TTL" default:"720h"`
+	SessionCacheTTL time.Duration `envconfig:"SESSION_CACHE_TTL" default:"30s"`
+
+	// TwoFactorKey encrypts the TOTP secrets: 32 bytes in base64. Without it 2FA is disabled.
+	TwoFactorKey          string        `envconfig:"TWO_FACTOR_KEY"`
+	TwoFactorIssuer       string        `envconfig:"TWO_FACTOR_ISSUER" default:"Bank API"`
+	TwoFactorChallengeTTL time.Duration `envconfig:"TWO_FACTOR_CHALLENGE_TTL" default:"5m"`
+
+	// TrustedProxies are the addresses of the proxies whose X-Forwarded-For header gives the client IP address.
+	TrustedProxies []string `envconfig:"TRUSTED_PROXIES"`
+
+	// Failed logins with an email are delayed, doubling from LoginBackoffBase, after LoginFreeAttempts and locked out for
+	// LoginLockout after LoginMaxFailures of an email or LoginIPMaxFailures from an IP address.
+	LoginFreeAttempts  int           `envconfig:"LOGIN_FREE_ATTEMPTS" default:"3"`
+	LoginBackoffBase   time.Duration `envconfig:"LOGIN_BACKOFF_BASE" default:"1s"`
+	LoginBackoffMax    time.Duration `envconfig:"LOGIN_BACKOFF_MAX" default:"5m"`
+	LoginMaxFailures   int           `envconfig:"LOGIN_MAX_FAILURES" default:"10"`
+	LoginIPMaxFailures int           `envconfig:"LOGIN_IP_MAX_FAILURES" default:"100"`
+	LoginLockout       time.Duration `envconfig:"LOGIN_LOCKOUT" default:"15m"`
+	LoginFailureWindow time.Duration `envconfig:"LOGIN_FAILURE_WINDOW" default:"1h"`
+
+	// MailDriver is how emails are sent: "smtp", "file" to append them to MailFile, or "log" to print them.
+	MailDriver   string `envconfig:"MAIL_DRIVER" default:"log"`
+	MailFile     string `envconfig:"MAIL_FILE" default:"mail.log"`
+	MailFrom     string `envconfig:"MAIL_FROM" default:"Bank API <no-reply@bank-api.local>"`
+	SmtpHost     string `envconfig:"SMTP_HOST"`
+	SmtpPort     string `envconfig:"SMTP_PORT" default:"587"`
+	SmtpUsername string `envconfig:"SMTP_USERNAME"`
+	SmtpPassword string `envconfig:"SMTP_PASSWORD"`
+
+	// PasswordResetURL is the page reset emails link to, with the token appended, e.g.
+	// https://bank.example/reset-password?token=. Without it the emails contain the bare token.
+	PasswordResetURL      string        `envconfig:"PASSWORD_RESET_URL"`
+	PasswordResetTTL      time.Duration `envconfig:"PASSWORD_RESET_TTL" default:"1h"`
+	PasswordResetInterval time.Duration `envconfig:"PASSWORD_RESET_INTERVAL" default:"1m"`
+	// PasswordResetSendInterval is how often the requested reset emails are sent.
+	PasswordResetSendInterval time.Duration `envconfig:"PASSWORD_RESET_SEND_INTERVAL" default:"5s"`
+
+	// EmailVerificationURL is the page verification emails link to, with the token appended, like PasswordResetURL.
+	EmailVerificationURL      string        `envconfig:"EMAIL_VERIFICATION_URL"`
+	EmailVerificationTTL      time.Duration `envconfig:"EMAIL_VERIFICATION_TTL" default:"24h"`
+	EmailVerificationInterval time.Duration `envconfig:"EMAIL_VERIFICATION_INTERVAL" default:"1m"`
+
+	IdempotencyKeyTTL time.Duration `envconfig:"IDEMPOTENCY_KEY_TTL" default:"24h"`
+	FxQuoteTTL        time.Duration `envconfig:"FX_QUOTE_TTL" default:"30s"`
+	HoldTTL           time.Duration `envconfig:"HOLD_TTL" default:"168h"`
+	HoldSweepInterval time.Duration `envconfig:"HOLD_SWEEP_INTERVAL" default:"1m"`
+	// PendingTimeout is how long after a transaction is recorded it is failed if it's still pending,
+	// which only happens if the instance posting it went away.
+	PendingTimeout       time.Duration `envconfig:"PENDING_TIMEOUT" default:"5m"`
+	PendingSweepInterval time.Duration `envconfig:"PENDING_SWEEP_INTERVAL" default:"1m"`
+
+	// StatementRetryInterval is how soon monthly statements are generated again after a run that failed.
+	StatementRetryInterval time.Duration `envconfig:"STATEMENT_RETRY_INTERVAL" default:"5m"`
+
+	StandingOrderInterval    time.Duration `envconfig:"STANDING_ORDER_INTERVAL" default:"1m"`
+	StandingOrderMaxAttempts int           `envconfig:"STANDING_ORDER_MAX_ATTEMPTS" default:"3"`
+	StandingOrderRetryDelay  time.Duration `envconfig:"STANDING_ORDER_RETRY_DELAY" default:"1h"`
 }
 
 func LoadConfig(log *zap.SugaredLogger) *Config {
@@ -0,0 +1,185 @@
+// Package fxrates parses exchange rate files: the European Central Bank daily reference rates XML
+// (eurofxref-daily.xml and its 90-day and historical variants) and a plain CSV with one rate per line.
+package fxrates
+
+import (
+	"encoding/csv"
+	"encoding/xml"
+	"errors"
+	"fmt"
+	"io"
+	"math/big"
+	"path/filepath"
+	"strings"
+	"time"
+)
+
+const (
+	FormatXML = "xml"
+	FormatCSV = "csv"
+
+	// ecbBase is the currency all ECB reference rates are quoted against.
+	ecbBase = "EUR"
+
+	dateLayout = "2006-01-02"
+)
+
+var (
+	ErrUnknownFormat = errors.New("unknown rates file format")
+	ErrInvalidFile   = errors.New("invalid rates file")
+)
+
+// Rate tells that one unit of Base was worth Value units of Quote starting from EffectiveAt.
+type Rate struct {
+	Base        string
+	Quote       string
+	Value       string
+	EffectiveAt time.Time
+}
+
+// FormatOf guesses the format of a rates file by its extension.
+func FormatOf(path string) (string, error) {
+	switch strings.ToLower(filepath.Ext(path)) {
+	case ".xml":
+		return FormatXML, nil
+	case ".csv":
+		return FormatCSV, nil
+	default:
+		return "", ErrUnknownFormat
+	}
+}
+
+func Parse(r io.Reader, format string) ([]Rate, error) {
+	switch format {
+	case FormatXML:
+		return ParseECB(r)
+	case FormatCSV:
+		return ParseCSV(r)
+	default:
+		return nil, ErrUnknownFormat
+	}
+}
+
+type ecbEnvelope struct {
+	Days []struct {
+		Time  string `xml:"time,attr"`
+		Rates []struct {
+			Currency string `xml:"currency,attr"`
+			Rate     string `xml:"rate,attr"`
+		} `xml:"Cube"`
+	} `xml:"Cube>Cube"`
+}
+
+// ParseECB parses the ECB reference rates XML, in which every rate is the price of one euro.
+func ParseECB(r io.Reader) ([]Rate, error) {
+	var envelope ecbEnvelope
+	if err := xml.NewDecoder(r).Decode(&envelope); err != nil {
+		return nil, fmt.Errorf("%w: %v", ErrInvalidFile, err)
+	}
+	if len(envelope.Days) == 0 {
+		return nil, fmt.Errorf("%w: no rates found", ErrInvalidFile)
+	}
+
+	var rates []Rate
+	for _, day := range envelope.Days {
+		effectiveAt, err := parseDate(day.Time)
+		if err != nil {
+			return nil, err
+		}
+		for _, rate := range day.Rates {
+			parsed, err := newRate(ecbBase, rate.Currency, rate.Rate, effectiveAt)
+			if err != nil {
+				return nil, fmt.Errorf("%s: %w", day.Time, err)
+			}
+			rates = append(rates, parsed)
+		}
+	}
+	return rates, nil
+}
+
+var csvHeader = []string{"base", "quote", "rate", "date"}
+
+// ParseCSV parses a CSV file with the "base,quote,rate,date" header, e.g.
+//
+//	base,quote,rate,date
+//	USD,RUB,89.5,2024-06-03
+//
+// The date is either a day or an RFC 3339 timestamp.
+func ParseCSV(r io.Reader) ([]Rate, error) {
+	reader := csv.NewReader(r)
+	reader.FieldsPerRecord = len(csvHeader)
+	reader.TrimLeadingSpace = true
+
+	header, err := reader.Read()
+	if err != nil {
+		return nil, fmt.Errorf("%w: %v", ErrInvalidFile, err)
+	}
+	for i, name := range csvHeader {
+		if !strings.EqualFold(strings.TrimSpace(header[i]), name) {
+			return nil, fmt.Errorf("%w: header must be %q", ErrInvalidFile, strings.Join(csvHeader, ","))
+		}
+	}
+
+	var rates []Rate
+	for {
+		record, err := reader.Read()
+		if errors.Is(err, io.EOF) {
+			break
+		}
+		if err != nil {
+			return nil, fmt.Errorf("%w: %v", ErrInvalidFile, err)
+		}
+
+		line, _ := reader.FieldPos(0)
+		effectiveAt, err := parseDate(record[3])
+		if err != nil {
+			return nil, fmt.Errorf("line %d: %w", line, err)
+		}
+		rate, err := newRate(record[0], record[1], record[2], effectiveAt)
+		if err != nil {
+			return nil, fmt.Errorf("line %d: %w", line, err)
+		}
+		rates = append(rates, rate)
+	}
+
+	if len(rates) == 0 {
+		return nil, fmt.Errorf("%w: no rates found", ErrInvalidFile)
+	}
+	return rates, nil
+}
+
+func newRate(base, quote, value string, effectiveAt time.Time) (Rate, error) {
+	base, quote, value = strings.ToUpper(strings.TrimSpace(base)), strings.ToUpper(strings.TrimSpace(quote)), strings.TrimSpace(value)
+
+	if !isCurrencyCode(base) || !isCurrencyCode(quote) || base == quote {
+		return Rate{}, fmt.Errorf("%w: invalid currency pair %s/%s", ErrInvalidFile, base, quote)
+	}
+	if parsed, ok := new(big.Rat).SetString(value); !ok || parsed.Sign() <= 0 || strings.ContainsAny(value, "/eE") {
+		return Rate{}, fmt.Errorf("%w: invalid rate %q for %s/%s", ErrInvalidFile, value, base, quote)
+	}
+
+	return Rate{Base: base, Quote: quote, Value: value, EffectiveAt: effectiveAt}, nil
+}
+
+func parseDate(s string) (time.Time, error) {
+	s = strings.TrimSpace(s)
+	if t, err := time.Parse(dateLayout, s); err == nil {
+		return t, nil
+	}
+	if t, err := time.Parse(time.RFC3339, s); err == nil {
+		return t.UTC(), nil
+	}
+	return time.Time{}, fmt.Errorf("%w: invalid date %q", ErrInvalidFile, s)
+}
+
+func isCurrencyCode(s string) bool {
+	if len(s) != 3 {
+		return false
+	}
+	for _, r := range s {
+		if r < 'A' || r > 'Z' {
+			return false
+		}
+	}
+	return true
+}
@@ -0,0 +1,101 @@
+package fxrates
+
+import (
+	"os"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func day(s string) time.Time {
+	t, _ := time.Parse(dateLayout, s)
+	return t
+}
+
+func TestParseECB(t *testing.T) {
+	f, err := os.Open("testdata/eurofxref-daily.xml")
+	assert.NoError(t, err)
+	defer f.Close()
+
+	rates, err := ParseECB(f)
+	assert.NoError(t, err)
+	assert.Equal(t, []Rate{
+		{Base: "EUR", Quote: "USD", Value: "1.0842", EffectiveAt: day("2024-06-03")},
+		{Base: "EUR", Quote: "JPY", Value: "169.92", EffectiveAt: day("2024-06-03")},
+		{Base: "EUR", Quote: "GBP", Value: "0.84950", EffectiveAt: day("2024-06-03")},
+	}, rates)
+}
+
+func TestParseECB_History(t *testing.T) {
+	f, err := os.Open("testdata/eurofxref-hist.xml")
+	assert.NoError(t, err)
+	defer f.Close()
+
+	rates, err := ParseECB(f)
+	assert.NoError(t, err)
+	assert.Equal(t, []Rate{
+		{Base: "EUR", Quote: "USD", Value: "1.0842", EffectiveAt: day("2024-06-03")},
+		{Base: "EUR", Quote: "USD", Value: "1.0848", EffectiveAt: day("2024-05-31")},
+	}, rates)
+}
+
+func TestParseECB_Invalid(t *testing.T) {
+	tests := []string{
+		``,
+		`<Envelope><Cube></Cube></Envelope>`,
+		`<Envelope><Cube><Cube time="03.06.2024"><Cube currency="USD" rate="1.08"/></Cube></Cube></Envelope>`,
+		`<Envelope><Cube><Cube time="2024-06-03"><Cube currency="USD" rate="-1"/></Cube></Cube></Envelope>`,
+		`<Envelope><Cube><Cube time="2024-06-03"><Cube currency="US" rate="1.08"/></Cube></Cube></Envelope>`,
+	}
+
+	for _, in := range tests {
+		_, err := ParseECB(strings.NewReader(in))
+		assert.ErrorIs(t, err, ErrInvalidFile, in)
+	}
+}
+
+func TestParseCSV(t *testing.T) {
+	in := "base,quote,rate,date\n" +
+		"USD,RUB,89.5,2024-06-03\n" +
+		"gbp, jpy, 199.1, 2024-06-03T12:00:00+03:00\n"
+
+	rates, err := ParseCSV(strings.NewReader(in))
+	assert.NoError(t, err)
+	assert.Equal(t, []Rate{
+		{Base: "USD", Quote: "RUB", Value: "89.5", EffectiveAt: day("2024-06-03")},
+		{Base: "GBP", Quote: "JPY", Value: "199.1", EffectiveAt: time.Date(2024, 6, 3, 9, 0, 0, 0, time.UTC)},
+	}, rates)
+}
+
+func TestParseCSV_Invalid(t *testing.T) {
+	tests := []string{
+		"",
+		"base,quote,rate,date\n",
+		"from,to,rate,date\nUSD,RUB,89.5,2024-06-03\n",
+		"base,quote,rate,date\nUSD,RUB,89.5\n",
+		"base,quote,rate,date\nUSD,USD,1,2024-06-03\n",
+		"base,quote,rate,date\nUSD,RUB,0,2024-06-03\n",
+		"base,quote,rate,date\nUSD,RUB,1e2,2024-06-03\n",
+		"base,quote,rate,date\nUSD,RUB,89.5,yesterday\n",
+	}
+
+	for _, in := range tests {
+		_, err := ParseCSV(strings.NewReader(in))
+		assert.ErrorIs(t, err, ErrInvalidFile, in)
+	}
+}
+
+func TestFormatOf(t *testing.T) {
+	format, err := FormatOf("/tmp/eurofxref-daily.XML")
+	assert.NoError(t, err)
+	assert.Equal(t, FormatXML, format)
+
+	format, err = FormatOf("rates.csv")
+	assert.NoError(t, err)
+	assert.Equal(t, FormatCSV, format)
+
+	_, err = FormatOf("rates.json")
+	assert.ErrorIs(t, err, ErrUnknownFormat)
+}
@@ -0,0 +1,30 @@
+package mail
+
+import (
+	"bytes"
+	"context"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestWriter(t *testing.T) {
+	var b bytes.Buffer
+	m := NewWriter(&b, "Bank API <no-reply@bank.example>")
+
+	err := m.Send(context.Background(), &Message{To: "user@example.com", Subject: "Réinitialiser", Body: "line 1\nline 2\n"})
+	require.NoError(t, err)
+	out := b.String()
+	assert.Contains(t, out, "From: Bank API <no-reply@bank.example>\r\n")
+	assert.Contains(t, out, "To: user@example.com\r\n")
+	assert.Contains(t, out, "Subject: =?utf-8?q?R=C3=A9initialiser?=\r\n")
+	assert.Contains(t, out, "\r\n\r\nline 1\r\nline 2\r\n")
+
+	b.Reset()
+	err = m.Send(context.Background(), &Message{To: "user@example.com\r\nBcc: other@example.com", Subject: "Hi"})
+	assert.ErrorIs(t, err, ErrInvalidHeader)
+	err = m.Send(context.Background(), &Message{To: "user@example.com", Subject: "Hi\nBcc: other@example.com"})
+	assert.ErrorIs(t, err, ErrInvalidHeader)
+	assert.Empty(t, b.String())
+}
@@ -0,0 +1,49 @@
+package pdf
+
+import (
+	"bytes"
+	"regexp"
+	"strconv"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestDocument(t *testing.T) {
+	doc := New("Statement (June)")
+	doc.AddPage().Text(50, 800, Bold, 14, `Mr. (Test) \ Ünïcödé Иван`)
+	p := doc.AddPage()
+	p.Line(50, 780, 545, 780, 0.5)
+	p.TextRight(545, 760, Regular, 10, "100.50")
+
+	var buf bytes.Buffer
+	_, err := doc.WriteTo(&buf)
+	require.NoError(t, err)
+	out := buf.Bytes()
+
+	assert.True(t, bytes.HasPrefix(out, []byte("%PDF-1.4\n")))
+	assert.True(t, bytes.HasSuffix(out, []byte("%%EOF\n")))
+	assert.Contains(t, buf.String(), "/Count 2")
+	assert.Contains(t, buf.String(), `/Title (Statement \(June\))`)
+	assert.Contains(t, buf.String(), `(Mr. \(Test\) \\ \334n\357c\366d\351 ????) Tj`)
+	assert.Contains(t, buf.String(), "BT /F1 10 Tf 514.42 760 Td (100.50) Tj ET")
+
+	// Every object has to be where the cross-reference table says it is.
+	startxref := regexp.MustCompile(`startxref\n(\d+)\n`).FindSubmatch(out)
+	require.NotNil(t, startxref)
+	xref, err := strconv.Atoi(string(startxref[1]))
+	require.NoError(t, err)
+	entries := regexp.MustCompile(`(\d{10}) 00000 n `).FindAllSubmatch(out[xref:], -1)
+	require.Len(t, entries, 9)
+	for i, entry := range entries {
+		offset, err := strconv.Atoi(string(entry[1]))
+		require.NoError(t, err)
+		assert.True(t, bytes.HasPrefix(out[offset:], []byte(strconv.Itoa(i+1)+" 0 obj\n")), "object %d", i+1)
+	}
+}
+
+func TestTextWidth(t *testing.T) {
+	assert.InDelta(t, 30.58, TextWidth("100.50", Regular, 10), 0.001)
+	assert.InDelta(t, 7.22, TextWidth("A", Bold, 10), 0.001)
+}
@@ -0,0 +1,66 @@
+// Package secretbox encrypts small secrets, such as TOTP secrets, to be stored in the database.
+package secretbox
+
+import (
+	"crypto/aes"
+	"crypto/cipher"
+	"crypto/rand"
+	"encoding/base64"
+	"errors"
+	"fmt"
+)
+
+const KeySize = 32
+
+var ErrDecrypt = errors.New("can't decrypt secret")
+
+// Box encrypts with AES-256-GCM. A sealed secret is the random nonce followed by the ciphertext.
+type Box struct {
+	aead cipher.AEAD
+}
+
+func New(key []byte) (*Box, error) {
+	if len(key) != KeySize {
+		return nil, fmt.Errorf("key must be %d bytes long, not %d", KeySize, len(key))
+	}
+	block, err := aes.NewCipher(key)
+	if err != nil {
+		return nil, err
+	}
+	aead, err := cipher.NewGCM(block)
+	if err != nil {
+		return nil, err
+	}
+	return &Box{aead: aead}, nil
+}
+
+// NewFromBase64 makes a Box of a key in standard base64, as it is configured.
+func NewFromBase64(key string) (*Box, error) {
+	raw, err := base64.StdEncoding.DecodeString(key)
+	if err != nil {
+		return nil, fmt.Errorf("key isn't valid base64: %w", err)
+	}
+	return New(raw)
+}
+
+// Seal encrypts the secret. The additional data is authenticated but not encrypted; it binds the sealed
+// secret to what it belongs to, so it can't be copied to another row.
+func (b *Box) Seal(secret, additionalData []byte) ([]byte, error) {
+	nonce := make([]byte, b.aead.NonceSize(), b.aead.NonceSize()+len(secret)+b.aead.Overhead())
+	if _, err := rand.Read(nonce); err != nil {
+		return nil, err
+	}
+	return b.aead.Seal(nonce, nonce, secret, additionalData), nil
+}
+
+func (b *Box) Open(sealed, additionalData []byte) ([]byte, error) {
+	if len(sealed) < b.aead.NonceSize() {
+		return nil, ErrDecrypt
+	}
+	nonce, ciphertext := sealed[:b.aead.NonceSize()], sealed[b.aead.NonceSize():]
+	secret, err := b.aead.Open(nil, nonce, ciphertext, additionalData)
+	if err != nil {
+		return nil, ErrDecrypt
+	}
+	return secret, nil
+}
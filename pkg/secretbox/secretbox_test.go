@@ -0,0 +1,50 @@
+package secretbox
+
+import (
+	"bytes"
+	"encoding/base64"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestBox(t *testing.T) {
+	box, err := NewFromBase64(base64.StdEncoding.EncodeToString(bytes.Repeat([]byte{1}, KeySize)))
+	require.NoError(t, err)
+
+	sealed, err := box.Seal([]byte("secret"), []byte("user 1"))
+	require.NoError(t, err)
+	assert.NotContains(t, string(sealed), "secret")
+
+	again, err := box.Seal([]byte("secret"), []byte("user 1"))
+	require.NoError(t, err)
+	assert.NotEqual(t, sealed, again)
+
+	secret, err := box.Open(sealed, []byte("user 1"))
+	assert.NoError(t, err)
+	assert.Equal(t, []byte("secret"), secret)
+
+	_, err = box.Open(sealed, []byte("user 2"))
+	assert.ErrorIs(t, err, ErrDecrypt)
+
+	tampered := bytes.Clone(sealed)
+	tampered[len(tampered)-1] ^= 1
+	_, err = box.Open(tampered, []byte("user 1"))
+	assert.ErrorIs(t, err, ErrDecrypt)
+
+	_, err = box.Open(sealed[:4], []byte("user 1"))
+	assert.ErrorIs(t, err, ErrDecrypt)
+
+	other, err := New(bytes.Repeat([]byte{2}, KeySize))
+	require.NoError(t, err)
+	_, err = other.Open(sealed, []byte("user 1"))
+	assert.ErrorIs(t, err, ErrDecrypt)
+}
+
+func TestNew_KeySize(t *testing.T) {
+	_, err := New(make([]byte, 16))
+	assert.Error(t, err)
+	_, err = NewFromBase64("not base64!")
+	assert.Error(t, err)
+}
@@ -0,0 +1,66 @@
+package totp
+
+import (
+	"net/url"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+var rfcSecret = []byte("12345678901234567890")
+
+// The SHA1 test vectors of RFC 6238, appendix B.
+func TestCode_RFC6238(t *testing.T) {
+	tests := []struct {
+		unix int64
+		code string
+	}{
+		{59, "94287082"},
+		{1111111109, "07081804"},
+		{1111111111, "14050471"},
+		{1234567890, "89005924"},
+		{2000000000, "69279037"},
+		{20000000000, "65353130"},
+	}
+
+	for _, tt := range tests {
+		assert.Equal(t, tt.code, code(rfcSecret, Step(time.Unix(tt.unix, 0)), 8), tt.unix)
+		assert.Equal(t, tt.code[2:], Code(rfcSecret, Step(time.Unix(tt.unix, 0))), tt.unix)
+	}
+}
+
+func TestVerify(t *testing.T) {
+	now := time.Unix(1111111111, 0)
+	step := Step(now)
+
+	matched, ok := Verify(rfcSecret, Code(rfcSecret, step), now, 1)
+	assert.True(t, ok)
+	assert.Equal(t, step, matched)
+
+	matched, ok = Verify(rfcSecret, Code(rfcSecret, step-1), now, 1)
+	assert.True(t, ok)
+	assert.Equal(t, step-1, matched)
+
+	_, ok = Verify(rfcSecret, Code(rfcSecret, step-2), now, 1)
+	assert.False(t, ok)
+	_, ok = Verify(rfcSecret, Code(rfcSecret, step+1), now, 0)
+	assert.False(t, ok)
+	_, ok = Verify(rfcSecret, "", now, 1)
+	assert.False(t, ok)
+	_, ok = Verify([]byte("another secret"), Code(rfcSecret, step), now, 1)
+	assert.False(t, ok)
+}
+
+func TestURI(t *testing.T) {
+	uri, err := url.Parse(URI("Bank API", "user@example.com", rfcSecret))
+	require.NoError(t, err)
+
+	assert.Equal(t, "otpauth", uri.Scheme)
+	assert.Equal(t, "totp", uri.Host)
+	assert.Equal(t, "/Bank API:user@example.com", uri.Path)
+	assert.Equal(t, "GEZDGNBVGY3TQOJQGEZDGNBVGY3TQOJQ", uri.Query().Get("secret"))
+	assert.Equal(t, "Bank API", uri.Query().Get("issuer"))
+	assert.Equal(t, "6", uri.Query().Get("digits"))
+}